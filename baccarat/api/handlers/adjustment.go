@@ -0,0 +1,255 @@
+package handlers
+
+import (
+	"baccarat/api/middleware"
+	"baccarat/config"
+	"baccarat/db"
+	"baccarat/internal/adjustment"
+	"baccarat/pkg/logger"
+	"baccarat/pkg/utils"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// adjustmentDetail 審計日誌中的調整描述
+func adjustmentDetail(a *adjustment.Adjustment, note string) string {
+	return fmt.Sprintf("id=%d amount=%.2f reason=%s note=%s", a.ID, a.Amount, a.Reason, note)
+}
+
+// Adjustments 查看（GET，參數 status、page、size）或發起（POST）人工調整餘額
+func (h *AdminHandler) Adjustments(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		h.listAdjustments(w, r)
+	case http.MethodPost:
+		h.requestAdjustment(w, r)
+	default:
+		logger.Warn("Invalid method for Adjustments:", r.Method)
+		utils.ErrorResponse(w, http.StatusMethodNotAllowed, "Method not allowed")
+	}
+}
+
+// listAdjustments 按狀態列出調整記錄
+func (h *AdminHandler) listAdjustments(w http.ResponseWriter, r *http.Request) {
+	status := adjustment.Status(r.URL.Query().Get("status"))
+	switch status {
+	case "", adjustment.StatusPending, adjustment.StatusApplied, adjustment.StatusRejected:
+	default:
+		utils.ValidationError(w, "無效的狀態，可選值: pending, applied, rejected")
+		return
+	}
+	page, pageSize := parsePage(r)
+
+	adjustments, err := db.ListAdjustments(status, pageSize, (page-1)*pageSize)
+	if err != nil {
+		logger.Error("Error listing adjustments:", err)
+		utils.ServerError(w, "Error retrieving adjustments")
+		return
+	}
+	utils.SuccessResponse(w, map[string]interface{}{
+		"page":        page,
+		"pageSize":    pageSize,
+		"adjustments": adjustments,
+	})
+}
+
+// requestAdjustment 發起人工調整，窗口內的累計金額不超過門檻時立即入帳，否則等待另一名管理員審批
+func (h *AdminHandler) requestAdjustment(w http.ResponseWriter, r *http.Request) {
+	adminID, ok := middleware.GetUserID(r)
+	if !ok {
+		logger.Warn("Unauthorized access to RequestAdjustment")
+		utils.UnauthorizedError(w)
+		return
+	}
+
+	var input struct {
+		Username   string `json:"username"`
+		Amount     string `json:"amount"`
+		ReasonCode string `json:"reasonCode"`
+		Note       string `json:"note"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+		logger.Warn("Invalid request body for RequestAdjustment:", err)
+		utils.ValidationError(w, "Invalid request body")
+		return
+	}
+
+	amount, err := strconv.ParseFloat(input.Amount, 64)
+	if err != nil || adjustment.ValidateAmount(amount) != nil {
+		utils.ValidationError(w, "無效的金額（不能為零，最多兩位小數）")
+		return
+	}
+	reason, err := adjustment.ParseReason(input.ReasonCode)
+	if err != nil {
+		utils.ValidationError(w, "無效的原因代碼，可選值: goodwill, correction, chargeback, promotion, other")
+		return
+	}
+	input.Note = strings.TrimSpace(input.Note)
+	if input.Note == "" || len(input.Note) > 255 {
+		utils.ValidationError(w, "必須填寫備註（最多255個字符）")
+		return
+	}
+	userID, ok := findUser(w, h.store.Users, input.Username, "requesting adjustment")
+	if !ok {
+		return
+	}
+
+	a := &adjustment.Adjustment{
+		UserID:      userID,
+		Username:    input.Username,
+		Amount:      amount,
+		Reason:      reason,
+		Note:        input.Note,
+		Status:      adjustment.StatusPending,
+		RequestedBy: adminID,
+	}
+	ip := clientIP(r)
+
+	err = db.TransactionContext(r.Context(), func(tx *sql.Tx) error {
+		// 按用戶 ID 順序鎖定發起人和目標用戶，使並發請求的累計金額串行計算
+		first, second := adminID, userID
+		if first > second {
+			first, second = second, first
+		}
+		if err := db.LockUser(tx, first); err != nil {
+			return err
+		}
+		if second != first {
+			if err := db.LockUser(tx, second); err != nil {
+				return err
+			}
+		}
+		since := time.Now().Add(-time.Duration(config.AppConfig.AdjustmentApprovalWindow) * time.Hour)
+		byUser, byAdmin, err := db.GetAutoAppliedAdjustmentTotals(tx, userID, adminID, since)
+		if err != nil {
+			return err
+		}
+		threshold := config.AppConfig.AdjustmentApprovalThreshold
+		needsApproval := adjustment.RequiresApproval(amount, byUser, threshold) ||
+			adjustment.RequiresApproval(amount, byAdmin, threshold)
+
+		if err := db.CreateAdjustment(tx, a); err != nil {
+			return err
+		}
+		if err := db.SaveAdminAuditEvent(tx, adminID, db.AdminActionAdjustRequested, userID, adjustmentDetail(a, a.Note), ip); err != nil {
+			return err
+		}
+		if needsApproval {
+			return nil
+		}
+
+		if err := db.ApplyAdjustment(tx, a); err != nil {
+			return err
+		}
+		if err := db.DecideAdjustment(tx, a, adjustment.StatusApplied, 0, "below approval threshold"); err != nil {
+			return err
+		}
+		return db.SaveAdminAuditEvent(tx, adminID, db.AdminActionAdjustApplied, userID, adjustmentDetail(a, a.DecisionNote), ip)
+	})
+	if err == db.ErrInsufficientBalance {
+		utils.ValidationError(w, "扣款金額超過用戶餘額")
+		return
+	}
+	if err != nil {
+		logger.Error("Error requesting adjustment for user", input.Username, "Error:", err)
+		utils.ServerError(w, "Error requesting adjustment")
+		return
+	}
+
+	logger.Info("Admin", adminID, "requested adjustment", a.ID, "for user", input.Username, "status:", a.Status)
+	utils.SuccessResponse(w, map[string]interface{}{"adjustment": a})
+}
+
+// ApproveAdjustment 審批通過待審批的調整並入帳，審批人不能是發起人
+func (h *AdminHandler) ApproveAdjustment(w http.ResponseWriter, r *http.Request) {
+	h.decideAdjustment(w, r, true)
+}
+
+// RejectAdjustment 拒絕待審批的調整
+func (h *AdminHandler) RejectAdjustment(w http.ResponseWriter, r *http.Request) {
+	h.decideAdjustment(w, r, false)
+}
+
+// decideAdjustment 鎖定調整記錄後審批，入帳、狀態變更和審計記錄在同一事務內
+func (h *AdminHandler) decideAdjustment(w http.ResponseWriter, r *http.Request, approve bool) {
+	if r.Method != http.MethodPost {
+		logger.Warn("Invalid method for DecideAdjustment:", r.Method)
+		utils.ErrorResponse(w, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	adminID, ok := middleware.GetUserID(r)
+	if !ok {
+		logger.Warn("Unauthorized access to DecideAdjustment")
+		utils.UnauthorizedError(w)
+		return
+	}
+
+	var input struct {
+		ID   int64  `json:"id"`
+		Note string `json:"note"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil || input.ID <= 0 {
+		logger.Warn("Invalid request body for DecideAdjustment:", err)
+		utils.ValidationError(w, "Invalid request body")
+		return
+	}
+	input.Note = strings.TrimSpace(input.Note)
+	if len(input.Note) > 255 {
+		utils.ValidationError(w, "備註最多255個字符")
+		return
+	}
+
+	status, action := adjustment.StatusRejected, db.AdminActionAdjustRejected
+	if approve {
+		status, action = adjustment.StatusApplied, db.AdminActionAdjustApplied
+	}
+
+	var a *adjustment.Adjustment
+	err := db.TransactionContext(r.Context(), func(tx *sql.Tx) error {
+		var err error
+		a, err = db.GetAdjustmentForUpdate(tx, input.ID)
+		if err != nil {
+			return err
+		}
+		if err := a.CanDecide(adminID); err != nil {
+			return err
+		}
+		if approve {
+			if err := db.ApplyAdjustment(tx, a); err != nil {
+				return err
+			}
+		}
+		if err := db.DecideAdjustment(tx, a, status, adminID, input.Note); err != nil {
+			return err
+		}
+		return db.SaveAdminAuditEvent(tx, adminID, action, a.UserID, adjustmentDetail(a, input.Note), clientIP(r))
+	})
+	switch err {
+	case nil:
+	case sql.ErrNoRows:
+		utils.ErrorResponse(w, http.StatusNotFound, "調整記錄不存在")
+		return
+	case adjustment.ErrNotPending:
+		utils.ErrorResponse(w, http.StatusConflict, "該調整已審批")
+		return
+	case adjustment.ErrSelfApproval:
+		utils.ErrorResponse(w, http.StatusForbidden, "不能審批自己發起的調整")
+		return
+	case db.ErrInsufficientBalance:
+		utils.ValidationError(w, "扣款金額超過用戶餘額")
+		return
+	default:
+		logger.Error("Error deciding adjustment", input.ID, "Error:", err)
+		utils.ServerError(w, "Error deciding adjustment")
+		return
+	}
+
+	logger.Info("Admin", adminID, action, "adjustment", a.ID)
+	utils.SuccessResponse(w, map[string]interface{}{"adjustment": a})
+}
@@ -0,0 +1,47 @@
+package handlers
+
+import (
+	"baccarat/config"
+	"baccarat/internal/auth"
+	"baccarat/internal/clock"
+	"baccarat/internal/store"
+	"net/http"
+	"testing"
+)
+
+func TestSplitAdjustmentsRequireApproval(t *testing.T) {
+	config.AppConfig.AdjustmentApprovalThreshold = 1000
+	config.AppConfig.AdjustmentApprovalWindow = 24
+	guard := auth.NewLoginGuard(auth.LockoutPolicy{}, auth.LockoutPolicy{}, auth.DBAttemptStore{}, clock.System{})
+	h := NewAdminHandler(store.NewDBStore(), guard)
+	adminID := createTestUser(t, "adjuster", "Secret123")
+	createTestUser(t, "adjusted", "Secret123")
+	userID := createTestUser(t, "adjustedtwo", "Secret123")
+
+	request := func(username, amount string) string {
+		t.Helper()
+		body := map[string]string{"username": username, "amount": amount, "reasonCode": "goodwill", "note": "split"}
+		code, data := call(t, h.Adjustments, http.MethodPost, "/api/admin/adjustments", adminID, body)
+		if code != http.StatusOK {
+			t.Fatalf("adjust %s %s: status %d", username, amount, code)
+		}
+		return data["adjustment"].(map[string]interface{})["status"].(string)
+	}
+
+	// 同一用戶兩筆 500 累計等於門檻，仍自動入帳；第三筆超過門檻等待審批
+	for i := 0; i < 2; i++ {
+		if status := request("adjusted", "500"); status != "applied" {
+			t.Fatalf("adjustment %d status = %s, want applied", i+1, status)
+		}
+	}
+	if status := request("adjusted", "1"); status != "pending" {
+		t.Errorf("adjustment over cumulative threshold status = %s, want pending", status)
+	}
+	// 同一管理員拆到另一名用戶也按發起人累計
+	if status := request("adjustedtwo", "1"); status != "pending" {
+		t.Errorf("adjustment to another user status = %s, want pending", status)
+	}
+	if got := balanceOf(t, userID); got != 0 {
+		t.Errorf("balance = %v, want 0", got)
+	}
+}
@@ -0,0 +1,74 @@
+package handlers
+
+import (
+	"baccarat/api/middleware"
+	"baccarat/db"
+	"baccarat/internal/auth"
+	"baccarat/internal/store"
+	"baccarat/pkg/logger"
+	"baccarat/pkg/utils"
+	"baccarat/pkg/validation"
+	"database/sql"
+	"encoding/json"
+	"net/http"
+	"strconv"
+)
+
+type AdminHandler struct {
+	store      store.Store
+	loginGuard *auth.LoginGuard
+}
+
+func NewAdminHandler(store store.Store, loginGuard *auth.LoginGuard) *AdminHandler {
+	return &AdminHandler{
+		store:      store,
+		loginGuard: loginGuard,
+	}
+}
+
+// UnlockUser 解除用戶因登入失敗而觸發的鎖定
+func (h *AdminHandler) UnlockUser(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		logger.Warn("Invalid method for UnlockUser:", r.Method)
+		utils.ErrorResponse(w, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	adminID, ok := middleware.GetUserID(r)
+	if !ok {
+		logger.Warn("Unauthorized access to UnlockUser")
+		utils.UnauthorizedError(w)
+		return
+	}
+
+	var input struct {
+		Username string `json:"username"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+		logger.Warn("Invalid request body for UnlockUser:", err)
+		utils.ValidationError(w, "Invalid request body")
+		return
+	}
+	if err := validation.ValidateUsername(input.Username); err != nil {
+		utils.ValidationError(w, err.Error())
+		return
+	}
+
+	if err := h.loginGuard.Unlock(input.Username); err != nil {
+		logger.Error("Error unlocking user", input.Username, "Error:", err)
+		utils.ServerError(w, "Error unlocking user")
+		return
+	}
+
+	userID, _, err := h.store.Users.FindByUsername(input.Username)
+	if err != nil && err != sql.ErrNoRows {
+		logger.Error("Error retrieving user:", err)
+	}
+	recordAuthEvent(userID, input.Username, clientIP(r), db.AuthEventAccountUnlock, "unlocked by admin "+strconv.Itoa(adminID))
+	if err := db.SaveAdminAuditEvent(db.DB, adminID, db.AdminActionAccountUnlocked, userID, input.Username, clientIP(r)); err != nil {
+		logger.Error("Error writing admin audit event for unlock of", input.Username, "Error:", err)
+	}
+
+	logger.Info("Admin", adminID, "unlocked user", input.Username)
+	utils.SuccessResponse(w, map[string]string{"message": "User unlocked"})
+}
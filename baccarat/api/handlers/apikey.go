@@ -0,0 +1,173 @@
+package handlers
+
+import (
+	"baccarat/api/middleware"
+	"baccarat/config"
+	"baccarat/db"
+	"baccarat/internal/auth"
+	"baccarat/pkg/logger"
+	"baccarat/pkg/utils"
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// 單個金鑰可設置的每分鐘請求上限
+const maxAPIKeyRateLimit = 600
+
+// APIKeys 列出（GET）或創建（POST）當前用戶的 API 金鑰
+func (h *AuthHandler) APIKeys(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		h.listAPIKeys(w, r)
+	case http.MethodPost:
+		h.createAPIKey(w, r)
+	default:
+		logger.Warn("Invalid method for APIKeys:", r.Method)
+		utils.ErrorResponse(w, http.StatusMethodNotAllowed, "Method not allowed")
+	}
+}
+
+// listAPIKeys 列出當前用戶的金鑰，不返回明文
+func (h *AuthHandler) listAPIKeys(w http.ResponseWriter, r *http.Request) {
+	userID, ok := middleware.GetUserID(r)
+	if !ok {
+		logger.Warn("Unauthorized access to ListAPIKeys")
+		utils.UnauthorizedError(w)
+		return
+	}
+
+	keys, err := db.ListAPIKeys(userID)
+	if err != nil {
+		logger.Error("Error listing API keys for user", userID, "Error:", err)
+		utils.ServerError(w, "Error retrieving API keys")
+		return
+	}
+	utils.SuccessResponse(w, map[string]interface{}{"keys": keys})
+}
+
+// createAPIKey 創建新金鑰，明文只在此響應中返回一次
+func (h *AuthHandler) createAPIKey(w http.ResponseWriter, r *http.Request) {
+	userID, ok := middleware.GetUserID(r)
+	if !ok {
+		logger.Warn("Unauthorized access to CreateAPIKey")
+		utils.UnauthorizedError(w)
+		return
+	}
+	role, ok := middleware.GetRole(r)
+	if !ok {
+		utils.UnauthorizedError(w)
+		return
+	}
+
+	var input struct {
+		Name      string   `json:"name"`
+		Scopes    []string `json:"scopes"`
+		RateLimit int      `json:"rateLimit"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+		logger.Warn("Invalid request body for CreateAPIKey:", err)
+		utils.ValidationError(w, "Invalid request body")
+		return
+	}
+
+	// 驗證輸入
+	input.Name = strings.TrimSpace(input.Name)
+	if input.Name == "" || len(input.Name) > 64 {
+		utils.ValidationError(w, "金鑰名稱長度必須在1-64個字符之間")
+		return
+	}
+	scopes, err := auth.ParseAPIKeyScopes(input.Scopes)
+	if err != nil {
+		utils.ValidationError(w, "無效的權限範圍，可選值: play, read-history, wallet")
+		return
+	}
+	if !auth.RoleAllowsScopes(role, scopes) {
+		logger.Warn("User", userID, "requested API key scopes beyond role:", scopes)
+		utils.ForbiddenError(w)
+		return
+	}
+	if input.RateLimit == 0 {
+		input.RateLimit = config.AppConfig.APIKeyRateLimit
+	}
+	if input.RateLimit < 1 || input.RateLimit > maxAPIKeyRateLimit {
+		utils.ValidationError(w, "每分鐘請求上限必須在1-"+strconv.Itoa(maxAPIKeyRateLimit)+"之間")
+		return
+	}
+
+	count, err := db.CountActiveAPIKeys(userID)
+	if err != nil {
+		logger.Error("Error counting API keys for user", userID, "Error:", err)
+		utils.ServerError(w, "Error creating API key")
+		return
+	}
+	if count >= config.AppConfig.APIKeyMaxPerUser {
+		utils.ValidationError(w, "已達到金鑰數量上限，請先撤銷不再使用的金鑰")
+		return
+	}
+
+	key, keyHash, prefix, err := auth.GenerateAPIKey()
+	if err != nil {
+		logger.Error("Error generating API key:", err)
+		utils.ServerError(w, "Error creating API key")
+		return
+	}
+	keyID, err := db.CreateAPIKey(userID, input.Name, prefix, keyHash, scopes, input.RateLimit)
+	if err != nil {
+		logger.Error("Error saving API key for user", userID, "Error:", err)
+		utils.ServerError(w, "Error creating API key")
+		return
+	}
+
+	recordAuthEvent(userID, "", clientIP(r), db.AuthEventAPIKeyCreated, "key "+strconv.FormatInt(keyID, 10))
+	logger.Info("API key created for user", userID, "KeyID:", keyID)
+	utils.SuccessResponse(w, map[string]interface{}{
+		"id":        keyID,
+		"name":      input.Name,
+		"key":       key,
+		"prefix":    prefix,
+		"scopes":    scopes,
+		"rateLimit": input.RateLimit,
+	})
+}
+
+// RevokeAPIKey 撤銷當前用戶的一個金鑰
+func (h *AuthHandler) RevokeAPIKey(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		logger.Warn("Invalid method for RevokeAPIKey:", r.Method)
+		utils.ErrorResponse(w, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	userID, ok := middleware.GetUserID(r)
+	if !ok {
+		logger.Warn("Unauthorized access to RevokeAPIKey")
+		utils.UnauthorizedError(w)
+		return
+	}
+
+	var input struct {
+		ID int64 `json:"id"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil || input.ID <= 0 {
+		logger.Warn("Invalid request body for RevokeAPIKey")
+		utils.ValidationError(w, "Invalid request body")
+		return
+	}
+
+	revoked, err := db.RevokeAPIKey(userID, input.ID)
+	if err != nil {
+		logger.Error("Error revoking API key", input.ID, "Error:", err)
+		utils.ServerError(w, "Error revoking API key")
+		return
+	}
+	if !revoked {
+		utils.ErrorResponse(w, http.StatusNotFound, "金鑰不存在或已撤銷")
+		return
+	}
+
+	recordAuthEvent(userID, "", clientIP(r), db.AuthEventAPIKeyRevoked, "key "+strconv.FormatInt(input.ID, 10))
+	logger.Info("API key revoked for user", userID, "KeyID:", input.ID)
+	utils.SuccessResponse(w, map[string]string{"message": "API key revoked"})
+}
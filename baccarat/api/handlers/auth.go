@@ -2,27 +2,37 @@ package handlers
 
 import (
 	"baccarat/api/middleware"
+	"baccarat/config"
 	"baccarat/db"
 	"baccarat/internal/auth"
+	"baccarat/internal/notify"
+	"baccarat/internal/store"
 	"baccarat/pkg/logger"
 	"baccarat/pkg/utils"
 	"baccarat/pkg/validation"
 	"database/sql"
 	"encoding/json"
 	"net/http"
+	"strconv"
+	"time"
 
+	"github.com/google/uuid"
 	"golang.org/x/crypto/bcrypt"
 )
 
 type AuthHandler struct {
-	db         *sql.DB
+	store      store.Store
 	jwtService *auth.JWTService
+	loginGuard *auth.LoginGuard
+	notifier   notify.AccountNotifier
 }
 
-func NewAuthHandler(db *sql.DB, jwtService *auth.JWTService) *AuthHandler {
+func NewAuthHandler(store store.Store, jwtService *auth.JWTService, loginGuard *auth.LoginGuard, notifier notify.AccountNotifier) *AuthHandler {
 	return &AuthHandler{
-		db:         db,
+		store:      store,
 		jwtService: jwtService,
+		loginGuard: loginGuard,
+		notifier:   notifier,
 	}
 }
 
@@ -53,8 +63,7 @@ func (h *AuthHandler) Register(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// 檢查用戶名是否已存在
-	var exists bool
-	err := h.db.QueryRow("SELECT EXISTS(SELECT 1 FROM users WHERE username = ?)", input.Username).Scan(&exists)
+	exists, err := h.store.Users.Exists(input.Username)
 	if err != nil {
 		logger.Error("Error checking username existence:", err)
 		utils.ServerError(w, "Error checking username")
@@ -75,7 +84,7 @@ func (h *AuthHandler) Register(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// 創建用戶
-	if err := db.CreateUser(input.Username, passwordHash); err != nil {
+	if err := h.store.Users.Create(input.Username, passwordHash); err != nil {
 		logger.Error("Error creating user:", err)
 		utils.ServerError(w, "Error creating account")
 		return
@@ -116,11 +125,32 @@ func (h *AuthHandler) Login(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	// 檢查是否處於節流或鎖定狀態
+	ip := clientIP(r)
+	if wait, err := h.loginGuard.Check(input.Username, ip); err != nil {
+		if err != auth.ErrLoginThrottled && err != auth.ErrAccountLocked {
+			logger.Error("Error checking login attempts:", err)
+			utils.ServerError(w, "Error during login")
+			return
+		}
+		logger.Warn("Login blocked for user:", input.Username, "IP:", ip, "Reason:", err)
+		recordAuthEvent(0, input.Username, ip, db.AuthEventLoginThrottled, err.Error())
+		retryAfter(w, wait)
+		if err == auth.ErrAccountLocked {
+			utils.ErrorResponse(w, http.StatusTooManyRequests, "帳戶已暫時鎖定，請稍後再試")
+		} else {
+			utils.ErrorResponse(w, http.StatusTooManyRequests, "登入嘗試過於頻繁，請稍後再試")
+		}
+		return
+	}
+
 	// 獲取用戶信息
-	userID, hashedPassword, err := db.GetUserByUsername(input.Username)
+	userID, hashedPassword, err := h.store.Users.FindByUsername(input.Username)
 	if err == sql.ErrNoRows {
+		// 同樣做一次 bcrypt 比較，響應時間不洩露用戶名是否存在
+		bcrypt.CompareHashAndPassword(dummyPasswordHash, []byte(input.Password))
 		logger.Warn("User not found:", input.Username)
-		utils.ValidationError(w, "Invalid username or password")
+		h.loginFailed(w, 0, input.Username, ip, "unknown user")
 		return
 	}
 	if err != nil {
@@ -131,21 +161,213 @@ func (h *AuthHandler) Login(w http.ResponseWriter, r *http.Request) {
 
 	// 驗證密碼
 	if err := bcrypt.CompareHashAndPassword([]byte(hashedPassword), []byte(input.Password)); err != nil {
-		logger.Warn("Invalid password for user:", input.Username)
-		utils.ValidationError(w, "Invalid username or password")
+		h.loginFailed(w, userID, input.Username, ip, "invalid password")
+		return
+	}
+
+	// 冷靜期或自我排除期內不允許登入
+	if rejectExcluded(w, r, userID, input.Username) {
 		return
 	}
 
-	// 生成 JWT token
-	token, err := h.jwtService.GenerateToken(userID)
+	// 已啟用兩步驗證或角色要求兩步驗證時，只簽發預認證令牌；
+	// 失敗記錄保留到第二步驗證通過，否則知道密碼就能重新登入清零，無限次嘗試驗證碼
+	challenge, err := h.twoFactorChallenge(userID)
 	if err != nil {
-		logger.Error("Error generating token:", err)
+		logger.Error("Error checking two-factor state for user:", input.Username, "Error:", err)
+		utils.ServerError(w, "Error during login")
+		return
+	}
+	if challenge != nil {
+		logger.Info("Password verified, awaiting second factor for user:", input.Username)
+		utils.SuccessResponse(w, challenge)
+		return
+	}
+	if err := h.loginGuard.RecordSuccess(input.Username); err != nil {
+		logger.Error("Error clearing login failures for user:", input.Username, "Error:", err)
+	}
+	recordAuthEvent(userID, input.Username, ip, db.AuthEventLoginSuccess, "")
+
+	// 創建會話並簽發令牌
+	tokens, err := h.issueSession(r, userID)
+	if err != nil {
+		logger.Error("Error issuing session:", err)
 		utils.ServerError(w, "Error during login")
 		return
 	}
 
 	logger.Info("User logged in successfully:", input.Username)
-	utils.SuccessResponse(w, map[string]string{"token": token})
+	utils.SuccessResponse(w, tokens)
+}
+
+// dummyPasswordHash 用戶不存在時用於比較的固定哈希，成本與註冊時的 bcrypt.DefaultCost 一致
+var dummyPasswordHash = []byte("$2a$10$1tJJq8GTIoYilJihRuZ1lecYQBr2KRYraVUA73uDk9wlJu8JGYJvu")
+
+// loginFailed 記錄失敗並返回統一的錯誤信息（不區分用戶不存在和密碼錯誤）
+func (h *AuthHandler) loginFailed(w http.ResponseWriter, userID int, username, ip, reason string) {
+	locked, err := h.loginGuard.RecordFailure(username, ip)
+	if err != nil {
+		logger.Error("Error recording login failure:", err)
+	}
+
+	logger.Warn("Login failed for user:", username, "IP:", ip, "Reason:", reason)
+	recordAuthEvent(userID, username, ip, db.AuthEventLoginFailed, reason)
+	if locked {
+		logger.Warn("Login locked for user:", username, "IP:", ip)
+		recordAuthEvent(userID, username, ip, db.AuthEventLoginLocked, "too many failures")
+	}
+
+	utils.ValidationError(w, "Invalid username or password")
+}
+
+// recordAuthEvent 寫入認證審計日誌，失敗只記錄日誌不影響請求
+func recordAuthEvent(userID int, username, ip, eventType, detail string) {
+	if err := db.SaveAuthAuditEvent(userID, username, ip, eventType, detail); err != nil {
+		logger.Error("Error saving auth audit event", eventType, "for user", username, "Error:", err)
+	}
+}
+
+// retryAfter 設置 Retry-After 響應頭（秒，向上取整）
+func retryAfter(w http.ResponseWriter, wait time.Duration) {
+	seconds := int((wait + time.Second - 1) / time.Second)
+	if seconds < 1 {
+		seconds = 1
+	}
+	w.Header().Set("Retry-After", strconv.Itoa(seconds))
+}
+
+// issueSession 創建新的登入會話，簽發訪問令牌和刷新令牌
+func (h *AuthHandler) issueSession(r *http.Request, userID int) (map[string]interface{}, error) {
+	sessionID := uuid.New().String()
+	refreshToken, refreshHash, err := auth.GenerateOpaqueToken()
+	if err != nil {
+		return nil, err
+	}
+
+	err = db.TransactionContext(r.Context(), func(tx *sql.Tx) error {
+		if err := db.CreateSession(tx, sessionID, userID, r.UserAgent(), clientIP(r)); err != nil {
+			return err
+		}
+		return db.SaveRefreshToken(tx, refreshHash, sessionID, userID, h.refreshExpiresAt())
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return h.tokenResponse(userID, sessionID, refreshToken)
+}
+
+// tokenResponse 為會話生成訪問令牌並組裝響應，角色每次從資料庫讀取，刷新後即反映角色變更
+func (h *AuthHandler) tokenResponse(userID int, sessionID, refreshToken string) (map[string]interface{}, error) {
+	roleName, err := h.store.Users.Role(userID)
+	if err != nil {
+		return nil, err
+	}
+	role, err := auth.ParseRole(roleName)
+	if err != nil {
+		return nil, err
+	}
+
+	token, err := h.jwtService.GenerateToken(userID, sessionID, role)
+	if err != nil {
+		return nil, err
+	}
+	return map[string]interface{}{
+		"token":        token,
+		"refreshToken": refreshToken,
+		"expiresIn":    int(h.jwtService.Expiry().Seconds()),
+		"role":         role,
+	}, nil
+}
+
+func (h *AuthHandler) refreshExpiresAt() time.Time {
+	return time.Now().Add(time.Duration(config.AppConfig.RefreshTokenExpiry) * time.Hour)
+}
+
+// Refresh 使用刷新令牌換取新的訪問令牌，並輪換刷新令牌
+func (h *AuthHandler) Refresh(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		logger.Warn("Invalid method for Refresh:", r.Method)
+		utils.ErrorResponse(w, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	var input struct {
+		RefreshToken string `json:"refreshToken"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil || input.RefreshToken == "" {
+		logger.Warn("Invalid request body for Refresh")
+		utils.ValidationError(w, "Invalid request body")
+		return
+	}
+
+	newToken, newHash, err := auth.GenerateOpaqueToken()
+	if err != nil {
+		logger.Error("Error generating refresh token:", err)
+		utils.ServerError(w, "Error refreshing token")
+		return
+	}
+
+	var (
+		userID    int
+		sessionID string
+		reused    bool
+	)
+	err = db.TransactionContext(r.Context(), func(tx *sql.Tx) error {
+		stored, err := db.GetRefreshTokenForUpdate(tx, auth.HashToken(input.RefreshToken))
+		if err == sql.ErrNoRows {
+			return auth.ErrInvalidRefreshToken
+		}
+		if err != nil {
+			return err
+		}
+		userID, sessionID = stored.UserID, stored.SessionID
+
+		if stored.SessionRevoked {
+			return auth.ErrSessionRevoked
+		}
+		// 已輪換的令牌再次出現，代表令牌可能被竊取，撤銷整個會話（提交撤銷後再返回錯誤）
+		if stored.UsedAt.Valid {
+			reused = true
+			return db.RevokeSession(tx, sessionID, "token_reuse")
+		}
+		if time.Now().After(stored.ExpiresAt) {
+			return auth.ErrInvalidRefreshToken
+		}
+
+		if err := db.MarkRefreshTokenUsed(tx, auth.HashToken(input.RefreshToken)); err != nil {
+			return err
+		}
+		if err := db.TouchSession(tx, sessionID); err != nil {
+			return err
+		}
+		return db.SaveRefreshToken(tx, newHash, sessionID, userID, h.refreshExpiresAt())
+	})
+	if reused && err == nil {
+		err = auth.ErrRefreshTokenReused
+	}
+
+	switch err {
+	case nil:
+	case auth.ErrInvalidRefreshToken, auth.ErrSessionRevoked, auth.ErrRefreshTokenReused:
+		logger.Warn("Refresh rejected for user", userID, "SessionID:", sessionID, "Error:", err)
+		utils.UnauthorizedError(w)
+		return
+	default:
+		logger.Error("Error refreshing token:", err)
+		utils.ServerError(w, "Error refreshing token")
+		return
+	}
+
+	tokens, err := h.tokenResponse(userID, sessionID, newToken)
+	if err != nil {
+		logger.Error("Error generating token:", err)
+		utils.ServerError(w, "Error refreshing token")
+		return
+	}
+
+	logger.Info("Token refreshed for user", userID, "SessionID:", sessionID)
+	utils.SuccessResponse(w, tokens)
 }
 
 // Logout 處理用戶登出
@@ -156,16 +378,83 @@ func (h *AuthHandler) Logout(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// Note: GetUserID function is not defined in the provided code,
-	// so I assume it's defined in the middleware package.
-	// If not, you need to define it or use a different way to get the user ID.
 	userID, ok := middleware.GetUserID(r)
 	if !ok {
 		logger.Warn("Unauthorized access to Logout")
 		utils.UnauthorizedError(w)
 		return
 	}
+	sessionID, ok := middleware.GetSessionID(r)
+	if !ok {
+		logger.Warn("Unauthorized access to Logout")
+		utils.UnauthorizedError(w)
+		return
+	}
+
+	// 撤銷當前會話，訪問令牌與刷新令牌立即失效
+	err := db.TransactionContext(r.Context(), func(tx *sql.Tx) error {
+		return db.RevokeSession(tx, sessionID, "logout")
+	})
+	if err != nil {
+		logger.Error("Error revoking session for user", userID, "Error:", err)
+		utils.ServerError(w, "Error during logout")
+		return
+	}
 
 	logger.Info("User logged out successfully, UserID:", userID)
 	utils.SuccessResponse(w, map[string]string{"message": "Logged out successfully"})
 }
+
+// LogoutAll 登出所有設備
+func (h *AuthHandler) LogoutAll(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		logger.Warn("Invalid method for LogoutAll:", r.Method)
+		utils.ErrorResponse(w, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	userID, ok := middleware.GetUserID(r)
+	if !ok {
+		logger.Warn("Unauthorized access to LogoutAll")
+		utils.UnauthorizedError(w)
+		return
+	}
+
+	var revoked int64
+	err := db.TransactionContext(r.Context(), func(tx *sql.Tx) error {
+		var err error
+		revoked, err = db.RevokeUserSessions(tx, userID, "logout_all")
+		return err
+	})
+	if err != nil {
+		logger.Error("Error revoking sessions for user", userID, "Error:", err)
+		utils.ServerError(w, "Error during logout")
+		return
+	}
+
+	logger.Info("User logged out from all devices, UserID:", userID, "Sessions:", revoked)
+	utils.SuccessResponse(w, map[string]interface{}{
+		"message":         "Logged out from all devices",
+		"revokedSessions": revoked,
+	})
+}
+
+// clientIP 獲取客戶端IP
+func clientIP(r *http.Request) string {
+	return middleware.ClientIP(r)
+}
+
+// JWKS 返回驗證訪問令牌所需的公鑰集合（RFC 7517）
+func (h *AuthHandler) JWKS(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		utils.ErrorResponse(w, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	// 輪換時新密鑰需先發布，緩存時間應短於新舊密鑰的重疊期
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "public, max-age=300")
+	if err := json.NewEncoder(w).Encode(map[string]interface{}{"keys": h.jwtService.Keys().JWKS()}); err != nil {
+		logger.Error("Error encoding JWKS:", err)
+	}
+}
@@ -0,0 +1,127 @@
+package handlers
+
+import (
+	"baccarat/db"
+	"baccarat/internal/auth"
+	"baccarat/internal/clock"
+	"context"
+	"database/sql"
+	"net/http"
+	"testing"
+	"time"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+// enableTestTOTP 為用戶啟用兩步驗證，返回密鑰
+func enableTestTOTP(t *testing.T, userID int) string {
+	t.Helper()
+	secret, err := auth.GenerateTOTPSecret()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := db.SaveTOTPSecret(userID, secret); err != nil {
+		t.Fatal(err)
+	}
+	if err := db.Transaction(func(tx *sql.Tx) error { return db.EnableTOTP(tx, userID) }); err != nil {
+		t.Fatal(err)
+	}
+	return secret
+}
+
+// wrongTOTPCode 當前前後時間步長都不接受的驗證碼
+func wrongTOTPCode(t *testing.T, secret string) string {
+	t.Helper()
+	for _, code := range []string{"000000", "111111", "222222", "333333"} {
+		if _, ok := auth.ValidateTOTPCode(secret, code, time.Now(), 0); !ok {
+			return code
+		}
+	}
+	t.Fatal("no rejected code found")
+	return ""
+}
+
+func TestLoginSecondFactorFailuresLockAccount(t *testing.T) {
+	clock := clock.NewFake(time.Now())
+	h, _ := newTestAuthHandler(clock)
+	userID := createTestUser(t, "totpuser", "Secret123")
+	secret := enableTestTOTP(t, userID)
+	wrong := wrongTOTPCode(t, secret)
+	login := map[string]string{"username": "totpuser", "password": "Secret123"}
+
+	// 每次密碼正確後猜錯驗證碼：重新登入不會清除失敗計數，第 3 次失敗時鎖定
+	for i := 1; i <= 3; i++ {
+		code, data := call(t, h.Login, http.MethodPost, "/api/login", 0, login)
+		if code != http.StatusOK || data["preAuthToken"] == nil {
+			t.Fatalf("login %d: status %d, data %v", i, code, data)
+		}
+		code, _ = call(t, h.LoginTwoFactor, http.MethodPost, "/api/login/2fa", 0, map[string]interface{}{
+			"preAuthToken": data["preAuthToken"],
+			"code":         wrong,
+		})
+		if code != http.StatusBadRequest {
+			t.Fatalf("wrong code %d: status %d", i, code)
+		}
+	}
+	if code, _ := call(t, h.Login, http.MethodPost, "/api/login", 0, login); code != http.StatusTooManyRequests {
+		t.Fatalf("login after repeated second factor failures: status %d, want 429", code)
+	}
+
+	// 鎖定結束後，通過第二步驗證才清除失敗記錄
+	clock.Advance(11 * time.Minute)
+	code, data := call(t, h.Login, http.MethodPost, "/api/login", 0, login)
+	if code != http.StatusOK {
+		t.Fatalf("login after lockout: status %d", code)
+	}
+	if record, _ := (auth.DBAttemptStore{}).Get(auth.ScopeUsername, "totpuser"); record.Failures == 0 {
+		t.Error("password alone cleared the failure count")
+	}
+	totpCode, err := auth.GenerateTOTPCode(secret, time.Now())
+	if err != nil {
+		t.Fatal(err)
+	}
+	code, data = call(t, h.LoginTwoFactor, http.MethodPost, "/api/login/2fa", 0, map[string]interface{}{
+		"preAuthToken": data["preAuthToken"],
+		"code":         totpCode,
+	})
+	if code != http.StatusOK || data["token"] == nil {
+		t.Fatalf("second factor: status %d, data %v", code, data)
+	}
+	if record, _ := (auth.DBAttemptStore{}).Get(auth.ScopeUsername, "totpuser"); record.Failures != 0 {
+		t.Errorf("failures after successful login = %d", record.Failures)
+	}
+}
+
+func TestDisableTwoFactorIsThrottled(t *testing.T) {
+	h, _ := newTestAuthHandler(clock.NewFake(time.Now()))
+	userID := createTestUser(t, "totpdisable", "Secret123")
+	secret := enableTestTOTP(t, userID)
+	wrong := wrongTOTPCode(t, secret)
+	disable := func(w http.ResponseWriter, r *http.Request) {
+		h.DisableTwoFactor(w, r.WithContext(context.WithValue(r.Context(), "role", auth.RolePlayer)))
+	}
+
+	// 持有訪問令牌也不能無限猜測驗證碼來關閉兩步驗證
+	for i := 1; i <= 3; i++ {
+		if code, _ := call(t, disable, http.MethodPost, "/api/2fa/disable", userID, map[string]string{"code": wrong}); code != http.StatusBadRequest {
+			t.Fatalf("wrong code %d: status %d", i, code)
+		}
+	}
+	totpCode, err := auth.GenerateTOTPCode(secret, time.Now())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if code, _ := call(t, disable, http.MethodPost, "/api/2fa/disable", userID, map[string]string{"code": totpCode}); code != http.StatusTooManyRequests {
+		t.Fatalf("disable after repeated failures: status %d, want 429", code)
+	}
+	if totp, err := db.GetUserTOTP(userID); err != nil || !totp.Enabled {
+		t.Errorf("two-factor state = %+v, %v, want still enabled", totp, err)
+	}
+}
+
+// 不存在的用戶也做一次同等成本的 bcrypt 比較
+func TestDummyPasswordHashCost(t *testing.T) {
+	if cost, err := bcrypt.Cost(dummyPasswordHash); err != nil || cost != bcrypt.DefaultCost {
+		t.Errorf("dummy hash cost = %d, %v, want %d", cost, err, bcrypt.DefaultCost)
+	}
+}
@@ -0,0 +1,268 @@
+package handlers
+
+import (
+	"baccarat/api/middleware"
+	"baccarat/db"
+	"baccarat/internal/store"
+	"baccarat/pkg/logger"
+	"baccarat/pkg/utils"
+	"baccarat/pkg/validation"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+var errAccountFrozen = errors.New("account is frozen")
+
+// frozenResponse 發送帳戶已凍結的錯誤響應
+func frozenResponse(w http.ResponseWriter) {
+	utils.ErrorResponse(w, http.StatusForbidden, "帳戶已被凍結，請聯繫客服")
+}
+
+// parsePage 解析 page、size 分頁參數，與玩家的交易和投注記錄接口一致
+func parsePage(r *http.Request) (int, int) {
+	page, pageSize := 1, 20
+	if pageStr := r.URL.Query().Get("page"); pageStr != "" {
+		if p, err := strconv.Atoi(pageStr); err == nil && p > 0 {
+			page = p
+		}
+	}
+	if sizeStr := r.URL.Query().Get("size"); sizeStr != "" {
+		if s, err := strconv.Atoi(sizeStr); err == nil && s > 0 && s <= 100 {
+			pageSize = s
+		}
+	}
+	return page, pageSize
+}
+
+// findUser 按用戶名查找用戶，失敗時已寫出錯誤響應並返回 false
+func findUser(w http.ResponseWriter, users store.Users, username, action string) (int, bool) {
+	if err := validation.ValidateUsername(username); err != nil {
+		utils.ValidationError(w, err.Error())
+		return 0, false
+	}
+	userID, _, err := users.FindByUsername(username)
+	if err == sql.ErrNoRows {
+		utils.ErrorResponse(w, http.StatusNotFound, "用戶不存在")
+		return 0, false
+	}
+	if err != nil {
+		logger.Error("Error retrieving user:", err)
+		utils.ServerError(w, "Error "+action)
+		return 0, false
+	}
+	return userID, true
+}
+
+// auditAdmin 記錄只讀的管理操作，寫入失敗時不返回數據
+func auditAdmin(w http.ResponseWriter, r *http.Request, adminID int, action string, targetUserID int, detail string) bool {
+	if err := db.SaveAdminAuditEvent(db.DB, adminID, action, targetUserID, detail, clientIP(r)); err != nil {
+		logger.Error("Error writing admin audit event", action, "Error:", err)
+		utils.ServerError(w, "Error writing audit log")
+		return false
+	}
+	return true
+}
+
+// SearchUsers 按用戶名前綴或用戶ID搜索用戶（參數 q、page、size）
+func (h *AdminHandler) SearchUsers(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		logger.Warn("Invalid method for SearchUsers:", r.Method)
+		utils.ErrorResponse(w, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	adminID, ok := middleware.GetUserID(r)
+	if !ok {
+		logger.Warn("Unauthorized access to SearchUsers")
+		utils.UnauthorizedError(w)
+		return
+	}
+
+	query := strings.TrimSpace(r.URL.Query().Get("q"))
+	if query == "" || len(query) > 50 {
+		utils.ValidationError(w, "請輸入1-50個字符的搜索條件")
+		return
+	}
+	page, pageSize := parsePage(r)
+
+	if !auditAdmin(w, r, adminID, db.AdminActionUserSearch, 0, "q="+query) {
+		return
+	}
+	users, err := db.SearchUsers(query, pageSize, (page-1)*pageSize)
+	if err != nil {
+		logger.Error("Error searching users:", err)
+		utils.ServerError(w, "Error searching users")
+		return
+	}
+
+	utils.SuccessResponse(w, map[string]interface{}{
+		"page":     page,
+		"pageSize": pageSize,
+		"users":    users,
+	})
+}
+
+// GetUserLedger 查看用戶的錢包流水（交易、下注扣款、派彩）及每筆之後的餘額
+//
+// 參數：username、page、size
+func (h *AdminHandler) GetUserLedger(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		logger.Warn("Invalid method for GetUserLedger:", r.Method)
+		utils.ErrorResponse(w, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	adminID, ok := middleware.GetUserID(r)
+	if !ok {
+		logger.Warn("Unauthorized access to GetUserLedger")
+		utils.UnauthorizedError(w)
+		return
+	}
+
+	username := r.URL.Query().Get("username")
+	userID, ok := findUser(w, h.store.Users, username, "retrieving ledger")
+	if !ok {
+		return
+	}
+	page, pageSize := parsePage(r)
+
+	if !auditAdmin(w, r, adminID, db.AdminActionLedgerViewed, userID, "page="+strconv.Itoa(page)) {
+		return
+	}
+	entries, err := db.GetLedger(userID, pageSize, (page-1)*pageSize)
+	if err != nil {
+		logger.Error("Error retrieving ledger for user", userID, "Error:", err)
+		utils.ServerError(w, "Error retrieving ledger")
+		return
+	}
+
+	utils.SuccessResponse(w, map[string]interface{}{
+		"username": username,
+		"page":     page,
+		"pageSize": pageSize,
+		"entries":  entries,
+	})
+}
+
+// FreezeUser 凍結帳戶，凍結後不能遊戲和存款，仍可登入查看記錄
+func (h *AdminHandler) FreezeUser(w http.ResponseWriter, r *http.Request) {
+	h.setFrozen(w, r, true)
+}
+
+// UnfreezeUser 解凍帳戶
+func (h *AdminHandler) UnfreezeUser(w http.ResponseWriter, r *http.Request) {
+	h.setFrozen(w, r, false)
+}
+
+// setFrozen 凍結或解凍帳戶，狀態變更和審計記錄在同一事務內
+func (h *AdminHandler) setFrozen(w http.ResponseWriter, r *http.Request, frozen bool) {
+	if r.Method != http.MethodPost {
+		logger.Warn("Invalid method for SetFrozen:", r.Method)
+		utils.ErrorResponse(w, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	adminID, ok := middleware.GetUserID(r)
+	if !ok {
+		logger.Warn("Unauthorized access to SetFrozen")
+		utils.UnauthorizedError(w)
+		return
+	}
+
+	var input struct {
+		Username string `json:"username"`
+		Reason   string `json:"reason"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+		logger.Warn("Invalid request body for SetFrozen:", err)
+		utils.ValidationError(w, "Invalid request body")
+		return
+	}
+	input.Reason = strings.TrimSpace(input.Reason)
+	if input.Reason == "" || len(input.Reason) > 255 {
+		utils.ValidationError(w, "必須填寫原因（最多255個字符）")
+		return
+	}
+	userID, ok := findUser(w, h.store.Users, input.Username, "updating account")
+	if !ok {
+		return
+	}
+
+	action := db.AdminActionAccountFrozen
+	if !frozen {
+		action = db.AdminActionAccountUnfrozen
+	}
+	var changed bool
+	err := db.TransactionContext(r.Context(), func(tx *sql.Tx) error {
+		var err error
+		changed, err = db.SetUserFrozen(tx, userID, frozen, input.Reason)
+		if err != nil || !changed {
+			return err
+		}
+		return db.SaveAdminAuditEvent(tx, adminID, action, userID, input.Reason, clientIP(r))
+	})
+	if err != nil {
+		logger.Error("Error updating frozen state for user", input.Username, "Error:", err)
+		utils.ServerError(w, "Error updating account")
+		return
+	}
+	if !changed {
+		if frozen {
+			utils.ValidationError(w, "帳戶已處於凍結狀態")
+		} else {
+			utils.ValidationError(w, "帳戶未被凍結")
+		}
+		return
+	}
+
+	logger.Info("Admin", adminID, action, "user", input.Username)
+	utils.SuccessResponse(w, map[string]interface{}{
+		"username": input.Username,
+		"frozen":   frozen,
+	})
+}
+
+// GetAuditLog 查看管理操作審計日誌（參數 username 篩選被操作的用戶，adminId 篩選操作人，page、size）
+func (h *AdminHandler) GetAuditLog(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		logger.Warn("Invalid method for GetAuditLog:", r.Method)
+		utils.ErrorResponse(w, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	query := r.URL.Query()
+	var targetUserID, adminID int
+	if username := query.Get("username"); username != "" {
+		id, ok := findUser(w, h.store.Users, username, "retrieving audit log")
+		if !ok {
+			return
+		}
+		targetUserID = id
+	}
+	if adminStr := query.Get("adminId"); adminStr != "" {
+		id, err := strconv.Atoi(adminStr)
+		if err != nil || id <= 0 {
+			utils.ValidationError(w, "無效的 adminId")
+			return
+		}
+		adminID = id
+	}
+	page, pageSize := parsePage(r)
+
+	events, err := db.ListAdminAuditEvents(targetUserID, adminID, pageSize, (page-1)*pageSize)
+	if err != nil {
+		logger.Error("Error retrieving admin audit log:", err)
+		utils.ServerError(w, "Error retrieving audit log")
+		return
+	}
+
+	utils.SuccessResponse(w, map[string]interface{}{
+		"page":     page,
+		"pageSize": pageSize,
+		"events":   events,
+	})
+}
@@ -0,0 +1,240 @@
+package handlers
+
+import (
+	"baccarat/api/middleware"
+	"baccarat/db"
+	"baccarat/game"
+	"baccarat/internal/correction"
+	"baccarat/internal/events"
+	"baccarat/pkg/logger"
+	"baccarat/pkg/utils"
+	"database/sql"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"unicode/utf8"
+)
+
+// correctionInput 作廢和重新結算的請求
+// 重新結算時提供更正後的牌（playerCards、bankerCards），或按投注類型指定返還金額（returns），二選一
+type correctionInput struct {
+	GameID      string             `json:"gameId"`
+	Reason      string             `json:"reason"`
+	PlayerCards []string           `json:"playerCards"`
+	BankerCards []string           `json:"bankerCards"`
+	Returns     map[string]float64 `json:"returns"`
+}
+
+// VoidGame 作廢牌局，所有下注退回本金，已派彩的差額從玩家餘額中扣回（餘額不足時拒絕）
+func (h *AdminHandler) VoidGame(w http.ResponseWriter, r *http.Request) {
+	h.correctGame(w, r, correction.ActionVoid)
+}
+
+// ResettleGame 按更正後的牌或返還金額重新結算牌局，差額記入玩家餘額
+func (h *AdminHandler) ResettleGame(w http.ResponseWriter, r *http.Request) {
+	h.correctGame(w, r, correction.ActionResettle)
+}
+
+// correctGame 鎖定牌局後計算修正結果，返還、餘額、修正記錄、審計和事件在同一事務內寫入
+func (h *AdminHandler) correctGame(w http.ResponseWriter, r *http.Request, action correction.Action) {
+	if r.Method != http.MethodPost {
+		logger.Warn("Invalid method for CorrectGame:", r.Method)
+		utils.ErrorResponse(w, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	adminID, ok := middleware.GetUserID(r)
+	if !ok {
+		logger.Warn("Unauthorized access to CorrectGame")
+		utils.UnauthorizedError(w)
+		return
+	}
+
+	var input correctionInput
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil || input.GameID == "" {
+		logger.Warn("Invalid request body for CorrectGame:", err)
+		utils.ValidationError(w, "Invalid request body")
+		return
+	}
+	input.Reason = strings.TrimSpace(input.Reason)
+	if input.Reason == "" || utf8.RuneCountInString(input.Reason) > 255 {
+		utils.ValidationError(w, "必須填寫原因（最多255個字符）")
+		return
+	}
+
+	// 更正後的牌先按補牌規則重放，得到勝方和每種投注的返還
+	var replayed *game.Game
+	if action == correction.ActionResettle {
+		hasCards := len(input.PlayerCards) > 0 || len(input.BankerCards) > 0
+		if hasCards == (len(input.Returns) > 0) {
+			utils.ValidationError(w, "請提供更正後的牌（playerCards、bankerCards）或返還金額（returns）其中之一")
+			return
+		}
+		if hasCards {
+			player, err := game.ParseCards(strings.Join(input.PlayerCards, ","))
+			if err != nil {
+				utils.ValidationError(w, "無效的閒家牌")
+				return
+			}
+			banker, err := game.ParseCards(strings.Join(input.BankerCards, ","))
+			if err != nil {
+				utils.ValidationError(w, "無效的莊家牌")
+				return
+			}
+			if replayed, err = game.Replay(player, banker); err != nil {
+				utils.ValidationError(w, "更正後的牌不符合補牌規則")
+				return
+			}
+		}
+	}
+
+	var original, corrected correction.Snapshot
+	var deltas []correction.Delta
+	ip := clientIP(r)
+
+	err := db.TransactionContext(r.Context(), func(tx *sql.Tx) error {
+		current, err := db.LockGameForCorrection(tx, input.GameID)
+		if err != nil {
+			return err
+		}
+		if err := correction.CanCorrect(current.Status); err != nil {
+			return err
+		}
+		original = *current
+
+		switch {
+		case action == correction.ActionVoid:
+			corrected = correction.Void(original)
+		case replayed != nil:
+			corrected = correction.Resettle(original, replayed.GetWinner(),
+				formatCards(replayed.GetPlayerHand()), formatCards(replayed.GetBankerHand()), replayed.BetReturn)
+		default:
+			if corrected, err = correction.ResettleReturns(original, input.Returns); err != nil {
+				return err
+			}
+		}
+
+		if deltas, err = db.SaveGameCorrection(tx, input.GameID, action, input.Reason, adminID, original, corrected); err != nil {
+			return err
+		}
+
+		auditAction := db.AdminActionGameVoided
+		if action == correction.ActionResettle {
+			auditAction = db.AdminActionGameResettled
+		}
+		if err := db.SaveAdminAuditEvent(tx, adminID, auditAction, 0, input.GameID+": "+input.Reason, ip); err != nil {
+			return err
+		}
+
+		return enqueueCorrectionEvents(tx, input.GameID, action, input.Reason, corrected, deltas)
+	})
+	switch err {
+	case nil:
+	case db.ErrGameNotFound:
+		utils.ErrorResponse(w, http.StatusNotFound, "遊戲不存在")
+		return
+	case db.ErrGameArchived:
+		utils.ErrorResponse(w, http.StatusConflict, "牌局已歸檔，不能再修正")
+		return
+	case correction.ErrAlreadyVoided:
+		utils.ErrorResponse(w, http.StatusConflict, "牌局已作廢，不能再修正")
+		return
+	case correction.ErrUnknownBetType:
+		utils.ValidationError(w, "牌局中沒有該類型的下注")
+		return
+	case correction.ErrAmbiguousBetType:
+		utils.ValidationError(w, "牌局中該類型有多筆下注，請改用更正後的牌重新結算")
+		return
+	case correction.ErrInvalidReturn:
+		utils.ValidationError(w, "無效的返還金額")
+		return
+	case db.ErrInsufficientBalance:
+		utils.ErrorResponse(w, http.StatusConflict, "扣回的差額超過玩家餘額，請先處理相關玩家的餘額")
+		return
+	default:
+		logger.Error("Error correcting game", input.GameID, "Error:", err)
+		utils.ServerError(w, "Error correcting game")
+		return
+	}
+
+	logger.Info("Admin", adminID, string(action), "game", input.GameID, "affected users:", len(deltas))
+	utils.SuccessResponse(w, map[string]interface{}{
+		"gameId":    input.GameID,
+		"original":  original,
+		"corrected": corrected,
+		"deltas":    deltas,
+	})
+}
+
+// enqueueCorrectionEvents 寫入牌局修正事件，以及每位餘額有變動的玩家的錢包交易事件
+func enqueueCorrectionEvents(tx *sql.Tx, gameID string, action correction.Action, reason string,
+	corrected correction.Snapshot, deltas []correction.Delta) error {
+
+	affected := corrected.AffectedUsers()
+	var e *events.Event
+	if action == correction.ActionVoid {
+		e = events.New(events.TypeGameVoided, events.GameVoided{
+			GameID:        gameID,
+			Reason:        reason,
+			AffectedUsers: affected,
+		})
+	} else {
+		e = events.New(events.TypeGameResettled, events.GameResettled{
+			GameID:        gameID,
+			Winner:        corrected.Winner,
+			TotalBets:     corrected.TotalBets(),
+			TotalPayouts:  corrected.TotalReturns(),
+			Reason:        reason,
+			AffectedUsers: affected,
+		})
+	}
+	if err := events.Enqueue(tx, e); err != nil {
+		return err
+	}
+
+	for _, d := range deltas {
+		err := events.Enqueue(tx, events.New(events.TypeWalletTransaction, events.WalletTransaction{
+			UserID:          d.UserID,
+			Amount:          d.Amount,
+			TransactionType: action.TransactionType(),
+			Reference:       gameID,
+		}))
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// GetGameCorrections 查詢牌局（?gameId=）的作廢和重新結算記錄，包含修正前後的快照
+func (h *AdminHandler) GetGameCorrections(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		logger.Warn("Invalid method for GetGameCorrections:", r.Method)
+		utils.ErrorResponse(w, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	if _, ok := middleware.GetUserID(r); !ok {
+		logger.Warn("Unauthorized access to GetGameCorrections")
+		utils.UnauthorizedError(w)
+		return
+	}
+
+	gameID := r.URL.Query().Get("gameId")
+	if gameID == "" {
+		utils.ValidationError(w, "必須提供 gameId")
+		return
+	}
+
+	corrections, err := db.ListGameCorrections(gameID)
+	if err != nil {
+		logger.Error("Error retrieving corrections for game", gameID, "Error:", err)
+		utils.ServerError(w, "Error retrieving game corrections")
+		return
+	}
+
+	utils.SuccessResponse(w, map[string]interface{}{
+		"gameId":      gameID,
+		"corrections": corrections,
+	})
+}
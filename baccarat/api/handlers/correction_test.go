@@ -0,0 +1,97 @@
+package handlers
+
+import (
+	"baccarat/db"
+	"baccarat/internal/auth"
+	"baccarat/internal/clock"
+	"baccarat/internal/store"
+	"database/sql"
+	"net/http"
+	"testing"
+)
+
+// setBalance 將用戶餘額設為 amount
+func setBalance(t *testing.T, userID int, amount float64) {
+	t.Helper()
+	err := db.Transaction(func(tx *sql.Tx) error {
+		_, err := tx.Exec("UPDATE users SET balance = ? WHERE id = ?", amount, userID)
+		return err
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func balanceOf(t *testing.T, userID int) float64 {
+	t.Helper()
+	balance, err := db.GetUserBalance(userID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return balance
+}
+
+func TestVoidGameRejectsNegativeBalance(t *testing.T) {
+	guard := auth.NewLoginGuard(auth.LockoutPolicy{}, auth.LockoutPolicy{}, auth.DBAttemptStore{}, clock.System{})
+	h := NewAdminHandler(store.NewDBStore(), guard)
+	adminID := createTestUser(t, "corrector", "Secret123")
+	winnerID := createTestUser(t, "lucky", "Secret123")
+	loserID := createTestUser(t, "unlucky", "Secret123")
+
+	// 閒家勝：lucky 押閒 10 得 20，unlucky 押莊 10 輸掉；作廢後 lucky 扣回 10，unlucky 退回 10
+	record := &db.GameRecord{
+		GameID:             "void-1",
+		PlayerInitialCards: "S7,H2",
+		BankerInitialCards: "D5,CK",
+		PlayerInitialScore: 9,
+		BankerInitialScore: 5,
+		PlayerFinalScore:   9,
+		BankerFinalScore:   5,
+		Winner:             "Player",
+		Payouts:            map[string]float64{"player": 20},
+	}
+	err := db.Transaction(func(tx *sql.Tx) error {
+		if err := db.SaveGameRecord(tx, record); err != nil {
+			return err
+		}
+		if err := db.SaveBet(tx, winnerID, "void-1", 10, "player"); err != nil {
+			return err
+		}
+		return db.SaveBet(tx, loserID, "void-1", 10, "banker")
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	setBalance(t, winnerID, 4)
+	setBalance(t, loserID, 0)
+
+	void := map[string]string{"gameId": "void-1", "reason": "dealer error"}
+	if code, _ := call(t, h.VoidGame, http.MethodPost, "/api/admin/games/void", adminID, void); code != http.StatusConflict {
+		t.Fatalf("void with insufficient balance: status %d, want 409", code)
+	}
+	// 整個修正不生效：餘額、牌局狀態和修正記錄都不變
+	if got := balanceOf(t, winnerID); got != 4 {
+		t.Errorf("winner balance = %v, want 4", got)
+	}
+	if got := balanceOf(t, loserID); got != 0 {
+		t.Errorf("loser balance = %v, want 0", got)
+	}
+	if corrections, err := db.ListGameCorrections("void-1"); err != nil || len(corrections) != 0 {
+		t.Errorf("corrections = %+v, %v", corrections, err)
+	}
+
+	setBalance(t, winnerID, 25)
+	code, data := call(t, h.VoidGame, http.MethodPost, "/api/admin/games/void", adminID, void)
+	if code != http.StatusOK {
+		t.Fatalf("void: status %d", code)
+	}
+	if deltas, _ := data["deltas"].([]interface{}); len(deltas) != 2 {
+		t.Errorf("deltas = %v", data["deltas"])
+	}
+	if got := balanceOf(t, winnerID); got != 15 {
+		t.Errorf("winner balance = %v, want 15", got)
+	}
+	if got := balanceOf(t, loserID); got != 10 {
+		t.Errorf("loser balance = %v, want 10", got)
+	}
+}
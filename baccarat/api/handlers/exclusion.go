@@ -0,0 +1,279 @@
+package handlers
+
+import (
+	"baccarat/api/middleware"
+	"baccarat/db"
+	"baccarat/internal/limits"
+	"baccarat/pkg/logger"
+	"baccarat/pkg/utils"
+	"baccarat/pkg/validation"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var (
+	errExclusionNotExtended = errors.New("active exclusion can only be extended")
+	errAccountExcluded      = errors.New("account is excluded")
+)
+
+// exclusionDetail 審計日誌中的排除描述
+func exclusionDetail(e *limits.Exclusion) string {
+	if e.Permanent() {
+		return string(e.Kind) + " permanent"
+	}
+	return string(e.Kind) + " until " + e.EndsAt.Format(time.RFC3339)
+}
+
+// Exclusion 查看（GET）或開始（POST）冷靜期和自我排除
+func (h *UserHandler) Exclusion(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		h.getExclusion(w, r)
+	case http.MethodPost:
+		h.startExclusion(w, r)
+	default:
+		logger.Warn("Invalid method for Exclusion:", r.Method)
+		utils.ErrorResponse(w, http.StatusMethodNotAllowed, "Method not allowed")
+	}
+}
+
+// getExclusion 返回當前生效的排除
+func (h *UserHandler) getExclusion(w http.ResponseWriter, r *http.Request) {
+	userID, ok := middleware.GetUserID(r)
+	if !ok {
+		logger.Warn("Unauthorized access to GetExclusion")
+		utils.UnauthorizedError(w)
+		return
+	}
+
+	exclusion, err := db.GetActiveExclusion(db.DB, userID)
+	if err != nil {
+		logger.Error("Error retrieving exclusion for user", userID, "Error:", err)
+		utils.ServerError(w, "Error retrieving exclusion")
+		return
+	}
+	utils.SuccessResponse(w, map[string]interface{}{"exclusion": exclusion})
+}
+
+// startExclusion 開始冷靜期或自我排除，立即登出所有設備並撤銷 API 金鑰
+// 生效中的排除只能延長，不能縮短或取消
+func (h *UserHandler) startExclusion(w http.ResponseWriter, r *http.Request) {
+	userID, ok := middleware.GetUserID(r)
+	if !ok {
+		logger.Warn("Unauthorized access to StartExclusion")
+		utils.UnauthorizedError(w)
+		return
+	}
+
+	var input struct {
+		Type     string `json:"type"`
+		Duration string `json:"duration"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+		logger.Warn("Invalid request body for StartExclusion:", err)
+		utils.ValidationError(w, "Invalid request body")
+		return
+	}
+
+	exclusion, err := limits.NewExclusion(limits.ExclusionKind(input.Type), input.Duration, time.Now())
+	if err != nil {
+		utils.ValidationError(w, "無效的類型或時長，冷靜期可選 24h, 7d, 30d, 42d，自我排除可選 6m, 1y, 5y, permanent")
+		return
+	}
+
+	ip := clientIP(r)
+	err = db.TransactionContext(r.Context(), func(tx *sql.Tx) error {
+		if err := db.LockUser(tx, userID); err != nil {
+			return err
+		}
+		current, err := db.GetActiveExclusion(tx, userID)
+		if err != nil {
+			return err
+		}
+		if current != nil && !exclusion.Extends(current) {
+			return errExclusionNotExtended
+		}
+
+		if err := db.CreateExclusion(tx, userID, exclusion); err != nil {
+			return err
+		}
+		if _, err := db.RevokeUserSessions(tx, userID, string(exclusion.Kind)); err != nil {
+			return err
+		}
+		if _, err := db.RevokeUserAPIKeys(tx, userID); err != nil {
+			return err
+		}
+		return db.SaveAuthAuditEventTx(tx, userID, "", ip, db.AuthEventExclusionStarted, exclusionDetail(exclusion))
+	})
+	if err == errExclusionNotExtended {
+		utils.ValidationError(w, "已有生效中的排除，只能延長不能縮短")
+		return
+	}
+	if err != nil {
+		logger.Error("Error starting exclusion for user", userID, "Error:", err)
+		utils.ServerError(w, "Error starting exclusion")
+		return
+	}
+
+	logger.Info("Exclusion started for user", userID, exclusionDetail(exclusion))
+	utils.SuccessResponse(w, map[string]interface{}{
+		"message":   "Exclusion started, all sessions have been signed out",
+		"exclusion": exclusion,
+	})
+}
+
+// rejectExcluded 登入時檢查帳戶是否處於排除期，是則返回錯誤響應並記錄審計
+func rejectExcluded(w http.ResponseWriter, r *http.Request, userID int, username string) bool {
+	exclusion, err := db.GetActiveExclusion(db.DB, userID)
+	if err != nil {
+		logger.Error("Error checking exclusion for user", userID, "Error:", err)
+		utils.ServerError(w, "Error during login")
+		return true
+	}
+	if exclusion == nil {
+		return false
+	}
+
+	logger.Warn("Login denied for excluded user:", username)
+	recordAuthEvent(userID, username, clientIP(r), db.AuthEventExcludedLoginDeny, exclusionDetail(exclusion))
+	middleware.ExcludedError(w, exclusion)
+	return true
+}
+
+// GetUserExclusion 管理員查看用戶的排除狀態和歷史記錄
+func (h *AdminHandler) GetUserExclusion(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		logger.Warn("Invalid method for GetUserExclusion:", r.Method)
+		utils.ErrorResponse(w, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	username := r.URL.Query().Get("username")
+	if err := validation.ValidateUsername(username); err != nil {
+		utils.ValidationError(w, err.Error())
+		return
+	}
+	userID, _, err := h.store.Users.FindByUsername(username)
+	if err == sql.ErrNoRows {
+		utils.ErrorResponse(w, http.StatusNotFound, "用戶不存在")
+		return
+	}
+	if err != nil {
+		logger.Error("Error retrieving user:", err)
+		utils.ServerError(w, "Error retrieving exclusion")
+		return
+	}
+
+	active, err := db.GetActiveExclusion(db.DB, userID)
+	if err != nil {
+		logger.Error("Error retrieving exclusion for user", userID, "Error:", err)
+		utils.ServerError(w, "Error retrieving exclusion")
+		return
+	}
+	history, err := db.ListExclusions(userID)
+	if err != nil {
+		logger.Error("Error listing exclusions for user", userID, "Error:", err)
+		utils.ServerError(w, "Error retrieving exclusion")
+		return
+	}
+
+	utils.SuccessResponse(w, map[string]interface{}{
+		"username": username,
+		"active":   active,
+		"history":  history,
+	})
+}
+
+// LiftUserExclusion 管理員提前解除定期排除（例如誤操作），永久自我排除不能解除
+func (h *AdminHandler) LiftUserExclusion(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		logger.Warn("Invalid method for LiftUserExclusion:", r.Method)
+		utils.ErrorResponse(w, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	adminID, ok := middleware.GetUserID(r)
+	if !ok {
+		logger.Warn("Unauthorized access to LiftUserExclusion")
+		utils.UnauthorizedError(w)
+		return
+	}
+
+	var input struct {
+		Username string `json:"username"`
+		Reason   string `json:"reason"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+		logger.Warn("Invalid request body for LiftUserExclusion:", err)
+		utils.ValidationError(w, "Invalid request body")
+		return
+	}
+	if err := validation.ValidateUsername(input.Username); err != nil {
+		utils.ValidationError(w, err.Error())
+		return
+	}
+	input.Reason = strings.TrimSpace(input.Reason)
+	if input.Reason == "" || len(input.Reason) > 255 {
+		utils.ValidationError(w, "必須填寫解除原因（最多255個字符）")
+		return
+	}
+
+	userID, _, err := h.store.Users.FindByUsername(input.Username)
+	if err == sql.ErrNoRows {
+		utils.ErrorResponse(w, http.StatusNotFound, "用戶不存在")
+		return
+	}
+	if err != nil {
+		logger.Error("Error retrieving user:", err)
+		utils.ServerError(w, "Error lifting exclusion")
+		return
+	}
+
+	var permanent bool
+	var lifted int64
+	err = db.TransactionContext(r.Context(), func(tx *sql.Tx) error {
+		if err := db.LockUser(tx, userID); err != nil {
+			return err
+		}
+		current, err := db.GetActiveExclusion(tx, userID)
+		if err != nil || current == nil {
+			return err
+		}
+		if current.Permanent() {
+			permanent = true
+			return nil
+		}
+
+		lifted, err = db.LiftExclusions(tx, userID, adminID, input.Reason)
+		if err != nil {
+			return err
+		}
+		detail := "lifted by admin " + strconv.Itoa(adminID) + ": " + input.Reason
+		if err := db.SaveAuthAuditEventTx(tx, userID, input.Username, clientIP(r), db.AuthEventExclusionLifted, detail); err != nil {
+			return err
+		}
+		return db.SaveAdminAuditEvent(tx, adminID, db.AdminActionExclusionLifted, userID, input.Reason, clientIP(r))
+	})
+	if err != nil {
+		logger.Error("Error lifting exclusion for user", input.Username, "Error:", err)
+		utils.ServerError(w, "Error lifting exclusion")
+		return
+	}
+	if permanent {
+		logger.Warn("Admin", adminID, "attempted to lift permanent exclusion of", input.Username)
+		utils.ErrorResponse(w, http.StatusForbidden, "永久自我排除不能提前解除")
+		return
+	}
+	if lifted == 0 {
+		utils.ErrorResponse(w, http.StatusNotFound, "用戶沒有生效中的排除")
+		return
+	}
+
+	logger.Info("Admin", adminID, "lifted exclusion of user", input.Username)
+	utils.SuccessResponse(w, map[string]string{"message": "Exclusion lifted"})
+}
@@ -4,25 +4,34 @@ import (
 	"baccarat/api/middleware"
 	"baccarat/db"
 	"baccarat/game"
+	"baccarat/internal/auth"
+	"baccarat/internal/limits"
+	"baccarat/internal/store"
 	"baccarat/pkg/logger"
+	"baccarat/pkg/metrics"
+	"baccarat/pkg/tracing"
 	"baccarat/pkg/utils"
 	"baccarat/pkg/validation"
+	"context"
 	"database/sql"
+	"encoding/hex"
 	"encoding/json"
 	"net/http"
 	"strconv"
 	"strings"
 
 	"github.com/google/uuid"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
 )
 
 type GameHandler struct {
-	db *sql.DB
+	store store.Store
 }
 
-func NewGameHandler(db *sql.DB) *GameHandler {
+func NewGameHandler(store store.Store) *GameHandler {
 	return &GameHandler{
-		db: db,
+		store: store,
 	}
 }
 
@@ -33,14 +42,15 @@ func (h *GameHandler) PlayGame(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	log := logger.FromContext(r.Context())
 	userID, ok := middleware.GetUserID(r)
 	if !ok {
-		logger.Warn("Unauthorized access to PlayGame")
+		log.Warn("Unauthorized access to PlayGame")
 		utils.UnauthorizedError(w)
 		return
 	}
 
-	logger.Debug("Starting game for user", userID)
+	log.Debug("Starting game")
 
 	// 解析投注信息
 	var bets struct {
@@ -52,14 +62,14 @@ func (h *GameHandler) PlayGame(w http.ResponseWriter, r *http.Request) {
 	}
 
 	if err := json.NewDecoder(r.Body).Decode(&bets); err != nil {
-		logger.Warn("Invalid bet data")
+		log.WithError(err).Warn("Invalid bet data")
 		utils.ValidationError(w, "Invalid bet data")
 		return
 	}
 
 	// 檢查是否同時下注莊家和閒家
 	if bets.Player > 0 && bets.Banker > 0 {
-		logger.Warn("Cannot bet on both Player and Banker")
+		log.Warn("Cannot bet on both Player and Banker")
 		utils.ValidationError(w, "不能同時下注莊家和閒家")
 		return
 	}
@@ -67,28 +77,28 @@ func (h *GameHandler) PlayGame(w http.ResponseWriter, r *http.Request) {
 	// 驗證每個投注金額
 	if bets.Player > 0 {
 		if err := validation.ValidateAmount(bets.Player); err != nil {
-			logger.Warn("Invalid player bet for user", userID, "Error:", err)
+			log.WithError(err).Warn("Invalid player bet")
 			utils.ValidationError(w, "Invalid player bet: "+err.Error())
 			return
 		}
 	}
 	if bets.Banker > 0 {
 		if err := validation.ValidateAmount(bets.Banker); err != nil {
-			logger.Warn("Invalid banker bet for user", userID, "Error:", err)
+			log.WithError(err).Warn("Invalid banker bet")
 			utils.ValidationError(w, "Invalid banker bet: "+err.Error())
 			return
 		}
 	}
 	if bets.Tie > 0 {
 		if err := validation.ValidateAmount(bets.Tie); err != nil {
-			logger.Warn("Invalid tie bet for user", userID, "Error:", err)
+			log.WithError(err).Warn("Invalid tie bet")
 			utils.ValidationError(w, "Invalid tie bet: "+err.Error())
 			return
 		}
 	}
 	if bets.LuckySix > 0 {
 		if err := validation.ValidateAmount(bets.LuckySix); err != nil {
-			logger.Warn("Invalid lucky six bet for user", userID, "Error:", err)
+			log.WithError(err).Warn("Invalid lucky six bet")
 			utils.ValidationError(w, "Invalid lucky six bet: "+err.Error())
 			return
 		}
@@ -97,7 +107,7 @@ func (h *GameHandler) PlayGame(w http.ResponseWriter, r *http.Request) {
 	// 計算總投注額
 	totalBet := bets.Player + bets.Banker + bets.Tie + bets.LuckySix
 	if totalBet <= 0 {
-		logger.Warn("No bets placed for user", userID)
+		log.Warn("No bets placed")
 		utils.ValidationError(w, "No bets placed")
 		return
 	}
@@ -108,26 +118,49 @@ func (h *GameHandler) PlayGame(w http.ResponseWriter, r *http.Request) {
 		var err error
 		runTimes, err = strconv.Atoi(bets.RUN_TIMES)
 		if err != nil || runTimes <= 0 {
-			logger.Warn("Invalid RUN_TIMES value for user", userID)
+			log.WithField("run_times", bets.RUN_TIMES).Warn("Invalid RUN_TIMES value")
 			utils.ValidationError(w, "Invalid RUN_TIMES value")
 			return
 		}
 	}
 
 	// 檢查用戶餘額是否足夠支付所有運行次數的投注
-	balance, err := db.GetUserBalance(userID)
+	balance, err := h.store.Wallet.Balance(userID)
 	if err != nil {
-		logger.Error("Error checking balance for user", userID, "Error:", err)
+		log.WithError(err).Error("Error checking balance")
 		utils.ServerError(w, "Error checking balance")
 		return
 	}
 
 	if balance < totalBet*float64(runTimes) {
-		logger.Warn("Insufficient balance for user", userID)
+		log.WithFields(logger.Fields{"balance": balance, "required": totalBet * float64(runTimes)}).Warn("Insufficient balance")
 		utils.ValidationError(w, "Insufficient balance for all runs")
 		return
 	}
 
+	// 檢查本次所有運行次數的投注是否超出限額（每局在事務內還會再次檢查）
+	if err := enforceLimits(db.DB, userID, 0, totalBet*float64(runTimes)); err != nil {
+		if exceeded, ok := err.(*limits.ExceededError); ok {
+			log.WithError(exceeded).Warn("Wager limit exceeded")
+			limitExceededResponse(w, exceeded)
+			return
+		}
+		log.WithError(err).Error("Error checking limits")
+		utils.ServerError(w, "Error checking limits")
+		return
+	}
+
+	// 下注的類型，記錄在每局的 span 上
+	var betTypes []string
+	for _, bet := range []struct {
+		name   string
+		amount float64
+	}{{"player", bets.Player}, {"banker", bets.Banker}, {"tie", bets.Tie}, {"luckySix", bets.LuckySix}} {
+		if bet.amount > 0 {
+			betTypes = append(betTypes, bet.name)
+		}
+	}
+
 	// 存儲所有遊戲結果
 	var allGameResults []map[string]interface{}
 
@@ -141,49 +174,117 @@ func (h *GameHandler) PlayGame(w http.ResponseWriter, r *http.Request) {
 			payouts     map[string]float64
 		)
 
+		roundCtx, roundSpan := tracing.Tracer().Start(r.Context(), "game.round", trace.WithAttributes(
+			attribute.Int("game.round", i+1),
+			attribute.StringSlice("game.bet_types", betTypes),
+			attribute.Float64("game.total_bet", totalBet),
+		))
+
 		// 開始事務
-		err = db.Transaction(func(tx *sql.Tx) error {
+		err = h.store.Transaction(roundCtx, func(tx *sql.Tx) (err error) {
+			// 鎖定用戶後再次檢查餘額和限額，防止並發請求同時通過預檢查
+			balance, err := h.store.Wallet.Lock(tx, userID)
+			if err != nil {
+				return err
+			}
+			if balance < totalBet {
+				return db.ErrInsufficientBalance
+			}
+			if err := enforceLimits(tx, userID, 0, totalBet); err != nil {
+				return err
+			}
+			// 多局運行期間玩家可能已開始冷靜期或自我排除
+			if exclusion, err := db.GetActiveExclusion(tx, userID); err != nil {
+				return err
+			} else if exclusion != nil {
+				return errAccountExcluded
+			}
+			// 管理員可能已凍結帳戶
+			if frozen, err := h.store.Users.Frozen(tx, userID); err != nil {
+				return err
+			} else if frozen {
+				return errAccountFrozen
+			}
+
 			// 扣除投注金額
-			if err := db.UpdateUserBalance(tx, userID, -totalBet); err != nil {
+			if err := h.store.Wallet.AddBalance(tx, userID, -totalBet); err != nil {
 				return err
 			}
 
 			// 進行遊戲
 			gameID = uuid.New().String()
-			g = game.NewGame()
-			g.Deal()
-			if g.NeedThirdCard() {
-				g.DealThirdCard()
+			roundSpan.SetAttributes(attribute.String("game_id", gameID))
+			var draw *shoeDraw
+			if g, draw, err = h.dealFromShoe(roundCtx, tx); err != nil {
+				return err
 			}
-			g.DetermineWinner()
-			g.CalculatePayouts()
+
+			_, settleSpan := tracing.Tracer().Start(roundCtx, "game.settle")
+			defer func() { tracing.End(settleSpan, err) }()
 
 			// 計算賠付
 			payouts = g.GetPayouts(bets)
 			totalPayout = g.GetTotalPayout(payouts)
+			settleSpan.SetAttributes(attribute.Float64("game.total_payout", totalPayout))
 
 			// 更新用戶餘額（加上賠付金額）
 			if totalPayout > 0 {
-				if err := db.UpdateUserBalance(tx, userID, totalPayout); err != nil {
+				if err := h.store.Wallet.AddBalance(tx, userID, totalPayout); err != nil {
 					return err
 				}
 			}
 
 			// 保存遊戲記錄
-			if err := saveGameRecord(tx, g, gameID, payouts); err != nil {
+			record := newGameRecord(g, gameID, payouts)
+			draw.apply(record)
+			if err := h.store.Games.Save(tx, record); err != nil {
 				return err
 			}
 
 			// 保存投注記錄
-			if err := saveBets(tx, userID, gameID, bets); err != nil {
+			if err := h.saveBets(tx, userID, gameID, bets); err != nil {
 				return err
 			}
 
 			return nil
 		})
-
+		tracing.End(roundSpan, err)
+
+		if exceeded, ok := err.(*limits.ExceededError); ok {
+			// 已完成的局數照常返回
+			log.WithError(exceeded).WithField("rounds_played", i).Warn("Limit reached")
+			if i == 0 {
+				limitExceededResponse(w, exceeded)
+				return
+			}
+			break
+		}
+		if err == errAccountExcluded {
+			log.WithField("rounds_played", i).Warn("User excluded")
+			if i == 0 {
+				utils.ErrorResponse(w, http.StatusForbidden, "帳戶處於冷靜期或自我排除期")
+				return
+			}
+			break
+		}
+		if err == db.ErrInsufficientBalance {
+			log.WithField("rounds_played", i).Warn("Insufficient balance")
+			if i == 0 {
+				utils.ValidationError(w, "Insufficient balance for all runs")
+				return
+			}
+			break
+		}
+		if err == errAccountFrozen {
+			log.WithField("rounds_played", i).Warn("User frozen")
+			if i == 0 {
+				frozenResponse(w)
+				return
+			}
+			break
+		}
 		if err != nil {
-			logger.Error("Error processing game for user", userID, "Error:", err)
+			log.WithError(err).WithField("game_id", gameID).Error("Error processing game")
 			utils.ServerError(w, "Error processing game")
 			return
 		}
@@ -225,10 +326,28 @@ func (h *GameHandler) PlayGame(w http.ResponseWriter, r *http.Request) {
 
 		allGameResults = append(allGameResults, gameResult)
 
+		metrics.ObserveRound(playTableID, g.GetWinner(), g.GetLuckySixType(),
+			map[string]float64{"player": bets.Player, "banker": bets.Banker, "tie": bets.Tie, "luckySix": bets.LuckySix},
+			map[string]float64{
+				"player":   payouts["player"] + payouts["player_principal"],
+				"banker":   payouts["banker"] + payouts["banker_principal"],
+				"tie":      payouts["tie"] + payouts["tie_principal"],
+				"luckySix": payouts["luckySix"] + payouts["luckySix_principal"],
+			},
+		)
+
 		// 每次遊戲完成後立即輸出日志
-		logger.Info("Successfully processed game for user", userID, "GameID:", gameID, "Round:", i+1, "of", runTimes)
+		log.WithFields(logger.Fields{
+			"game_id":      gameID,
+			"round":        i + 1,
+			"rounds":       runTimes,
+			"winner":       g.GetWinner(),
+			"total_bet":    totalBet,
+			"total_payout": totalPayout,
+		}).Info("Game processed")
 	}
 
+	setSessionReminderHeaders(w, r)
 	utils.SuccessResponse(w, allGameResults)
 }
 
@@ -259,17 +378,55 @@ func (h *GameHandler) GetGameDetails(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	log := logger.FromContext(r.Context()).WithField("game_id", req.GameID)
+	userID, ok := middleware.GetUserID(r)
+	if !ok {
+		log.Warn("Unauthorized access to GetGameDetails")
+		utils.UnauthorizedError(w)
+		return
+	}
+	role, _ := middleware.GetRole(r)
+
 	// 獲取遊戲詳情
-	result, err := db.GetGameDetails(req.GameID)
+	result, err := h.store.Games.Details(req.GameID)
 	if err != nil {
-		logger.Error("獲取遊戲詳情失敗:", err)
+		log.WithError(err).Error("獲取遊戲詳情失敗")
 		utils.ErrorResponse(w, http.StatusInternalServerError, err.Error())
 		return
 	}
 
+	// 非管理員只能看到自己的下注，未參與的遊戲視為不存在
+	if !role.Can(auth.PermGameDetailsAll) {
+		filterOwnBets(result, userID)
+		if len(result.Bets) == 0 {
+			log.Warn("Requested details of game without own bets")
+			utils.ErrorResponse(w, http.StatusNotFound, "遊戲不存在")
+			return
+		}
+	}
+
 	utils.SuccessResponse(w, result)
 }
 
+// filterOwnBets 只保留指定用戶的下注，並重新計算總計
+func filterOwnBets(result *db.GameResult, userID int) {
+	var own []db.BetDetail
+	var totalBets, totalPayouts float64
+	for _, bet := range result.Bets {
+		if bet.UserID != userID {
+			continue
+		}
+		own = append(own, bet)
+		totalBets += bet.BetAmount
+		if bet.Payout.Valid {
+			totalPayouts += bet.Payout.Float64
+		}
+	}
+	result.Bets = own
+	result.TotalBets = totalBets
+	result.TotalPayouts = totalPayouts
+}
+
 // 格式化牌
 func formatCards(cards []game.Card) []string {
 	result := make([]string, len(cards))
@@ -281,9 +438,7 @@ func formatCards(cards []game.Card) []string {
 
 // 格式化單張牌
 func formatCard(card game.Card) string {
-	suits := []string{"S", "H", "D", "C"} // Spades, Hearts, Diamonds, Clubs
-	values := []string{"A", "2", "3", "4", "5", "6", "7", "8", "9", "10", "J", "Q", "K"}
-	return suits[card.Suit] + values[card.Value-1] // 例如：S7, CK
+	return card.String() // 例如：S7, CK
 }
 
 // 格式化牌組為字符串
@@ -291,75 +446,124 @@ func formatCardsToString(cards []string) string {
 	return strings.Join(cards, ",") // 例如：S7,CK
 }
 
-// 保存遊戲記錄
-func saveGameRecord(tx *sql.Tx, g *game.Game, gameID string, payouts map[string]float64) error {
+// newGameRecord 由一局遊戲的結果組裝牌局記錄
+func newGameRecord(g *game.Game, gameID string, payouts map[string]float64) *db.GameRecord {
 	// 格式化初始牌（只取前兩張）
 	playerHand := g.GetPlayerHand()
 	bankerHand := g.GetBankerHand()
-	
-	playerInitialCards := formatCards(playerHand[:2])
-	bankerInitialCards := formatCards(bankerHand[:2])
 
-	// 處理第三張牌
-	var playerThirdCard, bankerThirdCard sql.NullString
-	var playerThirdValue, bankerThirdValue sql.NullInt64
+	record := &db.GameRecord{
+		GameID:             gameID,
+		PlayerInitialCards: formatCardsToString(formatCards(playerHand[:2])),
+		BankerInitialCards: formatCardsToString(formatCards(bankerHand[:2])),
+		PlayerInitialScore: g.GetPlayerInitialScore(),
+		BankerInitialScore: g.GetBankerInitialScore(),
+		PlayerFinalScore:   g.GetPlayerScore(),
+		BankerFinalScore:   g.GetBankerScore(),
+		Winner:             g.GetWinner(),
+		IsLuckySix:         g.GetIsLuckySix(),
+		Payouts:            payouts,
+	}
 
+	// 處理第三張牌
 	if len(playerHand) > 2 {
-		playerThirdCard = sql.NullString{
-			String: formatCard(playerHand[2]),
-			Valid:  true,
-		}
-		playerThirdValue = sql.NullInt64{
-			Int64: int64(g.GetPlayerThirdValue()),
-			Valid: true,
-		}
+		record.PlayerThirdCard = sql.NullString{String: formatCard(playerHand[2]), Valid: true}
+		record.PlayerThirdValue = sql.NullInt64{Int64: int64(g.GetPlayerThirdValue()), Valid: true}
 	}
 	if len(bankerHand) > 2 {
-		bankerThirdCard = sql.NullString{
-			String: formatCard(bankerHand[2]),
-			Valid:  true,
-		}
-		bankerThirdValue = sql.NullInt64{
-			Int64: int64(g.GetBankerThirdValue()),
-			Valid: true,
-		}
+		record.BankerThirdCard = sql.NullString{String: formatCard(bankerHand[2]), Valid: true}
+		record.BankerThirdValue = sql.NullInt64{Int64: int64(g.GetBankerThirdValue()), Valid: true}
 	}
 
 	// 處理幸運6類型
-	var luckySixType sql.NullString
 	if g.GetIsLuckySix() {
-		luckySixType = sql.NullString{
-			String: g.GetLuckySixType(),
-			Valid:  true,
+		record.LuckySixType = sql.NullString{String: g.GetLuckySixType(), Valid: true}
+	}
+
+	return record
+}
+
+// playTableID 玩家直接下注的牌局所在的牌桌
+const playTableID = "main"
+
+// shoeDraw 一局從牌靴發出的牌
+type shoeDraw struct {
+	shoeID   string
+	position int
+	cards    []game.Card
+}
+
+// apply 把牌靴、位置和發牌順序寫入牌局記錄
+func (d *shoeDraw) apply(record *db.GameRecord) {
+	record.ShoeID = sql.NullString{String: d.shoeID, Valid: true}
+	record.ShoePosition = sql.NullInt64{Int64: int64(d.position), Valid: true}
+	record.DrawnCards = sql.NullString{String: game.FormatCards(d.cards), Valid: true}
+}
+
+// dealFromShoe 鎖定牌桌當前的牌靴並從下一局的位置發牌，剩餘的牌到達切牌位置時先換新牌靴
+func (h *GameHandler) dealFromShoe(ctx context.Context, tx *sql.Tx) (g *game.Game, draw *shoeDraw, err error) {
+	ctx, span := tracing.Tracer().Start(ctx, "game.deal", trace.WithAttributes(attribute.String("table_id", playTableID)))
+	defer func() { tracing.End(span, err) }()
+
+	current, err := h.store.Shoes.Current(tx, playTableID)
+	if err != nil && err != sql.ErrNoRows {
+		return nil, nil, err
+	}
+	var cards []game.Card
+	if current != nil {
+		if cards, err = game.ParseCards(current.CardOrder); err != nil {
+			return nil, nil, err
 		}
 	}
 
-	// 保存遊戲記錄
-	if err := db.SaveGameRecord(
-		gameID,
-		formatCardsToString(playerInitialCards),
-		formatCardsToString(bankerInitialCards),
-		g.GetPlayerInitialScore(),  // 新增：保存閒家初始點數
-		g.GetBankerInitialScore(),  // 新增：保存莊家初始點數
-		playerThirdCard,
-		bankerThirdCard,
-		playerThirdValue,     // 新增：保存閒家第三張牌點數
-		bankerThirdValue,     // 新增：保存莊家第三張牌點數
-		g.GetPlayerScore(),
-		g.GetBankerScore(),
-		g.GetWinner(),
-		g.GetIsLuckySix(),
-		luckySixType,
-		payouts,
-	); err != nil {
-		return err
+	if current == nil || len(cards)-current.NextPosition < game.CutCardReserve {
+		number := 1
+		if current != nil {
+			number = current.ShoeNumber + 1
+		}
+		shoe, err := game.NewRandomShoe(game.ShoeDecks)
+		if err != nil {
+			return nil, nil, err
+		}
+		current = &db.Shoe{
+			ShoeID:        uuid.New().String(),
+			TableID:       playTableID,
+			ShoeNumber:    number,
+			Decks:         game.ShoeDecks,
+			Seed:          hex.EncodeToString(shoe.Seed),
+			CardOrder:     game.FormatCards(shoe.Cards),
+			CardOrderHash: game.HashCards(shoe.Cards),
+			BurnCards:     shoe.Burned,
+			NextPosition:  shoe.Burned,
+		}
+		if err := h.store.Shoes.Create(tx, current); err != nil {
+			return nil, nil, err
+		}
+		cards = shoe.Cards
+		logger.FromContext(ctx).WithFields(logger.Fields{
+			"shoe_id":         current.ShoeID,
+			"table_id":        playTableID,
+			"card_order_hash": current.CardOrderHash,
+		}).Info("New shoe")
 	}
 
-	return nil
+	g, drawn, err := game.DealFrom(cards, current.NextPosition)
+	if err != nil {
+		return nil, nil, err
+	}
+	if err := h.store.Shoes.Advance(tx, current.ShoeID, current.NextPosition+len(drawn)); err != nil {
+		return nil, nil, err
+	}
+	span.SetAttributes(
+		attribute.String("shoe_id", current.ShoeID),
+		attribute.Int("shoe.position", current.NextPosition),
+		attribute.String("game.winner", g.GetWinner()),
+	)
+	return g, &shoeDraw{shoeID: current.ShoeID, position: current.NextPosition, cards: drawn}, nil
 }
 
 // 保存投注記錄
-func saveBets(tx *sql.Tx, userID int, gameID string, bets struct {
+func (h *GameHandler) saveBets(tx *sql.Tx, userID int, gameID string, bets struct {
 	Player    float64 `json:"player"`
 	Banker    float64 `json:"banker"`
 	Tie       float64 `json:"tie"`
@@ -368,22 +572,22 @@ func saveBets(tx *sql.Tx, userID int, gameID string, bets struct {
 }) error {
 	// 保存每個投注
 	if bets.Player > 0 {
-		if err := db.SaveBet(tx, userID, gameID, bets.Player, "player"); err != nil {
+		if err := h.store.Bets.Save(tx, userID, gameID, bets.Player, "player"); err != nil {
 			return err
 		}
 	}
 	if bets.Banker > 0 {
-		if err := db.SaveBet(tx, userID, gameID, bets.Banker, "banker"); err != nil {
+		if err := h.store.Bets.Save(tx, userID, gameID, bets.Banker, "banker"); err != nil {
 			return err
 		}
 	}
 	if bets.Tie > 0 {
-		if err := db.SaveBet(tx, userID, gameID, bets.Tie, "tie"); err != nil {
+		if err := h.store.Bets.Save(tx, userID, gameID, bets.Tie, "tie"); err != nil {
 			return err
 		}
 	}
 	if bets.LuckySix > 0 {
-		if err := db.SaveBet(tx, userID, gameID, bets.LuckySix, "luckySix"); err != nil {
+		if err := h.store.Bets.Save(tx, userID, gameID, bets.LuckySix, "luckySix"); err != nil {
 			return err
 		}
 	}
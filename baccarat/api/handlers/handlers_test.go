@@ -0,0 +1,104 @@
+package handlers
+
+import (
+	"baccarat/config"
+	"baccarat/internal/auth"
+	"baccarat/internal/clock"
+	"baccarat/internal/store"
+	"baccarat/internal/testdb"
+	"baccarat/pkg/logger"
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+	"time"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+// 處理器測試使用臨時 SQLite 數據庫，執行全部遷移後共用；各測試使用不同的用戶名互不影響
+func TestMain(m *testing.M) {
+	logger.InitLogger()
+	config.AppConfig.AccessTokenExpiry = 15
+	config.AppConfig.RefreshTokenExpiry = 24
+	config.AppConfig.PasswordResetExpiry = 30
+	config.AppConfig.TOTPIssuer = "Baccarat"
+
+	cleanup, err := testdb.Open()
+	if err != nil {
+		panic(err)
+	}
+	code := m.Run()
+	cleanup()
+	os.Exit(code)
+}
+
+// recordingNotifier 記下發送的重設密碼令牌
+type recordingNotifier struct {
+	resetTokens map[int]string
+}
+
+func (n *recordingNotifier) SendPasswordReset(userID int, username, token string, expiresAt time.Time) error {
+	n.resetTokens[userID] = token
+	return nil
+}
+
+// newTestAuthHandler 以 HS256 密鑰和資料庫存儲的登入防護創建處理器，用戶名失敗 3 次後鎖定
+func newTestAuthHandler(clock clock.Clock) (*AuthHandler, *recordingNotifier) {
+	policy := auth.LockoutPolicy{
+		MaxFailures:     3,
+		Window:          15 * time.Minute,
+		LockoutDuration: 10 * time.Minute,
+	}
+	ipPolicy := policy
+	ipPolicy.MaxFailures = 100
+	guard := auth.NewLoginGuard(policy, ipPolicy, auth.DBAttemptStore{}, clock)
+	notifier := &recordingNotifier{resetTokens: make(map[int]string)}
+	jwtService := auth.NewJWTService(auth.NewHMACKeySet([]byte("test-secret")))
+	return NewAuthHandler(store.NewDBStore(), jwtService, guard, notifier), notifier
+}
+
+// createTestUser 創建用戶，返回用戶ID
+func createTestUser(t *testing.T, username, password string) int {
+	t.Helper()
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.MinCost)
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := store.NewDBStore()
+	if err := s.Users.Create(username, hash); err != nil {
+		t.Fatal(err)
+	}
+	userID, _, err := s.Users.FindByUsername(username)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return userID
+}
+
+// call 以 JSON 請求體調用處理器；userID 大於 0 時模擬已認證的請求。返回狀態碼和響應的 data
+func call(t *testing.T, handler http.HandlerFunc, method, target string, userID int, body interface{}) (int, map[string]interface{}) {
+	t.Helper()
+	var buf bytes.Buffer
+	if body != nil {
+		if err := json.NewEncoder(&buf).Encode(body); err != nil {
+			t.Fatal(err)
+		}
+	}
+	r := httptest.NewRequest(method, target, &buf)
+	r.RemoteAddr = "192.0.2.1:1234"
+	if userID > 0 {
+		r = r.WithContext(context.WithValue(r.Context(), "userID", userID))
+	}
+	w := httptest.NewRecorder()
+	handler(w, r)
+
+	var response struct {
+		Data map[string]interface{} `json:"data"`
+	}
+	json.Unmarshal(w.Body.Bytes(), &response)
+	return w.Code, response.Data
+}
@@ -0,0 +1,220 @@
+package handlers
+
+import (
+	"baccarat/api/middleware"
+	"baccarat/db"
+	"baccarat/pkg/logger"
+	"baccarat/pkg/utils"
+	"encoding/base64"
+	"errors"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	defaultHistoryLimit = 50
+	maxHistoryLimit     = 200
+)
+
+var (
+	errInvalidCursor = errors.New("invalid cursor")
+	tableIDPattern   = regexp.MustCompile(`^[A-Za-z0-9_-]{1,32}$`)
+	historyBetTypes  = map[string]bool{"player": true, "banker": true, "tie": true, "luckySix": true}
+	historyOutcomes  = map[string]bool{db.OutcomeWin: true, db.OutcomeLose: true, db.OutcomePush: true}
+)
+
+// encodeHistoryCursor 將分頁位置編碼為不透明字符串
+func encodeHistoryCursor(c db.BetHistoryCursor) string {
+	raw := strconv.FormatInt(c.CreatedAt.UnixNano(), 10) + ":" + strconv.FormatInt(c.ID, 10)
+	return base64.RawURLEncoding.EncodeToString([]byte(raw))
+}
+
+// decodeHistoryCursor 解析客戶端傳回的遊標
+func decodeHistoryCursor(s string) (*db.BetHistoryCursor, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return nil, errInvalidCursor
+	}
+	ts, id, ok := strings.Cut(string(raw), ":")
+	if !ok {
+		return nil, errInvalidCursor
+	}
+	nanos, err := strconv.ParseInt(ts, 10, 64)
+	if err != nil {
+		return nil, errInvalidCursor
+	}
+	betID, err := strconv.ParseInt(id, 10, 64)
+	if err != nil || betID <= 0 {
+		return nil, errInvalidCursor
+	}
+	return &db.BetHistoryCursor{CreatedAt: time.Unix(0, nanos).In(localNow().Location()), ID: betID}, nil
+}
+
+// parseHistoryDate 解析日期參數，支持 YYYY-MM-DD（按 TIME_ZONE 的自然日）或 RFC3339
+func parseHistoryDate(s string) (time.Time, bool, error) {
+	if t, err := time.ParseInLocation("2006-01-02", s, localNow().Location()); err == nil {
+		return t, true, nil
+	}
+	t, err := time.Parse(time.RFC3339, s)
+	return t, false, err
+}
+
+// historyQuery 解析後的下注歷史查詢參數
+type historyQuery struct {
+	filter db.BetHistoryFilter
+	cursor *db.BetHistoryCursor
+	limit  int
+}
+
+// parseHistoryQuery 解析並驗證查詢參數，錯誤信息可直接返回給客戶端
+func parseHistoryQuery(userID int, query url.Values) (historyQuery, error) {
+	q := historyQuery{filter: db.BetHistoryFilter{UserID: userID}}
+
+	if from := query.Get("from"); from != "" {
+		t, _, err := parseHistoryDate(from)
+		if err != nil {
+			return historyQuery{}, errors.New("無效的開始日期")
+		}
+		q.filter.From = t
+	}
+	if to := query.Get("to"); to != "" {
+		t, dateOnly, err := parseHistoryDate(to)
+		if err != nil {
+			return historyQuery{}, errors.New("無效的結束日期")
+		}
+		if dateOnly {
+			t = t.AddDate(0, 0, 1)
+		}
+		q.filter.To = t
+	}
+	if !q.filter.From.IsZero() && !q.filter.To.IsZero() && !q.filter.From.Before(q.filter.To) {
+		return historyQuery{}, errors.New("開始日期必須早於結束日期")
+	}
+
+	if betType := query.Get("betType"); betType != "" {
+		if !historyBetTypes[betType] {
+			return historyQuery{}, errors.New("無效的投注類型，可選值: player, banker, tie, luckySix")
+		}
+		q.filter.BetType = betType
+	}
+	if outcome := query.Get("outcome"); outcome != "" {
+		if !historyOutcomes[outcome] {
+			return historyQuery{}, errors.New("無效的結果，可選值: win, lose, push")
+		}
+		q.filter.Outcome = outcome
+	}
+	if table := query.Get("table"); table != "" {
+		if !tableIDPattern.MatchString(table) {
+			return historyQuery{}, errors.New("無效的遊戲桌")
+		}
+		q.filter.TableID = table
+	}
+
+	q.limit = defaultHistoryLimit
+	if limitStr := query.Get("limit"); limitStr != "" {
+		l, err := strconv.Atoi(limitStr)
+		if err != nil || l <= 0 || l > maxHistoryLimit {
+			return historyQuery{}, errors.New("limit 必須在1-" + strconv.Itoa(maxHistoryLimit) + "之間")
+		}
+		q.limit = l
+	}
+
+	if cursorStr := query.Get("cursor"); cursorStr != "" {
+		c, err := decodeHistoryCursor(cursorStr)
+		if err != nil {
+			return historyQuery{}, errors.New("無效的遊標")
+		}
+		q.cursor = c
+	}
+	return q, nil
+}
+
+// GetHistory 查詢玩家的下注歷史，支持日期、投注類型、結果和遊戲桌篩選，
+// 以遊標分頁並返回篩選範圍內的匯總
+//
+// 參數：from、to（YYYY-MM-DD 時 to 包含當天）、betType、outcome（win/lose/push）、table、cursor、limit
+func (h *UserHandler) GetHistory(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		logger.Warn("Invalid method for GetHistory:", r.Method)
+		utils.ErrorResponse(w, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	userID, ok := middleware.GetUserID(r)
+	if !ok {
+		logger.Warn("Unauthorized access to GetHistory")
+		utils.UnauthorizedError(w)
+		return
+	}
+
+	q, err := parseHistoryQuery(userID, r.URL.Query())
+	if err != nil {
+		utils.ValidationError(w, err.Error())
+		return
+	}
+	filter, limit := q.filter, q.limit
+
+	// 多取一條以判斷是否還有下一頁
+	items, err := h.store.Bets.History(filter, q.cursor, limit+1)
+	if err != nil {
+		logger.Error("Error retrieving history for user", userID, "Error:", err)
+		utils.ServerError(w, "Error retrieving history")
+		return
+	}
+	var nextCursor string
+	if len(items) > limit {
+		items = items[:limit]
+		last := items[len(items)-1]
+		nextCursor = encodeHistoryCursor(db.BetHistoryCursor{CreatedAt: last.CreatedAt, ID: last.ID})
+	}
+
+	summary, err := h.store.Bets.HistorySummary(filter)
+	if err != nil {
+		logger.Error("Error retrieving history summary for user", userID, "Error:", err)
+		utils.ServerError(w, "Error retrieving history")
+		return
+	}
+
+	bets := make([]map[string]interface{}, 0, len(items))
+	for _, item := range items {
+		bet := map[string]interface{}{
+			"id":          item.ID,
+			"gameId":      item.GameID,
+			"table":       item.TableID,
+			"betType":     item.BetType,
+			"stake":       item.Stake,
+			"payout":      item.Payout,
+			"net":         item.Net,
+			"outcome":     item.Outcome,
+			"winner":      item.Winner,
+			"playerCards": item.PlayerCards,
+			"bankerCards": item.BankerCards,
+			"playerScore": item.PlayerScore,
+			"bankerScore": item.BankerScore,
+			"status":      item.Status,
+			"createdAt":   item.CreatedAt,
+		}
+		if item.IsLuckySix {
+			bet["isLuckySix"] = true
+			if item.LuckySixType.Valid {
+				bet["luckySixType"] = item.LuckySixType.String
+			}
+		}
+		bets = append(bets, bet)
+	}
+
+	response := map[string]interface{}{
+		"bets":    bets,
+		"summary": summary,
+	}
+	if nextCursor != "" {
+		response["nextCursor"] = nextCursor
+	}
+
+	logger.Info("Successfully retrieved history for user", userID)
+	utils.SuccessResponse(w, response)
+}
@@ -0,0 +1,193 @@
+package handlers
+
+import (
+	"baccarat/db"
+	"baccarat/internal/store"
+	"database/sql"
+	"encoding/base64"
+	"net/http"
+	"net/url"
+	"testing"
+	"time"
+)
+
+func TestHistoryCursorRoundTrip(t *testing.T) {
+	want := db.BetHistoryCursor{CreatedAt: time.Date(2025, 6, 1, 12, 30, 0, 123456789, time.UTC), ID: 42}
+	got, err := decodeHistoryCursor(encodeHistoryCursor(want))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !got.CreatedAt.Equal(want.CreatedAt) || got.ID != want.ID {
+		t.Errorf("decoded %+v, want %+v", got, want)
+	}
+}
+
+func TestHistoryCursorRejectsTampering(t *testing.T) {
+	encode := func(raw string) string { return base64.RawURLEncoding.EncodeToString([]byte(raw)) }
+	valid := encodeHistoryCursor(db.BetHistoryCursor{CreatedAt: time.Now(), ID: 7})
+
+	tests := []struct {
+		name   string
+		cursor string
+	}{
+		{"Not base64", "!!" + valid},
+		{"Padded base64", valid + "=="},
+		{"Missing separator", encode("1700000000000000000")},
+		{"Non-numeric time", encode("yesterday:7")},
+		{"Non-numeric id", encode("1700000000000000000:seven")},
+		{"Zero id", encode("1700000000000000000:0")},
+		{"Negative id", encode("1700000000000000000:-7")},
+		{"Trailing data", encode("1700000000000000000:7:1")},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if c, err := decodeHistoryCursor(tt.cursor); err != errInvalidCursor {
+				t.Errorf("decodeHistoryCursor(%q) = %+v, %v", tt.cursor, c, err)
+			}
+		})
+	}
+}
+
+func TestParseHistoryQuery(t *testing.T) {
+	q, err := parseHistoryQuery(5, url.Values{})
+	if err != nil || q.limit != defaultHistoryLimit || q.cursor != nil || q.filter != (db.BetHistoryFilter{UserID: 5}) {
+		t.Fatalf("defaults = %+v, %v", q, err)
+	}
+
+	q, err = parseHistoryQuery(5, url.Values{
+		"from":    {"2025-06-01"},
+		"to":      {"2025-06-03"},
+		"betType": {"luckySix"},
+		"outcome": {"push"},
+		"table":   {"vip-1"},
+		"limit":   {"200"},
+		"cursor":  {encodeHistoryCursor(db.BetHistoryCursor{CreatedAt: time.Now(), ID: 9})},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	loc := localNow().Location()
+	// 只給日期時結束日期包含當天
+	if !q.filter.From.Equal(time.Date(2025, 6, 1, 0, 0, 0, 0, loc)) || !q.filter.To.Equal(time.Date(2025, 6, 4, 0, 0, 0, 0, loc)) {
+		t.Errorf("range = %v - %v", q.filter.From, q.filter.To)
+	}
+	if q.filter.BetType != "luckySix" || q.filter.Outcome != db.OutcomePush || q.filter.TableID != "vip-1" || q.limit != 200 || q.cursor == nil || q.cursor.ID != 9 {
+		t.Errorf("parsed %+v", q)
+	}
+
+	q, err = parseHistoryQuery(5, url.Values{"to": {"2025-06-03T10:00:00Z"}})
+	if err != nil || !q.filter.To.Equal(time.Date(2025, 6, 3, 10, 0, 0, 0, time.UTC)) {
+		t.Errorf("RFC3339 to = %v, %v", q.filter.To, err)
+	}
+
+	invalid := []url.Values{
+		{"from": {"2025-13-01"}},
+		{"to": {"tomorrow"}},
+		{"from": {"2025-06-02"}, "to": {"2025-06-01"}},
+		{"from": {"2025-06-02T00:00:00Z"}, "to": {"2025-06-02T00:00:00Z"}},
+		{"betType": {"dragon"}},
+		{"outcome": {"won"}},
+		{"table": {"main table"}},
+		{"table": {"a123456789012345678901234567890123"}},
+		{"limit": {"0"}},
+		{"limit": {"201"}},
+		{"limit": {"ten"}},
+		{"cursor": {"not-a-cursor"}},
+	}
+	for _, query := range invalid {
+		if q, err := parseHistoryQuery(5, query); err == nil {
+			t.Errorf("parseHistoryQuery(%v) = %+v, want error", query, q)
+		}
+	}
+}
+
+// saveRound 保存一局結果和用戶的一筆下注
+func saveRound(t *testing.T, userID int, gameID, winner string, payouts map[string]float64, betType string, amount float64) {
+	t.Helper()
+	record := &db.GameRecord{
+		GameID:             gameID,
+		PlayerInitialCards: "S7,H2",
+		BankerInitialCards: "D5,CK",
+		PlayerInitialScore: 9,
+		BankerInitialScore: 5,
+		PlayerFinalScore:   9,
+		BankerFinalScore:   5,
+		Winner:             winner,
+		Payouts:            payouts,
+	}
+	err := db.Transaction(func(tx *sql.Tx) error {
+		if err := db.SaveGameRecord(tx, record); err != nil {
+			return err
+		}
+		return db.SaveBet(tx, userID, gameID, amount, betType)
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestGetHistorySummary(t *testing.T) {
+	h := NewUserHandler(store.NewDBStore())
+	userID := createTestUser(t, "historian", "Secret123")
+	otherID := createTestUser(t, "bystander", "Secret123")
+
+	saveRound(t, userID, "hist-1", "Player", map[string]float64{"player": 20}, "player", 10) // 贏 10
+	saveRound(t, userID, "hist-2", "Banker", map[string]float64{}, "player", 30)             // 輸 30
+	saveRound(t, userID, "hist-3", "Tie", map[string]float64{}, "banker", 5)                 // 和局退回
+	saveRound(t, userID, "hist-4", "Tie", map[string]float64{"tie": 36}, "tie", 4)           // 贏 32
+	saveRound(t, otherID, "hist-5", "Player", map[string]float64{"player": 200}, "player", 100)
+
+	summaryOf := func(query string) (map[string]interface{}, []interface{}) {
+		t.Helper()
+		code, data := call(t, h.GetHistory, http.MethodGet, "/api/history"+query, userID, nil)
+		if code != http.StatusOK {
+			t.Fatalf("GetHistory%s: status %d", query, code)
+		}
+		bets, _ := data["bets"].([]interface{})
+		return data["summary"].(map[string]interface{}), bets
+	}
+	check := func(query string, count, wagered, won float64) {
+		t.Helper()
+		summary, _ := summaryOf(query)
+		if summary["count"] != count || summary["wagered"] != wagered || summary["won"] != won || summary["net"] != won-wagered {
+			t.Errorf("summary%s = %v, want count %v wagered %v won %v", query, summary, count, wagered, won)
+		}
+	}
+
+	check("", 4, 49, 61)
+	check("?outcome=win", 2, 14, 56)
+	check("?outcome=lose", 1, 30, 0)
+	check("?outcome=push", 1, 5, 5)
+	check("?betType=player", 2, 40, 20)
+	check("?table=main", 4, 49, 61)
+	check("?table=vip", 0, 0, 0)
+	check("?to=2000-01-01", 0, 0, 0)
+
+	// 分頁不影響匯總，遊標依次返回所有下注且不重複
+	seen := make(map[string]bool)
+	query := "?limit=3"
+	for page := 0; ; page++ {
+		code, data := call(t, h.GetHistory, http.MethodGet, "/api/history"+query, userID, nil)
+		if code != http.StatusOK || page > 2 {
+			t.Fatalf("page %d: status %d", page, code)
+		}
+		if summary := data["summary"].(map[string]interface{}); summary["count"] != float64(4) {
+			t.Errorf("page %d summary = %v", page, summary)
+		}
+		for _, bet := range data["bets"].([]interface{}) {
+			gameID := bet.(map[string]interface{})["gameId"].(string)
+			if seen[gameID] {
+				t.Errorf("page %d repeats %s", page, gameID)
+			}
+			seen[gameID] = true
+		}
+		next, ok := data["nextCursor"].(string)
+		if !ok {
+			break
+		}
+		query = "?limit=3&cursor=" + next
+	}
+	if len(seen) != 4 || seen["hist-5"] {
+		t.Errorf("paged bets = %v", seen)
+	}
+}
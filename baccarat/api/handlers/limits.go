@@ -0,0 +1,315 @@
+package handlers
+
+import (
+	"baccarat/api/middleware"
+	"baccarat/config"
+	"baccarat/db"
+	"baccarat/internal/limits"
+	"baccarat/pkg/logger"
+	"baccarat/pkg/utils"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// limitUsage 計算限額在當前週期內的已用額度
+func limitUsage(q db.Querier, userID int, l limits.Limit, now time.Time) (float64, error) {
+	since := l.Period.Start(now)
+	if l.Type == limits.TypeDeposit {
+		return db.GetDepositTotal(q, userID, since)
+	}
+
+	wagered, returned, err := db.GetWagerTotals(q, userID, since)
+	if err != nil {
+		return 0, err
+	}
+	if l.Type == limits.TypeWager {
+		return wagered, nil
+	}
+	// 淨虧損，週期內盈利時為 0
+	if returned >= wagered {
+		return 0, nil
+	}
+	return wagered - returned, nil
+}
+
+// enforceLimits 檢查本次存款或投注是否超出限額，返回 *limits.ExceededError
+// 需在已鎖定用戶行（db.LockUser）的事務內調用，避免並發請求同時通過檢查
+func enforceLimits(q db.Querier, userID int, deposit, wager float64) error {
+	userLimits, err := db.GetUserLimits(q, userID)
+	if err != nil {
+		return err
+	}
+
+	now := localNow()
+	for _, l := range userLimits {
+		if _, ok := l.Effective(now); !ok {
+			continue
+		}
+
+		var amount float64
+		switch l.Type {
+		case limits.TypeDeposit:
+			amount = deposit
+		case limits.TypeWager, limits.TypeLoss:
+			// 虧損限額按最壞情況（全部輸掉）檢查
+			amount = wager
+		}
+		if amount <= 0 {
+			continue
+		}
+
+		used, err := limitUsage(q, userID, l, now)
+		if err != nil {
+			return err
+		}
+		if err := l.Check(now, used, amount); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// limitExceededResponse 返回超出限額的錯誤響應
+func limitExceededResponse(w http.ResponseWriter, exceeded *limits.ExceededError) {
+	names := map[limits.Type]string{
+		limits.TypeDeposit: "存款",
+		limits.TypeLoss:    "虧損",
+		limits.TypeWager:   "投注",
+	}
+	periods := map[limits.Period]string{
+		limits.PeriodDaily:   "每日",
+		limits.PeriodWeekly:  "每週",
+		limits.PeriodMonthly: "每月",
+	}
+	utils.ErrorResponse(w, http.StatusForbidden, fmt.Sprintf("已達到%s%s限額，剩餘額度 %.2f",
+		periods[exceeded.Period], names[exceeded.Type], exceeded.Remaining))
+}
+
+// Limits 查看（GET）或設置（POST）負責任博彩限額
+func (h *UserHandler) Limits(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		h.getLimits(w, r)
+	case http.MethodPost:
+		h.setLimit(w, r)
+	default:
+		logger.Warn("Invalid method for Limits:", r.Method)
+		utils.ErrorResponse(w, http.StatusMethodNotAllowed, "Method not allowed")
+	}
+}
+
+// getLimits 返回各限額的生效值、本週期已用和剩餘額度，以及當前會話時長
+func (h *UserHandler) getLimits(w http.ResponseWriter, r *http.Request) {
+	userID, ok := middleware.GetUserID(r)
+	if !ok {
+		logger.Warn("Unauthorized access to GetLimits")
+		utils.UnauthorizedError(w)
+		return
+	}
+
+	userLimits, err := db.GetUserLimits(db.DB, userID)
+	if err != nil {
+		logger.Error("Error retrieving limits for user", userID, "Error:", err)
+		utils.ServerError(w, "Error retrieving limits")
+		return
+	}
+
+	now := localNow()
+	result := []map[string]interface{}{}
+	for _, l := range userLimits {
+		amount, limited := l.Effective(now)
+		pendingVisible := l.PendingAmount != nil && now.Before(*l.PendingEffectiveAt)
+		if !limited && !pendingVisible {
+			continue
+		}
+
+		item := map[string]interface{}{
+			"type":        l.Type,
+			"period":      l.Period,
+			"periodStart": l.Period.Start(now),
+			"resetsAt":    l.Period.End(now),
+		}
+		if limited {
+			used, err := limitUsage(db.DB, userID, l, now)
+			if err != nil {
+				logger.Error("Error calculating limit usage for user", userID, "Error:", err)
+				utils.ServerError(w, "Error retrieving limits")
+				return
+			}
+			item["limit"] = amount
+			item["used"] = used
+			item["remaining"] = limits.Remaining(amount, used)
+		}
+		if pendingVisible {
+			item["pending"] = map[string]interface{}{
+				"limit":       *l.PendingAmount,
+				"effectiveAt": *l.PendingEffectiveAt,
+			}
+		}
+		result = append(result, item)
+	}
+
+	response := map[string]interface{}{"limits": result}
+	if session, ok := sessionReminder(r); ok {
+		response["session"] = session
+	}
+	utils.SuccessResponse(w, response)
+}
+
+// setLimit 設置限額：收緊立即生效，放寬或取消（amount 為 0）需等待冷靜期
+func (h *UserHandler) setLimit(w http.ResponseWriter, r *http.Request) {
+	userID, ok := middleware.GetUserID(r)
+	if !ok {
+		logger.Warn("Unauthorized access to SetLimit")
+		utils.UnauthorizedError(w)
+		return
+	}
+
+	var input struct {
+		Type   string  `json:"type"`
+		Period string  `json:"period"`
+		Amount float64 `json:"amount"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+		logger.Warn("Invalid request body for SetLimit:", err)
+		utils.ValidationError(w, "Invalid request body")
+		return
+	}
+
+	limitType, err := limits.ParseType(input.Type)
+	if err != nil {
+		utils.ValidationError(w, "無效的限額類型，可選值: deposit, loss, wager")
+		return
+	}
+	period, err := limits.ParsePeriod(input.Period)
+	if err != nil {
+		utils.ValidationError(w, "無效的限額週期，可選值: daily, weekly, monthly")
+		return
+	}
+	if input.Amount < 0 {
+		utils.ValidationError(w, "限額不能為負數")
+		return
+	}
+
+	coolingOff := time.Duration(config.AppConfig.LimitCoolingOff) * time.Hour
+	var next limits.Limit
+	err = db.TransactionContext(r.Context(), func(tx *sql.Tx) error {
+		if err := db.LockUser(tx, userID); err != nil {
+			return err
+		}
+		userLimits, err := db.GetUserLimits(tx, userID)
+		if err != nil {
+			return err
+		}
+
+		current := limits.Limit{Type: limitType, Period: period}
+		for _, l := range userLimits {
+			if l.Type == limitType && l.Period == period {
+				current = l
+			}
+		}
+		next, err = current.Change(input.Amount, localNow(), coolingOff)
+		if err != nil {
+			return err
+		}
+		return db.SaveUserLimit(tx, userID, next)
+	})
+	if err != nil {
+		logger.Error("Error saving limit for user", userID, "Error:", err)
+		utils.ServerError(w, "Error saving limit")
+		return
+	}
+
+	amount, limited := next.Effective(localNow())
+	response := map[string]interface{}{
+		"type":   limitType,
+		"period": period,
+	}
+	if limited {
+		response["limit"] = amount
+	}
+	if next.PendingAmount != nil {
+		response["pending"] = map[string]interface{}{
+			"limit":       *next.PendingAmount,
+			"effectiveAt": *next.PendingEffectiveAt,
+		}
+	}
+
+	logger.Info("Limit updated for user", userID, "Type:", limitType, "Period:", period, "Amount:", input.Amount)
+	utils.SuccessResponse(w, response)
+}
+
+// sessionReminder 計算當前登入會話的遊戲時長，API 金鑰請求沒有會話
+func sessionReminder(r *http.Request) (map[string]interface{}, bool) {
+	userID, _ := middleware.GetUserID(r)
+	sessionID, ok := r.Context().Value("sessionID").(string)
+	interval := time.Duration(config.AppConfig.SessionReminderInterval) * time.Minute
+	if !ok || interval <= 0 {
+		return nil, false
+	}
+
+	startedAt, acknowledgedAt, err := db.GetSessionReminder(sessionID, userID)
+	if err != nil {
+		logger.Error("Error retrieving session reminder for user", userID, "Error:", err)
+		return nil, false
+	}
+
+	// 從上次確認提醒（或會話開始）起計算下一次提醒時間
+	since := startedAt
+	if acknowledgedAt.Valid && acknowledgedAt.Time.After(since) {
+		since = acknowledgedAt.Time
+	}
+	now := time.Now()
+	nextReminder := since.Add(interval)
+	return map[string]interface{}{
+		"startedAt":      startedAt,
+		"elapsedMinutes": int(now.Sub(startedAt).Minutes()),
+		"reminderDue":    !now.Before(nextReminder),
+		"nextReminderAt": nextReminder,
+	}, true
+}
+
+// setSessionReminderHeaders 在遊戲響應中附加會話時長，到達提醒間隔時提示客戶端彈出提醒
+func setSessionReminderHeaders(w http.ResponseWriter, r *http.Request) {
+	session, ok := sessionReminder(r)
+	if !ok {
+		return
+	}
+	w.Header().Set("X-Session-Elapsed-Minutes", strconv.Itoa(session["elapsedMinutes"].(int)))
+	if session["reminderDue"].(bool) {
+		w.Header().Set("X-Session-Reminder", "due")
+	}
+}
+
+// AcknowledgeSessionReminder 玩家確認已看到遊戲時長提醒，下一次提醒從此時重新計時
+func (h *UserHandler) AcknowledgeSessionReminder(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		logger.Warn("Invalid method for AcknowledgeSessionReminder:", r.Method)
+		utils.ErrorResponse(w, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	userID, ok := middleware.GetUserID(r)
+	if !ok {
+		utils.UnauthorizedError(w)
+		return
+	}
+	sessionID, ok := middleware.GetSessionID(r)
+	if !ok {
+		utils.UnauthorizedError(w)
+		return
+	}
+
+	if err := db.AcknowledgeSessionReminder(sessionID, userID); err != nil {
+		logger.Error("Error acknowledging session reminder for user", userID, "Error:", err)
+		utils.ServerError(w, "Error acknowledging reminder")
+		return
+	}
+
+	session, _ := sessionReminder(r)
+	utils.SuccessResponse(w, map[string]interface{}{"session": session})
+}
@@ -0,0 +1,246 @@
+package handlers
+
+import (
+	"baccarat/api/middleware"
+	"baccarat/config"
+	"baccarat/db"
+	"baccarat/internal/auth"
+	"baccarat/pkg/logger"
+	"baccarat/pkg/utils"
+	"baccarat/pkg/validation"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+// ChangePassword 已登入用戶修改密碼，成功後所有會話失效並為當前客戶端簽發新會話
+func (h *AuthHandler) ChangePassword(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		logger.Warn("Invalid method for ChangePassword:", r.Method)
+		utils.ErrorResponse(w, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	userID, ok := middleware.GetUserID(r)
+	if !ok {
+		logger.Warn("Unauthorized access to ChangePassword")
+		utils.UnauthorizedError(w)
+		return
+	}
+
+	var input struct {
+		OldPassword string `json:"oldPassword"`
+		NewPassword string `json:"newPassword"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+		logger.Warn("Invalid request body for ChangePassword:", err)
+		utils.ValidationError(w, "Invalid request body")
+		return
+	}
+
+	// 驗證輸入
+	if err := validation.ValidatePassword(input.OldPassword); err != nil {
+		utils.ValidationError(w, "原密碼錯誤")
+		return
+	}
+	if err := validation.ValidatePassword(input.NewPassword); err != nil {
+		utils.ValidationError(w, err.Error())
+		return
+	}
+	if input.OldPassword == input.NewPassword {
+		utils.ValidationError(w, "新密碼不能與原密碼相同")
+		return
+	}
+
+	hashedPassword, err := h.store.Users.PasswordHash(userID)
+	if err != nil {
+		logger.Error("Error retrieving password for user", userID, "Error:", err)
+		utils.ServerError(w, "Error changing password")
+		return
+	}
+	if err := bcrypt.CompareHashAndPassword([]byte(hashedPassword), []byte(input.OldPassword)); err != nil {
+		logger.Warn("Invalid old password for user", userID)
+		recordAuthEvent(userID, "", clientIP(r), db.AuthEventPasswordChangeFailed, "invalid old password")
+		utils.ValidationError(w, "原密碼錯誤")
+		return
+	}
+
+	if err := h.setPassword(r.Context(), userID, input.NewPassword, "password_change"); err != nil {
+		logger.Error("Error changing password for user", userID, "Error:", err)
+		utils.ServerError(w, "Error changing password")
+		return
+	}
+	recordAuthEvent(userID, "", clientIP(r), db.AuthEventPasswordChanged, "")
+
+	tokens, err := h.issueSession(r, userID)
+	if err != nil {
+		logger.Error("Error issuing session:", err)
+		utils.ServerError(w, "Error changing password")
+		return
+	}
+
+	logger.Info("Password changed for user", userID)
+	tokens["message"] = "Password changed successfully"
+	utils.SuccessResponse(w, tokens)
+}
+
+// ForgotPassword 申請重設密碼，無論用戶是否存在都返回相同響應以免洩露用戶名
+func (h *AuthHandler) ForgotPassword(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		logger.Warn("Invalid method for ForgotPassword:", r.Method)
+		utils.ErrorResponse(w, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	var input struct {
+		Username string `json:"username"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+		logger.Warn("Invalid request body for ForgotPassword:", err)
+		utils.ValidationError(w, "Invalid request body")
+		return
+	}
+	if err := validation.ValidateUsername(input.Username); err != nil {
+		utils.ValidationError(w, err.Error())
+		return
+	}
+
+	response := map[string]string{"message": "If the account exists, reset instructions have been sent"}
+
+	userID, _, err := h.store.Users.FindByUsername(input.Username)
+	if err == sql.ErrNoRows {
+		logger.Warn("Password reset requested for unknown user:", input.Username)
+		utils.SuccessResponse(w, response)
+		return
+	}
+	if err != nil {
+		logger.Error("Error retrieving user:", err)
+		utils.ServerError(w, "Error requesting password reset")
+		return
+	}
+
+	token, tokenHash, err := auth.GenerateOpaqueToken()
+	if err != nil {
+		logger.Error("Error generating reset token:", err)
+		utils.ServerError(w, "Error requesting password reset")
+		return
+	}
+	expiresAt := time.Now().Add(time.Duration(config.AppConfig.PasswordResetExpiry) * time.Minute)
+	if err := db.SavePasswordResetToken(userID, tokenHash, expiresAt); err != nil {
+		logger.Error("Error saving reset token for user", userID, "Error:", err)
+		utils.ServerError(w, "Error requesting password reset")
+		return
+	}
+
+	if err := h.notifier.SendPasswordReset(userID, input.Username, token, expiresAt); err != nil {
+		logger.Error("Error sending password reset to user", userID, "Error:", err)
+		utils.ServerError(w, "Error requesting password reset")
+		return
+	}
+
+	recordAuthEvent(userID, input.Username, clientIP(r), db.AuthEventPasswordResetRequested, "")
+	logger.Info("Password reset requested for user:", input.Username)
+	utils.SuccessResponse(w, response)
+}
+
+// ResetPassword 使用一次性令牌設置新密碼
+func (h *AuthHandler) ResetPassword(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		logger.Warn("Invalid method for ResetPassword:", r.Method)
+		utils.ErrorResponse(w, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	var input struct {
+		Token       string `json:"token"`
+		NewPassword string `json:"newPassword"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil || input.Token == "" {
+		logger.Warn("Invalid request body for ResetPassword")
+		utils.ValidationError(w, "Invalid request body")
+		return
+	}
+	if err := validation.ValidatePassword(input.NewPassword); err != nil {
+		utils.ValidationError(w, err.Error())
+		return
+	}
+
+	passwordHash, err := bcrypt.GenerateFromPassword([]byte(input.NewPassword), bcrypt.DefaultCost)
+	if err != nil {
+		logger.Error("Error hashing password:", err)
+		utils.ServerError(w, "Error resetting password")
+		return
+	}
+
+	var userID int
+	err = db.TransactionContext(r.Context(), func(tx *sql.Tx) error {
+		var err error
+		if userID, err = consumeResetToken(tx, input.Token, time.Now()); err != nil {
+			return err
+		}
+		if err := h.store.Users.SetPasswordHash(tx, userID, passwordHash); err != nil {
+			return err
+		}
+		_, err = db.RevokeUserSessions(tx, userID, "password_reset")
+		return err
+	})
+	if err == auth.ErrInvalidToken || err == auth.ErrExpiredToken {
+		logger.Warn("Invalid password reset token:", err)
+		recordAuthEvent(userID, "", clientIP(r), db.AuthEventPasswordResetFailed, err.Error())
+		utils.ValidationError(w, "重設連結無效或已過期")
+		return
+	}
+	if err != nil {
+		logger.Error("Error resetting password:", err)
+		utils.ServerError(w, "Error resetting password")
+		return
+	}
+
+	// 重設成功後解除登入鎖定
+	if username, err := h.store.Users.Username(userID); err == nil {
+		if err := h.loginGuard.Unlock(username); err != nil {
+			logger.Error("Error clearing login failures for user:", username, "Error:", err)
+		}
+	}
+
+	recordAuthEvent(userID, "", clientIP(r), db.AuthEventPasswordReset, "")
+	logger.Info("Password reset for user", userID)
+	utils.SuccessResponse(w, map[string]string{"message": "Password reset successfully"})
+}
+
+// consumeResetToken 驗證重設令牌未使用、未過期，並使該用戶的所有重設令牌失效，返回用戶ID
+func consumeResetToken(tx *sql.Tx, token string, now time.Time) (int, error) {
+	userID, expiresAt, usedAt, err := db.GetPasswordResetTokenForUpdate(tx, auth.HashToken(token))
+	if err == sql.ErrNoRows {
+		return 0, auth.ErrInvalidToken
+	}
+	if err != nil {
+		return 0, err
+	}
+	if usedAt.Valid {
+		return userID, auth.ErrInvalidToken
+	}
+	if now.After(expiresAt) {
+		return userID, auth.ErrExpiredToken
+	}
+	return userID, db.ConsumePasswordResetTokens(tx, userID)
+}
+
+// setPassword 更新密碼並撤銷用戶的所有會話
+func (h *AuthHandler) setPassword(ctx context.Context, userID int, password, reason string) error {
+	passwordHash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	if err != nil {
+		return err
+	}
+	return h.store.Transaction(ctx, func(tx *sql.Tx) error {
+		if err := h.store.Users.SetPasswordHash(tx, userID, passwordHash); err != nil {
+			return err
+		}
+		_, err := db.RevokeUserSessions(tx, userID, reason)
+		return err
+	})
+}
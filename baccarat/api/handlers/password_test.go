@@ -0,0 +1,146 @@
+package handlers
+
+import (
+	"baccarat/db"
+	"baccarat/internal/auth"
+	"baccarat/internal/clock"
+	"database/sql"
+	"net/http"
+	"testing"
+	"time"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+// saveResetToken 保存一個在 expiresAt 過期的重設令牌，返回明文
+func saveResetToken(t *testing.T, userID int, expiresAt time.Time) string {
+	t.Helper()
+	token, tokenHash, err := auth.GenerateOpaqueToken()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := db.SavePasswordResetToken(userID, tokenHash, expiresAt); err != nil {
+		t.Fatal(err)
+	}
+	return token
+}
+
+func consume(token string, now time.Time) (int, error) {
+	var userID int
+	err := db.Transaction(func(tx *sql.Tx) error {
+		var err error
+		userID, err = consumeResetToken(tx, token, now)
+		return err
+	})
+	return userID, err
+}
+
+func TestConsumeResetToken(t *testing.T) {
+	userID := createTestUser(t, "resetter", "Secret123")
+	now := time.Now()
+
+	expired := saveResetToken(t, userID, now.Add(-time.Minute))
+	if _, err := consume(expired, now); err != auth.ErrExpiredToken {
+		t.Errorf("expired token: err = %v, want ErrExpiredToken", err)
+	}
+
+	first := saveResetToken(t, userID, now.Add(30*time.Minute))
+	second := saveResetToken(t, userID, now.Add(30*time.Minute))
+	if got, err := consume(first, now); err != nil || got != userID {
+		t.Fatalf("valid token: userID = %d, err = %v", got, err)
+	}
+	// 令牌只能使用一次，同一用戶的其他令牌同時失效
+	if _, err := consume(first, now); err != auth.ErrInvalidToken {
+		t.Errorf("reused token: err = %v, want ErrInvalidToken", err)
+	}
+	if _, err := consume(second, now); err != auth.ErrInvalidToken {
+		t.Errorf("sibling token: err = %v, want ErrInvalidToken", err)
+	}
+	if _, err := consume("unknown-token", now); err != auth.ErrInvalidToken {
+		t.Errorf("unknown token: err = %v, want ErrInvalidToken", err)
+	}
+}
+
+func TestChangePasswordRejectsWrongCurrentPassword(t *testing.T) {
+	h, _ := newTestAuthHandler(clock.System{})
+	userID := createTestUser(t, "changer", "Secret123")
+
+	code, _ := call(t, h.ChangePassword, http.MethodPost, "/api/password/change", userID, map[string]string{
+		"oldPassword": "Wrong1234",
+		"newPassword": "Newpass123",
+	})
+	if code != http.StatusBadRequest {
+		t.Fatalf("wrong current password: status %d, want 400", code)
+	}
+	hash, err := db.GetPasswordHash(userID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if bcrypt.CompareHashAndPassword([]byte(hash), []byte("Secret123")) != nil {
+		t.Error("password changed despite wrong current password")
+	}
+
+	code, data := call(t, h.ChangePassword, http.MethodPost, "/api/password/change", userID, map[string]string{
+		"oldPassword": "Secret123",
+		"newPassword": "Newpass123",
+	})
+	if code != http.StatusOK || data["token"] == nil {
+		t.Fatalf("change password: status %d, data %v", code, data)
+	}
+}
+
+func TestResetPasswordRevokesSessions(t *testing.T) {
+	h, notifier := newTestAuthHandler(clock.System{})
+	userID := createTestUser(t, "forgetful", "Secret123")
+
+	code, session := call(t, h.Login, http.MethodPost, "/api/login", 0, map[string]string{"username": "forgetful", "password": "Secret123"})
+	if code != http.StatusOK {
+		t.Fatalf("login: status %d", code)
+	}
+	claims, err := h.jwtService.ValidateToken(session["token"].(string))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if code, _ := call(t, h.ForgotPassword, http.MethodPost, "/api/password/forgot", 0, map[string]string{"username": "forgetful"}); code != http.StatusOK {
+		t.Fatalf("forgot password: status %d", code)
+	}
+	token := notifier.resetTokens[userID]
+	if token == "" {
+		t.Fatal("no reset token sent")
+	}
+	reset := map[string]string{"token": token, "newPassword": "Newpass123"}
+	if code, _ := call(t, h.ResetPassword, http.MethodPost, "/api/password/reset", 0, reset); code != http.StatusOK {
+		t.Fatalf("reset password: status %d", code)
+	}
+
+	if active, err := db.IsSessionActive(claims.SessionID, userID); err != nil || active {
+		t.Errorf("session active after reset: %v, %v", active, err)
+	}
+	if code, _ := call(t, h.Refresh, http.MethodPost, "/api/refresh", 0, map[string]interface{}{"refreshToken": session["refreshToken"]}); code != http.StatusUnauthorized {
+		t.Errorf("refresh after reset: status %d, want 401", code)
+	}
+	if code, _ := call(t, h.ResetPassword, http.MethodPost, "/api/password/reset", 0, reset); code != http.StatusBadRequest {
+		t.Errorf("reused reset token: status %d, want 400", code)
+	}
+	if code, _ := call(t, h.Login, http.MethodPost, "/api/login", 0, map[string]string{"username": "forgetful", "password": "Newpass123"}); code != http.StatusOK {
+		t.Errorf("login with new password: status %d", code)
+	}
+}
+
+func TestForgotPasswordDoesNotRevealUsers(t *testing.T) {
+	h, notifier := newTestAuthHandler(clock.System{})
+	userID := createTestUser(t, "existing", "Secret123")
+
+	existingCode, existing := call(t, h.ForgotPassword, http.MethodPost, "/api/password/forgot", 0, map[string]string{"username": "existing"})
+	unknownCode, unknown := call(t, h.ForgotPassword, http.MethodPost, "/api/password/forgot", 0, map[string]string{"username": "nosuchuser"})
+	if existingCode != http.StatusOK || unknownCode != existingCode {
+		t.Errorf("status: existing %d, unknown %d", existingCode, unknownCode)
+	}
+	if existing["message"] != unknown["message"] || len(existing) != len(unknown) {
+		t.Errorf("responses differ: existing %v, unknown %v", existing, unknown)
+	}
+	if notifier.resetTokens[userID] == "" || len(notifier.resetTokens) != 1 {
+		t.Errorf("reset tokens sent: %v", notifier.resetTokens)
+	}
+}
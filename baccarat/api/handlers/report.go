@@ -0,0 +1,129 @@
+package handlers
+
+import (
+	"baccarat/db"
+	"baccarat/internal/reporting"
+	"baccarat/pkg/logger"
+	"baccarat/pkg/utils"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// maxReportHours 按小時查詢時的最長範圍
+const maxReportHours = 31 * 24
+
+var reportGranularities = map[string]bool{"hour": true, "day": true, "month": true}
+
+// GetReport 營運報表：投注數、活躍玩家、投注額、返還額、GGR、RTP 和平均每注金額，
+// 數據來自後台任務維護的小時匯總表
+//
+// 參數：from、to（YYYY-MM-DD，to 包含當天，默認最近7天）、granularity（hour/day/month，默認 day）、
+// groupBy（table、betType，可用逗號組合）、table
+func (h *AdminHandler) GetReport(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		logger.Warn("Invalid method for GetReport:", r.Method)
+		utils.ErrorResponse(w, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	query := r.URL.Query()
+	now := localNow()
+	loc := now.Location()
+	q := db.ReportQuery{
+		To:          time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, loc).AddDate(0, 0, 1),
+		Granularity: "day",
+	}
+	q.From = q.To.AddDate(0, 0, -7)
+
+	if from := query.Get("from"); from != "" {
+		t, err := time.ParseInLocation("2006-01-02", from, loc)
+		if err != nil {
+			utils.ValidationError(w, "無效的開始日期")
+			return
+		}
+		q.From = t
+	}
+	if to := query.Get("to"); to != "" {
+		t, err := time.ParseInLocation("2006-01-02", to, loc)
+		if err != nil {
+			utils.ValidationError(w, "無效的結束日期")
+			return
+		}
+		q.To = t.AddDate(0, 0, 1)
+	}
+	if !q.From.Before(q.To) {
+		utils.ValidationError(w, "開始日期必須早於結束日期")
+		return
+	}
+
+	if granularity := query.Get("granularity"); granularity != "" {
+		if !reportGranularities[granularity] {
+			utils.ValidationError(w, "無效的時間粒度，可選值: hour, day, month")
+			return
+		}
+		q.Granularity = granularity
+	}
+	if q.Granularity == "hour" && q.To.Sub(q.From) > maxReportHours*time.Hour {
+		utils.ValidationError(w, "按小時查詢的範圍最長31天")
+		return
+	}
+
+	if groupBy := query.Get("groupBy"); groupBy != "" {
+		for _, dim := range strings.Split(groupBy, ",") {
+			switch strings.TrimSpace(dim) {
+			case "table":
+				q.GroupByTable = true
+			case "betType":
+				q.GroupByBetType = true
+			default:
+				utils.ValidationError(w, "無效的分組，可選值: table, betType")
+				return
+			}
+		}
+	}
+	if table := query.Get("table"); table != "" {
+		if !tableIDPattern.MatchString(table) {
+			utils.ValidationError(w, "無效的遊戲桌")
+			return
+		}
+		q.TableID = table
+	}
+
+	rows, err := db.GetReport(q)
+	if err != nil {
+		logger.Error("Error retrieving report:", err)
+		utils.ServerError(w, "Error retrieving report")
+		return
+	}
+	totals, err := db.GetReportTotals(q)
+	if err != nil {
+		logger.Error("Error retrieving report totals:", err)
+		utils.ServerError(w, "Error retrieving report")
+		return
+	}
+	watermark, ok, err := db.GetReportRollupWatermark()
+	if err != nil {
+		logger.Error("Error retrieving report rollup state:", err)
+		utils.ServerError(w, "Error retrieving report")
+		return
+	}
+
+	metrics := make([]reporting.Metrics, 0, len(rows))
+	for _, row := range rows {
+		metrics = append(metrics, reporting.NewMetrics(row))
+	}
+
+	response := map[string]interface{}{
+		"from":        q.From,
+		"to":          q.To,
+		"granularity": q.Granularity,
+		"rows":        metrics,
+		"totals":      reporting.NewMetrics(totals),
+	}
+	// 匯總任務每隔幾分鐘執行一次，rolledUpTo 之後的數據（當前小時）可能尚未完整
+	if ok {
+		response["rolledUpTo"] = watermark
+	}
+	utils.SuccessResponse(w, response)
+}
@@ -0,0 +1,107 @@
+package handlers
+
+import (
+	"baccarat/db"
+	"baccarat/game"
+	"baccarat/internal/auth"
+	"baccarat/internal/clock"
+	"baccarat/internal/store"
+	"database/sql"
+	"encoding/hex"
+	"net/http"
+	"testing"
+)
+
+// saveShoeRound 保存牌靴並從燒牌後的位置發一局，返回牌靴
+func saveShoeRound(t *testing.T, tableID string, number int, gameID string) *game.Shoe {
+	t.Helper()
+	seed := make([]byte, game.SeedSize)
+	seed[0] = byte(number)
+	shoe, err := game.NewShoe(seed, game.ShoeDecks)
+	if err != nil {
+		t.Fatal(err)
+	}
+	g, drawn, err := game.DealFrom(shoe.Cards, shoe.Burned)
+	if err != nil {
+		t.Fatal(err)
+	}
+	shoeID := tableID + "-shoe-" + string(rune('0'+number))
+
+	player, banker := g.GetPlayerHand(), g.GetBankerHand()
+	record := &db.GameRecord{
+		GameID:             gameID,
+		PlayerInitialCards: game.FormatCards(player[:2]),
+		BankerInitialCards: game.FormatCards(banker[:2]),
+		Winner:             g.GetWinner(),
+		ShoeID:             sql.NullString{String: shoeID, Valid: true},
+		ShoePosition:       sql.NullInt64{Int64: int64(shoe.Burned), Valid: true},
+		DrawnCards:         sql.NullString{String: game.FormatCards(drawn), Valid: true},
+	}
+	if len(player) == 3 {
+		record.PlayerThirdCard = sql.NullString{String: player[2].String(), Valid: true}
+	}
+	if len(banker) == 3 {
+		record.BankerThirdCard = sql.NullString{String: banker[2].String(), Valid: true}
+	}
+	err = db.Transaction(func(tx *sql.Tx) error {
+		err := db.CreateShoe(tx, &db.Shoe{
+			ShoeID:        shoeID,
+			TableID:       tableID,
+			ShoeNumber:    number,
+			Decks:         game.ShoeDecks,
+			Seed:          hex.EncodeToString(shoe.Seed),
+			CardOrder:     game.FormatCards(shoe.Cards),
+			CardOrderHash: game.HashCards(shoe.Cards),
+			BurnCards:     shoe.Burned,
+			NextPosition:  shoe.Burned + len(drawn),
+		})
+		if err != nil {
+			return err
+		}
+		return db.SaveGameRecord(tx, record)
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return shoe
+}
+
+func TestVerifyGameHidesSeedOfLiveShoe(t *testing.T) {
+	guard := auth.NewLoginGuard(auth.LockoutPolicy{}, auth.LockoutPolicy{}, auth.DBAttemptStore{}, clock.System{})
+	h := NewAdminHandler(store.NewDBStore(), guard)
+	adminID := createTestUser(t, "verifier", "Secret123")
+
+	shoe := saveShoeRound(t, "verify", 1, "verify-1")
+	verify := func() map[string]interface{} {
+		t.Helper()
+		code, data := call(t, h.VerifyGame, http.MethodGet, "/api/admin/games/verify?gameId=verify-1", adminID, nil)
+		if code != http.StatusOK {
+			t.Fatalf("VerifyGame: status %d", code)
+		}
+		return data
+	}
+
+	// 牌靴仍在發牌：不返回種子和牌序，只核對已發出的牌
+	data := verify()
+	if _, ok := data["seed"]; ok {
+		t.Errorf("live shoe returned seed")
+	}
+	if _, ok := data["cardOrder"]; ok {
+		t.Errorf("live shoe returned card order")
+	}
+	checks := data["checks"].(map[string]interface{})
+	if data["shoeFinished"] != false || data["verified"] != true || checks["dealtOnly"] != true {
+		t.Errorf("live shoe = %v", data)
+	}
+
+	// 換新牌靴後公開種子，完整核對
+	saveShoeRound(t, "verify", 2, "verify-2")
+	data = verify()
+	checks = data["checks"].(map[string]interface{})
+	if data["shoeFinished"] != true || data["verified"] != true || checks["seedMatches"] != true {
+		t.Errorf("replaced shoe = %v", data)
+	}
+	if data["seed"] != hex.EncodeToString(shoe.Seed) || data["cardOrder"] != game.FormatCards(shoe.Cards) {
+		t.Errorf("replaced shoe seed = %v", data["seed"])
+	}
+}
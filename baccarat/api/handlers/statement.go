@@ -0,0 +1,156 @@
+package handlers
+
+import (
+	"baccarat/api/middleware"
+	"baccarat/db"
+	"baccarat/internal/statement"
+	"baccarat/pkg/logger"
+	"baccarat/pkg/utils"
+	"errors"
+	"io"
+	"net/http"
+	"time"
+)
+
+// maxStatementDays 單份對帳單的最長範圍
+const maxStatementDays = 366 * 5
+
+var errInvalidStatementPeriod = errors.New("invalid statement period")
+
+// parseStatementPeriod 解析對帳期間：month=YYYY-MM，或 from、to（YYYY-MM-DD，to 包含當天），
+// 都不提供時為當月；返回的 to 不包含
+func parseStatementPeriod(r *http.Request) (time.Time, time.Time, error) {
+	query := r.URL.Query()
+	loc := localNow().Location()
+
+	if month := query.Get("month"); month != "" {
+		from, err := time.ParseInLocation("2006-01", month, loc)
+		if err != nil {
+			return time.Time{}, time.Time{}, errInvalidStatementPeriod
+		}
+		return from, from.AddDate(0, 1, 0), nil
+	}
+
+	fromStr, toStr := query.Get("from"), query.Get("to")
+	if fromStr == "" && toStr == "" {
+		now := localNow()
+		from := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, loc)
+		return from, from.AddDate(0, 1, 0), nil
+	}
+	from, err := time.ParseInLocation("2006-01-02", fromStr, loc)
+	if err != nil {
+		return time.Time{}, time.Time{}, errInvalidStatementPeriod
+	}
+	to, err := time.ParseInLocation("2006-01-02", toStr, loc)
+	if err != nil {
+		return time.Time{}, time.Time{}, errInvalidStatementPeriod
+	}
+	to = to.AddDate(0, 0, 1)
+	if !from.Before(to) || to.After(from.AddDate(0, 0, maxStatementDays)) {
+		return time.Time{}, time.Time{}, errInvalidStatementPeriod
+	}
+	return from, to, nil
+}
+
+// writeStatement 校驗參數後以 CSV 或 PDF 流式輸出用戶的對帳單
+// 開始輸出後出錯只能中斷響應，錯誤記錄到日誌
+func writeStatement(w http.ResponseWriter, r *http.Request, userID int, username string) {
+	from, to, err := parseStatementPeriod(r)
+	if err != nil {
+		utils.ValidationError(w, "無效的對帳期間，請使用 month=YYYY-MM 或 from、to=YYYY-MM-DD（最長5年）")
+		return
+	}
+
+	format := r.URL.Query().Get("format")
+	if format == "" {
+		format = "csv"
+	}
+	var contentType string
+	var newWriter func(io.Writer) statement.Writer
+	switch format {
+	case "csv":
+		contentType, newWriter = "text/csv; charset=utf-8", statement.NewCSVWriter
+	case "pdf":
+		contentType, newWriter = "application/pdf", statement.NewPDFWriter
+	default:
+		utils.ValidationError(w, "無效的格式，可選值: csv, pdf")
+		return
+	}
+
+	filename := "statement-" + username + "-" + from.Format("20060102") + "-" + to.AddDate(0, 0, -1).Format("20060102") + "." + format
+	meta := statement.Meta{Username: username, From: from, To: to, GeneratedAt: localNow()}
+
+	started := false
+	err = db.StreamStatement(r.Context(), userID, from, to, func(opening float64, days func(func(statement.Day) error) error) error {
+		w.Header().Set("Content-Type", contentType)
+		w.Header().Set("Content-Disposition", `attachment; filename="`+filename+`"`)
+		w.Header().Set("Cache-Control", "no-store")
+		started = true
+
+		meta.OpeningBalance = opening
+		return statement.Generate(newWriter(w), meta, days)
+	})
+	if err != nil {
+		logger.Error("Error generating statement for user", userID, "Error:", err)
+		if !started {
+			utils.ServerError(w, "Error generating statement")
+		}
+		return
+	}
+
+	logger.Info("Statement generated for user", userID, from.Format("2006-01-02"), "to", to.Format("2006-01-02"), format)
+}
+
+// GetStatement 玩家下載自己的對帳單
+//
+// 參數：month（YYYY-MM）或 from、to（YYYY-MM-DD），format（csv/pdf，默認 csv）
+func (h *UserHandler) GetStatement(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		logger.Warn("Invalid method for GetStatement:", r.Method)
+		utils.ErrorResponse(w, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	userID, ok := middleware.GetUserID(r)
+	if !ok {
+		logger.Warn("Unauthorized access to GetStatement")
+		utils.UnauthorizedError(w)
+		return
+	}
+
+	username, err := h.store.Users.Username(userID)
+	if err != nil {
+		logger.Error("Error retrieving username for user", userID, "Error:", err)
+		utils.ServerError(w, "Error generating statement")
+		return
+	}
+
+	writeStatement(w, r, userID, username)
+}
+
+// GetUserStatement 客服下載指定用戶（?username=）的對帳單，參數同 GetStatement
+func (h *AdminHandler) GetUserStatement(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		logger.Warn("Invalid method for GetUserStatement:", r.Method)
+		utils.ErrorResponse(w, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	adminID, ok := middleware.GetUserID(r)
+	if !ok {
+		logger.Warn("Unauthorized access to GetUserStatement")
+		utils.UnauthorizedError(w)
+		return
+	}
+
+	username := r.URL.Query().Get("username")
+	userID, ok := findUser(w, h.store.Users, username, "generating statement")
+	if !ok {
+		return
+	}
+	if !auditAdmin(w, r, adminID, db.AdminActionStatementExported, userID, r.URL.RawQuery) {
+		return
+	}
+
+	writeStatement(w, r, userID, username)
+}
@@ -0,0 +1,11 @@
+package handlers
+
+import (
+	"baccarat/config"
+	"time"
+)
+
+// localNow 按 TIME_ZONE 返回當前時間，限額週期和歷史查詢的日期都以此時區的自然日計算
+func localNow() time.Time {
+	return time.Now().In(config.Location())
+}
@@ -0,0 +1,468 @@
+package handlers
+
+import (
+	"baccarat/api/middleware"
+	"baccarat/config"
+	"baccarat/db"
+	"baccarat/internal/auth"
+	"baccarat/pkg/logger"
+	"baccarat/pkg/utils"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"time"
+)
+
+// 每次啟用兩步驗證時生成的恢復碼數量
+const recoveryCodeCount = 10
+
+var errSecondFactorRejected = errors.New("second factor rejected")
+
+// twoFactorChallenge 判斷登入是否需要第二步驗證，需要時返回預認證響應，否則返回 nil
+func (h *AuthHandler) twoFactorChallenge(userID int) (map[string]interface{}, error) {
+	totp, err := db.GetUserTOTP(userID)
+	if err != nil && err != sql.ErrNoRows {
+		return nil, err
+	}
+	enabled := totp != nil && totp.Enabled
+
+	setupRequired := false
+	if !enabled {
+		roleName, err := h.store.Users.Role(userID)
+		if err != nil {
+			return nil, err
+		}
+		role, err := auth.ParseRole(roleName)
+		if err != nil {
+			return nil, err
+		}
+		if !role.RequiresTwoFactor() {
+			return nil, nil
+		}
+		setupRequired = true
+	}
+
+	preAuthToken, err := h.jwtService.GeneratePreAuthToken(userID)
+	if err != nil {
+		return nil, err
+	}
+	return map[string]interface{}{
+		"twoFactorRequired":      true,
+		"twoFactorSetupRequired": setupRequired,
+		"preAuthToken":           preAuthToken,
+		"expiresIn":              int(auth.PreAuthExpiry.Seconds()),
+	}, nil
+}
+
+// EnrollTwoFactor 為已登入的用戶生成新的TOTP密鑰（需確認後才生效）
+func (h *AuthHandler) EnrollTwoFactor(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		logger.Warn("Invalid method for EnrollTwoFactor:", r.Method)
+		utils.ErrorResponse(w, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	userID, ok := middleware.GetUserID(r)
+	if !ok {
+		logger.Warn("Unauthorized access to EnrollTwoFactor")
+		utils.UnauthorizedError(w)
+		return
+	}
+
+	h.enroll(w, r, userID)
+}
+
+// LoginTwoFactorEnroll 員工帳戶首次登入時，憑預認證令牌生成TOTP密鑰
+func (h *AuthHandler) LoginTwoFactorEnroll(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		logger.Warn("Invalid method for LoginTwoFactorEnroll:", r.Method)
+		utils.ErrorResponse(w, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	var input struct {
+		PreAuthToken string `json:"preAuthToken"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+		logger.Warn("Invalid request body for LoginTwoFactorEnroll:", err)
+		utils.ValidationError(w, "Invalid request body")
+		return
+	}
+
+	userID, err := h.jwtService.ValidatePreAuthToken(input.PreAuthToken)
+	if err != nil {
+		utils.UnauthorizedError(w)
+		return
+	}
+
+	h.enroll(w, r, userID)
+}
+
+// enroll 生成並保存待確認的密鑰，返回密鑰和供二維碼使用的 otpauth URI
+func (h *AuthHandler) enroll(w http.ResponseWriter, r *http.Request, userID int) {
+	existing, err := db.GetUserTOTP(userID)
+	if err != nil && err != sql.ErrNoRows {
+		logger.Error("Error retrieving two-factor state for user", userID, "Error:", err)
+		utils.ServerError(w, "Error enrolling two-factor authentication")
+		return
+	}
+	if existing != nil && existing.Enabled {
+		utils.ValidationError(w, "兩步驗證已啟用")
+		return
+	}
+
+	username, err := h.store.Users.Username(userID)
+	if err != nil {
+		logger.Error("Error retrieving user", userID, "Error:", err)
+		utils.ServerError(w, "Error enrolling two-factor authentication")
+		return
+	}
+
+	secret, err := auth.GenerateTOTPSecret()
+	if err != nil {
+		logger.Error("Error generating totp secret:", err)
+		utils.ServerError(w, "Error enrolling two-factor authentication")
+		return
+	}
+	if err := db.SaveTOTPSecret(userID, secret); err != nil {
+		logger.Error("Error saving totp secret for user", userID, "Error:", err)
+		utils.ServerError(w, "Error enrolling two-factor authentication")
+		return
+	}
+
+	recordAuthEvent(userID, username, clientIP(r), db.AuthEventTOTPEnrolled, "")
+	logger.Info("Two-factor enrollment started for user", userID)
+	utils.SuccessResponse(w, map[string]string{
+		"secret":          secret,
+		"provisioningUri": auth.TOTPProvisioningURI(config.AppConfig.TOTPIssuer, username, secret),
+	})
+}
+
+// ConfirmTwoFactor 已登入用戶以驗證碼確認密鑰，啟用兩步驗證並返回恢復碼
+func (h *AuthHandler) ConfirmTwoFactor(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		logger.Warn("Invalid method for ConfirmTwoFactor:", r.Method)
+		utils.ErrorResponse(w, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	userID, ok := middleware.GetUserID(r)
+	if !ok {
+		logger.Warn("Unauthorized access to ConfirmTwoFactor")
+		utils.UnauthorizedError(w)
+		return
+	}
+
+	var input struct {
+		Code string `json:"code"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+		logger.Warn("Invalid request body for ConfirmTwoFactor:", err)
+		utils.ValidationError(w, "Invalid request body")
+		return
+	}
+
+	username, err := h.store.Users.Username(userID)
+	if err != nil {
+		logger.Error("Error retrieving user", userID, "Error:", err)
+		utils.ServerError(w, "Error confirming two-factor authentication")
+		return
+	}
+	ip := clientIP(r)
+	if !h.allowSecondFactor(w, userID, username, ip, "Error confirming two-factor authentication") {
+		return
+	}
+
+	totp, err := db.GetUserTOTP(userID)
+	if err == sql.ErrNoRows || (err == nil && totp.Enabled) {
+		utils.ValidationError(w, "沒有待確認的兩步驗證")
+		return
+	}
+	if err != nil {
+		logger.Error("Error retrieving two-factor state for user", userID, "Error:", err)
+		utils.ServerError(w, "Error confirming two-factor authentication")
+		return
+	}
+
+	var recoveryCodes []string
+	err = db.TransactionContext(r.Context(), func(tx *sql.Tx) error {
+		var err error
+		recoveryCodes, err = activateTOTP(tx, userID, totp, input.Code)
+		return err
+	})
+	if err == errSecondFactorRejected {
+		h.secondFactorFailed(w, userID, username, ip, "enrollment confirmation")
+		return
+	}
+	if err != nil {
+		logger.Error("Error enabling two-factor for user", userID, "Error:", err)
+		utils.ServerError(w, "Error confirming two-factor authentication")
+		return
+	}
+
+	h.secondFactorSucceeded(username)
+	recordAuthEvent(userID, "", ip, db.AuthEventTOTPEnabled, "")
+	logger.Info("Two-factor enabled for user", userID)
+	utils.SuccessResponse(w, map[string]interface{}{
+		"message":       "Two-factor authentication enabled",
+		"recoveryCodes": recoveryCodes,
+	})
+}
+
+// DisableTwoFactor 玩家關閉兩步驗證（員工角色不允許關閉）
+func (h *AuthHandler) DisableTwoFactor(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		logger.Warn("Invalid method for DisableTwoFactor:", r.Method)
+		utils.ErrorResponse(w, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	userID, ok := middleware.GetUserID(r)
+	if !ok {
+		logger.Warn("Unauthorized access to DisableTwoFactor")
+		utils.UnauthorizedError(w)
+		return
+	}
+	if role, _ := middleware.GetRole(r); role.RequiresTwoFactor() {
+		logger.Warn("Staff user", userID, "attempted to disable two-factor")
+		utils.ErrorResponse(w, http.StatusForbidden, "此角色必須啟用兩步驗證")
+		return
+	}
+
+	var input struct {
+		Code string `json:"code"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+		logger.Warn("Invalid request body for DisableTwoFactor:", err)
+		utils.ValidationError(w, "Invalid request body")
+		return
+	}
+
+	username, err := h.store.Users.Username(userID)
+	if err != nil {
+		logger.Error("Error retrieving user", userID, "Error:", err)
+		utils.ServerError(w, "Error disabling two-factor authentication")
+		return
+	}
+	ip := clientIP(r)
+	if !h.allowSecondFactor(w, userID, username, ip, "Error disabling two-factor authentication") {
+		return
+	}
+
+	totp, err := db.GetUserTOTP(userID)
+	if err == sql.ErrNoRows || (err == nil && !totp.Enabled) {
+		utils.ValidationError(w, "兩步驗證未啟用")
+		return
+	}
+	if err != nil {
+		logger.Error("Error retrieving two-factor state for user", userID, "Error:", err)
+		utils.ServerError(w, "Error disabling two-factor authentication")
+		return
+	}
+
+	err = db.TransactionContext(r.Context(), func(tx *sql.Tx) error {
+		if err := useTOTPCode(tx, userID, totp, input.Code); err != nil {
+			return err
+		}
+		return db.DeleteTOTP(tx, userID)
+	})
+	if err == errSecondFactorRejected {
+		h.secondFactorFailed(w, userID, username, ip, "disable")
+		return
+	}
+	if err != nil {
+		logger.Error("Error disabling two-factor for user", userID, "Error:", err)
+		utils.ServerError(w, "Error disabling two-factor authentication")
+		return
+	}
+
+	h.secondFactorSucceeded(username)
+	recordAuthEvent(userID, "", ip, db.AuthEventTOTPDisabled, "")
+	logger.Info("Two-factor disabled for user", userID)
+	utils.SuccessResponse(w, map[string]string{"message": "Two-factor authentication disabled"})
+}
+
+// LoginTwoFactor 以預認證令牌和驗證碼（或恢復碼）完成登入
+// 員工帳戶首次設置時，驗證碼同時用於確認密鑰，響應中附帶恢復碼
+func (h *AuthHandler) LoginTwoFactor(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		logger.Warn("Invalid method for LoginTwoFactor:", r.Method)
+		utils.ErrorResponse(w, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	var input struct {
+		PreAuthToken string `json:"preAuthToken"`
+		Code         string `json:"code"`
+		RecoveryCode string `json:"recoveryCode"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+		logger.Warn("Invalid request body for LoginTwoFactor:", err)
+		utils.ValidationError(w, "Invalid request body")
+		return
+	}
+
+	userID, err := h.jwtService.ValidatePreAuthToken(input.PreAuthToken)
+	if err != nil {
+		utils.UnauthorizedError(w)
+		return
+	}
+	username, err := h.store.Users.Username(userID)
+	if err != nil {
+		logger.Error("Error retrieving user", userID, "Error:", err)
+		utils.ServerError(w, "Error during login")
+		return
+	}
+
+	// 驗證碼同樣受登入節流保護
+	ip := clientIP(r)
+	if !h.allowSecondFactor(w, userID, username, ip, "Error during login") {
+		return
+	}
+
+	totp, err := db.GetUserTOTP(userID)
+	if err == sql.ErrNoRows {
+		utils.ValidationError(w, "請先設置兩步驗證")
+		return
+	}
+	if err != nil {
+		logger.Error("Error retrieving two-factor state for user", userID, "Error:", err)
+		utils.ServerError(w, "Error during login")
+		return
+	}
+
+	var recoveryCodes []string
+	method := "totp"
+	err = db.TransactionContext(r.Context(), func(tx *sql.Tx) error {
+		if !totp.Enabled {
+			var err error
+			recoveryCodes, err = activateTOTP(tx, userID, totp, input.Code)
+			return err
+		}
+		if input.RecoveryCode != "" {
+			method = "recovery_code"
+			used, err := db.UseRecoveryCode(tx, userID, auth.HashToken(auth.NormalizeRecoveryCode(input.RecoveryCode)))
+			if err != nil {
+				return err
+			}
+			if !used {
+				return errSecondFactorRejected
+			}
+			return nil
+		}
+		return useTOTPCode(tx, userID, totp, input.Code)
+	})
+	if err == errSecondFactorRejected {
+		h.secondFactorFailed(w, userID, username, ip, method)
+		return
+	}
+	if err != nil {
+		logger.Error("Error verifying second factor for user", userID, "Error:", err)
+		utils.ServerError(w, "Error during login")
+		return
+	}
+
+	if method == "recovery_code" {
+		recordAuthEvent(userID, username, ip, db.AuthEventRecoveryCodeUsed, "")
+	}
+	if recoveryCodes != nil {
+		recordAuthEvent(userID, username, ip, db.AuthEventTOTPEnabled, "during login")
+	}
+	h.secondFactorSucceeded(username)
+	if rejectExcluded(w, r, userID, username) {
+		return
+	}
+	recordAuthEvent(userID, username, ip, db.AuthEventLoginSuccess, "2fa:"+method)
+
+	tokens, err := h.issueSession(r, userID)
+	if err != nil {
+		logger.Error("Error issuing session:", err)
+		utils.ServerError(w, "Error during login")
+		return
+	}
+	if recoveryCodes != nil {
+		tokens["recoveryCodes"] = recoveryCodes
+	}
+
+	logger.Info("User logged in with two-factor successfully:", username)
+	utils.SuccessResponse(w, tokens)
+}
+
+// useTOTPCode 驗證並消耗驗證碼（同一時間步長只能使用一次）
+func useTOTPCode(tx *sql.Tx, userID int, totp *db.UserTOTP, code string) error {
+	step, ok := auth.ValidateTOTPCode(totp.Secret, code, time.Now(), totp.LastUsedStep)
+	if !ok {
+		return errSecondFactorRejected
+	}
+	fresh, err := db.UseTOTPStep(tx, userID, step)
+	if err != nil {
+		return err
+	}
+	if !fresh {
+		return errSecondFactorRejected
+	}
+	return nil
+}
+
+// activateTOTP 以驗證碼確認待啟用的密鑰，並生成新的恢復碼
+func activateTOTP(tx *sql.Tx, userID int, totp *db.UserTOTP, code string) ([]string, error) {
+	if err := useTOTPCode(tx, userID, totp, code); err != nil {
+		return nil, err
+	}
+	if err := db.EnableTOTP(tx, userID); err != nil {
+		return nil, err
+	}
+
+	codes, err := auth.GenerateRecoveryCodes(recoveryCodeCount)
+	if err != nil {
+		return nil, err
+	}
+	hashes := make([]string, len(codes))
+	for i, code := range codes {
+		hashes[i] = auth.HashToken(code)
+	}
+	if err := db.ReplaceRecoveryCodes(tx, userID, hashes); err != nil {
+		return nil, err
+	}
+	return codes, nil
+}
+
+// allowSecondFactor 驗證碼和恢復碼與密碼共用登入節流，按用戶名和 IP 計算失敗次數；被節流時寫入響應並返回 false
+func (h *AuthHandler) allowSecondFactor(w http.ResponseWriter, userID int, username, ip, serverError string) bool {
+	wait, err := h.loginGuard.Check(username, ip)
+	if err == nil {
+		return true
+	}
+	if err != auth.ErrLoginThrottled && err != auth.ErrAccountLocked {
+		logger.Error("Error checking login attempts:", err)
+		utils.ServerError(w, serverError)
+		return false
+	}
+	recordAuthEvent(userID, username, ip, db.AuthEventLoginThrottled, err.Error())
+	retryAfter(w, wait)
+	utils.ErrorResponse(w, http.StatusTooManyRequests, "登入嘗試過於頻繁，請稍後再試")
+	return false
+}
+
+// secondFactorFailed 記錄一次驗證碼錯誤，達到上限時鎖定
+func (h *AuthHandler) secondFactorFailed(w http.ResponseWriter, userID int, username, ip, detail string) {
+	locked, err := h.loginGuard.RecordFailure(username, ip)
+	if err != nil {
+		logger.Error("Error recording login failure:", err)
+	}
+	recordAuthEvent(userID, username, ip, db.AuthEventTOTPFailed, detail)
+	logger.Warn("Second factor rejected for user:", username, "Detail:", detail)
+	if locked {
+		logger.Warn("Login locked for user:", username, "IP:", ip)
+		recordAuthEvent(userID, username, ip, db.AuthEventLoginLocked, "too many second factor failures")
+	}
+	utils.ValidationError(w, "驗證碼錯誤")
+}
+
+// secondFactorSucceeded 驗證通過後清除失敗記錄
+func (h *AuthHandler) secondFactorSucceeded(username string) {
+	if err := h.loginGuard.RecordSuccess(username); err != nil {
+		logger.Error("Error clearing login failures for user:", username, "Error:", err)
+	}
+}
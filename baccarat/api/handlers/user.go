@@ -2,7 +2,8 @@ package handlers
 
 import (
 	"baccarat/api/middleware"
-	"baccarat/db"
+	"baccarat/internal/limits"
+	"baccarat/internal/store"
 	"baccarat/pkg/logger"
 	"baccarat/pkg/utils"
 	"baccarat/pkg/validation"
@@ -13,16 +14,16 @@ import (
 )
 
 type UserHandler struct {
-	db *sql.DB
+	store store.Store
 }
 
 type DepositRequest struct {
 	Amount string `json:"amount"`
 }
 
-func NewUserHandler(db *sql.DB) *UserHandler {
+func NewUserHandler(store store.Store) *UserHandler {
 	return &UserHandler{
-		db: db,
+		store: store,
 	}
 }
 
@@ -36,7 +37,7 @@ func (h *UserHandler) GetBalance(w http.ResponseWriter, r *http.Request) {
 	}
 
 	logger.Debug("Retrieving balance for user", userID)
-	balance, err := db.GetUserBalance(userID)
+	balance, err := h.store.Wallet.Balance(userID)
 	if err != nil {
 		logger.Error("Error retrieving balance for user", userID, "Error:", err)
 		utils.ServerError(w, "Error retrieving balance")
@@ -85,20 +86,43 @@ func (h *UserHandler) Deposit(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// 使用事務處理存款
-	err = db.Transaction(func(tx *sql.Tx) error {
+	err = h.store.Transaction(r.Context(), func(tx *sql.Tx) error {
+		// 檢查存款限額
+		if _, err := h.store.Wallet.Lock(tx, userID); err != nil {
+			return err
+		}
+		if frozen, err := h.store.Users.Frozen(tx, userID); err != nil {
+			return err
+		} else if frozen {
+			return errAccountFrozen
+		}
+		if err := enforceLimits(tx, userID, amount, 0); err != nil {
+			return err
+		}
+
 		// 更新餘額
-		if err := db.UpdateUserBalance(tx, userID, amount); err != nil {
+		if err := h.store.Wallet.AddBalance(tx, userID, amount); err != nil {
 			return err
 		}
 
 		// 記錄交易
-		if err := db.SaveTransaction(tx, userID, amount, "deposit"); err != nil {
+		if err := h.store.Wallet.Record(tx, userID, amount, "deposit"); err != nil {
 			return err
 		}
 
 		return nil
 	})
 
+	if exceeded, ok := err.(*limits.ExceededError); ok {
+		logger.Warn("Deposit limit exceeded for user", userID, "Error:", exceeded)
+		limitExceededResponse(w, exceeded)
+		return
+	}
+	if err == errAccountFrozen {
+		logger.Warn("Deposit rejected for frozen user", userID)
+		frozenResponse(w)
+		return
+	}
 	if err != nil {
 		logger.Error("Error processing deposit for user", userID, "Error:", err)
 		utils.ServerError(w, "Error processing deposit")
@@ -138,33 +162,19 @@ func (h *UserHandler) GetTransactions(w http.ResponseWriter, r *http.Request) {
 
 	offset := (page - 1) * pageSize
 
-	rows, err := h.db.Query(`
-		SELECT amount, transaction_type, created_at 
-		FROM transactions 
-		WHERE user_id = ? 
-		ORDER BY created_at DESC
-		LIMIT ? OFFSET ?`, 
-		userID, pageSize, offset)
+	list, err := h.store.Wallet.Transactions(userID, pageSize, offset)
 	if err != nil {
 		logger.Error("Error retrieving transactions for user", userID, "Error:", err)
 		utils.ServerError(w, "Error retrieving transactions")
 		return
 	}
-	defer rows.Close()
 
 	var transactions []map[string]interface{}
-	for rows.Next() {
-		var amount float64
-		var transactionType, createdAt string
-		if err := rows.Scan(&amount, &transactionType, &createdAt); err != nil {
-			logger.Error("Error scanning transaction for user", userID, "Error:", err)
-			utils.ServerError(w, "Error scanning transaction")
-			return
-		}
+	for _, t := range list {
 		transactions = append(transactions, map[string]interface{}{
-			"amount":          amount,
-			"transactionType": transactionType,
-			"createdAt":       createdAt,
+			"amount":          t.Amount,
+			"transactionType": t.TransactionType,
+			"createdAt":       t.CreatedAt,
 		})
 	}
 
@@ -202,48 +212,27 @@ func (h *UserHandler) GetBets(w http.ResponseWriter, r *http.Request) {
 
 	offset := (page - 1) * pageSize
 
-	rows, err := h.db.Query(`
-		SELECT b.game_id, b.bet_amount, b.bet_type, b.created_at,
-			   g.winner, g.is_lucky_six, g.lucky_six_type
-		FROM bets b
-		LEFT JOIN game_records g ON b.game_id = g.game_id
-		WHERE b.user_id = ? 
-		ORDER BY b.created_at DESC
-		LIMIT ? OFFSET ?`, 
-		userID, pageSize, offset)
+	list, err := h.store.Bets.ListByUser(userID, pageSize, offset)
 	if err != nil {
 		logger.Error("Error retrieving bets for user", userID, "Error:", err)
 		utils.ServerError(w, "Error retrieving bets")
 		return
 	}
-	defer rows.Close()
 
 	var bets []map[string]interface{}
-	for rows.Next() {
-		var gameID, betType, createdAt, winner string
-		var betAmount float64
-		var isLuckySix bool
-		var luckySixType sql.NullString
-
-		if err := rows.Scan(&gameID, &betAmount, &betType, &createdAt,
-			&winner, &isLuckySix, &luckySixType); err != nil {
-			logger.Error("Error scanning bet for user", userID, "Error:", err)
-			utils.ServerError(w, "Error scanning bet")
-			return
-		}
-
+	for _, b := range list {
 		bet := map[string]interface{}{
-			"gameId":    gameID,
-			"amount":    betAmount,
-			"betType":   betType,
-			"createdAt": createdAt,
-			"winner":    winner,
+			"gameId":    b.GameID,
+			"amount":    b.Amount,
+			"betType":   b.BetType,
+			"createdAt": b.CreatedAt,
+			"winner":    b.Winner,
 		}
 
-		if isLuckySix {
+		if b.IsLuckySix {
 			bet["isLuckySix"] = true
-			if luckySixType.Valid {
-				bet["luckySixType"] = luckySixType.String
+			if b.LuckySixType.Valid {
+				bet["luckySixType"] = b.LuckySixType.String
 			}
 		}
 
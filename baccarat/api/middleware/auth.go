@@ -1,63 +1,346 @@
 package middleware
 
 import (
+	"baccarat/config"
+	"baccarat/db"
 	"baccarat/internal/auth"
+	"baccarat/internal/clock"
+	"baccarat/internal/limits"
 	"baccarat/pkg/logger"
 	"baccarat/pkg/utils"
 	"context"
+	"database/sql"
+	"fmt"
+	"net"
 	"net/http"
+	"strconv"
 	"strings"
+	"sync"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
 )
 
+// APIKeyHeader 攜帶 API 金鑰的請求頭
+const APIKeyHeader = "X-API-Key"
+
 type AuthMiddleware struct {
-	jwtService *auth.JWTService
+	jwtService    *auth.JWTService
+	apiKeyLimiter *auth.RateLimiter
 }
 
 func NewAuthMiddleware(jwtService *auth.JWTService) *AuthMiddleware {
 	return &AuthMiddleware{
-		jwtService: jwtService,
+		jwtService:    jwtService,
+		apiKeyLimiter: auth.NewRateLimiter(clock.System{}),
 	}
 }
 
-// Authenticate JWT認證中間件
+// Authenticate 認證中間件，接受 JWT 或 API 金鑰
 func (m *AuthMiddleware) Authenticate(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		authHeader := r.Header.Get("Authorization")
-		if authHeader == "" {
-			logger.Warn("Missing Authorization header")
-			utils.UnauthorizedError(w)
+		if key := r.Header.Get(APIKeyHeader); key != "" {
+			m.authenticateAPIKey(w, r, key, next)
 			return
 		}
+		m.authenticateJWT(w, r, next)
+	})
+}
 
-		// 檢查 Bearer token 格式
-		parts := strings.Split(authHeader, " ")
-		if len(parts) != 2 || parts[0] != "Bearer" {
-			logger.Warn("Invalid Authorization header format")
+// AuthenticateSession 只接受 JWT 的認證中間件，用於登出、改密碼、管理金鑰等賬戶操作
+func (m *AuthMiddleware) AuthenticateSession(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get(APIKeyHeader) != "" {
+			logger.FromContext(r.Context()).Warn("API key used on session-only endpoint")
 			utils.UnauthorizedError(w)
 			return
 		}
+		m.authenticateJWT(w, r, next)
+	})
+}
 
-		token := parts[1]
-		userID, err := m.jwtService.ValidateToken(token)
-		if err != nil {
-			logger.Warn("Invalid token:", err)
-			utils.UnauthorizedError(w)
+// authenticateJWT 驗證 Bearer token 和對應的會話
+func (m *AuthMiddleware) authenticateJWT(w http.ResponseWriter, r *http.Request, next http.Handler) {
+	authHeader := r.Header.Get("Authorization")
+	if authHeader == "" {
+		logger.FromContext(r.Context()).Warn("Missing Authorization header")
+		utils.UnauthorizedError(w)
+		return
+	}
+
+	// 檢查 Bearer token 格式
+	parts := strings.Split(authHeader, " ")
+	if len(parts) != 2 || parts[0] != "Bearer" {
+		logger.FromContext(r.Context()).Warn("Invalid Authorization header format")
+		utils.UnauthorizedError(w)
+		return
+	}
+
+	token := parts[1]
+	claims, err := m.jwtService.ValidateToken(token)
+	if err != nil {
+		logger.FromContext(r.Context()).WithError(err).Warn("Invalid token")
+		utils.UnauthorizedError(w)
+		return
+	}
+	userID, err := claims.UserID()
+	if err != nil {
+		logger.FromContext(r.Context()).WithError(err).Warn("Invalid token subject")
+		utils.UnauthorizedError(w)
+		return
+	}
+
+	// 之後本請求的日誌都帶有 user_id
+	logger.AddFields(r.Context(), logger.Fields{"user_id": userID})
+	trace.SpanFromContext(r.Context()).SetAttributes(attribute.Int("user_id", userID))
+
+	// 檢查會話是否已被撤銷（登出、登出所有設備）
+	active, err := db.IsSessionActive(claims.SessionID, userID)
+	if err != nil {
+		logger.FromContext(r.Context()).WithError(err).Error("Error checking session")
+		utils.ServerError(w, "Error checking session")
+		return
+	}
+	if !active {
+		logger.FromContext(r.Context()).WithField("session_id", claims.SessionID).Warn("Revoked session used")
+		utils.UnauthorizedError(w)
+		return
+	}
+
+	// 將用戶ID、會話ID和角色添加到請求上下文中
+	ctx := context.WithValue(r.Context(), "userID", userID)
+	ctx = context.WithValue(ctx, "sessionID", claims.SessionID)
+	ctx = context.WithValue(ctx, "role", claims.Role)
+	logger.FromContext(ctx).Debug("User authenticated")
+	next.ServeHTTP(w, r.WithContext(ctx))
+}
+
+// authenticateAPIKey 驗證 API 金鑰，並按金鑰限流和記錄最後使用時間
+func (m *AuthMiddleware) authenticateAPIKey(w http.ResponseWriter, r *http.Request, key string, next http.Handler) {
+	if !strings.HasPrefix(key, auth.APIKeyPrefix) {
+		logger.FromContext(r.Context()).Warn("Invalid API key format")
+		utils.UnauthorizedError(w)
+		return
+	}
+
+	owner, err := db.GetAPIKeyOwner(auth.HashToken(key))
+	if err == sql.ErrNoRows {
+		logger.FromContext(r.Context()).Warn("Unknown or revoked API key used")
+		utils.UnauthorizedError(w)
+		return
+	}
+	if err != nil {
+		logger.FromContext(r.Context()).WithError(err).Error("Error checking API key")
+		utils.ServerError(w, "Error checking API key")
+		return
+	}
+	logger.AddFields(r.Context(), logger.Fields{"user_id": owner.UserID, "api_key_id": owner.KeyID})
+	trace.SpanFromContext(r.Context()).SetAttributes(attribute.Int("user_id", owner.UserID), attribute.Int64("api_key_id", owner.KeyID))
+
+	role, err := auth.ParseRole(owner.Role)
+	if err != nil {
+		logger.FromContext(r.Context()).WithError(err).Error("Invalid role")
+		utils.UnauthorizedError(w)
+		return
+	}
+
+	if !m.apiKeyLimiter.Allow(owner.KeyID, owner.RateLimit) {
+		logger.FromContext(r.Context()).Warn("API key rate limit exceeded")
+		// 令牌桶每 60/limit 秒補充一次
+		retry := 60
+		if owner.RateLimit > 0 {
+			retry = (60 + owner.RateLimit - 1) / owner.RateLimit
+		}
+		w.Header().Set("Retry-After", strconv.Itoa(retry))
+		utils.ErrorResponse(w, http.StatusTooManyRequests, "Rate limit exceeded")
+		return
+	}
+
+	if err := db.TouchAPIKey(owner.KeyID, ClientIP(r)); err != nil {
+		// 記錄使用時間失敗不影響請求
+		logger.FromContext(r.Context()).WithError(err).Error("Error updating API key last use")
+	}
+
+	ctx := context.WithValue(r.Context(), "userID", owner.UserID)
+	ctx = context.WithValue(ctx, "role", role)
+	ctx = context.WithValue(ctx, "apiKeyID", owner.KeyID)
+	ctx = context.WithValue(ctx, "scopes", auth.ScopePermissions(owner.Scopes))
+	logger.FromContext(ctx).Debug("User authenticated by API key")
+	next.ServeHTTP(w, r.WithContext(ctx))
+}
+
+// Require 權限檢查中間件，需在 Authenticate 之後使用
+func (m *AuthMiddleware) Require(perm auth.Permission, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		role, ok := GetRole(r)
+		if !ok || !role.Can(perm) || !scopeAllows(r, perm) {
+			logger.FromContext(r.Context()).WithFields(logger.Fields{"role": role, "permission": perm}).Warn("Permission denied")
+			utils.ForbiddenError(w)
 			return
 		}
+		next.ServeHTTP(w, r)
+	})
+}
 
-		// 將用戶ID添加到請求上下文中
-		ctx := context.WithValue(r.Context(), "userID", userID)
-		logger.Debug("User authenticated, UserID:", userID)
-		next.ServeHTTP(w, r.WithContext(ctx))
+// RequireNotExcluded 拒絕處於冷靜期或自我排除期的用戶，需在 Authenticate 之後使用
+func (m *AuthMiddleware) RequireNotExcluded(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		userID, ok := GetUserID(r)
+		if !ok {
+			utils.UnauthorizedError(w)
+			return
+		}
+		exclusion, err := db.GetActiveExclusion(db.DB, userID)
+		if err != nil {
+			logger.FromContext(r.Context()).WithError(err).Error("Error checking exclusion")
+			utils.ServerError(w, "Error checking account status")
+			return
+		}
+		if exclusion != nil {
+			logger.FromContext(r.Context()).Warn("Excluded user blocked")
+			ExcludedError(w, exclusion)
+			return
+		}
+		next.ServeHTTP(w, r)
 	})
 }
 
+// ExcludedError 發送帳戶處於冷靜期或自我排除期的錯誤響應
+func ExcludedError(w http.ResponseWriter, exclusion *limits.Exclusion) {
+	state := "自我排除期"
+	if exclusion.Kind == limits.KindCoolingOff {
+		state = "冷靜期"
+	}
+	if exclusion.Permanent() {
+		utils.ErrorResponse(w, http.StatusForbidden, "帳戶已永久自我排除")
+		return
+	}
+	utils.ErrorResponse(w, http.StatusForbidden, fmt.Sprintf("帳戶處於%s，將於 %s 結束",
+		state, exclusion.EndsAt.Format("2006-01-02 15:04")))
+}
+
+// scopeAllows 通過 API 金鑰認證時，檢查金鑰範圍是否包含該權限
+func scopeAllows(r *http.Request, perm auth.Permission) bool {
+	scopes, ok := r.Context().Value("scopes").([]auth.Permission)
+	if !ok {
+		return true
+	}
+	for _, scope := range scopes {
+		if scope == perm {
+			return true
+		}
+	}
+	return false
+}
+
 // GetUserID 從請求上下文中獲取用戶ID
 func GetUserID(r *http.Request) (int, bool) {
 	userID, ok := r.Context().Value("userID").(int)
 	if !ok {
-		logger.Warn("Failed to get userID from context")
+		logger.FromContext(r.Context()).Warn("Failed to get userID from context")
 		return 0, false
 	}
 	return userID, true
 }
+
+// GetRole 從請求上下文中獲取用戶角色
+func GetRole(r *http.Request) (auth.Role, bool) {
+	role, ok := r.Context().Value("role").(auth.Role)
+	if !ok {
+		logger.FromContext(r.Context()).Warn("Failed to get role from context")
+		return "", false
+	}
+	return role, true
+}
+
+// GetSessionID 從請求上下文中獲取會話ID
+func GetSessionID(r *http.Request) (string, bool) {
+	sessionID, ok := r.Context().Value("sessionID").(string)
+	if !ok {
+		logger.FromContext(r.Context()).Warn("Failed to get sessionID from context")
+		return "", false
+	}
+	return sessionID, true
+}
+
+// GetAPIKeyID 從請求上下文中獲取 API 金鑰ID，JWT 認證的請求返回 false
+func GetAPIKeyID(r *http.Request) (int64, bool) {
+	keyID, ok := r.Context().Value("apiKeyID").(int64)
+	return keyID, ok
+}
+
+// ClientIP 獲取客戶端IP
+// 直連的對端不是 TRUSTED_PROXIES 中的反向代理時直接使用對端地址，轉發頭由客戶端任意填寫，不可信
+func ClientIP(r *http.Request) string {
+	trustedProxiesOnce.Do(func() {
+		trustedProxies = parseTrustedProxies(config.AppConfig.TrustedProxies)
+	})
+	return clientIP(r, trustedProxies)
+}
+
+var (
+	trustedProxiesOnce sync.Once
+	trustedProxies     []*net.IPNet
+)
+
+// clientIP 從右往左跳過 X-Forwarded-For 中受信任的代理，取第一個其他地址；
+// 最左邊的值是客戶端自己發送的，代理只會在末尾追加。沒有 X-Forwarded-For 時使用代理設置的 X-Real-IP
+func clientIP(r *http.Request, trusted []*net.IPNet) string {
+	remote := r.RemoteAddr
+	if host, _, err := net.SplitHostPort(remote); err == nil {
+		remote = host
+	}
+	if !isTrustedProxy(remote, trusted) {
+		return remote
+	}
+
+	hops := strings.Split(strings.Join(r.Header.Values("X-Forwarded-For"), ","), ",")
+	for i := len(hops) - 1; i >= 0; i-- {
+		hop := strings.TrimSpace(hops[i])
+		if hop != "" && !isTrustedProxy(hop, trusted) {
+			return hop
+		}
+	}
+	if realIP := strings.TrimSpace(r.Header.Get("X-Real-IP")); realIP != "" {
+		return realIP
+	}
+	return remote
+}
+
+// parseTrustedProxies 解析逗號分隔的 IP 或 CIDR，無效的項記錄日誌後忽略
+func parseTrustedProxies(value string) []*net.IPNet {
+	var nets []*net.IPNet
+	for _, item := range strings.Split(value, ",") {
+		item = strings.TrimSpace(item)
+		if item == "" {
+			continue
+		}
+		if !strings.Contains(item, "/") {
+			if ip := net.ParseIP(item); ip != nil && ip.To4() != nil {
+				item += "/32"
+			} else {
+				item += "/128"
+			}
+		}
+		_, ipNet, err := net.ParseCIDR(item)
+		if err != nil {
+			logger.Error("Invalid TRUSTED_PROXIES entry:", item, "Error:", err)
+			continue
+		}
+		nets = append(nets, ipNet)
+	}
+	return nets
+}
+
+func isTrustedProxy(addr string, trusted []*net.IPNet) bool {
+	ip := net.ParseIP(addr)
+	if ip == nil {
+		return false
+	}
+	for _, ipNet := range trusted {
+		if ipNet.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
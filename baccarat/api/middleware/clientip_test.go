@@ -0,0 +1,43 @@
+package middleware
+
+import (
+	"net/http/httptest"
+	"testing"
+)
+
+func TestClientIP(t *testing.T) {
+	trusted := parseTrustedProxies("10.0.0.0/8, 192.168.1.5")
+
+	tests := []struct {
+		name       string
+		remoteAddr string
+		forwarded  []string
+		realIP     string
+		want       string
+	}{
+		{"Direct client", "203.0.113.9:5000", nil, "", "203.0.113.9"},
+		{"Untrusted peer ignores headers", "203.0.113.9:5000", []string{"1.1.1.1"}, "2.2.2.2", "203.0.113.9"},
+		{"Proxy appended hop", "10.0.0.2:80", []string{"203.0.113.9"}, "", "203.0.113.9"},
+		{"Spoofed leftmost entry", "10.0.0.2:80", []string{"1.1.1.1, 203.0.113.9"}, "", "203.0.113.9"},
+		{"Chained trusted proxies", "10.0.0.2:80", []string{"1.1.1.1, 203.0.113.9, 192.168.1.5"}, "", "203.0.113.9"},
+		{"Repeated headers", "10.0.0.2:80", []string{"1.1.1.1", "203.0.113.9"}, "", "203.0.113.9"},
+		{"Real IP from proxy", "10.0.0.2:80", nil, "203.0.113.9", "203.0.113.9"},
+		{"Only proxies", "10.0.0.2:80", []string{"10.0.0.3"}, "", "10.0.0.2"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest("GET", "/", nil)
+			r.RemoteAddr = tt.remoteAddr
+			for _, value := range tt.forwarded {
+				r.Header.Add("X-Forwarded-For", value)
+			}
+			if tt.realIP != "" {
+				r.Header.Set("X-Real-IP", tt.realIP)
+			}
+			if got := clientIP(r, trusted); got != tt.want {
+				t.Errorf("clientIP() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,81 @@
+package middleware
+
+import (
+	"baccarat/pkg/logger"
+	"net/http"
+	"regexp"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// RequestIDHeader 請求 ID 的請求頭和響應頭，網關已生成時沿用
+const RequestIDHeader = "X-Request-ID"
+
+// validRequestID 沿用的請求 ID 只能是有限長度的安全字符，避免日誌注入
+var validRequestID = regexp.MustCompile(`^[A-Za-z0-9._-]{1,64}$`)
+
+// statusRecorder 記錄處理函數寫出的狀態碼
+type statusRecorder struct {
+	http.ResponseWriter
+	status int
+}
+
+func (w *statusRecorder) WriteHeader(status int) {
+	if w.status == 0 {
+		w.status = status
+	}
+	w.ResponseWriter.WriteHeader(status)
+}
+
+func (w *statusRecorder) Write(b []byte) (int, error) {
+	if w.status == 0 {
+		w.status = http.StatusOK
+	}
+	return w.ResponseWriter.Write(b)
+}
+
+// Unwrap 讓 http.ResponseController 能找到底層的 Flush 等方法
+func (w *statusRecorder) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+// RequestLogging 為每個請求分配 request_id，把 request_id、route 和 method 放入請求 context 的日誌字段，
+// 請求結束時記錄狀態碼和耗時；route 返回請求匹配的路由，未匹配時為空
+func RequestLogging(route func(*http.Request) string, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+
+		requestID := r.Header.Get(RequestIDHeader)
+		if !validRequestID.MatchString(requestID) {
+			requestID = uuid.NewString()
+		}
+		w.Header().Set(RequestIDHeader, requestID)
+
+		pattern := route(r)
+		if pattern == "" {
+			pattern = "unmatched"
+		}
+		ctx := logger.WithFields(r.Context(), logger.Fields{
+			"request_id": requestID,
+			"route":      pattern,
+			"method":     r.Method,
+		})
+		recorder := &statusRecorder{ResponseWriter: w}
+		next.ServeHTTP(recorder, r.WithContext(ctx))
+
+		status := recorder.status
+		if status == 0 {
+			status = http.StatusOK
+		}
+		entry := logger.FromContext(ctx).WithFields(logger.Fields{
+			"status":     status,
+			"latency_ms": float64(time.Since(start).Microseconds()) / 1000,
+		})
+		if status >= http.StatusInternalServerError {
+			entry.Error("Request failed")
+		} else {
+			entry.Info("Request completed")
+		}
+	})
+}
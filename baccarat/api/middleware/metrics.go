@@ -0,0 +1,45 @@
+package middleware
+
+import (
+	"baccarat/pkg/metrics"
+	"baccarat/pkg/utils"
+	"crypto/subtle"
+	"net/http"
+	"time"
+)
+
+// Metrics 按路由、方法和狀態碼記錄請求耗時；未匹配的請求以 "unmatched" 記錄，避免標籤數量隨路徑增長
+func Metrics(route func(*http.Request) string, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		recorder := &statusRecorder{ResponseWriter: w}
+		next.ServeHTTP(recorder, r)
+
+		pattern := route(r)
+		if pattern == "" {
+			pattern = "unmatched"
+		}
+		status := recorder.status
+		if status == 0 {
+			status = http.StatusOK
+		}
+		metrics.ObserveRequest(pattern, r.Method, status, time.Since(start))
+	})
+}
+
+// MetricsToken 要求抓取請求帶有 "Authorization: Bearer <token>"；未配置 token 時不對外提供指標
+func MetricsToken(token string, next http.Handler) http.Handler {
+	if token == "" {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			utils.ErrorResponse(w, http.StatusNotFound, "Not found")
+		})
+	}
+	expected := []byte("Bearer " + token)
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if subtle.ConstantTimeCompare([]byte(r.Header.Get("Authorization")), expected) != 1 {
+			utils.UnauthorizedError(w)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
@@ -0,0 +1,36 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestMetricsToken(t *testing.T) {
+	ok := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {})
+	scrape := func(h http.Handler, authorization string) int {
+		r := httptest.NewRequest(http.MethodGet, "/metrics", nil)
+		if authorization != "" {
+			r.Header.Set("Authorization", authorization)
+		}
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, r)
+		return w.Code
+	}
+
+	// 未配置令牌時不對外提供指標
+	if code := scrape(MetricsToken("", ok), ""); code != http.StatusNotFound {
+		t.Errorf("without token configured: status %d, want 404", code)
+	}
+
+	h := MetricsToken("scrape-secret", ok)
+	if code := scrape(h, ""); code != http.StatusUnauthorized {
+		t.Errorf("missing token: status %d, want 401", code)
+	}
+	if code := scrape(h, "Bearer wrong"); code != http.StatusUnauthorized {
+		t.Errorf("wrong token: status %d, want 401", code)
+	}
+	if code := scrape(h, "Bearer scrape-secret"); code != http.StatusOK {
+		t.Errorf("valid token: status %d, want 200", code)
+	}
+}
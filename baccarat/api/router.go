@@ -3,8 +3,12 @@ package api
 import (
 	"baccarat/api/handlers"
 	"baccarat/api/middleware"
+	"baccarat/config"
 	"baccarat/internal/auth"
-	"database/sql"
+	"baccarat/internal/notify"
+	"baccarat/internal/store"
+	"baccarat/pkg/logger"
+	"baccarat/pkg/metrics"
 	"net/http"
 )
 
@@ -13,38 +17,114 @@ type Router struct {
 	authHandler   *handlers.AuthHandler
 	userHandler   *handlers.UserHandler
 	gameHandler   *handlers.GameHandler
+	adminHandler  *handlers.AdminHandler
 	authMiddleware *middleware.AuthMiddleware
+	handler       http.Handler
 }
 
-func NewRouter(db *sql.DB) *Router {
-	jwtService := auth.NewJWTService()
+func NewRouter(store store.Store, keys *auth.KeySet) *Router {
+	jwtService := auth.NewJWTService(keys)
+	loginGuard := auth.NewDefaultLoginGuard()
 	router := &Router{
 		mux:           http.NewServeMux(),
-		authHandler:   handlers.NewAuthHandler(db, jwtService),
-		userHandler:   handlers.NewUserHandler(db),
-		gameHandler:   handlers.NewGameHandler(db),
+		authHandler:   handlers.NewAuthHandler(store, jwtService, loginGuard, notify.NewLogNotifier()),
+		userHandler:   handlers.NewUserHandler(store),
+		gameHandler:   handlers.NewGameHandler(store),
+		adminHandler:  handlers.NewAdminHandler(store, loginGuard),
 		authMiddleware: middleware.NewAuthMiddleware(jwtService),
 	}
 	router.setupRoutes()
+	router.handler = middleware.Tracing(router.route, middleware.Metrics(router.route, middleware.RequestLogging(router.route, router.mux)))
 	return router
 }
 
 func (r *Router) setupRoutes() {
+	// 公開的令牌驗證公鑰，供網關和其他服務驗證訪問令牌
+	r.mux.Handle("/.well-known/jwks.json", http.HandlerFunc(r.authHandler.JWKS))
+
+	// Prometheus 指標，需要 METRICS_TOKEN 作為 Bearer 令牌，未配置時不提供
+	if config.AppConfig.MetricsToken == "" {
+		logger.Warn("METRICS_TOKEN is not set, /metrics is disabled")
+	}
+	r.mux.Handle("/metrics", middleware.MetricsToken(config.AppConfig.MetricsToken, metrics.Handler()))
+
 	// 用戶相關路由
 	r.mux.Handle("/api/register", http.HandlerFunc(r.authHandler.Register))
 	r.mux.Handle("/api/login", http.HandlerFunc(r.authHandler.Login))
-	r.mux.Handle("/api/user/balance", r.authMiddleware.Authenticate(http.HandlerFunc(r.userHandler.GetBalance)))
-	r.mux.Handle("/api/user/bets", r.authMiddleware.Authenticate(http.HandlerFunc(r.userHandler.GetBets)))
-	r.mux.Handle("/api/user/deposit", r.authMiddleware.Authenticate(http.HandlerFunc(r.userHandler.Deposit)))
-	r.mux.Handle("/api/user/transactions", r.authMiddleware.Authenticate(http.HandlerFunc(r.userHandler.GetTransactions)))
-	r.mux.Handle("/api/game/play", r.authMiddleware.Authenticate(http.HandlerFunc(r.gameHandler.PlayGame)))
-	r.mux.Handle("/api/logout", r.authMiddleware.Authenticate(http.HandlerFunc(r.authHandler.Logout)))
-
-	// 遊戲詳情
-	r.mux.Handle("/api/game/details", r.authMiddleware.Authenticate(http.HandlerFunc(r.gameHandler.GetGameDetails)))
+	r.mux.Handle("/api/user/balance", r.protectPlayer(auth.PermWallet, r.userHandler.GetBalance))
+	r.mux.Handle("/api/user/bets", r.protect(auth.PermHistoryRead, r.userHandler.GetBets))
+	r.mux.Handle("/api/user/history", r.protect(auth.PermHistoryRead, r.userHandler.GetHistory))
+	r.mux.Handle("/api/user/statement", r.protect(auth.PermHistoryRead, r.userHandler.GetStatement))
+	r.mux.Handle("/api/user/deposit", r.protectPlayer(auth.PermWallet, r.userHandler.Deposit))
+	r.mux.Handle("/api/user/transactions", r.protectPlayer(auth.PermWallet, r.userHandler.GetTransactions))
+	r.mux.Handle("/api/game/play", r.protectPlayer(auth.PermGamePlay, r.gameHandler.PlayGame))
+	r.mux.Handle("/api/user/limits", r.protectPlayer(auth.PermWallet, r.userHandler.Limits))
+	r.mux.Handle("/api/user/exclusion", r.authMiddleware.AuthenticateSession(r.authMiddleware.Require(auth.PermGamePlay, http.HandlerFunc(r.userHandler.Exclusion))))
+	r.mux.Handle("/api/user/session/reminder", r.authMiddleware.AuthenticateSession(http.HandlerFunc(r.userHandler.AcknowledgeSessionReminder)))
+	r.mux.Handle("/api/login/2fa", http.HandlerFunc(r.authHandler.LoginTwoFactor))
+	r.mux.Handle("/api/login/2fa/enroll", http.HandlerFunc(r.authHandler.LoginTwoFactorEnroll))
+	r.mux.Handle("/api/token/refresh", http.HandlerFunc(r.authHandler.Refresh))
+	r.mux.Handle("/api/password/forgot", http.HandlerFunc(r.authHandler.ForgotPassword))
+	r.mux.Handle("/api/password/reset", http.HandlerFunc(r.authHandler.ResetPassword))
+	r.mux.Handle("/api/user/password", r.authMiddleware.AuthenticateSession(http.HandlerFunc(r.authHandler.ChangePassword)))
+	r.mux.Handle("/api/logout", r.authMiddleware.AuthenticateSession(http.HandlerFunc(r.authHandler.Logout)))
+	r.mux.Handle("/api/logout/all", r.authMiddleware.AuthenticateSession(http.HandlerFunc(r.authHandler.LogoutAll)))
+
+	// 兩步驗證設置
+	r.mux.Handle("/api/2fa/enroll", r.authMiddleware.AuthenticateSession(http.HandlerFunc(r.authHandler.EnrollTwoFactor)))
+	r.mux.Handle("/api/2fa/confirm", r.authMiddleware.AuthenticateSession(http.HandlerFunc(r.authHandler.ConfirmTwoFactor)))
+	r.mux.Handle("/api/2fa/disable", r.authMiddleware.AuthenticateSession(http.HandlerFunc(r.authHandler.DisableTwoFactor)))
+
+	// API 金鑰管理（只能通過登入會話操作，金鑰本身不能創建或撤銷金鑰）
+	r.mux.Handle("/api/user/api-keys", r.authMiddleware.AuthenticateSession(http.HandlerFunc(r.authHandler.APIKeys)))
+	r.mux.Handle("/api/user/api-keys/revoke", r.authMiddleware.AuthenticateSession(http.HandlerFunc(r.authHandler.RevokeAPIKey)))
+
+	// 遊戲詳情（無 PermGameDetailsAll 時只返回自己的下注）
+	r.mux.Handle("/api/game/details", r.protect(auth.PermHistoryRead, r.gameHandler.GetGameDetails))
+
+	// 管理後台
+	r.mux.Handle("/api/admin/users/unlock", r.protect(auth.PermAccountsManage, r.adminHandler.UnlockUser))
+	r.mux.Handle("/api/admin/users/exclusion", r.protect(auth.PermUsersRead, r.adminHandler.GetUserExclusion))
+	r.mux.Handle("/api/admin/users/exclusion/lift", r.protect(auth.PermAccountsManage, r.adminHandler.LiftUserExclusion))
+	r.mux.Handle("/api/admin/users/statement", r.protect(auth.PermUsersRead, r.adminHandler.GetUserStatement))
+	r.mux.Handle("/api/admin/reports", r.protect(auth.PermReportsRead, r.adminHandler.GetReport))
+
+	// 管理後台：用戶、錢包流水、凍結、人工調整和審計日誌
+	r.mux.Handle("/api/admin/users", r.protect(auth.PermUsersRead, r.adminHandler.SearchUsers))
+	r.mux.Handle("/api/admin/users/ledger", r.protect(auth.PermUsersRead, r.adminHandler.GetUserLedger))
+	r.mux.Handle("/api/admin/users/freeze", r.protect(auth.PermAccountsManage, r.adminHandler.FreezeUser))
+	r.mux.Handle("/api/admin/users/unfreeze", r.protect(auth.PermAccountsManage, r.adminHandler.UnfreezeUser))
+	r.mux.Handle("/api/admin/adjustments", r.protect(auth.PermBalanceAdjust, r.adminHandler.Adjustments))
+	r.mux.Handle("/api/admin/adjustments/approve", r.protect(auth.PermBalanceAdjust, r.adminHandler.ApproveAdjustment))
+	r.mux.Handle("/api/admin/adjustments/reject", r.protect(auth.PermBalanceAdjust, r.adminHandler.RejectAdjustment))
+	r.mux.Handle("/api/admin/audit", r.protect(auth.PermAccountsManage, r.adminHandler.GetAuditLog))
+
+	// 管理後台：牌局作廢和重新結算
+	r.mux.Handle("/api/admin/games/void", r.protect(auth.PermGamesSettle, r.adminHandler.VoidGame))
+	r.mux.Handle("/api/admin/games/resettle", r.protect(auth.PermGamesSettle, r.adminHandler.ResettleGame))
+	r.mux.Handle("/api/admin/games/corrections", r.protect(auth.PermGameDetailsAll, r.adminHandler.GetGameCorrections))
+
+	// 管理後台：由牌靴重新發牌核對牌局
+	r.mux.Handle("/api/admin/games/verify", r.protect(auth.PermGameDetailsAll, r.adminHandler.VerifyGame))
+}
+
+// protect 要求請求已認證（JWT 或 API 金鑰）且擁有指定權限
+func (r *Router) protect(perm auth.Permission, handler http.HandlerFunc) http.Handler {
+	return r.authMiddleware.Authenticate(r.authMiddleware.Require(perm, handler))
+}
+
+// protectPlayer 在 protect 的基礎上拒絕處於冷靜期或自我排除期的用戶，用於錢包和遊戲接口
+func (r *Router) protectPlayer(perm auth.Permission, handler http.HandlerFunc) http.Handler {
+	return r.authMiddleware.Authenticate(r.authMiddleware.Require(perm, r.authMiddleware.RequireNotExcluded(handler)))
+}
+
+// route 返回請求匹配的路由，未匹配時為空
+func (r *Router) route(req *http.Request) string {
+	_, pattern := r.mux.Handler(req)
+	return pattern
 }
 
 // ServeHTTP implements the http.Handler interface
 func (r *Router) ServeHTTP(w http.ResponseWriter, req *http.Request) {
-	r.mux.ServeHTTP(w, req)
+	r.handler.ServeHTTP(w, req)
 }
@@ -1,3 +1,8 @@
+//go:build ignore
+
+// 自動下注處理器的舊草稿，沒有接入路由，不參與構建
+package main
+
 // package handlers
 
 // import (
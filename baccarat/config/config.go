@@ -3,12 +3,15 @@ package config
 import (
 	"os"
 	"strconv"
+	"time"
 
 	"github.com/joho/godotenv"
 )
 
 type Config struct {
 	// 數據庫配置
+	DBDriver   string // mysql 或 sqlite
+	DBPath     string // SQLite 數據庫文件
 	DBHost     string
 	DBPort     string
 	DBUser     string
@@ -16,12 +19,81 @@ type Config struct {
 	DBName     string
 	TimeZone   string // 添加時區配置
 
+	// 只讀副本配置（僅 MySQL）
+	DBReplicaDSNs          string // 逗號分隔的副本 DSN，如 "user:pass@tcp(replica1:3306)/baccarat"，為空時全部查詢走主庫
+	DBReplicaMaxLag        int    // 秒，複製延遲超過此值的副本暫停使用
+	DBReplicaCheckInterval int    // 秒，檢測副本延遲的間隔
+
 	// 日志配置
-	LogLevel string
+	LogLevel  string
+	LogFormat string // text 或 json
+
+	// 追蹤配置
+	TracingExporter    string  // none、otlp 或 stdout
+	TracingEndpoint    string  // OTLP/HTTP 收集器地址，如 "http://jaeger:4318"，為空時使用 OTEL_EXPORTER_OTLP_ENDPOINT
+	TracingSampleRatio float64 // 上游沒有採樣決定時的採樣比例
+
+	// 反向代理配置
+	TrustedProxies string // 逗號分隔的反向代理 IP 或 CIDR，如 "10.0.0.0/8"；只有來自這些地址的請求才採用 X-Forwarded-For、X-Real-IP
+
+	// 指標配置
+	MetricsToken string // 抓取 /metrics 需要 "Authorization: Bearer <token>"，為空時不提供 /metrics
 
 	// JWT配置
-	JWTSecret string
-	JWTExpiry int // 小時
+	JWTSecret           string // 未配置簽名私鑰時使用的 HS256 共享密鑰（僅限開發環境）
+	JWTSigningKeyFile   string // RSA 或 Ed25519 私鑰 PEM 文件
+	JWTSigningKeyID     string // 簽名密鑰的 kid
+	JWTVerificationKeys string // 輪換後仍有效的舊公鑰，格式 "kid=/path/key.pem,..."
+	AccessTokenExpiry   int    // 分鐘
+	RefreshTokenExpiry  int    // 小時
+
+	// 登入防護配置
+	LoginMaxFailures     int // 同一用戶名失敗多少次後鎖定
+	LoginIPMaxFailures   int // 同一IP失敗多少次後鎖定
+	LoginFailureWindow   int // 分鐘，超過此時間沒有失敗則重新計數
+	LoginLockoutDuration int // 分鐘
+
+	// 兩步驗證配置
+	TOTPIssuer string // 驗證器 App 中顯示的發行者名稱
+
+	// 重設密碼配置
+	PasswordResetExpiry int // 分鐘
+
+	// API 金鑰配置
+	APIKeyRateLimit  int // 每個金鑰每分鐘的默認請求上限
+	APIKeyMaxPerUser int // 每個用戶可持有的有效金鑰數量
+
+	// 負責任博彩配置
+	LimitCoolingOff         int // 小時，放寬或取消限額需等待的冷靜期
+	SessionReminderInterval int // 分鐘，遊戲時長提醒間隔
+
+	// 報表配置
+	ReportRollupInterval int // 分鐘，小時匯總任務的執行間隔
+
+	// 管理後台配置
+	AdjustmentApprovalThreshold float64 // 人工調整金額超過此值時需另一名管理員審批
+	AdjustmentApprovalWindow    int     // 小時，門檻按窗口內自動入帳的累計金額計算
+
+	// 事件投遞配置
+	WebhookURL         string // 為空時不投遞，事件保留在發件箱
+	WebhookSecret      string // HMAC 簽名密鑰，與 webhook 服務一致
+	EventRelayInterval int    // 秒，發件箱投遞間隔
+
+	// 歸檔配置
+	ArchiveAfterDays int // 牌局超過此天數後移到歸檔表，0 表示不歸檔
+	ArchiveInterval  int // 分鐘，歸檔任務的執行間隔
+
+	// 自動賭局恢復配置
+	AutoGameRecoveryInterval int // 分鐘，檢查中斷賭局的間隔
+	AutoGameRecoveryTimeout  int // 分鐘，開始超過此時長仍未完成的賭局視為中斷
+
+	// 返還率監控配置
+	RTPMonitorInterval    int     // 分鐘，檢測間隔，0 表示不監控
+	RTPMonitorWindow      int     // 小時，滾動窗口
+	RTPAlertSigma         float64 // 偏離理論值超過此標準差倍數時告警
+	RTPMinRounds          int     // 窗口內少於此局數時不檢測
+	RTPAlertWebhookURL    string  // 為空時只記錄日誌
+	RTPAlertWebhookSecret string  // 不為空時按事件投遞的格式簽名
 
 	// 遊戲配置
 	PlayerPayout           float64
@@ -37,12 +109,15 @@ var AppConfig Config
 
 // LoadConfig 載入配置
 func LoadConfig() error {
-	if err := godotenv.Load(); err != nil {
+	// 容器中直接使用環境變量，沒有 .env 文件時跳過
+	if err := godotenv.Load(); err != nil && !os.IsNotExist(err) {
 		return err
 	}
 
 	AppConfig = Config{
 		// 數據庫配置
+		DBDriver:   getEnvAsString("DB_DRIVER", "mysql"),
+		DBPath:     getEnvAsString("DB_PATH", "baccarat.db"),
 		DBHost:     os.Getenv("DB_HOST"),
 		DBPort:     os.Getenv("DB_PORT"),
 		DBUser:     os.Getenv("DB_USER"),
@@ -50,12 +125,81 @@ func LoadConfig() error {
 		DBName:     os.Getenv("DB_NAME"),
 		TimeZone:   os.Getenv("TIME_ZONE"), // 添加時區配置
 
+		// 只讀副本配置
+		DBReplicaDSNs:          os.Getenv("DB_REPLICA_DSNS"),
+		DBReplicaMaxLag:        getEnvAsInt("DB_REPLICA_MAX_LAG", 5),
+		DBReplicaCheckInterval: getEnvAsInt("DB_REPLICA_CHECK_INTERVAL", 5),
+
 		// 日志配置
 		LogLevel:   os.Getenv("LOG_LEVEL"),
+		LogFormat:  getEnvAsString("LOG_FORMAT", "text"),
+
+		// 追蹤配置
+		TracingExporter:    getEnvAsString("TRACING_EXPORTER", "none"),
+		TracingEndpoint:    getEnvAsString("OTLP_ENDPOINT", ""),
+		TracingSampleRatio: getEnvAsFloat("TRACING_SAMPLE_RATIO", 1.0),
+
+		// 反向代理配置
+		TrustedProxies: getEnvAsString("TRUSTED_PROXIES", ""),
+
+		// 指標配置
+		MetricsToken: getEnvAsString("METRICS_TOKEN", ""),
 
 		// JWT配置
-		JWTSecret: os.Getenv("JWT_SECRET"),
-		JWTExpiry: getEnvAsInt("JWT_EXPIRY", 24), // 默認24小時
+		JWTSecret:           os.Getenv("JWT_SECRET"),
+		JWTSigningKeyFile:   os.Getenv("JWT_SIGNING_KEY_FILE"),
+		JWTSigningKeyID:     getEnvAsString("JWT_SIGNING_KEY_ID", "default"),
+		JWTVerificationKeys: os.Getenv("JWT_VERIFICATION_KEYS"),
+		AccessTokenExpiry:   getEnvAsInt("ACCESS_TOKEN_EXPIRY", 15),   // 默認15分鐘
+		RefreshTokenExpiry:  getEnvAsInt("REFRESH_TOKEN_EXPIRY", 720), // 默認30天
+
+		// 登入防護配置
+		LoginMaxFailures:     getEnvAsInt("LOGIN_MAX_FAILURES", 5),
+		LoginIPMaxFailures:   getEnvAsInt("LOGIN_IP_MAX_FAILURES", 20),
+		LoginFailureWindow:   getEnvAsInt("LOGIN_FAILURE_WINDOW", 15),
+		LoginLockoutDuration: getEnvAsInt("LOGIN_LOCKOUT_DURATION", 15),
+
+		// 兩步驗證配置
+		TOTPIssuer: getEnvAsString("TOTP_ISSUER", "Baccarat"),
+
+		// 重設密碼配置
+		PasswordResetExpiry: getEnvAsInt("PASSWORD_RESET_EXPIRY", 30),
+
+		// API 金鑰配置
+		APIKeyRateLimit:  getEnvAsInt("API_KEY_RATE_LIMIT", 60),
+		APIKeyMaxPerUser: getEnvAsInt("API_KEY_MAX_PER_USER", 10),
+
+		// 負責任博彩配置
+		LimitCoolingOff:         getEnvAsInt("LIMIT_COOLING_OFF", 24),
+		SessionReminderInterval: getEnvAsInt("SESSION_REMINDER_INTERVAL", 60),
+
+		// 報表配置
+		ReportRollupInterval: getEnvAsInt("REPORT_ROLLUP_INTERVAL", 5),
+
+		// 管理後台配置
+		AdjustmentApprovalThreshold: getEnvAsFloat("ADJUSTMENT_APPROVAL_THRESHOLD", 1000),
+		AdjustmentApprovalWindow:    getEnvAsInt("ADJUSTMENT_APPROVAL_WINDOW", 24),
+
+		// 事件投遞配置
+		WebhookURL:         getEnvAsString("WEBHOOK_URL", ""),
+		WebhookSecret:      getEnvAsString("WEBHOOK_SECRET", ""),
+		EventRelayInterval: getEnvAsInt("EVENT_RELAY_INTERVAL", 5),
+
+		// 歸檔配置
+		ArchiveAfterDays: getEnvAsInt("ARCHIVE_AFTER_DAYS", 365),
+		ArchiveInterval:  getEnvAsInt("ARCHIVE_INTERVAL", 60),
+
+		// 自動賭局恢復配置
+		AutoGameRecoveryInterval: getEnvAsInt("AUTO_GAME_RECOVERY_INTERVAL", 1),
+		AutoGameRecoveryTimeout:  getEnvAsInt("AUTO_GAME_RECOVERY_TIMEOUT", 10),
+
+		// 返還率監控配置
+		RTPMonitorInterval:    getEnvAsInt("RTP_MONITOR_INTERVAL", 5),
+		RTPMonitorWindow:      getEnvAsInt("RTP_MONITOR_WINDOW", 24),
+		RTPAlertSigma:         getEnvAsFloat("RTP_ALERT_SIGMA", 4),
+		RTPMinRounds:          getEnvAsInt("RTP_MIN_ROUNDS", 1000),
+		RTPAlertWebhookURL:    getEnvAsString("RTP_ALERT_WEBHOOK_URL", ""),
+		RTPAlertWebhookSecret: getEnvAsString("RTP_ALERT_WEBHOOK_SECRET", ""),
 
 		// 遊戲配置
 		PlayerPayout:         getEnvAsFloat("PLAYER_PAYOUT", 1.0),
@@ -67,9 +211,31 @@ func LoadConfig() error {
 		BankerLucky6_3Cards: getEnvAsFloat("BANKER_LUCKY6_3CARDS_PAYOUT", 0.95),
 	}
 
+	location, err := time.LoadLocation(AppConfig.TimeZone)
+	if err != nil {
+		return err
+	}
+	appLocation = location
+
 	return nil
 }
 
+// appLocation TIME_ZONE 對應的時區，未配置時為 UTC（與數據庫連接的 loc 參數一致）
+var appLocation = time.Local
+
+// Location 返回 TIME_ZONE 對應的時區，日期統計（限額週期、歷史、報表）都以此時區的自然日計算
+func Location() *time.Location {
+	return appLocation
+}
+
+// getEnvAsString 獲取環境變數的字符串值
+func getEnvAsString(key string, defaultVal string) string {
+	if value, exists := os.LookupEnv(key); exists && value != "" {
+		return value
+	}
+	return defaultVal
+}
+
 // getEnvAsInt 獲取環境變數的整數值
 func getEnvAsInt(key string, defaultVal int) int {
 	if value, exists := os.LookupEnv(key); exists {
@@ -0,0 +1,414 @@
+package db
+
+import (
+	"baccarat/internal/adjustment"
+	"context"
+	"database/sql"
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// ErrInsufficientBalance 扣款後餘額會變為負數
+var ErrInsufficientBalance = errors.New("insufficient balance")
+
+// 管理操作審計類型
+const (
+	AdminActionUserSearch        = "user_search"
+	AdminActionLedgerViewed      = "ledger_viewed"
+	AdminActionStatementExported = "statement_exported"
+	AdminActionAccountFrozen     = "account_frozen"
+	AdminActionAccountUnfrozen   = "account_unfrozen"
+	AdminActionAccountUnlocked   = "account_unlocked"
+	AdminActionExclusionLifted   = "exclusion_lifted"
+	AdminActionAdjustRequested   = "adjustment_requested"
+	AdminActionAdjustApplied     = "adjustment_applied"
+	AdminActionAdjustRejected    = "adjustment_rejected"
+	AdminActionGameVoided        = "game_voided"
+	AdminActionGameResettled     = "game_resettled"
+)
+
+// Execer *sql.DB 和 *sql.Tx 共有的寫入方法
+type Execer interface {
+	Exec(query string, args ...interface{}) (sql.Result, error)
+}
+
+// AdminUserSummary 管理後台用戶列表中的一行
+type AdminUserSummary struct {
+	ID           int        `json:"id"`
+	Username     string     `json:"username"`
+	Role         string     `json:"role"`
+	Balance      float64    `json:"balance"`
+	FrozenAt     *time.Time `json:"frozenAt,omitempty"`
+	FrozenReason string     `json:"frozenReason,omitempty"`
+	CreatedAt    time.Time  `json:"createdAt"`
+}
+
+// LedgerEntry 錢包流水中的一筆，金額正數為入帳、負數為出帳
+type LedgerEntry struct {
+	Source       string    `json:"source"` // transaction, bet
+	RefID        int64     `json:"refId"`
+	Kind         string    `json:"kind"` // 交易類型，或 wager、payout
+	Amount       float64   `json:"amount"`
+	GameID       string    `json:"gameId,omitempty"`
+	BalanceAfter float64   `json:"balanceAfter"`
+	CreatedAt    time.Time `json:"createdAt"`
+}
+
+// AdminAuditEvent 管理操作審計記錄
+type AdminAuditEvent struct {
+	ID           int64     `json:"id"`
+	AdminID      int       `json:"adminId"`
+	Action       string    `json:"action"`
+	TargetUserID *int      `json:"targetUserId,omitempty"`
+	Detail       string    `json:"detail"`
+	IPAddress    string    `json:"ipAddress"`
+	CreatedAt    time.Time `json:"createdAt"`
+}
+
+// SearchUsers 按用戶名前綴或用戶ID搜索用戶
+func SearchUsers(query string, limit, offset int) ([]AdminUserSummary, error) {
+	pattern := strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`).Replace(query) + "%"
+	id, _ := strconv.Atoi(query)
+
+	rows, err := DB.Query(`
+		SELECT id, username, role, balance, frozen_at, frozen_reason, created_at
+		FROM users
+		WHERE username LIKE ? OR id = ?
+		ORDER BY username
+		LIMIT ? OFFSET ?`,
+		pattern, id, limit, offset,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	users := []AdminUserSummary{}
+	for rows.Next() {
+		var u AdminUserSummary
+		var frozenAt sql.NullTime
+		var reason sql.NullString
+		if err := rows.Scan(&u.ID, &u.Username, &u.Role, &u.Balance, &frozenAt, &reason, &u.CreatedAt); err != nil {
+			return nil, err
+		}
+		if frozenAt.Valid {
+			u.FrozenAt = &frozenAt.Time
+		}
+		u.FrozenReason = reason.String
+		users = append(users, u)
+	}
+	return users, rows.Err()
+}
+
+// ledgerQuery 錢包流水：交易記錄、下注扣款和派彩入帳，按時間倒序；返回查詢和參數
+func ledgerQuery(userID int) (string, []interface{}) {
+	wagers, wagerArgs := unionArchived(`
+		SELECT 'bet', b.id, 'wager', -b.bet_amount, b.game_id, b.created_at, 0
+		FROM {bets} b
+		WHERE b.user_id = ?`,
+		userID,
+	)
+	payouts, payoutArgs := unionArchived(`
+		SELECT 'bet', b.id, 'payout', `+betReturnExpr+`, b.game_id, b.created_at, 1
+		FROM {bets} b
+		JOIN {game_records} gr ON b.game_id = gr.game_id
+		WHERE b.user_id = ? AND (`+betReturnExpr+`) > 0`,
+		userID,
+	)
+	query := `
+	SELECT source, ref_id, kind, amount, game_id, created_at
+	FROM (
+		SELECT 'transaction' AS source, t.id AS ref_id, t.transaction_type AS kind, t.amount,
+			COALESCE(t.reference, '') AS game_id, t.created_at, 0 AS seq
+		FROM transactions t
+		WHERE t.user_id = ?
+		UNION ALL` + wagers + `
+		UNION ALL` + payouts + `
+	) ledger
+	ORDER BY created_at DESC, source DESC, ref_id DESC, seq DESC`
+	args := append([]interface{}{userID}, wagerArgs...)
+	return query, append(args, payoutArgs...)
+}
+
+// GetLedger 分頁查詢用戶的錢包流水，並計算每筆之後的餘額
+func GetLedger(userID, limit, offset int) ([]LedgerEntry, error) {
+	tx, err := ReadDB(userID).BeginTx(context.Background(), &sql.TxOptions{Isolation: sql.LevelRepeatableRead, ReadOnly: true})
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	// 當前餘額減去本頁之前（更新）的流水，即本頁第一筆之後的餘額
+	ledger, args := ledgerQuery(userID)
+	var balance float64
+	err = tx.QueryRow(`
+		SELECT u.balance - COALESCE((
+			SELECT SUM(amount) FROM (`+ledger+` LIMIT ?) newer
+		), 0)
+		FROM users u
+		WHERE u.id = ?`,
+		append(args, offset, userID)...,
+	).Scan(&balance)
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := tx.Query(ledger+" LIMIT ? OFFSET ?", append(args, limit, offset)...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	entries := []LedgerEntry{}
+	for rows.Next() {
+		var e LedgerEntry
+		if err := rows.Scan(&e.Source, &e.RefID, &e.Kind, &e.Amount, &e.GameID, &e.CreatedAt); err != nil {
+			return nil, err
+		}
+		e.BalanceAfter = balance
+		balance -= e.Amount
+		entries = append(entries, e)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return entries, tx.Commit()
+}
+
+// IsUserFrozen 查詢帳戶是否被凍結
+func IsUserFrozen(q Querier, userID int) (bool, error) {
+	var frozenAt sql.NullTime
+	err := q.QueryRow("SELECT frozen_at FROM users WHERE id = ?", userID).Scan(&frozenAt)
+	return frozenAt.Valid, err
+}
+
+// SetUserFrozen 凍結或解凍帳戶，狀態沒有變化時返回 false
+func SetUserFrozen(tx *sql.Tx, userID int, frozen bool, reason string) (bool, error) {
+	var result sql.Result
+	var err error
+	if frozen {
+		result, err = tx.Exec(
+			"UPDATE users SET frozen_at = CURRENT_TIMESTAMP, frozen_reason = ? WHERE id = ? AND frozen_at IS NULL",
+			reason, userID,
+		)
+	} else {
+		result, err = tx.Exec(
+			"UPDATE users SET frozen_at = NULL, frozen_reason = NULL WHERE id = ? AND frozen_at IS NOT NULL",
+			userID,
+		)
+	}
+	if err != nil {
+		return false, err
+	}
+	n, err := result.RowsAffected()
+	return n > 0, err
+}
+
+const adjustmentColumns = `a.id, a.user_id, u.username, a.amount, a.reason_code, a.note, a.status,
+	a.requested_by, a.decided_by, a.decision_note, a.created_at, a.decided_at`
+
+func scanAdjustment(scan func(dest ...interface{}) error) (*adjustment.Adjustment, error) {
+	var a adjustment.Adjustment
+	var decidedBy sql.NullInt64
+	var decisionNote sql.NullString
+	var decidedAt sql.NullTime
+	if err := scan(&a.ID, &a.UserID, &a.Username, &a.Amount, &a.Reason, &a.Note, &a.Status,
+		&a.RequestedBy, &decidedBy, &decisionNote, &a.CreatedAt, &decidedAt); err != nil {
+		return nil, err
+	}
+	if decidedBy.Valid {
+		by := int(decidedBy.Int64)
+		a.DecidedBy = &by
+	}
+	a.DecisionNote = decisionNote.String
+	if decidedAt.Valid {
+		a.DecidedAt = &decidedAt.Time
+	}
+	return &a, nil
+}
+
+// CreateAdjustment 保存新的調整記錄
+func CreateAdjustment(tx *sql.Tx, a *adjustment.Adjustment) error {
+	result, err := tx.Exec(`
+		INSERT INTO balance_adjustments (user_id, amount, reason_code, note, status, requested_by)
+		VALUES (?, ?, ?, ?, ?, ?)`,
+		a.UserID, a.Amount, a.Reason, a.Note, a.Status, a.RequestedBy,
+	)
+	if err != nil {
+		return err
+	}
+	a.ID, err = result.LastInsertId()
+	return err
+}
+
+// GetAdjustmentForUpdate 在事務內鎖定並讀取調整記錄
+func GetAdjustmentForUpdate(tx *sql.Tx, id int64) (*adjustment.Adjustment, error) {
+	return scanAdjustment(tx.QueryRow(`
+		SELECT `+adjustmentColumns+`
+		FROM balance_adjustments a
+		JOIN users u ON a.user_id = u.id
+		WHERE a.id = ?`+dialect.forUpdate(),
+		id,
+	).Scan)
+}
+
+// ListAdjustments 按狀態查詢調整記錄，status 為空時返回全部
+func ListAdjustments(status adjustment.Status, limit, offset int) ([]*adjustment.Adjustment, error) {
+	where, args := "1 = 1", []interface{}{}
+	if status != "" {
+		where, args = "a.status = ?", append(args, status)
+	}
+	args = append(args, limit, offset)
+
+	rows, err := DB.Query(`
+		SELECT `+adjustmentColumns+`
+		FROM balance_adjustments a
+		JOIN users u ON a.user_id = u.id
+		WHERE `+where+`
+		ORDER BY a.created_at DESC, a.id DESC
+		LIMIT ? OFFSET ?`,
+		args...,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	adjustments := []*adjustment.Adjustment{}
+	for rows.Next() {
+		a, err := scanAdjustment(rows.Scan)
+		if err != nil {
+			return nil, err
+		}
+		adjustments = append(adjustments, a)
+	}
+	return adjustments, rows.Err()
+}
+
+// DecideAdjustment 記錄審批結果，decidedBy 為 0 代表門檻以下自動入帳
+func DecideAdjustment(tx *sql.Tx, a *adjustment.Adjustment, status adjustment.Status, decidedBy int, note string) error {
+	var by sql.NullInt64
+	if decidedBy > 0 {
+		by = sql.NullInt64{Int64: int64(decidedBy), Valid: true}
+	}
+	_, err := tx.Exec(`
+		UPDATE balance_adjustments
+		SET status = ?, decided_by = ?, decision_note = ?, decided_at = CURRENT_TIMESTAMP
+		WHERE id = ?`,
+		status, by, note, a.ID,
+	)
+	if err != nil {
+		return err
+	}
+	a.Status = status
+	if decidedBy > 0 {
+		a.DecidedBy = &decidedBy
+	}
+	a.DecisionNote = note
+	return nil
+}
+
+// GetAutoAppliedAdjustmentTotals 統計 since 以來未經審批自動入帳的調整金額絕對值，
+// 分別按目標用戶和發起的管理員累計
+func GetAutoAppliedAdjustmentTotals(tx *sql.Tx, userID, adminID int, since time.Time) (byUser, byAdmin float64, err error) {
+	query := `
+		SELECT COALESCE(SUM(ABS(amount)), 0)
+		FROM balance_adjustments
+		WHERE status = ? AND decided_by IS NULL AND created_at >= ? AND `
+	if err := tx.QueryRow(query+"user_id = ?", adjustment.StatusApplied, since, userID).Scan(&byUser); err != nil {
+		return 0, 0, err
+	}
+	if err := tx.QueryRow(query+"requested_by = ?", adjustment.StatusApplied, since, adminID).Scan(&byAdmin); err != nil {
+		return 0, 0, err
+	}
+	return byUser, byAdmin, nil
+}
+
+// ApplyAdjustment 鎖定用戶後入帳並記錄交易，扣款不能使餘額變為負數
+func ApplyAdjustment(tx *sql.Tx, a *adjustment.Adjustment) error {
+	if err := LockUser(tx, a.UserID); err != nil {
+		return err
+	}
+	var balance float64
+	if err := tx.QueryRow("SELECT balance FROM users WHERE id = ?", a.UserID).Scan(&balance); err != nil {
+		return err
+	}
+	if balance+a.Amount < 0 {
+		return ErrInsufficientBalance
+	}
+	if err := UpdateUserBalance(tx, a.UserID, a.Amount); err != nil {
+		return err
+	}
+	return SaveTransaction(tx, a.UserID, a.Amount, "adjustment")
+}
+
+// truncateRunes 截取 s 的前 n 個字符；按字節截取會切斷多字節的中文字符
+func truncateRunes(s string, n int) string {
+	if utf8.RuneCountInString(s) <= n {
+		return s
+	}
+	return string([]rune(s)[:n])
+}
+
+// SaveAdminAuditEvent 寫入管理操作審計記錄，targetUserID 為 0 代表不針對特定用戶
+// 狀態變更應傳入事務，確保變更和審計記錄同時成功或失敗
+func SaveAdminAuditEvent(exec Execer, adminID int, action string, targetUserID int, detail, ipAddress string) error {
+	var target sql.NullInt64
+	if targetUserID > 0 {
+		target = sql.NullInt64{Int64: int64(targetUserID), Valid: true}
+	}
+	detail = truncateRunes(detail, 500)
+	_, err := exec.Exec(
+		"INSERT INTO admin_audit_log (admin_id, action, target_user_id, detail, ip_address) VALUES (?, ?, ?, ?, ?)",
+		adminID, action, target, detail, ipAddress,
+	)
+	return err
+}
+
+// ListAdminAuditEvents 查詢審計記錄，targetUserID、adminID 為 0 時不過濾
+func ListAdminAuditEvents(targetUserID, adminID, limit, offset int) ([]AdminAuditEvent, error) {
+	conditions := []string{"1 = 1"}
+	args := []interface{}{}
+	if targetUserID > 0 {
+		conditions = append(conditions, "target_user_id = ?")
+		args = append(args, targetUserID)
+	}
+	if adminID > 0 {
+		conditions = append(conditions, "admin_id = ?")
+		args = append(args, adminID)
+	}
+	args = append(args, limit, offset)
+
+	rows, err := DB.Query(`
+		SELECT id, admin_id, action, target_user_id, detail, ip_address, created_at
+		FROM admin_audit_log
+		WHERE `+strings.Join(conditions, " AND ")+`
+		ORDER BY id DESC
+		LIMIT ? OFFSET ?`,
+		args...,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	events := []AdminAuditEvent{}
+	for rows.Next() {
+		var e AdminAuditEvent
+		var target sql.NullInt64
+		var detail, ip sql.NullString
+		if err := rows.Scan(&e.ID, &e.AdminID, &e.Action, &target, &detail, &ip, &e.CreatedAt); err != nil {
+			return nil, err
+		}
+		if target.Valid {
+			id := int(target.Int64)
+			e.TargetUserID = &id
+		}
+		e.Detail, e.IPAddress = detail.String, ip.String
+		events = append(events, e)
+	}
+	return events, rows.Err()
+}
@@ -0,0 +1,148 @@
+package db
+
+import (
+	"database/sql"
+	"strings"
+	"time"
+)
+
+// API 金鑰審計事件類型
+const (
+	AuthEventAPIKeyCreated = "api_key_created"
+	AuthEventAPIKeyRevoked = "api_key_revoked"
+)
+
+// APIKey API 金鑰記錄（不含雜湊）
+type APIKey struct {
+	ID         int64      `json:"id"`
+	UserID     int        `json:"-"`
+	Name       string     `json:"name"`
+	Prefix     string     `json:"prefix"`
+	Scopes     []string   `json:"scopes"`
+	RateLimit  int        `json:"rateLimit"`
+	LastUsedAt *time.Time `json:"lastUsedAt"`
+	LastUsedIP string     `json:"lastUsedIp,omitempty"`
+	CreatedAt  time.Time  `json:"createdAt"`
+	RevokedAt  *time.Time `json:"revokedAt,omitempty"`
+}
+
+// APIKeyOwner 通過金鑰認證時需要的金鑰和持有人信息
+type APIKeyOwner struct {
+	KeyID     int64
+	UserID    int
+	Role      string
+	Scopes    []string
+	RateLimit int
+}
+
+// CreateAPIKey 保存新的 API 金鑰
+func CreateAPIKey(userID int, name, prefix, keyHash string, scopes []string, rateLimit int) (int64, error) {
+	result, err := DB.Exec(
+		"INSERT INTO api_keys (user_id, name, key_prefix, key_hash, scopes, rate_limit) VALUES (?, ?, ?, ?, ?, ?)",
+		userID, name, prefix, keyHash, strings.Join(scopes, ","), rateLimit,
+	)
+	if err != nil {
+		return 0, err
+	}
+	return result.LastInsertId()
+}
+
+// CountActiveAPIKeys 統計用戶未撤銷的金鑰數量
+func CountActiveAPIKeys(userID int) (int, error) {
+	var count int
+	err := DB.QueryRow(
+		"SELECT COUNT(*) FROM api_keys WHERE user_id = ? AND revoked_at IS NULL",
+		userID,
+	).Scan(&count)
+	return count, err
+}
+
+// ListAPIKeys 列出用戶的所有金鑰（包括已撤銷的）
+func ListAPIKeys(userID int) ([]APIKey, error) {
+	rows, err := DB.Query(`
+		SELECT id, user_id, name, key_prefix, scopes, rate_limit, last_used_at, last_used_ip, created_at, revoked_at
+		FROM api_keys
+		WHERE user_id = ?
+		ORDER BY created_at DESC, id DESC`,
+		userID,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	keys := []APIKey{}
+	for rows.Next() {
+		var key APIKey
+		var scopes string
+		var lastUsedAt, revokedAt sql.NullTime
+		var lastUsedIP sql.NullString
+		if err := rows.Scan(&key.ID, &key.UserID, &key.Name, &key.Prefix, &scopes, &key.RateLimit,
+			&lastUsedAt, &lastUsedIP, &key.CreatedAt, &revokedAt); err != nil {
+			return nil, err
+		}
+		key.Scopes = strings.Split(scopes, ",")
+		if lastUsedAt.Valid {
+			key.LastUsedAt = &lastUsedAt.Time
+		}
+		if revokedAt.Valid {
+			key.RevokedAt = &revokedAt.Time
+		}
+		key.LastUsedIP = lastUsedIP.String
+		keys = append(keys, key)
+	}
+	return keys, rows.Err()
+}
+
+// RevokeAPIKey 撤銷用戶的一個金鑰，金鑰不存在或已撤銷時返回 false
+func RevokeAPIKey(userID int, keyID int64) (bool, error) {
+	result, err := DB.Exec(
+		"UPDATE api_keys SET revoked_at = ? WHERE id = ? AND user_id = ? AND revoked_at IS NULL",
+		time.Now(), keyID, userID,
+	)
+	if err != nil {
+		return false, err
+	}
+	affected, err := result.RowsAffected()
+	return affected == 1, err
+}
+
+// GetAPIKeyOwner 通過雜湊查詢有效金鑰及其持有人，金鑰不存在或已撤銷時返回 sql.ErrNoRows
+func GetAPIKeyOwner(keyHash string) (*APIKeyOwner, error) {
+	var owner APIKeyOwner
+	var scopes string
+	err := DB.QueryRow(`
+		SELECT k.id, k.user_id, u.role, k.scopes, k.rate_limit
+		FROM api_keys k
+		JOIN users u ON k.user_id = u.id
+		WHERE k.key_hash = ? AND k.revoked_at IS NULL`,
+		keyHash,
+	).Scan(&owner.KeyID, &owner.UserID, &owner.Role, &scopes, &owner.RateLimit)
+	if err != nil {
+		return nil, err
+	}
+	owner.Scopes = strings.Split(scopes, ",")
+	return &owner, nil
+}
+
+// TouchAPIKey 更新金鑰最後使用時間和IP，一分鐘內重複使用不再寫入以減少寫放大
+func TouchAPIKey(keyID int64, ipAddress string) error {
+	now := time.Now()
+	_, err := DB.Exec(
+		"UPDATE api_keys SET last_used_at = ?, last_used_ip = ? WHERE id = ? AND (last_used_at IS NULL OR last_used_at < ?)",
+		now, ipAddress, keyID, now.Add(-time.Minute),
+	)
+	return err
+}
+
+// RevokeUserAPIKeys 撤銷用戶的所有金鑰
+func RevokeUserAPIKeys(tx *sql.Tx, userID int) (int64, error) {
+	result, err := tx.Exec(
+		"UPDATE api_keys SET revoked_at = ? WHERE user_id = ? AND revoked_at IS NULL",
+		time.Now(), userID,
+	)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
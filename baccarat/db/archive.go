@@ -0,0 +1,98 @@
+package db
+
+import (
+	"database/sql"
+	"errors"
+	"strings"
+	"time"
+)
+
+// ErrGameArchived 牌局已移到歸檔表，只能查詢不能修正
+var ErrGameArchived = errors.New("game archived")
+
+// 歸檔時複製的列，與 0017_archive 遷移中的歸檔表和視圖一致
+const (
+	gameRecordColumns = `id, game_id, table_id, shoe_id, shoe_position, drawn_cards,
+		player_initial_cards, banker_initial_cards, player_initial_score, banker_initial_score,
+		player_third_card, banker_third_card, player_third_value, banker_third_value,
+		player_final_score, banker_final_score, winner, is_lucky_six, lucky_six_type,
+		player_payout, banker_payout, tie_payout, lucky_six_payout, total_bets, total_payouts,
+		status, created_at`
+	betColumns = "id, user_id, game_id, bet_amount, bet_type, settled_return, created_at"
+)
+
+// liveAndArchive 依次為在線表和歸檔表替換查詢中的 {bets}、{game_records}
+// 歸檔按整局移動，下注和所屬牌局總在同一組表中
+var liveAndArchive = []*strings.Replacer{
+	strings.NewReplacer("{bets}", "bets", "{game_records}", "game_records"),
+	strings.NewReplacer("{bets}", "bets_archive", "{game_records}", "game_records_archive"),
+}
+
+// unionArchived 把 branch 分別套用在線表和歸檔表，以 UNION ALL 合併，args 在每個分支重複一次
+//
+// 服務內的查詢不 JOIN game_records_all、bets_all 視圖：MySQL 會先把 UNION ALL 視圖物化為
+// 沒有索引的臨時表再 JOIN，每次查詢都掃描全部下注。篩選條件寫在 branch 內，
+// 兩個分支各自使用 (user_id, created_at)、game_id 等索引（EXPLAIN 中沒有 <derived> 全表掃描）
+func unionArchived(branch string, args ...interface{}) (string, []interface{}) {
+	branches := make([]string, len(liveAndArchive))
+	all := make([]interface{}, 0, len(args)*len(liveAndArchive))
+	for i, r := range liveAndArchive {
+		branches[i] = r.Replace(branch)
+		all = append(all, args...)
+	}
+	return strings.Join(branches, "\n\t\tUNION ALL\n"), all
+}
+
+// ArchiveRounds 在一個事務內把 cutoff 之前最早的至多 limit 局及其下注移到歸檔表，返回移動的局數
+func ArchiveRounds(cutoff time.Time, limit int) (int, error) {
+	var moved int
+	err := Transaction(func(tx *sql.Tx) error {
+		rows, err := tx.Query(`
+			SELECT game_id
+			FROM game_records
+			WHERE created_at < ?
+			ORDER BY created_at, id
+			LIMIT ?`+dialect.forUpdate(),
+			cutoff, limit,
+		)
+		if err != nil {
+			return err
+		}
+		var gameIDs []interface{}
+		for rows.Next() {
+			var gameID string
+			if err := rows.Scan(&gameID); err != nil {
+				rows.Close()
+				return err
+			}
+			gameIDs = append(gameIDs, gameID)
+		}
+		rows.Close()
+		if err := rows.Err(); err != nil || len(gameIDs) == 0 {
+			return err
+		}
+
+		in := "(?" + strings.Repeat(", ?", len(gameIDs)-1) + ")"
+		statements := []string{
+			"INSERT INTO game_records_archive (" + gameRecordColumns + ") SELECT " + gameRecordColumns + " FROM game_records WHERE game_id IN " + in,
+			"INSERT INTO bets_archive (" + betColumns + ") SELECT " + betColumns + " FROM bets WHERE game_id IN " + in,
+			"DELETE FROM bets WHERE game_id IN " + in,
+			"DELETE FROM game_records WHERE game_id IN " + in,
+		}
+		for _, statement := range statements {
+			if _, err := tx.Exec(statement, gameIDs...); err != nil {
+				return err
+			}
+		}
+		moved = len(gameIDs)
+		return nil
+	})
+	return moved, err
+}
+
+// isGameArchived 牌局是否已移到歸檔表
+func isGameArchived(q Querier, gameID string) (bool, error) {
+	var exists bool
+	err := q.QueryRow("SELECT EXISTS(SELECT 1 FROM game_records_archive WHERE game_id = ?)", gameID).Scan(&exists)
+	return exists, err
+}
@@ -0,0 +1,148 @@
+package db_test
+
+import (
+	"baccarat/db"
+	"baccarat/internal/testdb"
+	"database/sql"
+	"fmt"
+	"os"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestMain(m *testing.M) {
+	cleanup, err := testdb.Open()
+	if err != nil {
+		panic(err)
+	}
+	code := m.Run()
+	cleanup()
+	os.Exit(code)
+}
+
+// queryPlan 返回 SQLite 的 EXPLAIN QUERY PLAN 各步驟
+func queryPlan(t *testing.T, query string, args []interface{}) []string {
+	t.Helper()
+	rows, err := db.DB.Query("EXPLAIN QUERY PLAN "+query, args...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer rows.Close()
+	var steps []string
+	for rows.Next() {
+		var id, parent, unused int
+		var detail string
+		if err := rows.Scan(&id, &parent, &unused, &detail); err != nil {
+			t.Fatal(err)
+		}
+		steps = append(steps, detail)
+	}
+	return steps
+}
+
+func TestHistoryQueriesUseIndexesOnBothTables(t *testing.T) {
+	filter := db.BetHistoryFilter{UserID: 1, From: time.Now().Add(-time.Hour), Outcome: db.OutcomeWin}
+	history, historyArgs := db.BetHistoryQuery(filter, &db.BetHistoryCursor{CreatedAt: time.Now(), ID: 10}, 50)
+	summary, summaryArgs := db.BetHistorySummaryQuery(filter)
+
+	for name, plan := range map[string][]string{
+		"history": queryPlan(t, history, historyArgs),
+		"summary": queryPlan(t, summary, summaryArgs),
+	} {
+		joined := strings.Join(plan, "\n")
+		// 下注按 (user_id, created_at) 索引查找，牌局按 game_id 查找，兩個分支都不掃描整張表
+		for _, want := range []string{
+			"SEARCH b USING INDEX idx_bets_user_created",
+			"SEARCH b USING INDEX idx_bets_archive_user_created",
+		} {
+			if !strings.Contains(joined, want) {
+				t.Errorf("%s plan missing %q:\n%s", name, want, joined)
+			}
+		}
+		for _, step := range plan {
+			// JOIN 視圖時的計劃為 MATERIALIZE game_records_all、SCAN game_records、SCAN b
+			if strings.HasPrefix(step, "SCAN b") || strings.HasPrefix(step, "SCAN gr") || strings.HasPrefix(step, "SCAN game_records") {
+				t.Errorf("%s plan scans a table: %q\n%s", name, step, joined)
+			}
+		}
+	}
+}
+
+// playRound 保存一局閒家勝出的牌局和用戶押閒 amount 的下注，並入帳派彩
+func playRound(t *testing.T, userID int, gameID string, amount float64) {
+	t.Helper()
+	record := &db.GameRecord{
+		GameID:             gameID,
+		PlayerInitialCards: "S7,H2",
+		BankerInitialCards: "D5,CK",
+		PlayerInitialScore: 9,
+		BankerInitialScore: 5,
+		PlayerFinalScore:   9,
+		BankerFinalScore:   5,
+		Winner:             "Player",
+		Payouts:            map[string]float64{"player": amount * 2},
+	}
+	err := db.Transaction(func(tx *sql.Tx) error {
+		if err := db.SaveGameRecord(tx, record); err != nil {
+			return err
+		}
+		if err := db.SaveBet(tx, userID, gameID, amount, "player"); err != nil {
+			return err
+		}
+		return db.UpdateUserBalance(tx, userID, amount)
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestQueriesIncludeArchivedRounds(t *testing.T) {
+	if err := db.CreateUser("archived-player", []byte("hash")); err != nil {
+		t.Fatal(err)
+	}
+	userID, _, err := db.GetUserByUsername("archived-player")
+	if err != nil {
+		t.Fatal(err)
+	}
+	playRound(t, userID, "archived-1", 10)
+	playRound(t, userID, "archived-2", 20)
+	if moved, err := db.ArchiveRounds(time.Now().Add(time.Hour), 1); err != nil || moved != 1 {
+		t.Fatalf("ArchiveRounds = %d, %v", moved, err)
+	}
+
+	filter := db.BetHistoryFilter{UserID: userID}
+	items, err := db.GetBetHistory(filter, nil, 10)
+	if err != nil || len(items) != 2 {
+		t.Fatalf("GetBetHistory = %+v, %v", items, err)
+	}
+	summary, err := db.GetBetHistorySummary(filter)
+	if err != nil || summary.Count != 2 || summary.Wagered != 30 || summary.Won != 60 {
+		t.Errorf("GetBetHistorySummary = %+v, %v", summary, err)
+	}
+	if bets, err := db.ListUserBets(userID, 1, 1); err != nil || len(bets) != 1 {
+		t.Errorf("ListUserBets second page = %+v, %v", bets, err)
+	}
+	for _, gameID := range []string{"archived-1", "archived-2"} {
+		if result, err := db.GetGameDetails(gameID); err != nil || len(result.Bets) != 1 {
+			t.Errorf("GetGameDetails(%s) = %+v, %v", gameID, result, err)
+		}
+	}
+
+	if earliest, ok, err := db.GetEarliestBetTime(); err != nil || !ok || earliest.IsZero() {
+		t.Errorf("GetEarliestBetTime = %v, %v, %v", earliest, ok, err)
+	}
+	to := time.Now().Truncate(time.Hour).Add(time.Hour)
+	if err := db.RebuildReportRollups(to.Add(-24*time.Hour), to, to); err != nil {
+		t.Errorf("RebuildReportRollups: %v", err)
+	}
+
+	// 流水：兩筆下注扣款和兩筆派彩，最新一筆之後的餘額為當前餘額
+	ledger, err := db.GetLedger(userID, 10, 0)
+	if err != nil || len(ledger) != 4 {
+		t.Fatalf("GetLedger = %+v, %v", ledger, err)
+	}
+	if ledger[0].BalanceAfter != 30 || ledger[3].BalanceAfter != -10 {
+		t.Errorf("ledger balances = %s", fmt.Sprint(ledger))
+	}
+}
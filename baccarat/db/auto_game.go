@@ -0,0 +1,140 @@
+package db
+
+import (
+	"database/sql"
+	"errors"
+	"time"
+)
+
+// ErrAutoGameChanged 賭局狀態在恢復前已被改變
+var ErrAutoGameChanged = errors.New("auto game status changed")
+
+// AutoGameRefundType 取消賭局時退回下注使用的交易類型
+const AutoGameRefundType = "auto_game_refund"
+
+// UnfinishedAutoGame 停在下注、封盤或開牌階段的自動賭局
+type UnfinishedAutoGame struct {
+	GameID       string
+	Status       string
+	BettingStart time.Time
+}
+
+// AutoGameRefund 取消賭局時退回給單個用戶的金額
+type AutoGameRefund struct {
+	UserID int
+	Bets   int
+	Amount float64
+}
+
+// ListUnfinishedAutoGames 查詢 before 之前開始且仍未完成的自動賭局，按開始時間排序
+func ListUnfinishedAutoGames(before time.Time) ([]UnfinishedAutoGame, error) {
+	rows, err := DB.Query(`
+		SELECT game_id, game_status, created_at
+		FROM auto_game_records
+		WHERE game_status IN ('betting', 'closed', 'drawing') AND created_at < ?
+		ORDER BY created_at, id`,
+		before,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var games []UnfinishedAutoGame
+	for rows.Next() {
+		var g UnfinishedAutoGame
+		if err := rows.Scan(&g.GameID, &g.Status, &g.BettingStart); err != nil {
+			return nil, err
+		}
+		games = append(games, g)
+	}
+	return games, rows.Err()
+}
+
+// CountActiveAutoGames 統計下注、封盤和開牌階段的自動賭局數，沒有賭局的階段為 0
+func CountActiveAutoGames() (map[string]int, error) {
+	counts := map[string]int{"betting": 0, "closed": 0, "drawing": 0}
+	rows, err := DB.Query(`
+		SELECT game_status, COUNT(*)
+		FROM auto_game_records
+		WHERE game_status IN ('betting', 'closed', 'drawing')
+		GROUP BY game_status`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var status string
+		var n int
+		if err := rows.Scan(&status, &n); err != nil {
+			return nil, err
+		}
+		counts[status] = n
+	}
+	return counts, rows.Err()
+}
+
+// CancelAutoGame 取消賭局並退回所有 pending 下注，按用戶記入退款交易
+// 賭局狀態已不是 previousStatus 時返回 ErrAutoGameChanged
+func CancelAutoGame(tx *sql.Tx, gameID, previousStatus string) ([]AutoGameRefund, error) {
+	var status string
+	err := tx.QueryRow("SELECT game_status FROM auto_game_records WHERE game_id = ?"+dialect.forUpdate(), gameID).Scan(&status)
+	if err != nil {
+		return nil, err
+	}
+	if status != previousStatus {
+		return nil, ErrAutoGameChanged
+	}
+
+	rows, err := tx.Query(`
+		SELECT user_id, COUNT(*), SUM(amount)
+		FROM auto_game_bets
+		WHERE game_id = ? AND status = 'pending'
+		GROUP BY user_id
+		ORDER BY user_id`+dialect.forUpdate(),
+		gameID,
+	)
+	if err != nil {
+		return nil, err
+	}
+	var refunds []AutoGameRefund
+	for rows.Next() {
+		var r AutoGameRefund
+		if err := rows.Scan(&r.UserID, &r.Bets, &r.Amount); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		refunds = append(refunds, r)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	for _, r := range refunds {
+		if err := UpdateUserBalance(tx, r.UserID, r.Amount); err != nil {
+			return nil, err
+		}
+		if err := SaveReferencedTransaction(tx, r.UserID, r.Amount, AutoGameRefundType, gameID); err != nil {
+			return nil, err
+		}
+	}
+	if _, err := tx.Exec("UPDATE auto_game_bets SET status = 'cancelled' WHERE game_id = ? AND status = 'pending'", gameID); err != nil {
+		return nil, err
+	}
+	if _, err := tx.Exec("UPDATE auto_game_records SET game_status = 'cancelled' WHERE game_id = ?", gameID); err != nil {
+		return nil, err
+	}
+	return refunds, nil
+}
+
+// SaveAutoGameRecovery 記錄對未完成賭局的恢復操作
+func SaveAutoGameRecovery(exec Execer, gameID, previousStatus, action string, refundedBets int, refundedAmount float64) error {
+	_, err := exec.Exec(`
+		INSERT INTO auto_game_recoveries (game_id, previous_status, action, refunded_bets, refunded_amount)
+		VALUES (?, ?, ?, ?, ?)`,
+		gameID, previousStatus, action, refundedBets, refundedAmount,
+	)
+	return err
+}
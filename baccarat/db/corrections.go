@@ -0,0 +1,191 @@
+package db
+
+import (
+	"baccarat/config"
+	"baccarat/internal/correction"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"time"
+)
+
+// ErrGameNotFound 牌局不存在
+var ErrGameNotFound = errors.New("game not found")
+
+// GameCorrection 一次作廢或重新結算的記錄
+type GameCorrection struct {
+	ID        int64               `json:"id"`
+	GameID    string              `json:"gameId"`
+	Action    string              `json:"action"`
+	Reason    string              `json:"reason"`
+	AdminID   int                 `json:"adminId"`
+	Original  correction.Snapshot `json:"original"`
+	Corrected correction.Snapshot `json:"corrected"`
+	CreatedAt time.Time           `json:"createdAt"`
+}
+
+// LockGameForCorrection 鎖定牌局和其下注，返回當前生效的結果
+// 重新結算過的牌局以最近一次修正後的牌和勝方為準
+func LockGameForCorrection(tx *sql.Tx, gameID string) (*correction.Snapshot, error) {
+	var snapshot correction.Snapshot
+	var playerCards, bankerCards string
+	var playerThird, bankerThird sql.NullString
+	err := tx.QueryRow(`
+		SELECT status, winner, player_initial_cards, player_third_card, banker_initial_cards, banker_third_card
+		FROM game_records
+		WHERE game_id = ?`+dialect.forUpdate(),
+		gameID,
+	).Scan(&snapshot.Status, &snapshot.Winner, &playerCards, &playerThird, &bankerCards, &bankerThird)
+	if err == sql.ErrNoRows {
+		if archived, err := isGameArchived(tx, gameID); err != nil {
+			return nil, err
+		} else if archived {
+			return nil, ErrGameArchived
+		}
+		return nil, ErrGameNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	snapshot.PlayerCards = joinCards(playerCards, playerThird)
+	snapshot.BankerCards = joinCards(bankerCards, bankerThird)
+
+	if snapshot.Status == correction.StatusResettled {
+		var corrected string
+		err := tx.QueryRow(
+			"SELECT corrected_snapshot FROM game_corrections WHERE game_id = ? ORDER BY id DESC LIMIT 1",
+			gameID,
+		).Scan(&corrected)
+		if err != nil {
+			return nil, err
+		}
+		var last correction.Snapshot
+		if err := json.Unmarshal([]byte(corrected), &last); err != nil {
+			return nil, err
+		}
+		snapshot.Winner, snapshot.PlayerCards, snapshot.BankerCards = last.Winner, last.PlayerCards, last.BankerCards
+	}
+
+	rows, err := tx.Query(`
+		SELECT b.id, b.user_id, b.bet_type, b.bet_amount, `+settledReturnExpr+`
+		FROM bets b
+		JOIN game_records gr ON b.game_id = gr.game_id
+		WHERE b.game_id = ?
+		ORDER BY b.id`+dialect.forUpdate(),
+		gameID,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	snapshot.Bets = []correction.Bet{}
+	for rows.Next() {
+		var b correction.Bet
+		if err := rows.Scan(&b.ID, &b.UserID, &b.BetType, &b.Amount, &b.Return); err != nil {
+			return nil, err
+		}
+		snapshot.Bets = append(snapshot.Bets, b)
+	}
+	return &snapshot, rows.Err()
+}
+
+// SaveGameCorrection 寫入修正後的返還和牌局狀態，按用戶記入差額交易並更新餘額，
+// 保存修正前後的快照，並重建受影響小時的報表匯總；返回各用戶的差額
+// 與 ApplyAdjustment 相同，扣回差額不能使餘額變為負數，否則返回 ErrInsufficientBalance 且不做任何修改
+func SaveGameCorrection(tx *sql.Tx, gameID string, action correction.Action, reason string, adminID int,
+	original, corrected correction.Snapshot) ([]correction.Delta, error) {
+
+	// 差額按用戶ID排序，依次鎖定，避免與其他修正或調整互相等待
+	deltas := correction.Deltas(original, corrected)
+	for _, d := range deltas {
+		var balance float64
+		if err := tx.QueryRow("SELECT balance FROM users WHERE id = ?"+dialect.forUpdate(), d.UserID).Scan(&balance); err != nil {
+			return nil, err
+		}
+		if balance+d.Amount < 0 {
+			return nil, ErrInsufficientBalance
+		}
+	}
+
+	for _, b := range corrected.Bets {
+		if _, err := tx.Exec("UPDATE bets SET settled_return = ? WHERE id = ?", b.Return, b.ID); err != nil {
+			return nil, err
+		}
+	}
+	if _, err := tx.Exec("UPDATE game_records SET status = ? WHERE game_id = ?", corrected.Status, gameID); err != nil {
+		return nil, err
+	}
+
+	for _, d := range deltas {
+		if err := UpdateUserBalance(tx, d.UserID, d.Amount); err != nil {
+			return nil, err
+		}
+		if err := SaveReferencedTransaction(tx, d.UserID, d.Amount, action.TransactionType(), gameID); err != nil {
+			return nil, err
+		}
+	}
+
+	originalJSON, err := json.Marshal(original)
+	if err != nil {
+		return nil, err
+	}
+	correctedJSON, err := json.Marshal(corrected)
+	if err != nil {
+		return nil, err
+	}
+	_, err = tx.Exec(`
+		INSERT INTO game_corrections (game_id, action, reason, admin_id, original_snapshot, corrected_snapshot)
+		VALUES (?, ?, ?, ?, ?, ?)`,
+		gameID, action, reason, adminID, originalJSON, correctedJSON,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	// 已匯總的小時按修正後的返還重建，尚未匯總的由匯總任務處理
+	var first, last nullTime
+	if err := tx.QueryRow("SELECT MIN(created_at), MAX(created_at) FROM bets WHERE game_id = ?", gameID).Scan(&first, &last); err != nil {
+		return nil, err
+	}
+	if first.Valid {
+		loc := config.Location()
+		if err := rebuildReportHours(tx, reportHour(first.Time, loc), reportHour(last.Time, loc).Add(time.Hour)); err != nil {
+			return nil, err
+		}
+	}
+
+	return deltas, nil
+}
+
+// ListGameCorrections 按時間順序查詢牌局的修正記錄
+func ListGameCorrections(gameID string) ([]GameCorrection, error) {
+	rows, err := DB.Query(`
+		SELECT id, game_id, action, reason, admin_id, original_snapshot, corrected_snapshot, created_at
+		FROM game_corrections
+		WHERE game_id = ?
+		ORDER BY id`,
+		gameID,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	corrections := []GameCorrection{}
+	for rows.Next() {
+		var c GameCorrection
+		var original, corrected string
+		if err := rows.Scan(&c.ID, &c.GameID, &c.Action, &c.Reason, &c.AdminID, &original, &corrected, &c.CreatedAt); err != nil {
+			return nil, err
+		}
+		if err := json.Unmarshal([]byte(original), &c.Original); err != nil {
+			return nil, err
+		}
+		if err := json.Unmarshal([]byte(corrected), &c.Corrected); err != nil {
+			return nil, err
+		}
+		corrections = append(corrections, c)
+	}
+	return corrections, rows.Err()
+}
@@ -3,9 +3,12 @@ package db
 import (
 	"baccarat/config"
 	"baccarat/pkg/logger"
+	"baccarat/pkg/metrics"
+	"context"
 	"database/sql"
 	"fmt"
 	"net/url"
+	"time"
 
 	_ "github.com/go-sql-driver/mysql"
 )
@@ -13,7 +16,23 @@ import (
 var DB *sql.DB
 
 // InitDB initializes the database connection
+// DB_DRIVER 為 sqlite 時使用 DB_PATH 的本地文件，無需外部數據庫
 func InitDB() error {
+	var err error
+	if config.AppConfig.DBDriver == DriverSQLite {
+		DB, err = OpenSQLite(config.AppConfig.DBPath)
+		if err != nil {
+			logger.Error("Error opening database:", err)
+			return fmt.Errorf("error opening database: %v", err)
+		}
+		logger.Info("Successfully opened SQLite database", config.AppConfig.DBPath)
+		metrics.RegisterDB("primary", DB)
+		if config.AppConfig.DBReplicaDSNs != "" {
+			logger.Warn("DB_REPLICA_DSNS is ignored with SQLite")
+		}
+		return nil
+	}
+
 	dsn := fmt.Sprintf("%s:%s@tcp(%s:%s)/%s?charset=utf8mb4&parseTime=True&loc=%s",
 		config.AppConfig.DBUser,
 		config.AppConfig.DBPassword,
@@ -25,12 +44,12 @@ func InitDB() error {
 
 	logger.Debug("Attempting to connect to database with DSN:", dsn)
 
-	var err error
-	DB, err = sql.Open("mysql", dsn)
+	DB, err = openTraced("mysql", dsn)
 	if err != nil {
 		logger.Error("Error opening database:", err)
 		return fmt.Errorf("error opening database: %v", err)
 	}
+	dialect = mysqlDialect{}
 
 	logger.Debug("Database connection opened successfully")
 
@@ -44,104 +63,111 @@ func InitDB() error {
 	// 設置連接池參數
 	DB.SetMaxOpenConns(25)
 	DB.SetMaxIdleConns(5)
+	metrics.RegisterDB("primary", DB)
+
+	// 只讀副本
+	if err := openReplicas(config.AppConfig.DBReplicaDSNs); err != nil {
+		logger.Error("Error opening read replicas:", err)
+		return err
+	}
 
 	return nil
 }
 
 // Transaction 執行事務
 func Transaction(fn func(*sql.Tx) error) error {
-	tx, err := DB.Begin()
-	if err != nil {
-		return err
-	}
-
-	defer func() {
-		if p := recover(); p != nil {
-			tx.Rollback()
-			panic(p)
-		}
-	}()
-
-	if err := fn(tx); err != nil {
-		tx.Rollback()
-		return err
-	}
+	return TransactionContext(context.Background(), fn)
+}
 
-	return tx.Commit()
+// GameRecord 一局遊戲的牌、結果和派彩
+// Payouts 的鍵與 game.GetPayouts 一致：player、banker、tie、luckySix 為派彩，*_bet 為投注額
+type GameRecord struct {
+	GameID             string
+	PlayerInitialCards string
+	BankerInitialCards string
+	PlayerInitialScore int
+	BankerInitialScore int
+	PlayerThirdCard    sql.NullString
+	BankerThirdCard    sql.NullString
+	PlayerThirdValue   sql.NullInt64
+	BankerThirdValue   sql.NullInt64
+	PlayerFinalScore   int
+	BankerFinalScore   int
+	Winner             string
+	IsLuckySix         bool
+	LuckySixType       sql.NullString
+	Payouts            map[string]float64
+	// 牌靴、第一張牌在牌序中的位置和按發牌順序的全部牌
+	ShoeID       sql.NullString
+	ShoePosition sql.NullInt64
+	DrawnCards   sql.NullString
 }
 
 // SaveGameRecord saves the game record to database
-func SaveGameRecord(gameID string, playerInitialCards, bankerInitialCards string,
-	playerInitialScore, bankerInitialScore int,
-	playerThirdCard, bankerThirdCard sql.NullString,
-	playerThirdValue, bankerThirdValue sql.NullInt64,
-	playerFinalScore, bankerFinalScore int,
-	winner string, isLuckySix bool,
-	luckySixType sql.NullString,
-	payouts map[string]float64) error {
-
-	return Transaction(func(tx *sql.Tx) error {
-		query := `
-			INSERT INTO game_records (
-				game_id, player_initial_cards, banker_initial_cards,
-				player_initial_score, banker_initial_score,
-				player_third_card, banker_third_card,
-				player_third_value, banker_third_value,
-				player_final_score, banker_final_score,
-				winner, is_lucky_six, lucky_six_type,
-				player_payout, banker_payout, tie_payout, lucky_six_payout,
-				total_bets, total_payouts
-			) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
-		`
-
-		var playerPayout, bankerPayout, tiePayout, luckySixPayout sql.NullFloat64
-		totalBets := 0.0
-		totalPayouts := 0.0
-
-		if p, ok := payouts["player"]; ok {
-			playerPayout = sql.NullFloat64{Float64: p, Valid: true}
-			totalPayouts += p
-		}
-		if p, ok := payouts["banker"]; ok {
-			bankerPayout = sql.NullFloat64{Float64: p, Valid: true}
-			totalPayouts += p
-		}
-		if p, ok := payouts["tie"]; ok {
-			tiePayout = sql.NullFloat64{Float64: p, Valid: true}
-			totalPayouts += p
-		}
-		if p, ok := payouts["luckySix"]; ok {
-			luckySixPayout = sql.NullFloat64{Float64: p, Valid: true}
-			totalPayouts += p
-		}
-
-		// 計算總投注額
-		if p, ok := payouts["player_bet"]; ok {
-			totalBets += p
-		}
-		if p, ok := payouts["banker_bet"]; ok {
-			totalBets += p
-		}
-		if p, ok := payouts["tie_bet"]; ok {
-			totalBets += p
-		}
-		if p, ok := payouts["luckySix_bet"]; ok {
-			totalBets += p
-		}
+// 在下注和餘額變動的同一事務內寫入
+func SaveGameRecord(tx *sql.Tx, r *GameRecord) error {
+	query := `
+		INSERT INTO game_records (
+			game_id, player_initial_cards, banker_initial_cards,
+			player_initial_score, banker_initial_score,
+			player_third_card, banker_third_card,
+			player_third_value, banker_third_value,
+			player_final_score, banker_final_score,
+			winner, is_lucky_six, lucky_six_type,
+			player_payout, banker_payout, tie_payout, lucky_six_payout,
+			total_bets, total_payouts,
+			shoe_id, shoe_position, drawn_cards
+		) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+	`
+
+	payouts := r.Payouts
+	var playerPayout, bankerPayout, tiePayout, luckySixPayout sql.NullFloat64
+	totalBets := 0.0
+	totalPayouts := 0.0
+
+	if p, ok := payouts["player"]; ok {
+		playerPayout = sql.NullFloat64{Float64: p, Valid: true}
+		totalPayouts += p
+	}
+	if p, ok := payouts["banker"]; ok {
+		bankerPayout = sql.NullFloat64{Float64: p, Valid: true}
+		totalPayouts += p
+	}
+	if p, ok := payouts["tie"]; ok {
+		tiePayout = sql.NullFloat64{Float64: p, Valid: true}
+		totalPayouts += p
+	}
+	if p, ok := payouts["luckySix"]; ok {
+		luckySixPayout = sql.NullFloat64{Float64: p, Valid: true}
+		totalPayouts += p
+	}
 
-		_, err := tx.Exec(query,
-			gameID, playerInitialCards, bankerInitialCards,
-			playerInitialScore, bankerInitialScore,
-			playerThirdCard, bankerThirdCard,
-			playerThirdValue, bankerThirdValue,
-			playerFinalScore, bankerFinalScore,
-			winner, isLuckySix, luckySixType,
-			playerPayout, bankerPayout, tiePayout, luckySixPayout,
-			totalBets, totalPayouts,
-		)
+	// 計算總投注額
+	if p, ok := payouts["player_bet"]; ok {
+		totalBets += p
+	}
+	if p, ok := payouts["banker_bet"]; ok {
+		totalBets += p
+	}
+	if p, ok := payouts["tie_bet"]; ok {
+		totalBets += p
+	}
+	if p, ok := payouts["luckySix_bet"]; ok {
+		totalBets += p
+	}
 
-		return err
-	})
+	_, err := tx.Exec(query,
+		r.GameID, r.PlayerInitialCards, r.BankerInitialCards,
+		r.PlayerInitialScore, r.BankerInitialScore,
+		r.PlayerThirdCard, r.BankerThirdCard,
+		r.PlayerThirdValue, r.BankerThirdValue,
+		r.PlayerFinalScore, r.BankerFinalScore,
+		r.Winner, r.IsLuckySix, r.LuckySixType,
+		playerPayout, bankerPayout, tiePayout, luckySixPayout,
+		totalBets, totalPayouts,
+		r.ShoeID, r.ShoePosition, r.DrawnCards,
+	)
+	return err
 }
 
 // GetUserBalance 獲取用戶餘額
@@ -153,6 +179,7 @@ func GetUserBalance(userID int) (float64, error) {
 
 // UpdateUserBalance 更新用戶餘額
 func UpdateUserBalance(tx *sql.Tx, userID int, amount float64) error {
+	markWrite(tx, userID)
 	_, err := tx.Exec("UPDATE users SET balance = balance + ? WHERE id = ?", amount, userID)
 	return err
 }
@@ -168,6 +195,13 @@ func CreateUser(username string, passwordHash []byte) error {
 	})
 }
 
+// UsernameExists 用戶名是否已被使用
+func UsernameExists(username string) (bool, error) {
+	var exists bool
+	err := DB.QueryRow("SELECT EXISTS(SELECT 1 FROM users WHERE username = ?)", username).Scan(&exists)
+	return exists, err
+}
+
 // GetUserByUsername 通過用戶名獲取用戶信息
 func GetUserByUsername(username string) (int, string, error) {
 	var id int
@@ -179,17 +213,79 @@ func GetUserByUsername(username string) (int, string, error) {
 	return id, passwordHash, err
 }
 
+// GetUsernameByID 通過用戶ID獲取用戶名
+func GetUsernameByID(userID int) (string, error) {
+	var username string
+	err := DB.QueryRow("SELECT username FROM users WHERE id = ?", userID).Scan(&username)
+	return username, err
+}
+
+// GetUserRole 獲取用戶角色
+func GetUserRole(userID int) (string, error) {
+	var role string
+	err := DB.QueryRow("SELECT role FROM users WHERE id = ?", userID).Scan(&role)
+	return role, err
+}
+
 // SaveTransaction 保存交易記錄
 func SaveTransaction(tx *sql.Tx, userID int, amount float64, transactionType string) error {
 	_, err := tx.Exec(
 		"INSERT INTO transactions (user_id, amount, transaction_type) VALUES (?, ?, ?)",
 		userID, amount, transactionType,
 	)
+	if err == nil {
+		afterCommit(tx, func() { metrics.ObserveWalletTransaction(transactionType) })
+	}
+	return err
+}
+
+// SaveReferencedTransaction 保存關聯遊戲等記錄的交易
+func SaveReferencedTransaction(tx *sql.Tx, userID int, amount float64, transactionType, reference string) error {
+	_, err := tx.Exec(
+		"INSERT INTO transactions (user_id, amount, transaction_type, reference) VALUES (?, ?, ?, ?)",
+		userID, amount, transactionType, reference,
+	)
+	if err == nil {
+		afterCommit(tx, func() { metrics.ObserveWalletTransaction(transactionType) })
+	}
 	return err
 }
 
+// WalletTransaction 錢包交易記錄
+type WalletTransaction struct {
+	Amount          float64
+	TransactionType string
+	CreatedAt       time.Time
+}
+
+// ListTransactions 按時間倒序分頁查詢用戶的交易記錄
+func ListTransactions(userID, limit, offset int) ([]WalletTransaction, error) {
+	rows, err := ReadDB(userID).Query(`
+		SELECT amount, transaction_type, created_at
+		FROM transactions
+		WHERE user_id = ?
+		ORDER BY created_at DESC
+		LIMIT ? OFFSET ?`,
+		userID, limit, offset)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var transactions []WalletTransaction
+	for rows.Next() {
+		var t WalletTransaction
+		if err := rows.Scan(&t.Amount, &t.TransactionType, &t.CreatedAt); err != nil {
+			return nil, err
+		}
+		transactions = append(transactions, t)
+	}
+	return transactions, rows.Err()
+}
+
 // SaveBet 保存投注記錄
 func SaveBet(tx *sql.Tx, userID int, gameID string, amount float64, betType string) error {
+	markWrite(tx, userID)
 	_, err := tx.Exec(
 		"INSERT INTO bets (user_id, game_id, bet_amount, bet_type) VALUES (?, ?, ?, ?)",
 		userID, gameID, amount, betType,
@@ -197,6 +293,54 @@ func SaveBet(tx *sql.Tx, userID int, gameID string, amount float64, betType stri
 	return err
 }
 
+// UserBet 用戶的一筆投注及所屬牌局的結果
+type UserBet struct {
+	GameID       string
+	Amount       float64
+	BetType      string
+	CreatedAt    time.Time
+	Winner       string
+	IsLuckySix   bool
+	LuckySixType sql.NullString
+}
+
+// ListUserBets 按時間倒序分頁查詢用戶的投注
+func ListUserBets(userID, limit, offset int) ([]UserBet, error) {
+	// 每個分支最多需要前 limit+offset 條
+	page, args := unionArchived(`
+		SELECT * FROM (
+			SELECT b.game_id, b.bet_amount, b.bet_type, b.created_at,
+				   g.winner, g.is_lucky_six, g.lucky_six_type
+			FROM {bets} b
+			LEFT JOIN {game_records} g ON b.game_id = g.game_id
+			WHERE b.user_id = ?
+			ORDER BY b.created_at DESC
+			LIMIT ?
+		) page`,
+		userID, limit+offset)
+	rows, err := ReadDB(userID).Query(`
+		SELECT * FROM (`+page+`
+		) bets
+		ORDER BY created_at DESC
+		LIMIT ? OFFSET ?`,
+		append(args, limit, offset)...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var bets []UserBet
+	for rows.Next() {
+		var b UserBet
+		if err := rows.Scan(&b.GameID, &b.Amount, &b.BetType, &b.CreatedAt,
+			&b.Winner, &b.IsLuckySix, &b.LuckySixType); err != nil {
+			return nil, err
+		}
+		bets = append(bets, b)
+	}
+	return bets, rows.Err()
+}
+
 // GameResult 遊戲結果完整信息
 type GameResult struct {
 	GameID             string         `json:"game_id"`
@@ -217,6 +361,7 @@ type GameResult struct {
 	BankerPayout       sql.NullFloat64 `json:"banker_payout"`
 	TiePayout          sql.NullFloat64 `json:"tie_payout"`
 	LuckySixPayout     sql.NullFloat64 `json:"lucky_six_payout"`
+	Status             string         `json:"status"` // settled, voided, resettled
 	Bets               []BetDetail    `json:"bets"`
 	TotalBets          float64        `json:"total_bets"`
 	TotalPayouts       float64        `json:"total_payouts"`
@@ -224,6 +369,7 @@ type GameResult struct {
 
 // BetDetail 下注詳情
 type BetDetail struct {
+	UserID    int            `json:"-"`
 	Username  string         `json:"username"`
 	BetType   string         `json:"bet_type"`
 	BetAmount float64        `json:"bet_amount"`
@@ -252,12 +398,14 @@ func GetGameDetails(gameID string) (*GameResult, error) {
 			player_payout,
 			banker_payout,
 			tie_payout,
-			lucky_six_payout
-		FROM game_records
+			lucky_six_payout,
+			status
+		FROM {game_records}
 		WHERE game_id = ?`
+	baseQuery, baseArgs := unionArchived(baseQuery, gameID)
 
 	var result GameResult
-	err := DB.QueryRow(baseQuery, gameID).Scan(
+	conn, err := queryRowOrPrimary(baseQuery, baseArgs,
 		&result.GameID,
 		&result.Winner,
 		&result.PlayerInitialScore,
@@ -276,6 +424,7 @@ func GetGameDetails(gameID string) (*GameResult, error) {
 		&result.BankerPayout,
 		&result.TiePayout,
 		&result.LuckySixPayout,
+		&result.Status,
 	)
 	if err != nil {
 		if err == sql.ErrNoRows {
@@ -287,6 +436,7 @@ func GetGameDetails(gameID string) (*GameResult, error) {
 	// 查詢所有下注記錄
 	betsQuery := `
 		SELECT 
+			b.user_id,
 			u.username,
 			b.bet_type,
 			b.bet_amount,
@@ -296,13 +446,13 @@ func GetGameDetails(gameID string) (*GameResult, error) {
 				WHEN 'tie' THEN gr.tie_payout
 				WHEN 'luckySix' THEN gr.lucky_six_payout
 			END as payout
-		FROM bets b
+		FROM {bets} b
 		JOIN users u ON b.user_id = u.id
-		JOIN game_records gr ON b.game_id = gr.game_id
-		WHERE b.game_id = ?
-		ORDER BY u.username, b.bet_type`
+		JOIN {game_records} gr ON b.game_id = gr.game_id
+		WHERE b.game_id = ?`
+	betsQuery, betsArgs := unionArchived(betsQuery, gameID)
 
-	rows, err := DB.Query(betsQuery, gameID)
+	rows, err := conn.Query("SELECT * FROM ("+betsQuery+") bets ORDER BY username, bet_type", betsArgs...)
 	if err != nil {
 		return nil, fmt.Errorf("查詢下注記錄失敗: %v", err)
 	}
@@ -313,6 +463,7 @@ func GetGameDetails(gameID string) (*GameResult, error) {
 	for rows.Next() {
 		var bet BetDetail
 		err := rows.Scan(
+			&bet.UserID,
 			&bet.Username,
 			&bet.BetType,
 			&bet.BetAmount,
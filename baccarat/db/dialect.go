@@ -0,0 +1,97 @@
+package db
+
+import (
+	"baccarat/config"
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// 支持的數據庫
+const (
+	DriverMySQL  = "mysql"
+	DriverSQLite = "sqlite"
+)
+
+// sqlDialect MySQL 和 SQLite 語法不同的部分，其餘查詢兩者共用
+type sqlDialect interface {
+	name() string
+	// forUpdate 鎖定讀取的行直到事務結束
+	forUpdate() string
+	// upsert 唯一鍵 keys 衝突時改為更新：columns 取插入的值，set 為其他賦值
+	upsert(keys, columns []string, set ...string) string
+	// tableOptions 建表語句的表選項
+	tableOptions() string
+}
+
+// dialect 當前連接使用的方言，由 InitDB 或 OpenSQLite 設置
+var dialect sqlDialect = mysqlDialect{}
+
+// Driver 當前連接的數據庫類型，DriverMySQL 或 DriverSQLite
+func Driver() string {
+	return dialect.name()
+}
+
+type mysqlDialect struct{}
+
+func (mysqlDialect) name() string { return DriverMySQL }
+
+func (mysqlDialect) forUpdate() string { return " FOR UPDATE" }
+
+func (mysqlDialect) upsert(keys, columns []string, set ...string) string {
+	assignments := make([]string, 0, len(columns)+len(set))
+	for _, c := range columns {
+		assignments = append(assignments, c+" = VALUES("+c+")")
+	}
+	return " ON DUPLICATE KEY UPDATE " + strings.Join(append(assignments, set...), ", ")
+}
+
+func (mysqlDialect) tableOptions() string {
+	return " ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci"
+}
+
+// sqliteDialect SQLite 的寫事務以 BEGIN IMMEDIATE 開始，整個數據庫只有一個寫入者，不需要行鎖
+type sqliteDialect struct{}
+
+func (sqliteDialect) name() string { return DriverSQLite }
+
+func (sqliteDialect) forUpdate() string { return "" }
+
+func (sqliteDialect) upsert(keys, columns []string, set ...string) string {
+	assignments := make([]string, 0, len(columns)+len(set))
+	for _, c := range columns {
+		assignments = append(assignments, c+" = excluded."+c)
+	}
+	return " ON CONFLICT (" + strings.Join(keys, ", ") + ") DO UPDATE SET " + strings.Join(append(assignments, set...), ", ")
+}
+
+func (sqliteDialect) tableOptions() string { return "" }
+
+// sqliteTimeLayouts SQLite 以文本保存的時間格式（TIME_ZONE 的本地時間）
+var sqliteTimeLayouts = []string{"2006-01-02 15:04:05", "2006-01-02"}
+
+// nullTime 掃描聚合或函數返回的時間：SQLite 不為 MIN、DATE 等表達式標注類型，結果是文本
+type nullTime struct {
+	sql.NullTime
+}
+
+// Scan 實現 sql.Scanner
+func (t *nullTime) Scan(value interface{}) error {
+	var text string
+	switch v := value.(type) {
+	case string:
+		text = v
+	case []byte:
+		text = string(v)
+	default:
+		return t.NullTime.Scan(value)
+	}
+	for _, layout := range sqliteTimeLayouts {
+		if parsed, err := time.ParseInLocation(layout, text, config.Location()); err == nil {
+			t.Time, t.Valid = parsed, true
+			return nil
+		}
+	}
+	return fmt.Errorf("cannot parse %q as time", text)
+}
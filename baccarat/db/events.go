@@ -0,0 +1,68 @@
+package db
+
+import (
+	"time"
+)
+
+// OutboxEvent 發件箱中待投遞的事件
+type OutboxEvent struct {
+	ID       int64
+	EventID  string
+	Type     string
+	Payload  []byte
+	Attempts int
+}
+
+// maxEventErrorLength last_error 欄位長度
+const maxEventErrorLength = 255
+
+// SaveEvent 寫入發件箱，應與產生事件的業務數據在同一事務內
+func SaveEvent(exec Execer, eventID, eventType string, payload []byte) error {
+	_, err := exec.Exec(
+		"INSERT INTO event_outbox (event_id, event_type, payload, next_attempt_at) VALUES (?, ?, ?, ?)",
+		eventID, eventType, payload, time.Now(),
+	)
+	return err
+}
+
+// ListPendingEvents 按寫入順序查詢到期未投遞的事件
+func ListPendingEvents(now time.Time, limit int) ([]OutboxEvent, error) {
+	rows, err := DB.Query(`
+		SELECT id, event_id, event_type, payload, attempts
+		FROM event_outbox
+		WHERE sent_at IS NULL AND next_attempt_at <= ?
+		ORDER BY id
+		LIMIT ?`,
+		now, limit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var events []OutboxEvent
+	for rows.Next() {
+		var e OutboxEvent
+		if err := rows.Scan(&e.ID, &e.EventID, &e.Type, &e.Payload, &e.Attempts); err != nil {
+			return nil, err
+		}
+		events = append(events, e)
+	}
+	return events, rows.Err()
+}
+
+// MarkEventSent 標記事件已投遞
+func MarkEventSent(id int64) error {
+	_, err := DB.Exec("UPDATE event_outbox SET sent_at = NOW() WHERE id = ?", id)
+	return err
+}
+
+// MarkEventFailed 記錄投遞失敗並安排下次重試時間
+func MarkEventFailed(id int64, attempts int, nextAttempt time.Time, reason string) error {
+	reason = truncateRunes(reason, maxEventErrorLength)
+	_, err := DB.Exec(
+		"UPDATE event_outbox SET attempts = ?, next_attempt_at = ?, last_error = ? WHERE id = ?",
+		attempts, nextAttempt, reason, id,
+	)
+	return err
+}
@@ -0,0 +1,107 @@
+package db
+
+import (
+	"baccarat/internal/limits"
+	"database/sql"
+	"time"
+)
+
+// 冷靜期和自我排除審計事件類型
+const (
+	AuthEventExclusionStarted  = "exclusion_started"
+	AuthEventExclusionLifted   = "exclusion_lifted"
+	AuthEventExcludedLoginDeny = "excluded_login_denied"
+)
+
+const exclusionColumns = "id, kind, starts_at, ends_at, lifted_at, lifted_by, lift_reason"
+
+func scanExclusion(scan func(dest ...interface{}) error) (*limits.Exclusion, error) {
+	var e limits.Exclusion
+	var endsAt, liftedAt sql.NullTime
+	var liftedBy sql.NullInt64
+	var reason sql.NullString
+	if err := scan(&e.ID, &e.Kind, &e.StartsAt, &endsAt, &liftedAt, &liftedBy, &reason); err != nil {
+		return nil, err
+	}
+	if endsAt.Valid {
+		e.EndsAt = &endsAt.Time
+	}
+	if liftedAt.Valid {
+		e.LiftedAt = &liftedAt.Time
+	}
+	if liftedBy.Valid {
+		by := int(liftedBy.Int64)
+		e.LiftedBy = &by
+	}
+	e.Reason = reason.String
+	return &e, nil
+}
+
+// CreateExclusion 保存新的冷靜期或自我排除
+func CreateExclusion(tx *sql.Tx, userID int, e *limits.Exclusion) error {
+	result, err := tx.Exec(
+		"INSERT INTO user_exclusions (user_id, kind, starts_at, ends_at) VALUES (?, ?, ?, ?)",
+		userID, e.Kind, e.StartsAt, e.EndsAt,
+	)
+	if err != nil {
+		return err
+	}
+	e.ID, err = result.LastInsertId()
+	return err
+}
+
+// GetActiveExclusion 獲取用戶當前生效且結束最晚的排除，沒有時返回 nil
+func GetActiveExclusion(q Querier, userID int) (*limits.Exclusion, error) {
+	e, err := scanExclusion(q.QueryRow(`
+		SELECT `+exclusionColumns+`
+		FROM user_exclusions
+		WHERE user_id = ? AND lifted_at IS NULL AND (ends_at IS NULL OR ends_at > ?)
+		ORDER BY ends_at IS NULL DESC, ends_at DESC
+		LIMIT 1`,
+		userID, time.Now(),
+	).Scan)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	return e, err
+}
+
+// ListExclusions 列出用戶的所有排除記錄（最新在前）
+func ListExclusions(userID int) ([]limits.Exclusion, error) {
+	rows, err := DB.Query(`
+		SELECT `+exclusionColumns+`
+		FROM user_exclusions
+		WHERE user_id = ?
+		ORDER BY starts_at DESC, id DESC`,
+		userID,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	exclusions := []limits.Exclusion{}
+	for rows.Next() {
+		e, err := scanExclusion(rows.Scan)
+		if err != nil {
+			return nil, err
+		}
+		exclusions = append(exclusions, *e)
+	}
+	return exclusions, rows.Err()
+}
+
+// LiftExclusions 提前解除用戶所有生效中的定期排除，永久排除不受影響
+func LiftExclusions(tx *sql.Tx, userID, adminID int, reason string) (int64, error) {
+	now := time.Now()
+	result, err := tx.Exec(`
+		UPDATE user_exclusions
+		SET lifted_at = ?, lifted_by = ?, lift_reason = ?
+		WHERE user_id = ? AND lifted_at IS NULL AND ends_at IS NOT NULL AND ends_at > ?`,
+		now, adminID, reason, userID, now,
+	)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
@@ -0,0 +1,7 @@
+package db
+
+// 導出給 db_test 的內部查詢
+var (
+	BetHistoryQuery        = betHistoryQuery
+	BetHistorySummaryQuery = betHistorySummaryQuery
+)
@@ -0,0 +1,196 @@
+package db
+
+import (
+	"database/sql"
+	"strings"
+	"time"
+)
+
+// 單筆下注結果
+const (
+	OutcomeWin  = "win"
+	OutcomeLose = "lose"
+	OutcomePush = "push"
+)
+
+// BetHistoryFilter 下注歷史查詢條件，零值字段不過濾
+type BetHistoryFilter struct {
+	UserID  int
+	From    time.Time // 包含
+	To      time.Time // 不包含
+	BetType string
+	Outcome string
+	TableID string
+}
+
+// BetHistoryCursor 遊標分頁位置，按 (created_at, id) 倒序
+type BetHistoryCursor struct {
+	CreatedAt time.Time
+	ID        int64
+}
+
+// BetHistoryItem 單筆下注及其所在遊戲的結果
+type BetHistoryItem struct {
+	ID           int64
+	GameID       string
+	TableID      string
+	BetType      string
+	Stake        float64
+	Payout       float64
+	Net          float64
+	Outcome      string
+	Winner       string
+	PlayerCards  []string
+	BankerCards  []string
+	PlayerScore  int
+	BankerScore  int
+	IsLuckySix   bool
+	LuckySixType sql.NullString
+	Status       string // settled, voided, resettled
+	CreatedAt    time.Time
+}
+
+// BetHistorySummary 篩選範圍內的匯總
+type BetHistorySummary struct {
+	Count   int     `json:"count"`
+	Wagered float64 `json:"wagered"`
+	Won     float64 `json:"won"`
+	Net     float64 `json:"net"`
+}
+
+// where 組裝篩選條件
+func (f BetHistoryFilter) where() (string, []interface{}) {
+	conditions := []string{"b.user_id = ?"}
+	args := []interface{}{f.UserID}
+	if !f.From.IsZero() {
+		conditions = append(conditions, "b.created_at >= ?")
+		args = append(args, f.From)
+	}
+	if !f.To.IsZero() {
+		conditions = append(conditions, "b.created_at < ?")
+		args = append(args, f.To)
+	}
+	if f.BetType != "" {
+		conditions = append(conditions, "b.bet_type = ?")
+		args = append(args, f.BetType)
+	}
+	if f.TableID != "" {
+		conditions = append(conditions, "gr.table_id = ?")
+		args = append(args, f.TableID)
+	}
+	switch f.Outcome {
+	case OutcomeWin:
+		conditions = append(conditions, "("+settledReturnExpr+") > b.bet_amount")
+	case OutcomeLose:
+		conditions = append(conditions, "("+settledReturnExpr+") < b.bet_amount")
+	case OutcomePush:
+		conditions = append(conditions, "("+settledReturnExpr+") = b.bet_amount")
+	}
+	return strings.Join(conditions, " AND "), args
+}
+
+// betHistoryQuery 下注歷史的分頁查詢
+func betHistoryQuery(filter BetHistoryFilter, cursor *BetHistoryCursor, limit int) (string, []interface{}) {
+	where, args := filter.where()
+	if cursor != nil {
+		where += " AND (b.created_at < ? OR (b.created_at = ? AND b.id < ?))"
+		args = append(args, cursor.CreatedAt, cursor.CreatedAt, cursor.ID)
+	}
+
+	// 每個分支先按遊標排序取 limit 條，合併後再取一次
+	page, args := unionArchived(`
+		SELECT * FROM (
+			SELECT b.id, b.game_id, gr.table_id, b.bet_type, b.bet_amount, `+settledReturnExpr+` AS payout,
+				gr.winner, gr.player_initial_cards, gr.player_third_card, gr.banker_initial_cards, gr.banker_third_card,
+				gr.player_final_score, gr.banker_final_score, gr.is_lucky_six, gr.lucky_six_type, gr.status, b.created_at
+			FROM {bets} b
+			JOIN {game_records} gr ON b.game_id = gr.game_id
+			WHERE `+where+`
+			ORDER BY b.created_at DESC, b.id DESC
+			LIMIT ?
+		) page`,
+		append(args, limit)...,
+	)
+	return `
+		SELECT * FROM (` + page + `
+		) history
+		ORDER BY created_at DESC, id DESC
+		LIMIT ?`, append(args, limit)
+}
+
+// GetBetHistory 查詢下注歷史，cursor 為 nil 時從最新記錄開始
+func GetBetHistory(filter BetHistoryFilter, cursor *BetHistoryCursor, limit int) ([]BetHistoryItem, error) {
+	query, args := betHistoryQuery(filter, cursor, limit)
+	rows, err := ReadDB(filter.UserID).Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	items := []BetHistoryItem{}
+	for rows.Next() {
+		var item BetHistoryItem
+		var playerCards, bankerCards string
+		var playerThird, bankerThird sql.NullString
+		if err := rows.Scan(&item.ID, &item.GameID, &item.TableID, &item.BetType, &item.Stake, &item.Payout,
+			&item.Winner, &playerCards, &playerThird, &bankerCards, &bankerThird,
+			&item.PlayerScore, &item.BankerScore, &item.IsLuckySix, &item.LuckySixType, &item.Status, &item.CreatedAt); err != nil {
+			return nil, err
+		}
+		item.PlayerCards = joinCards(playerCards, playerThird)
+		item.BankerCards = joinCards(bankerCards, bankerThird)
+		item.Net = item.Payout - item.Stake
+		switch {
+		case item.Net > 0:
+			item.Outcome = OutcomeWin
+		case item.Net < 0:
+			item.Outcome = OutcomeLose
+		default:
+			item.Outcome = OutcomePush
+		}
+		items = append(items, item)
+	}
+	return items, rows.Err()
+}
+
+// betHistorySummaryQuery 篩選範圍內的下注數、投注和返還合計
+func betHistorySummaryQuery(filter BetHistoryFilter) (string, []interface{}) {
+	where, args := filter.where()
+	totals, args := unionArchived(`
+		SELECT COUNT(*) AS bets, COALESCE(SUM(b.bet_amount), 0) AS wagered, COALESCE(SUM(`+settledReturnExpr+`), 0) AS won
+		FROM {bets} b
+		JOIN {game_records} gr ON b.game_id = gr.game_id
+		WHERE `+where,
+		args...,
+	)
+	return `
+		SELECT SUM(bets), SUM(wagered), SUM(won)
+		FROM (` + totals + `
+		) totals`, args
+}
+
+// GetBetHistorySummary 統計篩選範圍內的投注、返還和淨輸贏（不受分頁影響）
+func GetBetHistorySummary(filter BetHistoryFilter) (*BetHistorySummary, error) {
+	query, args := betHistorySummaryQuery(filter)
+	var summary BetHistorySummary
+	err := ReadDB(filter.UserID).QueryRow(query, args...).Scan(&summary.Count, &summary.Wagered, &summary.Won)
+	if err != nil {
+		return nil, err
+	}
+	summary.Net = summary.Won - summary.Wagered
+	return &summary, nil
+}
+
+// joinCards 合併初始牌（逗號分隔）和補牌
+func joinCards(initial string, third sql.NullString) []string {
+	cards := []string{}
+	for _, card := range strings.Split(initial, ",") {
+		if card = strings.TrimSpace(card); card != "" {
+			cards = append(cards, card)
+		}
+	}
+	if third.Valid && third.String != "" {
+		cards = append(cards, third.String)
+	}
+	return cards
+}
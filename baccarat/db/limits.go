@@ -0,0 +1,133 @@
+package db
+
+import (
+	"baccarat/internal/limits"
+	"database/sql"
+	"time"
+)
+
+// Querier *sql.DB 和 *sql.Tx 共有的查詢方法，讓同一查詢可在事務內外使用
+type Querier interface {
+	Query(query string, args ...interface{}) (*sql.Rows, error)
+	QueryRow(query string, args ...interface{}) *sql.Row
+}
+
+// betReturnExpr 單筆下注返還給玩家的金額（含本金），需 JOIN game_records gr
+// 和局時閒、莊、幸運6的本金退回，其餘情況 game_records 中的賠付已包含本金
+const betReturnExpr = `
+	CASE b.bet_type
+		WHEN 'player' THEN COALESCE(gr.player_payout, 0) + IF(gr.winner = 'Tie', b.bet_amount, 0)
+		WHEN 'banker' THEN COALESCE(gr.banker_payout, 0) + IF(gr.winner = 'Tie', b.bet_amount, 0)
+		WHEN 'tie' THEN COALESCE(gr.tie_payout, 0)
+		WHEN 'luckySix' THEN COALESCE(gr.lucky_six_payout, 0) + IF(gr.winner = 'Tie', b.bet_amount, 0)
+		ELSE 0
+	END`
+
+// settledReturnExpr 作廢或重新結算後的實際返還，未修正時同 betReturnExpr
+// 修正產生的差額另記為交易，因此對帳單和流水仍按原返還加交易計算
+const settledReturnExpr = `COALESCE(b.settled_return, ` + betReturnExpr + `)`
+
+// GetUserLimits 獲取用戶設置的所有限額
+func GetUserLimits(q Querier, userID int) ([]limits.Limit, error) {
+	rows, err := q.Query(`
+		SELECT limit_type, period, amount, pending_amount, pending_effective_at
+		FROM user_limits
+		WHERE user_id = ?`,
+		userID,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var result []limits.Limit
+	for rows.Next() {
+		var l limits.Limit
+		var pendingAmount sql.NullFloat64
+		var pendingAt sql.NullTime
+		if err := rows.Scan(&l.Type, &l.Period, &l.Amount, &pendingAmount, &pendingAt); err != nil {
+			return nil, err
+		}
+		if pendingAmount.Valid && pendingAt.Valid {
+			l.PendingAmount = &pendingAmount.Float64
+			l.PendingEffectiveAt = &pendingAt.Time
+		}
+		result = append(result, l)
+	}
+	return result, rows.Err()
+}
+
+// SaveUserLimit 保存用戶限額
+func SaveUserLimit(tx *sql.Tx, userID int, l limits.Limit) error {
+	var pendingAmount sql.NullFloat64
+	var pendingAt sql.NullTime
+	if l.PendingAmount != nil && l.PendingEffectiveAt != nil {
+		pendingAmount = sql.NullFloat64{Float64: *l.PendingAmount, Valid: true}
+		pendingAt = sql.NullTime{Time: *l.PendingEffectiveAt, Valid: true}
+	}
+	_, err := tx.Exec(`
+		INSERT INTO user_limits (user_id, limit_type, period, amount, pending_amount, pending_effective_at)
+		VALUES (?, ?, ?, ?, ?, ?)`+
+		dialect.upsert([]string{"user_id", "limit_type", "period"}, []string{"amount", "pending_amount", "pending_effective_at"}),
+		userID, l.Type, l.Period, l.Amount, pendingAmount, pendingAt,
+	)
+	return err
+}
+
+// LockUser 鎖定用戶行，使同一用戶的限額檢查和扣款串行執行
+func LockUser(tx *sql.Tx, userID int) error {
+	var id int
+	return tx.QueryRow("SELECT id FROM users WHERE id = ?"+dialect.forUpdate(), userID).Scan(&id)
+}
+
+// LockUserBalance 鎖定用戶行並返回鎖定後讀到的餘額，直到事務結束其他事務不能變動
+func LockUserBalance(tx *sql.Tx, userID int) (float64, error) {
+	var balance float64
+	err := tx.QueryRow("SELECT balance FROM users WHERE id = ?"+dialect.forUpdate(), userID).Scan(&balance)
+	return balance, err
+}
+
+// GetDepositTotal 統計用戶自 since 起的存款總額
+func GetDepositTotal(q Querier, userID int, since time.Time) (float64, error) {
+	var total float64
+	err := q.QueryRow(`
+		SELECT COALESCE(SUM(amount), 0)
+		FROM transactions
+		WHERE user_id = ? AND transaction_type = 'deposit' AND created_at >= ?`,
+		userID, since,
+	).Scan(&total)
+	return total, err
+}
+
+// GetWagerTotals 統計用戶自 since 起的投注總額和返還總額（虧損 = 投注 - 返還）
+func GetWagerTotals(q Querier, userID int, since time.Time) (float64, float64, error) {
+	var wagered, returned float64
+	err := q.QueryRow(`
+		SELECT COALESCE(SUM(b.bet_amount), 0), COALESCE(SUM(`+settledReturnExpr+`), 0)
+		FROM bets b
+		JOIN game_records gr ON b.game_id = gr.game_id
+		WHERE b.user_id = ? AND b.created_at >= ?`,
+		userID, since,
+	).Scan(&wagered, &returned)
+	return wagered, returned, err
+}
+
+// GetSessionReminder 獲取會話開始時間和最後一次確認時長提醒的時間
+func GetSessionReminder(sessionID string, userID int) (time.Time, sql.NullTime, error) {
+	var createdAt time.Time
+	var acknowledgedAt sql.NullTime
+	err := DB.QueryRow(
+		"SELECT created_at, reminder_acknowledged_at FROM user_sessions WHERE id = ? AND user_id = ?",
+		sessionID, userID,
+	).Scan(&createdAt, &acknowledgedAt)
+	return createdAt, acknowledgedAt, err
+}
+
+// AcknowledgeSessionReminder 記錄玩家已確認遊戲時長提醒
+func AcknowledgeSessionReminder(sessionID string, userID int) error {
+	_, err := DB.Exec(
+		"UPDATE user_sessions SET reminder_acknowledged_at = ? WHERE id = ? AND user_id = ?",
+		time.Now(), sessionID, userID,
+	)
+	return err
+}
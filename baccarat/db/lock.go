@@ -0,0 +1,34 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+)
+
+// TryLock 不等待地取得名為 name 的資料庫級鎖，用於保證多個實例中同一時間只有一個執行某項任務；
+// 已被其他連接持有時 ok 為 false。取得後必須調用 unlock 釋放。
+// MySQL 使用 GET_LOCK，鎖綁定在取得它的連接上；SQLite 數據庫文件只由一個進程使用，總是成功
+func TryLock(name string) (unlock func(), ok bool, err error) {
+	if Driver() == DriverSQLite {
+		return func() {}, true, nil
+	}
+
+	ctx := context.Background()
+	conn, err := DB.Conn(ctx)
+	if err != nil {
+		return nil, false, err
+	}
+	var got sql.NullInt64
+	if err := conn.QueryRowContext(ctx, "SELECT GET_LOCK(?, 0)", name).Scan(&got); err != nil {
+		conn.Close()
+		return nil, false, err
+	}
+	if got.Int64 != 1 {
+		conn.Close()
+		return nil, false, nil
+	}
+	return func() {
+		conn.ExecContext(ctx, "DO RELEASE_LOCK(?)", name)
+		conn.Close()
+	}, true, nil
+}
@@ -0,0 +1,94 @@
+package db
+
+import (
+	"database/sql"
+	"time"
+)
+
+// 認證審計事件類型
+const (
+	AuthEventLoginSuccess   = "login_success"
+	AuthEventLoginFailed    = "login_failed"
+	AuthEventLoginThrottled = "login_throttled"
+	AuthEventLoginLocked    = "login_locked"
+	AuthEventAccountUnlock  = "account_unlocked"
+)
+
+// GetLoginFailure 查詢某個維度的登入失敗記錄
+func GetLoginFailure(scope, key string) (int, time.Time, sql.NullTime, error) {
+	var failures int
+	var lastFailure time.Time
+	var lockedUntil sql.NullTime
+	err := DB.QueryRow(
+		"SELECT failures, last_failure_at, locked_until FROM login_failures WHERE scope = ? AND scope_key = ?",
+		scope, key,
+	).Scan(&failures, &lastFailure, &lockedUntil)
+	return failures, lastFailure, lockedUntil, err
+}
+
+// LoginFailure 某個維度的登入失敗記錄
+type LoginFailure struct {
+	Failures    int
+	LastFailure time.Time
+	LockedUntil sql.NullTime
+}
+
+// UpdateLoginFailure 在事務內鎖定記錄並以 update 修改，不存在時先插入空記錄；
+// 並發的失敗依次讀取、修改，計數不會互相覆蓋
+func UpdateLoginFailure(scope, key string, update func(*LoginFailure)) error {
+	return Transaction(func(tx *sql.Tx) error {
+		// 插入或鎖定已有的行，兩個並發的首次失敗不會各自插入
+		if _, err := tx.Exec(`
+			INSERT INTO login_failures (scope, scope_key, failures, last_failure_at)
+			VALUES (?, ?, 0, NOW())`+
+			dialect.upsert([]string{"scope", "scope_key"}, nil, "failures = failures"),
+			scope, key,
+		); err != nil {
+			return err
+		}
+
+		var record LoginFailure
+		if err := tx.QueryRow(
+			"SELECT failures, last_failure_at, locked_until FROM login_failures WHERE scope = ? AND scope_key = ?"+dialect.forUpdate(),
+			scope, key,
+		).Scan(&record.Failures, &record.LastFailure, &record.LockedUntil); err != nil {
+			return err
+		}
+		update(&record)
+		_, err := tx.Exec(
+			"UPDATE login_failures SET failures = ?, last_failure_at = ?, locked_until = ? WHERE scope = ? AND scope_key = ?",
+			record.Failures, record.LastFailure, record.LockedUntil, scope, key,
+		)
+		return err
+	})
+}
+
+// DeleteLoginFailure 清除登入失敗記錄
+func DeleteLoginFailure(scope, key string) error {
+	_, err := DB.Exec("DELETE FROM login_failures WHERE scope = ? AND scope_key = ?", scope, key)
+	return err
+}
+
+// SaveAuthAuditEvent 寫入認證審計日誌，userID 為 0 表示未知用戶
+func SaveAuthAuditEvent(userID int, username, ipAddress, eventType, detail string) error {
+	return saveAuthAuditEvent(DB, userID, username, ipAddress, eventType, detail)
+}
+
+// SaveAuthAuditEventTx 在事務內寫入審計日誌，確保狀態變更和審計記錄同時成功或失敗
+func SaveAuthAuditEventTx(tx *sql.Tx, userID int, username, ipAddress, eventType, detail string) error {
+	return saveAuthAuditEvent(tx, userID, username, ipAddress, eventType, detail)
+}
+
+func saveAuthAuditEvent(exec interface {
+	Exec(query string, args ...interface{}) (sql.Result, error)
+}, userID int, username, ipAddress, eventType, detail string) error {
+	var uid sql.NullInt64
+	if userID > 0 {
+		uid = sql.NullInt64{Int64: int64(userID), Valid: true}
+	}
+	_, err := exec.Exec(
+		"INSERT INTO auth_audit_log (user_id, username, ip_address, event_type, detail) VALUES (?, ?, ?, ?, ?)",
+		uid, username, ipAddress, eventType, detail,
+	)
+	return err
+}
@@ -0,0 +1,46 @@
+package db
+
+import (
+	"database/sql"
+)
+
+// schemaMigrationsTable 記錄已執行的遷移版本，最大的版本即當前結構版本
+const schemaMigrationsTable = `
+	CREATE TABLE IF NOT EXISTS schema_migrations (
+		version INT PRIMARY KEY,
+		name VARCHAR(100) NOT NULL,
+		dirty BOOLEAN NOT NULL DEFAULT FALSE,
+		applied_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+	)`
+
+// GetSchemaVersion 返回當前結構版本及是否 dirty，沒有執行過遷移時版本為 0
+func GetSchemaVersion() (int, bool, error) {
+	if _, err := DB.Exec(schemaMigrationsTable + dialect.tableOptions()); err != nil {
+		return 0, false, err
+	}
+	var version int
+	var dirty bool
+	err := DB.QueryRow("SELECT version, dirty FROM schema_migrations ORDER BY version DESC LIMIT 1").Scan(&version, &dirty)
+	if err == sql.ErrNoRows {
+		return 0, false, nil
+	}
+	return version, dirty, err
+}
+
+// SetSchemaVersion 記錄當前結構版本，並刪除更高版本的記錄；version 為 0 時清空
+func SetSchemaVersion(version int, name string, dirty bool) error {
+	return Transaction(func(tx *sql.Tx) error {
+		if _, err := tx.Exec("DELETE FROM schema_migrations WHERE version > ?", version); err != nil {
+			return err
+		}
+		if version == 0 {
+			return nil
+		}
+		_, err := tx.Exec(`
+			INSERT INTO schema_migrations (version, name, dirty) VALUES (?, ?, ?)`+
+			dialect.upsert([]string{"version"}, []string{"name", "dirty"}),
+			version, name, dirty,
+		)
+		return err
+	})
+}
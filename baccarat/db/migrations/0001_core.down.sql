@@ -0,0 +1,6 @@
+DROP TABLE IF EXISTS auto_game_bets;
+DROP TABLE IF EXISTS auto_game_records;
+DROP TABLE IF EXISTS bets;
+DROP TABLE IF EXISTS game_records;
+DROP TABLE IF EXISTS transactions;
+DROP TABLE IF EXISTS users;
@@ -0,0 +1,107 @@
+-- 基準結構：sql/init.sql、sql/user_tables.sql 和 sql/auto_game_tables.sql 建立的表，
+-- 以及當時代碼已經寫入的 game_records 初始點數和補牌點數列；之後新增的列和索引見後續遷移
+
+-- 用戶表
+CREATE TABLE IF NOT EXISTS users (
+    id INT AUTO_INCREMENT PRIMARY KEY,
+    username VARCHAR(50) UNIQUE NOT NULL,
+    password_hash VARCHAR(255) NOT NULL,
+    balance DECIMAL(10, 2) DEFAULT 0.00,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
+
+-- 儲值紀錄表
+CREATE TABLE IF NOT EXISTS transactions (
+    id INT AUTO_INCREMENT PRIMARY KEY,
+    user_id INT NOT NULL,
+    amount DECIMAL(10, 2) NOT NULL,
+    transaction_type VARCHAR(20) NOT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (user_id) REFERENCES users(id)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
+
+-- 遊戲記錄表
+CREATE TABLE IF NOT EXISTS game_records (
+    id BIGINT AUTO_INCREMENT PRIMARY KEY,
+    game_id VARCHAR(36) NOT NULL,                -- UUID
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    player_initial_cards VARCHAR(100) NOT NULL,  -- 閒家初始牌
+    banker_initial_cards VARCHAR(100) NOT NULL,  -- 莊家初始牌
+    player_initial_score INT NOT NULL,           -- 閒家初始牌點數
+    banker_initial_score INT NOT NULL,           -- 莊家初始牌點數
+    player_third_card VARCHAR(50),               -- 閒家補牌
+    banker_third_card VARCHAR(50),               -- 莊家補牌
+    player_third_value INT,                      -- 閒家補牌點數
+    banker_third_value INT,                      -- 莊家補牌點數
+    player_final_score INT NOT NULL,             -- 閒家最終點數
+    banker_final_score INT NOT NULL,             -- 莊家最終點數
+    winner ENUM('Player', 'Banker', 'Tie') NOT NULL,
+    is_lucky_six BOOLEAN DEFAULT FALSE,
+    lucky_six_type VARCHAR(10),                  -- '2cards' 或 '3cards'
+    player_payout DECIMAL(10, 2),                -- 閒家派彩
+    banker_payout DECIMAL(10, 2),                -- 莊家派彩
+    tie_payout DECIMAL(10, 2),                   -- 和局派彩
+    lucky_six_payout DECIMAL(10, 2),             -- 幸運6派彩
+    total_bets DECIMAL(10, 2) DEFAULT 0.00,      -- 總投注額
+    total_payouts DECIMAL(10, 2) DEFAULT 0.00,   -- 總派彩額
+    INDEX idx_game_id (game_id),
+    INDEX idx_created_at (created_at)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
+
+-- 投注紀錄表
+CREATE TABLE IF NOT EXISTS bets (
+    id INT AUTO_INCREMENT PRIMARY KEY,
+    user_id INT NOT NULL,
+    game_id VARCHAR(36) NOT NULL,
+    bet_amount DECIMAL(10, 2) NOT NULL,
+    bet_type VARCHAR(20) NOT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (user_id) REFERENCES users(id),
+    FOREIGN KEY (game_id) REFERENCES game_records(game_id),
+    INDEX idx_user_game (user_id, game_id)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
+
+-- 自動賭局記錄表
+CREATE TABLE IF NOT EXISTS auto_game_records (
+    id BIGINT AUTO_INCREMENT PRIMARY KEY,
+    game_id VARCHAR(36) NOT NULL,  -- UUID
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    player_initial_cards VARCHAR(100),  -- 閒家初始牌
+    banker_initial_cards VARCHAR(100),  -- 莊家初始牌
+    player_third_card VARCHAR(50),      -- 閒家補牌
+    banker_third_card VARCHAR(50),      -- 莊家補牌
+    player_final_score INT,             -- 閒家最終點數
+    banker_final_score INT,             -- 莊家最終點數
+    winner ENUM('Player', 'Banker', 'Tie'),
+    is_lucky_six BOOLEAN DEFAULT FALSE,
+    lucky_six_type VARCHAR(10),         -- '2cards' 或 '3cards'
+    player_payout DECIMAL(10,2),        -- 閒家賠率
+    banker_payout DECIMAL(10,2),        -- 莊家賠率
+    tie_payout DECIMAL(10,2),           -- 和局賠率
+    lucky_six_payout DECIMAL(10,2),     -- 幸運6賠率
+    game_status ENUM('pending', 'betting', 'closed', 'drawing', 'completed', 'cancelled') NOT NULL DEFAULT 'pending',
+    betting_start_time TIMESTAMP NULL DEFAULT CURRENT_TIMESTAMP,  -- 開始下注時間
+    betting_end_time TIMESTAMP NULL DEFAULT CURRENT_TIMESTAMP,    -- 結束下注時間
+    INDEX idx_game_id (game_id),
+    INDEX idx_created_at (created_at),
+    INDEX idx_game_status (game_status),
+    UNIQUE KEY uk_game_id (game_id)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
+
+-- 自動賭局下注記錄表
+CREATE TABLE IF NOT EXISTS auto_game_bets (
+    id BIGINT AUTO_INCREMENT PRIMARY KEY,
+    game_id VARCHAR(36) NOT NULL,
+    user_id INT NOT NULL,
+    bet_type ENUM('Player', 'Banker', 'Tie', 'Lucky6') NOT NULL,
+    amount DECIMAL(10,2) NOT NULL,
+    status ENUM('pending', 'completed', 'cancelled') NOT NULL DEFAULT 'pending',
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+    FOREIGN KEY (game_id) REFERENCES auto_game_records(game_id),
+    FOREIGN KEY (user_id) REFERENCES users(id),
+    INDEX idx_game_id (game_id),
+    INDEX idx_user_id (user_id),
+    INDEX idx_status (status)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
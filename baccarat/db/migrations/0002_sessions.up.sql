@@ -0,0 +1,28 @@
+-- 登入會話表（撤銷會話後，其訪問令牌與刷新令牌立即失效）
+CREATE TABLE IF NOT EXISTS user_sessions (
+    id VARCHAR(36) PRIMARY KEY,                  -- UUID，寫入訪問令牌的 sid 聲明
+    user_id INT NOT NULL,
+    user_agent VARCHAR(255),
+    ip_address VARCHAR(45),
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    last_refreshed_at DATETIME NULL,
+    revoked_at DATETIME NULL,
+    revoke_reason VARCHAR(50),                   -- logout, logout_all, token_reuse
+    FOREIGN KEY (user_id) REFERENCES users(id),
+    INDEX idx_user_id (user_id)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
+
+-- 刷新令牌表（只保存雜湊，每次刷新輪換，舊令牌被重用時撤銷整個會話）
+CREATE TABLE IF NOT EXISTS refresh_tokens (
+    id BIGINT AUTO_INCREMENT PRIMARY KEY,
+    token_hash CHAR(64) NOT NULL,                -- SHA-256 hex
+    session_id VARCHAR(36) NOT NULL,
+    user_id INT NOT NULL,
+    expires_at DATETIME NOT NULL,
+    used_at DATETIME NULL,                       -- 已輪換的時間，非空代表已失效
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE KEY uk_token_hash (token_hash),
+    FOREIGN KEY (session_id) REFERENCES user_sessions(id),
+    FOREIGN KEY (user_id) REFERENCES users(id),
+    INDEX idx_session_id (session_id)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
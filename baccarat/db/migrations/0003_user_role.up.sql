@@ -0,0 +1,3 @@
+-- 用戶角色：player, support, finance, admin
+ALTER TABLE users
+    ADD COLUMN role VARCHAR(20) NOT NULL DEFAULT 'player' AFTER balance;
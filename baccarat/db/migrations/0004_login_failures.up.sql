@@ -0,0 +1,22 @@
+-- 登入失敗計數表（依用戶名和IP分別計數，用於遞增延遲和臨時鎖定）
+CREATE TABLE IF NOT EXISTS login_failures (
+    scope VARCHAR(10) NOT NULL,                  -- username 或 ip
+    scope_key VARCHAR(64) NOT NULL,
+    failures INT NOT NULL DEFAULT 0,
+    last_failure_at DATETIME NOT NULL,
+    locked_until DATETIME NULL,
+    PRIMARY KEY (scope, scope_key)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
+
+-- 認證審計日誌
+CREATE TABLE IF NOT EXISTS auth_audit_log (
+    id BIGINT AUTO_INCREMENT PRIMARY KEY,
+    user_id INT NULL,                            -- 用戶不存在時為空
+    username VARCHAR(50) NOT NULL,
+    ip_address VARCHAR(45),
+    event_type VARCHAR(30) NOT NULL,             -- login_*, account_unlocked, 2fa_*, password_*
+    detail VARCHAR(255),
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    INDEX idx_username (username),
+    INDEX idx_event_type_created (event_type, created_at)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
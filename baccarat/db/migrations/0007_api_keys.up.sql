@@ -0,0 +1,17 @@
+-- API 金鑰（只保存雜湊，scopes 為逗號分隔的範圍列表）
+CREATE TABLE IF NOT EXISTS api_keys (
+    id BIGINT AUTO_INCREMENT PRIMARY KEY,
+    user_id INT NOT NULL,
+    name VARCHAR(64) NOT NULL,
+    key_prefix VARCHAR(16) NOT NULL,             -- 明文前幾位，用於在列表中辨認金鑰
+    key_hash CHAR(64) NOT NULL,
+    scopes VARCHAR(255) NOT NULL,
+    rate_limit INT NOT NULL,                     -- 每分鐘請求上限
+    last_used_at DATETIME NULL,
+    last_used_ip VARCHAR(45) NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    revoked_at DATETIME NULL,
+    UNIQUE KEY uk_key_hash (key_hash),
+    FOREIGN KEY (user_id) REFERENCES users(id),
+    INDEX idx_user_id (user_id)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
@@ -0,0 +1,4 @@
+ALTER TABLE bets DROP INDEX idx_user_created;
+ALTER TABLE transactions DROP INDEX idx_user_type_created;
+ALTER TABLE user_sessions DROP COLUMN reminder_acknowledged_at;
+DROP TABLE IF EXISTS user_limits;
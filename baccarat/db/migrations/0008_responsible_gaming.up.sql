@@ -0,0 +1,21 @@
+-- 負責任博彩限額（玩家自設）
+-- amount 為 0 代表沒有限額；放寬或取消限額先寫入 pending_*，冷靜期結束後生效
+CREATE TABLE IF NOT EXISTS user_limits (
+    user_id INT NOT NULL,
+    limit_type VARCHAR(20) NOT NULL,             -- deposit, loss, wager
+    period VARCHAR(20) NOT NULL,                 -- daily, weekly, monthly
+    amount DECIMAL(10, 2) NOT NULL DEFAULT 0.00,
+    pending_amount DECIMAL(10, 2) NULL,
+    pending_effective_at DATETIME NULL,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+    PRIMARY KEY (user_id, limit_type, period),
+    FOREIGN KEY (user_id) REFERENCES users(id)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
+
+-- 玩家最後一次確認遊戲時長提醒的時間
+ALTER TABLE user_sessions
+    ADD COLUMN reminder_acknowledged_at DATETIME NULL AFTER revoke_reason;
+
+-- 限額按時段匯總存款、輸贏和投注額
+ALTER TABLE transactions ADD INDEX idx_user_type_created (user_id, transaction_type, created_at);
+ALTER TABLE bets ADD INDEX idx_user_created (user_id, created_at);
@@ -0,0 +1,14 @@
+-- 冷靜期和自我排除記錄（只追加，解除時寫入 lifted_*，不刪除）
+CREATE TABLE IF NOT EXISTS user_exclusions (
+    id BIGINT AUTO_INCREMENT PRIMARY KEY,
+    user_id INT NOT NULL,
+    kind VARCHAR(20) NOT NULL,                   -- cooling_off, self_exclusion
+    starts_at DATETIME NOT NULL,
+    ends_at DATETIME NULL,                       -- NULL 代表永久排除
+    lifted_at DATETIME NULL,
+    lifted_by INT NULL,                          -- 提前解除的管理員
+    lift_reason VARCHAR(255) NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (user_id) REFERENCES users(id),
+    INDEX idx_user_id (user_id)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
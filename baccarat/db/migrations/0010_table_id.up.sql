@@ -0,0 +1,3 @@
+-- 牌局所屬的遊戲桌，之前的牌局都屬於 main
+ALTER TABLE game_records
+    ADD COLUMN table_id VARCHAR(32) NOT NULL DEFAULT 'main' AFTER game_id;
@@ -0,0 +1,20 @@
+-- 營運報表小時匯總表，由後台任務從 bets 和 game_records 重建
+-- 按 玩家 × 遊戲桌 × 投注類型 匯總，任意分組下活躍玩家數都可用 COUNT(DISTINCT user_id) 得出
+CREATE TABLE IF NOT EXISTS report_hourly (
+    bucket DATETIME NOT NULL,  -- 小時起點（TIME_ZONE）
+    table_id VARCHAR(32) NOT NULL,
+    bet_type VARCHAR(10) NOT NULL,
+    user_id INT NOT NULL,
+    bet_count INT NOT NULL,
+    wagered DECIMAL(14, 2) NOT NULL,
+    returned DECIMAL(14, 2) NOT NULL,  -- 返還給玩家的金額（含本金）
+    PRIMARY KEY (bucket, table_id, bet_type, user_id),
+    INDEX idx_table_bucket (table_id, bucket)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
+
+-- 匯總進度，rolled_up_to 之前的完整小時已匯總
+CREATE TABLE IF NOT EXISTS report_rollup_state (
+    name VARCHAR(32) PRIMARY KEY,
+    rolled_up_to DATETIME NOT NULL,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
@@ -0,0 +1,5 @@
+ALTER TABLE users
+    DROP COLUMN frozen_reason,
+    DROP COLUMN frozen_at;
+DROP TABLE IF EXISTS admin_audit_log;
+DROP TABLE IF EXISTS balance_adjustments;
@@ -0,0 +1,46 @@
+-- 人工調整餘額，超過審批門檻的調整需另一名管理員批准後才入帳
+CREATE TABLE IF NOT EXISTS balance_adjustments (
+    id BIGINT AUTO_INCREMENT PRIMARY KEY,
+    user_id INT NOT NULL,
+    amount DECIMAL(10, 2) NOT NULL,              -- 正數為加款，負數為扣款
+    reason_code VARCHAR(20) NOT NULL,            -- goodwill, correction, chargeback, promotion, other
+    note VARCHAR(255) NOT NULL,
+    status VARCHAR(10) NOT NULL,                 -- pending, applied, rejected
+    requested_by INT NOT NULL,
+    decided_by INT NULL,                         -- 審批人，門檻以下自動入帳時為空
+    decision_note VARCHAR(255),
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    decided_at TIMESTAMP NULL,
+    FOREIGN KEY (user_id) REFERENCES users(id),
+    FOREIGN KEY (requested_by) REFERENCES users(id),
+    FOREIGN KEY (decided_by) REFERENCES users(id),
+    INDEX idx_status_created (status, created_at),
+    INDEX idx_user_created (user_id, created_at)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
+
+-- 管理操作審計日誌，只允許寫入
+CREATE TABLE IF NOT EXISTS admin_audit_log (
+    id BIGINT AUTO_INCREMENT PRIMARY KEY,
+    admin_id INT NOT NULL,
+    action VARCHAR(30) NOT NULL,                 -- user_search, ledger_viewed, account_frozen, adjustment_*, ...
+    target_user_id INT NULL,
+    detail VARCHAR(500),
+    ip_address VARCHAR(45),
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    INDEX idx_target_created (target_user_id, created_at),
+    INDEX idx_admin_created (admin_id, created_at)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
+
+-- 禁止修改或刪除審計記錄（應用帳號另應只授予 INSERT、SELECT 權限）
+DROP TRIGGER IF EXISTS admin_audit_log_no_update;
+CREATE TRIGGER admin_audit_log_no_update BEFORE UPDATE ON admin_audit_log
+FOR EACH ROW SIGNAL SQLSTATE '45000' SET MESSAGE_TEXT = 'admin_audit_log is append-only';
+
+DROP TRIGGER IF EXISTS admin_audit_log_no_delete;
+CREATE TRIGGER admin_audit_log_no_delete BEFORE DELETE ON admin_audit_log
+FOR EACH ROW SIGNAL SQLSTATE '45000' SET MESSAGE_TEXT = 'admin_audit_log is append-only';
+
+-- 凍結後不能遊戲、存款，直到管理員解凍
+ALTER TABLE users
+    ADD COLUMN frozen_at TIMESTAMP NULL AFTER role,
+    ADD COLUMN frozen_reason VARCHAR(255) AFTER frozen_at;
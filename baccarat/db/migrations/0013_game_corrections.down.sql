@@ -0,0 +1,5 @@
+DROP TABLE IF EXISTS event_outbox;
+ALTER TABLE transactions DROP COLUMN reference;
+ALTER TABLE bets DROP COLUMN settled_return;
+ALTER TABLE game_records DROP COLUMN status;
+DROP TABLE IF EXISTS game_corrections;
@@ -0,0 +1,47 @@
+-- 牌局作廢與重新結算記錄，保留修正前後的完整快照
+CREATE TABLE IF NOT EXISTS game_corrections (
+    id BIGINT AUTO_INCREMENT PRIMARY KEY,
+    game_id VARCHAR(36) NOT NULL,
+    action VARCHAR(10) NOT NULL,                 -- void, resettle
+    reason VARCHAR(255) NOT NULL,
+    admin_id INT NOT NULL,
+    original_snapshot TEXT NOT NULL,             -- JSON：修正前的結果和每筆下注的返還
+    corrected_snapshot TEXT NOT NULL,            -- JSON：修正後的結果和每筆下注的返還
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (admin_id) REFERENCES users(id),
+    INDEX idx_game_created (game_id, created_at)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
+
+DROP TRIGGER IF EXISTS game_corrections_no_update;
+CREATE TRIGGER game_corrections_no_update BEFORE UPDATE ON game_corrections
+FOR EACH ROW SIGNAL SQLSTATE '45000' SET MESSAGE_TEXT = 'game_corrections is append-only';
+
+DROP TRIGGER IF EXISTS game_corrections_no_delete;
+CREATE TRIGGER game_corrections_no_delete BEFORE DELETE ON game_corrections
+FOR EACH ROW SIGNAL SQLSTATE '45000' SET MESSAGE_TEXT = 'game_corrections is append-only';
+
+-- 牌局狀態：settled, voided, resettled
+ALTER TABLE game_records
+    ADD COLUMN status VARCHAR(10) NOT NULL DEFAULT 'settled' AFTER total_payouts;
+
+-- 作廢或重新結算後的返還金額（含本金），為空時按原結果計算
+ALTER TABLE bets
+    ADD COLUMN settled_return DECIMAL(10, 2) NULL AFTER bet_type;
+
+-- 關聯的遊戲ID等
+ALTER TABLE transactions
+    ADD COLUMN reference VARCHAR(36) NULL AFTER transaction_type;
+
+-- 事件發件箱：事件與業務數據在同一事務寫入，由後台任務投遞到 webhook 服務
+CREATE TABLE IF NOT EXISTS event_outbox (
+    id BIGINT AUTO_INCREMENT PRIMARY KEY,
+    event_id VARCHAR(64) NOT NULL UNIQUE,
+    event_type VARCHAR(50) NOT NULL,
+    payload TEXT NOT NULL,                       -- 完整的事件 JSON
+    attempts INT NOT NULL DEFAULT 0,
+    next_attempt_at DATETIME NOT NULL,
+    sent_at DATETIME NULL,
+    last_error VARCHAR(255),
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    INDEX idx_pending (sent_at, next_attempt_at)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
@@ -0,0 +1,12 @@
+-- 啟動時對未完成賭局的恢復記錄
+CREATE TABLE IF NOT EXISTS auto_game_recoveries (
+    id BIGINT AUTO_INCREMENT PRIMARY KEY,
+    game_id VARCHAR(36) NOT NULL,
+    previous_status VARCHAR(10) NOT NULL,        -- 恢復前的 game_status
+    action VARCHAR(10) NOT NULL,                 -- cancelled
+    refunded_bets INT NOT NULL DEFAULT 0,
+    refunded_amount DECIMAL(10,2) NOT NULL DEFAULT 0.00,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (game_id) REFERENCES auto_game_records(game_id),
+    INDEX idx_game_id (game_id)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
@@ -0,0 +1,7 @@
+ALTER TABLE transactions DROP INDEX idx_user_created;
+ALTER TABLE bets
+    DROP INDEX idx_created_at,
+    DROP INDEX idx_game_id;
+ALTER TABLE game_records
+    ADD INDEX idx_game_id (game_id),
+    DROP INDEX uk_game_id;
@@ -0,0 +1,10 @@
+-- game_id 改為唯一索引（bets 的外鍵引用它）
+ALTER TABLE game_records
+    ADD UNIQUE KEY uk_game_id (game_id),
+    DROP INDEX idx_game_id;
+
+-- 牌局明細、報表重建和歸檔按牌局或時間掃描下注，帳本按用戶和時間分頁
+ALTER TABLE bets
+    ADD INDEX idx_game_id (game_id),
+    ADD INDEX idx_created_at (created_at);
+ALTER TABLE transactions ADD INDEX idx_user_created (user_id, created_at);
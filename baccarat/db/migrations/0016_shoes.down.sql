@@ -0,0 +1,6 @@
+ALTER TABLE game_records
+    DROP FOREIGN KEY fk_game_records_shoe,
+    DROP COLUMN drawn_cards,
+    DROP COLUMN shoe_position,
+    DROP COLUMN shoe_id;
+DROP TABLE IF EXISTS shoes;
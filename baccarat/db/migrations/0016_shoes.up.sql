@@ -0,0 +1,22 @@
+-- 牌靴：洗牌種子、完整牌序及其雜湊，牌局按位置引用牌靴，可逐張重新發牌核對
+CREATE TABLE IF NOT EXISTS shoes (
+    id BIGINT AUTO_INCREMENT PRIMARY KEY,
+    shoe_id VARCHAR(36) NOT NULL UNIQUE,
+    table_id VARCHAR(32) NOT NULL,
+    shoe_number INT NOT NULL,                    -- 牌桌內的牌靴序號
+    decks INT NOT NULL,
+    seed CHAR(64) NOT NULL,                      -- 洗牌種子（十六進制）
+    card_order TEXT NOT NULL,                    -- 洗牌後的完整牌序，逗號分隔
+    card_order_hash CHAR(64) NOT NULL,           -- card_order 的 SHA-256
+    burn_cards INT NOT NULL,                     -- 開靴時燒掉的張數（含翻開的第一張）
+    next_position INT NOT NULL,                  -- 下一局第一張牌在牌序中的位置
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE KEY uk_table_shoe_number (table_id, shoe_number)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
+
+-- 牌局使用的牌靴、第一張牌的位置和按發牌順序的全部牌，之前的牌局為空
+ALTER TABLE game_records
+    ADD COLUMN shoe_id VARCHAR(36) NULL AFTER table_id,
+    ADD COLUMN shoe_position INT NULL AFTER shoe_id,
+    ADD COLUMN drawn_cards VARCHAR(100) NULL AFTER shoe_position,
+    ADD CONSTRAINT fk_game_records_shoe FOREIGN KEY (shoe_id) REFERENCES shoes(shoe_id);
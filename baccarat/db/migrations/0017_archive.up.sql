@@ -0,0 +1,73 @@
+-- 超過保留期的牌局和下注由歸檔任務移到壓縮的歸檔表（見 internal/archive）
+-- 沒有使用分區：分區表不支持外鍵，而 bets 和 game_records 之間有外鍵約束
+-- 歸檔表的列與在線表相同並保留原 id；在線表新增列時歸檔表和下面的視圖需同時修改
+CREATE TABLE IF NOT EXISTS game_records_archive (
+    id BIGINT PRIMARY KEY,
+    game_id VARCHAR(36) NOT NULL,
+    table_id VARCHAR(32) NOT NULL,
+    shoe_id VARCHAR(36) NULL,
+    shoe_position INT NULL,
+    drawn_cards VARCHAR(100) NULL,
+    player_initial_cards VARCHAR(100) NOT NULL,
+    banker_initial_cards VARCHAR(100) NOT NULL,
+    player_initial_score INT NOT NULL,
+    banker_initial_score INT NOT NULL,
+    player_third_card VARCHAR(50),
+    banker_third_card VARCHAR(50),
+    player_third_value INT,
+    banker_third_value INT,
+    player_final_score INT NOT NULL,
+    banker_final_score INT NOT NULL,
+    winner ENUM('Player', 'Banker', 'Tie') NOT NULL,
+    is_lucky_six BOOLEAN DEFAULT FALSE,
+    lucky_six_type VARCHAR(10),
+    player_payout DECIMAL(10, 2),
+    banker_payout DECIMAL(10, 2),
+    tie_payout DECIMAL(10, 2),
+    lucky_six_payout DECIMAL(10, 2),
+    total_bets DECIMAL(10, 2) DEFAULT 0.00,
+    total_payouts DECIMAL(10, 2) DEFAULT 0.00,
+    status VARCHAR(10) NOT NULL,
+    created_at TIMESTAMP NULL,
+    archived_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE KEY uk_game_id (game_id),
+    INDEX idx_created_at (created_at)
+) ENGINE=InnoDB ROW_FORMAT=COMPRESSED DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
+
+CREATE TABLE IF NOT EXISTS bets_archive (
+    id INT PRIMARY KEY,
+    user_id INT NOT NULL,
+    game_id VARCHAR(36) NOT NULL,
+    bet_amount DECIMAL(10, 2) NOT NULL,
+    bet_type VARCHAR(20) NOT NULL,
+    settled_return DECIMAL(10, 2) NULL,
+    created_at TIMESTAMP NULL,
+    archived_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    INDEX idx_game_id (game_id),
+    INDEX idx_user_created (user_id, created_at),
+    INDEX idx_created_at (created_at)
+) ENGINE=InnoDB ROW_FORMAT=COMPRESSED DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
+
+-- 在線和已歸檔的全部牌局、下注，供驗證工具查詢；服務內的歷史記錄、對帳單和報表重建不經過視圖，
+-- 由 db/archive.go 的 unionArchived 分別查詢兩組表，新增列時兩處都要修改
+CREATE OR REPLACE VIEW game_records_all AS
+SELECT id, game_id, table_id, shoe_id, shoe_position, drawn_cards,
+    player_initial_cards, banker_initial_cards, player_initial_score, banker_initial_score,
+    player_third_card, banker_third_card, player_third_value, banker_third_value,
+    player_final_score, banker_final_score, winner, is_lucky_six, lucky_six_type,
+    player_payout, banker_payout, tie_payout, lucky_six_payout, total_bets, total_payouts,
+    status, created_at
+FROM game_records
+UNION ALL
+SELECT id, game_id, table_id, shoe_id, shoe_position, drawn_cards,
+    player_initial_cards, banker_initial_cards, player_initial_score, banker_initial_score,
+    player_third_card, banker_third_card, player_third_value, banker_third_value,
+    player_final_score, banker_final_score, winner, is_lucky_six, lucky_six_type,
+    player_payout, banker_payout, tie_payout, lucky_six_payout, total_bets, total_payouts,
+    status, created_at
+FROM game_records_archive;
+
+CREATE OR REPLACE VIEW bets_all AS
+SELECT id, user_id, game_id, bet_amount, bet_type, settled_return, created_at FROM bets
+UNION ALL
+SELECT id, user_id, game_id, bet_amount, bet_type, settled_return, created_at FROM bets_archive;
@@ -0,0 +1,26 @@
+// Package migrations 內嵌的資料庫結構遷移腳本
+//
+// 文件名格式為 NNNN_name.up.sql 和 NNNN_name.down.sql，版本號遞增且不重複；
+// 每條語句以行末的分號結束，已發布的遷移不應再修改。
+// sqlite 目錄是同一結構的 SQLite 版本，新增遷移時兩邊需同時添加相同版本
+//
+// 0001 是改用遷移之前 sql/*.sql 建立的基準結構，之後的改動按需求的先後各為一個遷移。
+// 已按舊腳本部署的資料庫先核對現有結構：與 0001 一致時執行 baccarat migrate force 1，
+// 已包含後續遷移的改動時 force 到最後一個已包含的版本，然後執行 baccarat migrate up
+package migrations
+
+import (
+	"embed"
+	"io/fs"
+)
+
+// FS 所有遷移腳本（MySQL）
+//
+//go:embed *.sql
+var FS embed.FS
+
+//go:embed sqlite/*.sql
+var sqliteFiles embed.FS
+
+// SQLiteFS SQLite 版本的遷移腳本
+var SQLiteFS, _ = fs.Sub(sqliteFiles, "sqlite")
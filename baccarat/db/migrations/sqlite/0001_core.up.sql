@@ -0,0 +1,101 @@
+-- SQLite 版本的結構，與上層目錄的 MySQL 遷移版本一一對應
+-- 時間以 TIME_ZONE 的本地時間文本保存，默認值用 NOW()（見 db/sqlite.go）；ON UPDATE 自動更新時間不支持
+-- SQLite 的外鍵要求被引用的列唯一，game_records.game_id 從這裡開始就是唯一約束（MySQL 見 0015）
+
+-- 用戶表
+CREATE TABLE IF NOT EXISTS users (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    username VARCHAR(50) UNIQUE NOT NULL,
+    password_hash VARCHAR(255) NOT NULL,
+    balance DECIMAL(10, 2) DEFAULT 0.00,
+    created_at TIMESTAMP DEFAULT (NOW()),
+    updated_at TIMESTAMP DEFAULT (NOW())
+);
+
+-- 儲值紀錄表
+CREATE TABLE IF NOT EXISTS transactions (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    user_id INT NOT NULL REFERENCES users(id),
+    amount DECIMAL(10, 2) NOT NULL,
+    transaction_type VARCHAR(20) NOT NULL,
+    created_at TIMESTAMP DEFAULT (NOW())
+);
+
+-- 遊戲記錄表
+CREATE TABLE IF NOT EXISTS game_records (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    game_id VARCHAR(36) NOT NULL UNIQUE,
+    created_at TIMESTAMP DEFAULT (NOW()),
+    player_initial_cards VARCHAR(100) NOT NULL,
+    banker_initial_cards VARCHAR(100) NOT NULL,
+    player_initial_score INT NOT NULL,
+    banker_initial_score INT NOT NULL,
+    player_third_card VARCHAR(50),
+    banker_third_card VARCHAR(50),
+    player_third_value INT,
+    banker_third_value INT,
+    player_final_score INT NOT NULL,
+    banker_final_score INT NOT NULL,
+    winner VARCHAR(6) NOT NULL CHECK (winner IN ('Player', 'Banker', 'Tie')),
+    is_lucky_six BOOLEAN DEFAULT FALSE,
+    lucky_six_type VARCHAR(10),
+    player_payout DECIMAL(10, 2),
+    banker_payout DECIMAL(10, 2),
+    tie_payout DECIMAL(10, 2),
+    lucky_six_payout DECIMAL(10, 2),
+    total_bets DECIMAL(10, 2) DEFAULT 0.00,
+    total_payouts DECIMAL(10, 2) DEFAULT 0.00
+);
+CREATE INDEX IF NOT EXISTS idx_game_records_created_at ON game_records (created_at);
+
+-- 投注紀錄表
+CREATE TABLE IF NOT EXISTS bets (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    user_id INT NOT NULL REFERENCES users(id),
+    game_id VARCHAR(36) NOT NULL REFERENCES game_records(game_id),
+    bet_amount DECIMAL(10, 2) NOT NULL,
+    bet_type VARCHAR(20) NOT NULL,
+    created_at TIMESTAMP DEFAULT (NOW())
+);
+CREATE INDEX IF NOT EXISTS idx_bets_user_game ON bets (user_id, game_id);
+
+-- 自動賭局記錄表
+CREATE TABLE IF NOT EXISTS auto_game_records (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    game_id VARCHAR(36) NOT NULL UNIQUE,
+    created_at TIMESTAMP DEFAULT (NOW()),
+    player_initial_cards VARCHAR(100),
+    banker_initial_cards VARCHAR(100),
+    player_third_card VARCHAR(50),
+    banker_third_card VARCHAR(50),
+    player_final_score INT,
+    banker_final_score INT,
+    winner VARCHAR(6) CHECK (winner IN ('Player', 'Banker', 'Tie')),
+    is_lucky_six BOOLEAN DEFAULT FALSE,
+    lucky_six_type VARCHAR(10),
+    player_payout DECIMAL(10,2),
+    banker_payout DECIMAL(10,2),
+    tie_payout DECIMAL(10,2),
+    lucky_six_payout DECIMAL(10,2),
+    game_status VARCHAR(10) NOT NULL DEFAULT 'pending'
+        CHECK (game_status IN ('pending', 'betting', 'closed', 'drawing', 'completed', 'cancelled')),
+    betting_start_time TIMESTAMP NULL DEFAULT (NOW()),
+    betting_end_time TIMESTAMP NULL DEFAULT (NOW())
+);
+CREATE INDEX IF NOT EXISTS idx_auto_game_records_created_at ON auto_game_records (created_at);
+CREATE INDEX IF NOT EXISTS idx_auto_game_records_game_status ON auto_game_records (game_status);
+
+-- 自動賭局下注記錄表
+CREATE TABLE IF NOT EXISTS auto_game_bets (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    game_id VARCHAR(36) NOT NULL REFERENCES auto_game_records(game_id),
+    user_id INT NOT NULL REFERENCES users(id),
+    bet_type VARCHAR(6) NOT NULL CHECK (bet_type IN ('Player', 'Banker', 'Tie', 'Lucky6')),
+    amount DECIMAL(10,2) NOT NULL,
+    status VARCHAR(9) NOT NULL DEFAULT 'pending' CHECK (status IN ('pending', 'completed', 'cancelled')),
+    created_at TIMESTAMP DEFAULT (NOW()),
+    updated_at TIMESTAMP DEFAULT (NOW())
+);
+CREATE INDEX IF NOT EXISTS idx_auto_game_bets_game_id ON auto_game_bets (game_id);
+CREATE INDEX IF NOT EXISTS idx_auto_game_bets_user_id ON auto_game_bets (user_id);
+CREATE INDEX IF NOT EXISTS idx_auto_game_bets_status ON auto_game_bets (status);
@@ -0,0 +1,24 @@
+-- 登入會話表
+CREATE TABLE IF NOT EXISTS user_sessions (
+    id VARCHAR(36) PRIMARY KEY,
+    user_id INT NOT NULL REFERENCES users(id),
+    user_agent VARCHAR(255),
+    ip_address VARCHAR(45),
+    created_at TIMESTAMP DEFAULT (NOW()),
+    last_refreshed_at DATETIME NULL,
+    revoked_at DATETIME NULL,
+    revoke_reason VARCHAR(50)
+);
+CREATE INDEX IF NOT EXISTS idx_user_sessions_user_id ON user_sessions (user_id);
+
+-- 刷新令牌表
+CREATE TABLE IF NOT EXISTS refresh_tokens (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    token_hash CHAR(64) NOT NULL UNIQUE,
+    session_id VARCHAR(36) NOT NULL REFERENCES user_sessions(id),
+    user_id INT NOT NULL REFERENCES users(id),
+    expires_at DATETIME NOT NULL,
+    used_at DATETIME NULL,
+    created_at TIMESTAMP DEFAULT (NOW())
+);
+CREATE INDEX IF NOT EXISTS idx_refresh_tokens_session_id ON refresh_tokens (session_id);
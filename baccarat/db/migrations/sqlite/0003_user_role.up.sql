@@ -0,0 +1,2 @@
+-- 用戶角色
+ALTER TABLE users ADD COLUMN role VARCHAR(20) NOT NULL DEFAULT 'player';
@@ -0,0 +1,22 @@
+-- 登入失敗計數表
+CREATE TABLE IF NOT EXISTS login_failures (
+    scope VARCHAR(10) NOT NULL,
+    scope_key VARCHAR(64) NOT NULL,
+    failures INT NOT NULL DEFAULT 0,
+    last_failure_at DATETIME NOT NULL,
+    locked_until DATETIME NULL,
+    PRIMARY KEY (scope, scope_key)
+);
+
+-- 認證審計日誌
+CREATE TABLE IF NOT EXISTS auth_audit_log (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    user_id INT NULL,
+    username VARCHAR(50) NOT NULL,
+    ip_address VARCHAR(45),
+    event_type VARCHAR(30) NOT NULL,
+    detail VARCHAR(255),
+    created_at TIMESTAMP DEFAULT (NOW())
+);
+CREATE INDEX IF NOT EXISTS idx_auth_audit_log_username ON auth_audit_log (username);
+CREATE INDEX IF NOT EXISTS idx_auth_audit_log_event_type_created ON auth_audit_log (event_type, created_at);
@@ -0,0 +1,4 @@
+DROP INDEX IF EXISTS idx_bets_user_created;
+DROP INDEX IF EXISTS idx_transactions_user_type_created;
+ALTER TABLE user_sessions DROP COLUMN reminder_acknowledged_at;
+DROP TABLE IF EXISTS user_limits;
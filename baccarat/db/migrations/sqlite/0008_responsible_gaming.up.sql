@@ -0,0 +1,16 @@
+-- 負責任博彩限額
+CREATE TABLE IF NOT EXISTS user_limits (
+    user_id INT NOT NULL REFERENCES users(id),
+    limit_type VARCHAR(20) NOT NULL,
+    period VARCHAR(20) NOT NULL,
+    amount DECIMAL(10, 2) NOT NULL DEFAULT 0.00,
+    pending_amount DECIMAL(10, 2) NULL,
+    pending_effective_at DATETIME NULL,
+    updated_at TIMESTAMP DEFAULT (NOW()),
+    PRIMARY KEY (user_id, limit_type, period)
+);
+
+ALTER TABLE user_sessions ADD COLUMN reminder_acknowledged_at DATETIME NULL;
+
+CREATE INDEX IF NOT EXISTS idx_transactions_user_type_created ON transactions (user_id, transaction_type, created_at);
+CREATE INDEX IF NOT EXISTS idx_bets_user_created ON bets (user_id, created_at);
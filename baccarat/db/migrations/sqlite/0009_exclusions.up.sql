@@ -0,0 +1,13 @@
+-- 冷靜期和自我排除記錄
+CREATE TABLE IF NOT EXISTS user_exclusions (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    user_id INT NOT NULL REFERENCES users(id),
+    kind VARCHAR(20) NOT NULL,
+    starts_at DATETIME NOT NULL,
+    ends_at DATETIME NULL,
+    lifted_at DATETIME NULL,
+    lifted_by INT NULL,
+    lift_reason VARCHAR(255) NULL,
+    created_at TIMESTAMP DEFAULT (NOW())
+);
+CREATE INDEX IF NOT EXISTS idx_user_exclusions_user_id ON user_exclusions (user_id);
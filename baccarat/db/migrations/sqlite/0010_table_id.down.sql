@@ -0,0 +1 @@
+ALTER TABLE game_records DROP COLUMN table_id;
@@ -0,0 +1,2 @@
+-- 牌局所屬的遊戲桌
+ALTER TABLE game_records ADD COLUMN table_id VARCHAR(32) NOT NULL DEFAULT 'main';
@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS report_rollup_state;
+DROP TABLE IF EXISTS report_hourly;
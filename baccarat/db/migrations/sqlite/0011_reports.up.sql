@@ -0,0 +1,19 @@
+-- 營運報表小時匯總表
+CREATE TABLE IF NOT EXISTS report_hourly (
+    bucket DATETIME NOT NULL,
+    table_id VARCHAR(32) NOT NULL,
+    bet_type VARCHAR(10) NOT NULL,
+    user_id INT NOT NULL,
+    bet_count INT NOT NULL,
+    wagered DECIMAL(14, 2) NOT NULL,
+    returned DECIMAL(14, 2) NOT NULL,
+    PRIMARY KEY (bucket, table_id, bet_type, user_id)
+);
+CREATE INDEX IF NOT EXISTS idx_report_hourly_table_bucket ON report_hourly (table_id, bucket);
+
+-- 匯總進度
+CREATE TABLE IF NOT EXISTS report_rollup_state (
+    name VARCHAR(32) PRIMARY KEY,
+    rolled_up_to DATETIME NOT NULL,
+    updated_at TIMESTAMP DEFAULT (NOW())
+);
@@ -0,0 +1,4 @@
+ALTER TABLE users DROP COLUMN frozen_reason;
+ALTER TABLE users DROP COLUMN frozen_at;
+DROP TABLE IF EXISTS admin_audit_log;
+DROP TABLE IF EXISTS balance_adjustments;
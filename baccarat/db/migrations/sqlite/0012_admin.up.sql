@@ -0,0 +1,37 @@
+-- 人工調整餘額
+CREATE TABLE IF NOT EXISTS balance_adjustments (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    user_id INT NOT NULL REFERENCES users(id),
+    amount DECIMAL(10, 2) NOT NULL,
+    reason_code VARCHAR(20) NOT NULL,
+    note VARCHAR(255) NOT NULL,
+    status VARCHAR(10) NOT NULL,
+    requested_by INT NOT NULL REFERENCES users(id),
+    decided_by INT NULL REFERENCES users(id),
+    decision_note VARCHAR(255),
+    created_at TIMESTAMP DEFAULT (NOW()),
+    decided_at TIMESTAMP NULL
+);
+CREATE INDEX IF NOT EXISTS idx_balance_adjustments_status_created ON balance_adjustments (status, created_at);
+CREATE INDEX IF NOT EXISTS idx_balance_adjustments_user_created ON balance_adjustments (user_id, created_at);
+
+-- 管理操作審計日誌，只允許寫入
+CREATE TABLE IF NOT EXISTS admin_audit_log (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    admin_id INT NOT NULL,
+    action VARCHAR(30) NOT NULL,
+    target_user_id INT NULL,
+    detail VARCHAR(500),
+    ip_address VARCHAR(45),
+    created_at TIMESTAMP DEFAULT (NOW())
+);
+CREATE INDEX IF NOT EXISTS idx_admin_audit_log_target_created ON admin_audit_log (target_user_id, created_at);
+CREATE INDEX IF NOT EXISTS idx_admin_audit_log_admin_created ON admin_audit_log (admin_id, created_at);
+
+-- 觸發器寫在一行內，遷移按行末的分號拆分語句
+CREATE TRIGGER IF NOT EXISTS admin_audit_log_no_update BEFORE UPDATE ON admin_audit_log BEGIN SELECT RAISE(ABORT, 'admin_audit_log is append-only'); END;
+CREATE TRIGGER IF NOT EXISTS admin_audit_log_no_delete BEFORE DELETE ON admin_audit_log BEGIN SELECT RAISE(ABORT, 'admin_audit_log is append-only'); END;
+
+-- 凍結的帳號
+ALTER TABLE users ADD COLUMN frozen_at TIMESTAMP NULL;
+ALTER TABLE users ADD COLUMN frozen_reason VARCHAR(255);
@@ -0,0 +1,33 @@
+-- 牌局作廢與重新結算記錄
+CREATE TABLE IF NOT EXISTS game_corrections (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    game_id VARCHAR(36) NOT NULL,
+    action VARCHAR(10) NOT NULL,
+    reason VARCHAR(255) NOT NULL,
+    admin_id INT NOT NULL REFERENCES users(id),
+    original_snapshot TEXT NOT NULL,
+    corrected_snapshot TEXT NOT NULL,
+    created_at TIMESTAMP DEFAULT (NOW())
+);
+CREATE INDEX IF NOT EXISTS idx_game_corrections_game_created ON game_corrections (game_id, created_at);
+
+CREATE TRIGGER IF NOT EXISTS game_corrections_no_update BEFORE UPDATE ON game_corrections BEGIN SELECT RAISE(ABORT, 'game_corrections is append-only'); END;
+CREATE TRIGGER IF NOT EXISTS game_corrections_no_delete BEFORE DELETE ON game_corrections BEGIN SELECT RAISE(ABORT, 'game_corrections is append-only'); END;
+
+ALTER TABLE game_records ADD COLUMN status VARCHAR(10) NOT NULL DEFAULT 'settled';
+ALTER TABLE bets ADD COLUMN settled_return DECIMAL(10, 2) NULL;
+ALTER TABLE transactions ADD COLUMN reference VARCHAR(36) NULL;
+
+-- 事件發件箱
+CREATE TABLE IF NOT EXISTS event_outbox (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    event_id VARCHAR(64) NOT NULL UNIQUE,
+    event_type VARCHAR(50) NOT NULL,
+    payload TEXT NOT NULL,
+    attempts INT NOT NULL DEFAULT 0,
+    next_attempt_at DATETIME NOT NULL,
+    sent_at DATETIME NULL,
+    last_error VARCHAR(255),
+    created_at TIMESTAMP DEFAULT (NOW())
+);
+CREATE INDEX IF NOT EXISTS idx_event_outbox_pending ON event_outbox (sent_at, next_attempt_at);
@@ -0,0 +1 @@
+DROP TABLE IF EXISTS auto_game_recoveries;
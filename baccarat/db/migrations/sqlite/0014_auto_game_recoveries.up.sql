@@ -0,0 +1,11 @@
+-- 啟動時對未完成賭局的恢復記錄
+CREATE TABLE IF NOT EXISTS auto_game_recoveries (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    game_id VARCHAR(36) NOT NULL REFERENCES auto_game_records(game_id),
+    previous_status VARCHAR(10) NOT NULL,
+    action VARCHAR(10) NOT NULL,
+    refunded_bets INT NOT NULL DEFAULT 0,
+    refunded_amount DECIMAL(10,2) NOT NULL DEFAULT 0.00,
+    created_at TIMESTAMP DEFAULT (NOW())
+);
+CREATE INDEX IF NOT EXISTS idx_auto_game_recoveries_game_id ON auto_game_recoveries (game_id);
@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS idx_transactions_user_created;
+DROP INDEX IF EXISTS idx_bets_created_at;
+DROP INDEX IF EXISTS idx_bets_game_id;
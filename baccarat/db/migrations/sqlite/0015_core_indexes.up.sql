@@ -0,0 +1,4 @@
+-- game_records.game_id 在 0001 已是唯一約束（SQLite 的外鍵要求被引用的列唯一）
+CREATE INDEX IF NOT EXISTS idx_bets_game_id ON bets (game_id);
+CREATE INDEX IF NOT EXISTS idx_bets_created_at ON bets (created_at);
+CREATE INDEX IF NOT EXISTS idx_transactions_user_created ON transactions (user_id, created_at);
@@ -0,0 +1,4 @@
+ALTER TABLE game_records DROP COLUMN drawn_cards;
+ALTER TABLE game_records DROP COLUMN shoe_position;
+ALTER TABLE game_records DROP COLUMN shoe_id;
+DROP TABLE IF EXISTS shoes;
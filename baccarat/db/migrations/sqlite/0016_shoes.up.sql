@@ -0,0 +1,19 @@
+-- 牌靴
+CREATE TABLE IF NOT EXISTS shoes (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    shoe_id VARCHAR(36) NOT NULL UNIQUE,
+    table_id VARCHAR(32) NOT NULL,
+    shoe_number INT NOT NULL,
+    decks INT NOT NULL,
+    seed CHAR(64) NOT NULL,
+    card_order TEXT NOT NULL,
+    card_order_hash CHAR(64) NOT NULL,
+    burn_cards INT NOT NULL,
+    next_position INT NOT NULL,
+    created_at TIMESTAMP DEFAULT (NOW()),
+    UNIQUE (table_id, shoe_number)
+);
+
+ALTER TABLE game_records ADD COLUMN shoe_id VARCHAR(36) NULL REFERENCES shoes(shoe_id);
+ALTER TABLE game_records ADD COLUMN shoe_position INT NULL;
+ALTER TABLE game_records ADD COLUMN drawn_cards VARCHAR(100) NULL;
@@ -0,0 +1,4 @@
+DROP VIEW IF EXISTS bets_all;
+DROP VIEW IF EXISTS game_records_all;
+DROP TABLE IF EXISTS bets_archive;
+DROP TABLE IF EXISTS game_records_archive;
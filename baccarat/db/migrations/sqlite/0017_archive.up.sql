@@ -0,0 +1,72 @@
+-- 歸檔表（SQLite 不壓縮）
+CREATE TABLE IF NOT EXISTS game_records_archive (
+    id INTEGER PRIMARY KEY,
+    game_id VARCHAR(36) NOT NULL UNIQUE,
+    table_id VARCHAR(32) NOT NULL,
+    shoe_id VARCHAR(36) NULL,
+    shoe_position INT NULL,
+    drawn_cards VARCHAR(100) NULL,
+    player_initial_cards VARCHAR(100) NOT NULL,
+    banker_initial_cards VARCHAR(100) NOT NULL,
+    player_initial_score INT NOT NULL,
+    banker_initial_score INT NOT NULL,
+    player_third_card VARCHAR(50),
+    banker_third_card VARCHAR(50),
+    player_third_value INT,
+    banker_third_value INT,
+    player_final_score INT NOT NULL,
+    banker_final_score INT NOT NULL,
+    winner VARCHAR(6) NOT NULL CHECK (winner IN ('Player', 'Banker', 'Tie')),
+    is_lucky_six BOOLEAN DEFAULT FALSE,
+    lucky_six_type VARCHAR(10),
+    player_payout DECIMAL(10, 2),
+    banker_payout DECIMAL(10, 2),
+    tie_payout DECIMAL(10, 2),
+    lucky_six_payout DECIMAL(10, 2),
+    total_bets DECIMAL(10, 2) DEFAULT 0.00,
+    total_payouts DECIMAL(10, 2) DEFAULT 0.00,
+    status VARCHAR(10) NOT NULL,
+    created_at TIMESTAMP NULL,
+    archived_at TIMESTAMP DEFAULT (NOW())
+);
+CREATE INDEX IF NOT EXISTS idx_game_records_archive_created_at ON game_records_archive (created_at);
+
+CREATE TABLE IF NOT EXISTS bets_archive (
+    id INTEGER PRIMARY KEY,
+    user_id INT NOT NULL,
+    game_id VARCHAR(36) NOT NULL,
+    bet_amount DECIMAL(10, 2) NOT NULL,
+    bet_type VARCHAR(20) NOT NULL,
+    settled_return DECIMAL(10, 2) NULL,
+    created_at TIMESTAMP NULL,
+    archived_at TIMESTAMP DEFAULT (NOW())
+);
+CREATE INDEX IF NOT EXISTS idx_bets_archive_game_id ON bets_archive (game_id);
+CREATE INDEX IF NOT EXISTS idx_bets_archive_user_created ON bets_archive (user_id, created_at);
+CREATE INDEX IF NOT EXISTS idx_bets_archive_created_at ON bets_archive (created_at);
+
+-- 在線和已歸檔的全部牌局、下注，供驗證工具查詢；服務內的歷史記錄、對帳單和報表重建不經過視圖，
+-- 由 db/archive.go 的 unionArchived 分別查詢兩組表，新增列時兩處都要修改
+DROP VIEW IF EXISTS game_records_all;
+CREATE VIEW game_records_all AS
+SELECT id, game_id, table_id, shoe_id, shoe_position, drawn_cards,
+    player_initial_cards, banker_initial_cards, player_initial_score, banker_initial_score,
+    player_third_card, banker_third_card, player_third_value, banker_third_value,
+    player_final_score, banker_final_score, winner, is_lucky_six, lucky_six_type,
+    player_payout, banker_payout, tie_payout, lucky_six_payout, total_bets, total_payouts,
+    status, created_at
+FROM game_records
+UNION ALL
+SELECT id, game_id, table_id, shoe_id, shoe_position, drawn_cards,
+    player_initial_cards, banker_initial_cards, player_initial_score, banker_initial_score,
+    player_third_card, banker_third_card, player_third_value, banker_third_value,
+    player_final_score, banker_final_score, winner, is_lucky_six, lucky_six_type,
+    player_payout, banker_payout, tie_payout, lucky_six_payout, total_bets, total_payouts,
+    status, created_at
+FROM game_records_archive;
+
+DROP VIEW IF EXISTS bets_all;
+CREATE VIEW bets_all AS
+SELECT id, user_id, game_id, bet_amount, bet_type, settled_return, created_at FROM bets
+UNION ALL
+SELECT id, user_id, game_id, bet_amount, bet_type, settled_return, created_at FROM bets_archive;
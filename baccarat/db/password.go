@@ -0,0 +1,58 @@
+package db
+
+import (
+	"database/sql"
+	"time"
+)
+
+// 密碼相關審計事件類型
+const (
+	AuthEventPasswordChanged        = "password_changed"
+	AuthEventPasswordChangeFailed   = "password_change_failed"
+	AuthEventPasswordResetRequested = "password_reset_requested"
+	AuthEventPasswordReset          = "password_reset"
+	AuthEventPasswordResetFailed    = "password_reset_failed"
+)
+
+// GetPasswordHash 獲取用戶的密碼雜湊
+func GetPasswordHash(userID int) (string, error) {
+	var passwordHash string
+	err := DB.QueryRow("SELECT password_hash FROM users WHERE id = ?", userID).Scan(&passwordHash)
+	return passwordHash, err
+}
+
+// UpdatePasswordHash 更新用戶的密碼雜湊
+func UpdatePasswordHash(tx *sql.Tx, userID int, passwordHash []byte) error {
+	_, err := tx.Exec("UPDATE users SET password_hash = ? WHERE id = ?", passwordHash, userID)
+	return err
+}
+
+// SavePasswordResetToken 保存重設密碼令牌雜湊
+func SavePasswordResetToken(userID int, tokenHash string, expiresAt time.Time) error {
+	_, err := DB.Exec(
+		"INSERT INTO password_reset_tokens (user_id, token_hash, expires_at) VALUES (?, ?, ?)",
+		userID, tokenHash, expiresAt,
+	)
+	return err
+}
+
+// GetPasswordResetTokenForUpdate 通過雜湊查詢重設令牌並鎖定該行
+func GetPasswordResetTokenForUpdate(tx *sql.Tx, tokenHash string) (int, time.Time, sql.NullTime, error) {
+	var userID int
+	var expiresAt time.Time
+	var usedAt sql.NullTime
+	err := tx.QueryRow(
+		"SELECT user_id, expires_at, used_at FROM password_reset_tokens WHERE token_hash = ?"+dialect.forUpdate(),
+		tokenHash,
+	).Scan(&userID, &expiresAt, &usedAt)
+	return userID, expiresAt, usedAt, err
+}
+
+// ConsumePasswordResetTokens 使用戶所有未使用的重設令牌失效
+func ConsumePasswordResetTokens(tx *sql.Tx, userID int) error {
+	_, err := tx.Exec(
+		"UPDATE password_reset_tokens SET used_at = ? WHERE user_id = ? AND used_at IS NULL",
+		time.Now(), userID,
+	)
+	return err
+}
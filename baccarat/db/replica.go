@@ -0,0 +1,171 @@
+package db
+
+import (
+	"baccarat/config"
+	"baccarat/internal/clock"
+	"baccarat/internal/replica"
+	"baccarat/pkg/logger"
+	"baccarat/pkg/metrics"
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-sql-driver/mysql"
+)
+
+// replicaConns 只讀副本的連接，下標與 replicas 中的副本一致
+var replicaConns []*sql.DB
+
+// replicas 只讀查詢的路由，未配置副本時為 nil
+var replicas *replica.Router
+
+// openReplicas 連接 DB_REPLICA_DSNS 中的副本並定期檢測複製延遲
+// 副本連不上不影響啟動，檢測成功前查詢都走主庫
+func openReplicas(dsns string) error {
+	for _, dsn := range strings.Split(dsns, ",") {
+		dsn = strings.TrimSpace(dsn)
+		if dsn == "" {
+			continue
+		}
+		cfg, err := mysql.ParseDSN(dsn)
+		if err != nil {
+			return fmt.Errorf("invalid replica DSN: %v", err)
+		}
+		// 時間的解析和時區與主庫連接一致
+		cfg.ParseTime = true
+		cfg.Loc = config.Location()
+		conn, err := openTraced("mysql", cfg.FormatDSN())
+		if err != nil {
+			return fmt.Errorf("error opening replica %s: %v", cfg.Addr, err)
+		}
+		conn.SetMaxOpenConns(25)
+		conn.SetMaxIdleConns(5)
+		// 與日誌中副本的編號一致
+		metrics.RegisterDB(fmt.Sprintf("replica-%d", len(replicaConns)), conn)
+		replicaConns = append(replicaConns, conn)
+	}
+	if len(replicaConns) == 0 {
+		return nil
+	}
+
+	maxLag := time.Duration(config.AppConfig.DBReplicaMaxLag) * time.Second
+	interval := time.Duration(config.AppConfig.DBReplicaCheckInterval) * time.Second
+	// 寫入後副本最多落後 maxLag，加上兩次檢測之間延遲可能的增長
+	replicas = replica.NewRouter(len(replicaConns), maxLag, maxLag+interval, clock.System{})
+	checkReplicas()
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for range ticker.C {
+			checkReplicas()
+			replicas.Prune()
+		}
+	}()
+
+	logger.Info("Using", len(replicaConns), "read replicas, max lag", maxLag)
+	return nil
+}
+
+// checkReplicas 檢測每個副本的延遲，副本變為可用或不可用時記錄日誌
+func checkReplicas() {
+	for i, conn := range replicaConns {
+		lag, err := replicationLag(conn)
+		_, wasHealthy := replicas.Lag(i)
+		replicas.SetLag(i, lag, err == nil)
+		_, healthy := replicas.Lag(i)
+		switch {
+		case err != nil && wasHealthy:
+			logger.Warn("Read replica", i, "unavailable:", err)
+		case err == nil && wasHealthy && !healthy:
+			logger.Warn("Read replica", i, "lagging", lag, "behind the primary")
+		case !wasHealthy && healthy:
+			logger.Info("Read replica", i, "available, lag", lag)
+		}
+	}
+}
+
+// replicationLag 以 SHOW REPLICA STATUS（MySQL 8.0.22 之前為 SHOW SLAVE STATUS）讀取副本延遲
+// 需要 REPLICATION CLIENT 權限；複製線程停止時延遲為 NULL，視為不可用
+func replicationLag(conn *sql.DB) (time.Duration, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+
+	rows, err := conn.QueryContext(ctx, "SHOW REPLICA STATUS")
+	if err != nil {
+		rows, err = conn.QueryContext(ctx, "SHOW SLAVE STATUS")
+	}
+	if err != nil {
+		return 0, err
+	}
+	defer rows.Close()
+
+	columns, err := rows.Columns()
+	if err != nil {
+		return 0, err
+	}
+	if !rows.Next() {
+		if err := rows.Err(); err != nil {
+			return 0, err
+		}
+		return 0, errors.New("server is not replicating")
+	}
+	values := make([]sql.NullString, len(columns))
+	dest := make([]interface{}, len(columns))
+	for i := range values {
+		dest[i] = &values[i]
+	}
+	if err := rows.Scan(dest...); err != nil {
+		return 0, err
+	}
+	for i, column := range columns {
+		if column != "Seconds_Behind_Source" && column != "Seconds_Behind_Master" {
+			continue
+		}
+		if !values[i].Valid {
+			return 0, errors.New("replication stopped")
+		}
+		seconds, err := strconv.Atoi(values[i].String)
+		if err != nil {
+			return 0, err
+		}
+		return time.Duration(seconds) * time.Second, nil
+	}
+	return 0, errors.New("replica status has no Seconds_Behind_Source")
+}
+
+// ReadDB 返回只讀查詢使用的連接：未配置副本、userID 的用戶剛寫入過或沒有延遲在範圍內的副本時返回主庫
+// userID 為 0 表示查詢不屬於某個用戶的會話（報表、牌局詳情）
+// 寫入記錄只保存在本實例內存中，部署多個實例時需由網關按用戶固定轉發到同一實例，否則讀到剛寫入數據的保證不成立
+func ReadDB(userID int) *sql.DB {
+	if replicas == nil {
+		return DB
+	}
+	if i := replicas.Pick(userID); i != replica.Primary {
+		return replicaConns[i]
+	}
+	return DB
+}
+
+// markWrite 在事務提交後記錄用戶的餘額或下注剛有變動，之後一段時間該用戶的只讀查詢走主庫
+// 在語句執行時記錄的話，長事務提交前 StickyFor 可能已經過去
+func markWrite(tx *sql.Tx, userID int) {
+	if replicas != nil {
+		afterCommit(tx, func() { replicas.MarkWrite(userID) })
+	}
+}
+
+// queryRowOrPrimary 在副本上查詢單行，副本上還沒有該行（剛寫入尚未複製）時改查主庫
+// 返回實際使用的連接，同一請求的後續查詢應使用同一連接
+func queryRowOrPrimary(query string, args []interface{}, dest ...interface{}) (*sql.DB, error) {
+	conn := ReadDB(0)
+	err := conn.QueryRow(query, args...).Scan(dest...)
+	if err == sql.ErrNoRows && conn != DB {
+		conn = DB
+		err = conn.QueryRow(query, args...).Scan(dest...)
+	}
+	return conn, err
+}
@@ -0,0 +1,174 @@
+package db
+
+import (
+	"database/sql"
+	"strings"
+	"time"
+)
+
+// reportRollupName report_rollup_state 中小時匯總的進度名稱
+const reportRollupName = "hourly"
+
+// reportPeriodFormats 各時間粒度對應的 DATE_FORMAT 格式
+var reportPeriodFormats = map[string]string{
+	"hour":  "%Y-%m-%d %H:00",
+	"day":   "%Y-%m-%d",
+	"month": "%Y-%m",
+}
+
+// ReportQuery 報表查詢條件
+type ReportQuery struct {
+	From           time.Time // 包含
+	To             time.Time // 不包含
+	Granularity    string    // hour, day, month
+	GroupByTable   bool
+	GroupByBetType bool
+	TableID        string // 為空時不過濾
+}
+
+// ReportRow 一個分組的匯總，未分組的維度為空字符串
+type ReportRow struct {
+	Period   string
+	TableID  string
+	BetType  string
+	Bets     int
+	Players  int
+	Wagered  float64
+	Returned float64
+}
+
+// GetReportRollupWatermark 返回已匯總到的時間，尚未匯總過時 ok 為 false
+func GetReportRollupWatermark() (time.Time, bool, error) {
+	var t time.Time
+	err := DB.QueryRow("SELECT rolled_up_to FROM report_rollup_state WHERE name = ?", reportRollupName).Scan(&t)
+	if err == sql.ErrNoRows {
+		return time.Time{}, false, nil
+	}
+	if err != nil {
+		return time.Time{}, false, err
+	}
+	return t, true, nil
+}
+
+// GetEarliestBetTime 返回最早一筆下注的時間，沒有下注時 ok 為 false
+func GetEarliestBetTime() (time.Time, bool, error) {
+	earliest, args := unionArchived("SELECT MIN(created_at) AS earliest FROM {bets}")
+	var t nullTime
+	if err := DB.QueryRow("SELECT MIN(earliest) FROM ("+earliest+") e", args...).Scan(&t); err != nil {
+		return time.Time{}, false, err
+	}
+	return t.Time, t.Valid, nil
+}
+
+// RebuildReportRollups 在同一事務內重建 [from, to) 的小時匯總並更新進度為 watermark
+// from 和 to 必須是整點
+func RebuildReportRollups(from, to, watermark time.Time) error {
+	return Transaction(func(tx *sql.Tx) error {
+		if err := rebuildReportHours(tx, from, to); err != nil {
+			return err
+		}
+		_, err := tx.Exec(`
+			INSERT INTO report_rollup_state (name, rolled_up_to) VALUES (?, ?)`+
+			dialect.upsert([]string{"name"}, []string{"rolled_up_to"}),
+			reportRollupName, watermark,
+		)
+		return err
+	})
+}
+
+// reportHour 返回 t 在 loc 中所在小時的開始，與匯總任務的分桶一致
+// 不能用 Truncate(time.Hour)：它按 UTC 取整，半小時時差的時區會落在錯誤的小時
+func reportHour(t time.Time, loc *time.Location) time.Time {
+	t = t.In(loc)
+	return time.Date(t.Year(), t.Month(), t.Day(), t.Hour(), 0, 0, 0, loc)
+}
+
+// rebuildReportHours 重建 [from, to) 的小時匯總，不更新進度
+func rebuildReportHours(tx *sql.Tx, from, to time.Time) error {
+	if _, err := tx.Exec("DELETE FROM report_hourly WHERE bucket >= ? AND bucket < ?", from, to); err != nil {
+		return err
+	}
+	// 在線表和歸檔表分別匯總後合併，同一小時可能部分已歸檔
+	hours, args := unionArchived(`
+		SELECT DATE_FORMAT(b.created_at, '%Y-%m-%d %H:00:00') AS bucket, gr.table_id, b.bet_type, b.user_id,
+			COUNT(*) AS bet_count, SUM(b.bet_amount) AS wagered, SUM(`+settledReturnExpr+`) AS returned
+		FROM {bets} b
+		JOIN {game_records} gr ON b.game_id = gr.game_id
+		WHERE b.created_at >= ? AND b.created_at < ?
+		GROUP BY 1, gr.table_id, b.bet_type, b.user_id`,
+		from, to,
+	)
+	_, err := tx.Exec(`
+		INSERT INTO report_hourly (bucket, table_id, bet_type, user_id, bet_count, wagered, returned)
+		SELECT bucket, table_id, bet_type, user_id, SUM(bet_count), SUM(wagered), SUM(returned)
+		FROM (`+hours+`
+		) hours
+		GROUP BY bucket, table_id, bet_type, user_id`,
+		args...,
+	)
+	return err
+}
+
+// where 組裝報表篩選條件
+func (q ReportQuery) where() (string, []interface{}) {
+	conditions := []string{"bucket >= ?", "bucket < ?"}
+	args := []interface{}{q.From, q.To}
+	if q.TableID != "" {
+		conditions = append(conditions, "table_id = ?")
+		args = append(args, q.TableID)
+	}
+	return strings.Join(conditions, " AND "), args
+}
+
+// GetReport 按時間粒度和所選維度查詢匯總
+func GetReport(q ReportQuery) ([]ReportRow, error) {
+	format, ok := reportPeriodFormats[q.Granularity]
+	if !ok {
+		format = reportPeriodFormats["day"]
+	}
+	tableCol, betTypeCol := "''", "''"
+	if q.GroupByTable {
+		tableCol = "table_id"
+	}
+	if q.GroupByBetType {
+		betTypeCol = "bet_type"
+	}
+
+	where, args := q.where()
+	rows, err := ReadDB(0).Query(`
+		SELECT DATE_FORMAT(bucket, '`+format+`') AS period, `+tableCol+` AS tbl, `+betTypeCol+` AS bt,
+			SUM(bet_count), COUNT(DISTINCT user_id), SUM(wagered), SUM(returned)
+		FROM report_hourly
+		WHERE `+where+`
+		GROUP BY period, tbl, bt
+		ORDER BY period, tbl, bt`,
+		args...,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	report := []ReportRow{}
+	for rows.Next() {
+		var row ReportRow
+		if err := rows.Scan(&row.Period, &row.TableID, &row.BetType, &row.Bets, &row.Players, &row.Wagered, &row.Returned); err != nil {
+			return nil, err
+		}
+		report = append(report, row)
+	}
+	return report, rows.Err()
+}
+
+// GetReportTotals 查詢整個範圍的匯總（活躍玩家按整個範圍去重）
+func GetReportTotals(q ReportQuery) (ReportRow, error) {
+	where, args := q.where()
+	var row ReportRow
+	err := ReadDB(0).QueryRow(`
+		SELECT COALESCE(SUM(bet_count), 0), COUNT(DISTINCT user_id), COALESCE(SUM(wagered), 0), COALESCE(SUM(returned), 0)
+		FROM report_hourly
+		WHERE `+where,
+		args...,
+	).Scan(&row.Bets, &row.Players, &row.Wagered, &row.Returned)
+	return row, err
+}
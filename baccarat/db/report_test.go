@@ -0,0 +1,24 @@
+package db
+
+import (
+	"testing"
+	"time"
+)
+
+func TestReportHourHalfHourOffset(t *testing.T) {
+	loc := time.FixedZone("IST", 5*3600+1800)
+	got := reportHour(time.Date(2024, 5, 1, 4, 50, 0, 0, time.UTC), loc)
+	want := time.Date(2024, 5, 1, 10, 0, 0, 0, loc)
+	if !got.Equal(want) {
+		t.Errorf("reportHour = %v, want %v", got, want)
+	}
+}
+
+func TestTruncateRunes(t *testing.T) {
+	if got := truncateRunes("荷官發錯牌", 3); got != "荷官發" {
+		t.Errorf("truncateRunes = %q, want 荷官發", got)
+	}
+	if got := truncateRunes("void", 10); got != "void" {
+		t.Errorf("truncateRunes = %q, want void", got)
+	}
+}
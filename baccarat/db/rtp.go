@@ -0,0 +1,78 @@
+package db
+
+import "time"
+
+// RTPBetStat 一張牌桌一種投注類型的下注和返還匯總，下注先按局合計
+type RTPBetStat struct {
+	TableID  string
+	BetType  string
+	Rounds   int     // 有此類投注的局數
+	Wagered  float64 // 總投注額
+	Squares  float64 // 每局投注額平方之和，計算返還的方差
+	Returned float64 // 按開局結果計算的返還（含本金），不含作廢和重新結算的修正
+}
+
+// RTPOutcomeStat 一張牌桌一種結果的局數
+type RTPOutcomeStat struct {
+	TableID      string
+	Winner       string
+	LuckySixType string // 非幸運6時為空
+	Rounds       int
+}
+
+// ListRTPBetStats 匯總 since 之後開局的下注和返還
+// 同一局多名玩家的下注結果相同，按局合計後才是獨立樣本
+func ListRTPBetStats(since time.Time) ([]RTPBetStat, error) {
+	rows, err := ReadDB(0).Query(`
+		SELECT table_id, bet_type, COUNT(*), SUM(wagered), SUM(wagered * wagered), SUM(returned)
+		FROM (
+			SELECT gr.table_id, b.bet_type, b.game_id,
+				SUM(b.bet_amount) AS wagered, SUM(`+betReturnExpr+`) AS returned
+			FROM bets b
+			JOIN game_records gr ON b.game_id = gr.game_id
+			WHERE gr.created_at >= ?
+			GROUP BY gr.table_id, b.bet_type, b.game_id
+		) r
+		GROUP BY table_id, bet_type`,
+		since,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var stats []RTPBetStat
+	for rows.Next() {
+		var s RTPBetStat
+		if err := rows.Scan(&s.TableID, &s.BetType, &s.Rounds, &s.Wagered, &s.Squares, &s.Returned); err != nil {
+			return nil, err
+		}
+		stats = append(stats, s)
+	}
+	return stats, rows.Err()
+}
+
+// ListRTPOutcomeStats 統計 since 之後開局的勝方和幸運6
+func ListRTPOutcomeStats(since time.Time) ([]RTPOutcomeStat, error) {
+	rows, err := ReadDB(0).Query(`
+		SELECT table_id, winner, CASE WHEN is_lucky_six THEN COALESCE(lucky_six_type, '') ELSE '' END AS lucky_six, COUNT(*)
+		FROM game_records
+		WHERE created_at >= ?
+		GROUP BY table_id, winner, lucky_six`,
+		since,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var stats []RTPOutcomeStat
+	for rows.Next() {
+		var s RTPOutcomeStat
+		if err := rows.Scan(&s.TableID, &s.Winner, &s.LuckySixType, &s.Rounds); err != nil {
+			return nil, err
+		}
+		stats = append(stats, s)
+	}
+	return stats, rows.Err()
+}
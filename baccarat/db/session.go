@@ -0,0 +1,94 @@
+package db
+
+import (
+	"database/sql"
+	"time"
+)
+
+// RefreshToken 刷新令牌記錄
+type RefreshToken struct {
+	SessionID      string
+	UserID         int
+	ExpiresAt      time.Time
+	UsedAt         sql.NullTime
+	SessionRevoked bool
+}
+
+// CreateSession 創建登入會話
+func CreateSession(tx *sql.Tx, sessionID string, userID int, userAgent, ipAddress string) error {
+	_, err := tx.Exec(
+		"INSERT INTO user_sessions (id, user_id, user_agent, ip_address) VALUES (?, ?, ?, ?)",
+		sessionID, userID, userAgent, ipAddress,
+	)
+	return err
+}
+
+// SaveRefreshToken 保存刷新令牌雜湊
+func SaveRefreshToken(tx *sql.Tx, tokenHash, sessionID string, userID int, expiresAt time.Time) error {
+	_, err := tx.Exec(
+		"INSERT INTO refresh_tokens (token_hash, session_id, user_id, expires_at) VALUES (?, ?, ?, ?)",
+		tokenHash, sessionID, userID, expiresAt,
+	)
+	return err
+}
+
+// GetRefreshTokenForUpdate 通過雜湊查詢刷新令牌並鎖定該行
+func GetRefreshTokenForUpdate(tx *sql.Tx, tokenHash string) (*RefreshToken, error) {
+	var token RefreshToken
+	var revokedAt sql.NullTime
+	err := tx.QueryRow(`
+		SELECT rt.session_id, rt.user_id, rt.expires_at, rt.used_at, s.revoked_at
+		FROM refresh_tokens rt
+		JOIN user_sessions s ON rt.session_id = s.id
+		WHERE rt.token_hash = ?`+dialect.forUpdate(),
+		tokenHash,
+	).Scan(&token.SessionID, &token.UserID, &token.ExpiresAt, &token.UsedAt, &revokedAt)
+	if err != nil {
+		return nil, err
+	}
+	token.SessionRevoked = revokedAt.Valid
+	return &token, nil
+}
+
+// MarkRefreshTokenUsed 標記刷新令牌已輪換
+func MarkRefreshTokenUsed(tx *sql.Tx, tokenHash string) error {
+	_, err := tx.Exec("UPDATE refresh_tokens SET used_at = ? WHERE token_hash = ?", time.Now(), tokenHash)
+	return err
+}
+
+// TouchSession 更新會話的最後刷新時間
+func TouchSession(tx *sql.Tx, sessionID string) error {
+	_, err := tx.Exec("UPDATE user_sessions SET last_refreshed_at = ? WHERE id = ?", time.Now(), sessionID)
+	return err
+}
+
+// RevokeSession 撤銷單個會話
+func RevokeSession(tx *sql.Tx, sessionID, reason string) error {
+	_, err := tx.Exec(
+		"UPDATE user_sessions SET revoked_at = ?, revoke_reason = ? WHERE id = ? AND revoked_at IS NULL",
+		time.Now(), reason, sessionID,
+	)
+	return err
+}
+
+// RevokeUserSessions 撤銷用戶的所有會話，返回被撤銷的數量
+func RevokeUserSessions(tx *sql.Tx, userID int, reason string) (int64, error) {
+	result, err := tx.Exec(
+		"UPDATE user_sessions SET revoked_at = ?, revoke_reason = ? WHERE user_id = ? AND revoked_at IS NULL",
+		time.Now(), reason, userID,
+	)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+// IsSessionActive 檢查會話是否屬於該用戶且未被撤銷
+func IsSessionActive(sessionID string, userID int) (bool, error) {
+	var active bool
+	err := DB.QueryRow(
+		"SELECT EXISTS(SELECT 1 FROM user_sessions WHERE id = ? AND user_id = ? AND revoked_at IS NULL)",
+		sessionID, userID,
+	).Scan(&active)
+	return active, err
+}
@@ -0,0 +1,120 @@
+package db
+
+import (
+	"database/sql"
+	"errors"
+)
+
+// ErrNoShoeRecord 牌局沒有牌靴記錄（引入牌靴之前的牌局）
+var ErrNoShoeRecord = errors.New("game has no shoe record")
+
+// Shoe 牌桌使用的一個牌靴
+type Shoe struct {
+	ShoeID        string
+	TableID       string
+	ShoeNumber    int
+	Decks         int
+	Seed          string // 十六進制
+	CardOrder     string // 逗號分隔的完整牌序
+	CardOrderHash string
+	BurnCards     int
+	NextPosition  int
+}
+
+// ShoeRound 一局牌的牌靴記錄和牌局結果，用於重新發牌核對
+type ShoeRound struct {
+	Shoe
+	GameID      string
+	Position    int
+	DrawnCards  string
+	PlayerCards []string
+	BankerCards []string
+	Winner      string
+	Status      string
+	Replaced    bool // 牌桌已換用序號更大的牌靴
+}
+
+// LockCurrentShoe 鎖定牌桌序號最大的牌靴直到事務結束，牌桌還沒有牌靴時返回 sql.ErrNoRows
+func LockCurrentShoe(tx *sql.Tx, tableID string) (*Shoe, error) {
+	var s Shoe
+	err := tx.QueryRow(`
+		SELECT shoe_id, table_id, shoe_number, decks, seed, card_order, card_order_hash, burn_cards, next_position
+		FROM shoes
+		WHERE table_id = ?
+		ORDER BY shoe_number DESC
+		LIMIT 1`+dialect.forUpdate(),
+		tableID,
+	).Scan(&s.ShoeID, &s.TableID, &s.ShoeNumber, &s.Decks, &s.Seed, &s.CardOrder, &s.CardOrderHash, &s.BurnCards, &s.NextPosition)
+	if err != nil {
+		return nil, err
+	}
+	return &s, nil
+}
+
+// CreateShoe 保存新牌靴
+func CreateShoe(tx *sql.Tx, s *Shoe) error {
+	_, err := tx.Exec(`
+		INSERT INTO shoes (shoe_id, table_id, shoe_number, decks, seed, card_order, card_order_hash, burn_cards, next_position)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		s.ShoeID, s.TableID, s.ShoeNumber, s.Decks, s.Seed, s.CardOrder, s.CardOrderHash, s.BurnCards, s.NextPosition,
+	)
+	return err
+}
+
+// AdvanceShoe 更新牌靴下一局的起始位置
+func AdvanceShoe(tx *sql.Tx, shoeID string, nextPosition int) error {
+	_, err := tx.Exec("UPDATE shoes SET next_position = ? WHERE shoe_id = ?", nextPosition, shoeID)
+	return err
+}
+
+// GetShoeRound 查詢牌局及其牌靴，牌局不存在時返回 ErrGameNotFound，沒有牌靴記錄時返回 ErrNoShoeRecord
+func GetShoeRound(gameID string) (*ShoeRound, error) {
+	var r ShoeRound
+	var shoeID, drawn sql.NullString
+	var position sql.NullInt64
+	var playerCards, bankerCards string
+	var playerThird, bankerThird sql.NullString
+	query, args := unionArchived(`
+		SELECT game_id, shoe_id, shoe_position, drawn_cards, winner, status,
+			player_initial_cards, player_third_card, banker_initial_cards, banker_third_card
+		FROM {game_records}
+		WHERE game_id = ?`,
+		gameID,
+	)
+	conn, err := queryRowOrPrimary(query, args,
+		&r.GameID, &shoeID, &position, &drawn, &r.Winner, &r.Status,
+		&playerCards, &playerThird, &bankerCards, &bankerThird)
+	if err == sql.ErrNoRows {
+		return nil, ErrGameNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	if !shoeID.Valid {
+		return nil, ErrNoShoeRecord
+	}
+	r.Position, r.DrawnCards = int(position.Int64), drawn.String
+	r.PlayerCards = joinCards(playerCards, playerThird)
+	r.BankerCards = joinCards(bankerCards, bankerThird)
+
+	err = conn.QueryRow(`
+		SELECT shoe_id, table_id, shoe_number, decks, seed, card_order, card_order_hash, burn_cards, next_position
+		FROM shoes
+		WHERE shoe_id = ?`,
+		shoeID.String,
+	).Scan(&r.ShoeID, &r.TableID, &r.ShoeNumber, &r.Decks, &r.Seed, &r.CardOrder, &r.CardOrderHash, &r.BurnCards, &r.NextPosition)
+	if err != nil {
+		return nil, err
+	}
+
+	var newer int
+	err = conn.QueryRow(
+		"SELECT COUNT(*) FROM shoes WHERE table_id = ? AND shoe_number > ?",
+		r.TableID, r.ShoeNumber,
+	).Scan(&newer)
+	if err != nil {
+		return nil, err
+	}
+	r.Replaced = newer > 0
+	return &r, nil
+}
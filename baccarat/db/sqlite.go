@@ -0,0 +1,104 @@
+package db
+
+import (
+	"baccarat/config"
+	"database/sql"
+	"database/sql/driver"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	"modernc.org/sqlite"
+)
+
+func init() {
+	// 查詢中用到的 MySQL 函數，在 SQLite 上以同名函數實現
+	sqlite.MustRegisterScalarFunction("NOW", 0, sqliteNow)
+	sqlite.MustRegisterDeterministicScalarFunction("DATE_FORMAT", 2, sqliteDateFormat)
+}
+
+// OpenSQLite 打開（不存在時創建）SQLite 數據庫文件，並切換為 SQLite 方言
+// 時間以 TIME_ZONE 的本地時間保存，與 MySQL 連接的 loc 參數一致
+func OpenSQLite(path string) (*sql.DB, error) {
+	params := url.Values{}
+	params.Set("_time_format", "datetime")
+	params.Set("_timezone", config.Location().String())
+	params.Set("_txlock", "immediate")
+	params.Add("_pragma", "foreign_keys(1)")
+	params.Add("_pragma", "busy_timeout(5000)")
+	params.Add("_pragma", "journal_mode(WAL)")
+
+	conn, err := openTraced("sqlite", "file:"+path+"?"+params.Encode())
+	if err != nil {
+		return nil, err
+	}
+	if err := conn.Ping(); err != nil {
+		conn.Close()
+		return nil, err
+	}
+	dialect = sqliteDialect{}
+	return conn, nil
+}
+
+// sqliteNow MySQL 的 NOW()：TIME_ZONE 的當前時間
+func sqliteNow(ctx *sqlite.FunctionContext, args []driver.Value) (driver.Value, error) {
+	return time.Now().In(config.Location()).Format(sqliteTimeLayouts[0]), nil
+}
+
+// dateFormatSpecifiers 支持的 DATE_FORMAT 格式符及對應的 Go 時間格式
+var dateFormatSpecifiers = map[byte]string{
+	'Y': "2006",
+	'm': "01",
+	'd': "02",
+	'H': "15",
+	'i': "04",
+	's': "05",
+}
+
+// sqliteDateFormat MySQL 的 DATE_FORMAT(date, format)，只支持 dateFormatSpecifiers 中的格式符
+func sqliteDateFormat(ctx *sqlite.FunctionContext, args []driver.Value) (driver.Value, error) {
+	var t time.Time
+	switch v := args[0].(type) {
+	case nil:
+		return nil, nil
+	case time.Time:
+		t = v
+	case string:
+		var err error
+		if t, err = parseSQLiteTime(v); err != nil {
+			return nil, err
+		}
+	default:
+		return nil, fmt.Errorf("DATE_FORMAT: unsupported value %v", v)
+	}
+	format, ok := args[1].(string)
+	if !ok {
+		return nil, fmt.Errorf("DATE_FORMAT: format must be text")
+	}
+
+	var result strings.Builder
+	for i := 0; i < len(format); i++ {
+		if format[i] != '%' || i == len(format)-1 {
+			result.WriteByte(format[i])
+			continue
+		}
+		i++
+		layout, ok := dateFormatSpecifiers[format[i]]
+		if !ok {
+			return nil, fmt.Errorf("DATE_FORMAT: unsupported specifier %%%c", format[i])
+		}
+		result.WriteString(t.Format(layout))
+	}
+	return result.String(), nil
+}
+
+// parseSQLiteTime 解析以文本保存的時間，不做時區換算
+func parseSQLiteTime(text string) (time.Time, error) {
+	for _, layout := range sqliteTimeLayouts {
+		if t, err := time.Parse(layout, text); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("cannot parse %q as time", text)
+}
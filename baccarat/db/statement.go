@@ -0,0 +1,93 @@
+package db
+
+import (
+	"baccarat/internal/statement"
+	"context"
+	"database/sql"
+	"time"
+)
+
+// statementDaysQuery 按天匯總交易和下注，存款以外的交易類型計入 other；返回查詢和參數
+func statementDaysQuery(userID int, from, to time.Time) (string, []interface{}) {
+	bets, betArgs := unionArchived(`
+		SELECT DATE(b.created_at), 0, 0, b.bet_amount, `+betReturnExpr+`
+		FROM {bets} b
+		JOIN {game_records} gr ON b.game_id = gr.game_id
+		WHERE b.user_id = ? AND b.created_at >= ? AND b.created_at < ?`,
+		userID, from, to,
+	)
+	query := `
+	SELECT day, SUM(deposits), SUM(other), SUM(wagered), SUM(won)
+	FROM (
+		SELECT DATE(t.created_at) AS day,
+			IF(t.transaction_type = 'deposit', t.amount, 0) AS deposits,
+			IF(t.transaction_type = 'deposit', 0, t.amount) AS other,
+			0 AS wagered, 0 AS won
+		FROM transactions t
+		WHERE t.user_id = ? AND t.created_at >= ? AND t.created_at < ?
+		UNION ALL` + bets + `
+	) activity
+	GROUP BY day
+	ORDER BY day`
+	return query, append([]interface{}{userID, from, to}, betArgs...)
+}
+
+// StreamStatement 在同一個只讀快照內計算期初餘額並逐天讀取 [from, to) 的帳戶變動，
+// 期初餘額由當前餘額減去 from 之後的全部變動得出
+// fn 返回後事務才結束，days 只能在 fn 內調用
+func StreamStatement(ctx context.Context, userID int, from, to time.Time,
+	fn func(opening float64, days func(func(statement.Day) error) error) error) error {
+	tx, err := ReadDB(userID).BeginTx(ctx, &sql.TxOptions{Isolation: sql.LevelRepeatableRead, ReadOnly: true})
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	since, sinceArgs := unionArchived(`
+		SELECT SUM(`+betReturnExpr+` - b.bet_amount) AS net
+		FROM {bets} b
+		JOIN {game_records} gr ON b.game_id = gr.game_id
+		WHERE b.user_id = ? AND b.created_at >= ?`,
+		userID, from,
+	)
+	var opening float64
+	err = tx.QueryRowContext(ctx, `
+		SELECT u.balance
+			- COALESCE((SELECT SUM(t.amount) FROM transactions t WHERE t.user_id = u.id AND t.created_at >= ?), 0)
+			- COALESCE((SELECT SUM(net) FROM (`+since+`
+			) since), 0)
+		FROM users u
+		WHERE u.id = ?`,
+		append(append([]interface{}{from}, sinceArgs...), userID)...,
+	).Scan(&opening)
+	if err != nil {
+		return err
+	}
+
+	days := func(emit func(statement.Day) error) error {
+		query, args := statementDaysQuery(userID, from, to)
+		rows, err := tx.QueryContext(ctx, query, args...)
+		if err != nil {
+			return err
+		}
+		defer rows.Close()
+
+		for rows.Next() {
+			var day statement.Day
+			var date nullTime
+			if err := rows.Scan(&date, &day.Deposits, &day.Other, &day.Wagered, &day.Won); err != nil {
+				return err
+			}
+			day.Date = date.Time
+			if err := emit(day); err != nil {
+				return err
+			}
+		}
+		return rows.Err()
+	}
+
+	if err := fn(opening, days); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
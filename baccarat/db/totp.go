@@ -0,0 +1,106 @@
+package db
+
+import (
+	"database/sql"
+	"time"
+)
+
+// 兩步驗證審計事件類型
+const (
+	AuthEventTOTPEnrolled     = "2fa_enrolled"
+	AuthEventTOTPEnabled      = "2fa_enabled"
+	AuthEventTOTPFailed       = "2fa_failed"
+	AuthEventTOTPDisabled     = "2fa_disabled"
+	AuthEventRecoveryCodeUsed = "2fa_recovery_code_used"
+)
+
+// UserTOTP 用戶的兩步驗證設置
+type UserTOTP struct {
+	Secret       string
+	Enabled      bool
+	LastUsedStep int64
+}
+
+// GetUserTOTP 獲取用戶的兩步驗證設置，未設置時返回 sql.ErrNoRows
+func GetUserTOTP(userID int) (*UserTOTP, error) {
+	var totp UserTOTP
+	err := DB.QueryRow(
+		"SELECT secret, enabled, last_used_step FROM user_totp WHERE user_id = ?",
+		userID,
+	).Scan(&totp.Secret, &totp.Enabled, &totp.LastUsedStep)
+	if err != nil {
+		return nil, err
+	}
+	return &totp, nil
+}
+
+// SaveTOTPSecret 保存待確認的密鑰（覆蓋之前未確認的密鑰）
+func SaveTOTPSecret(userID int, secret string) error {
+	_, err := DB.Exec(`
+		INSERT INTO user_totp (user_id, secret, enabled, last_used_step)
+		VALUES (?, ?, FALSE, 0)`+
+		dialect.upsert([]string{"user_id"}, []string{"secret"}, "enabled = FALSE", "last_used_step = 0", "enabled_at = NULL"),
+		userID, secret,
+	)
+	return err
+}
+
+// UseTOTPStep 記錄已使用的時間步長，步長不大於上次記錄時返回 false（並發重放）
+func UseTOTPStep(tx *sql.Tx, userID int, step int64) (bool, error) {
+	result, err := tx.Exec(
+		"UPDATE user_totp SET last_used_step = ? WHERE user_id = ? AND last_used_step < ?",
+		step, userID, step,
+	)
+	if err != nil {
+		return false, err
+	}
+	affected, err := result.RowsAffected()
+	return affected == 1, err
+}
+
+// EnableTOTP 確認並啟用兩步驗證
+func EnableTOTP(tx *sql.Tx, userID int) error {
+	_, err := tx.Exec(
+		"UPDATE user_totp SET enabled = TRUE, enabled_at = ? WHERE user_id = ?",
+		time.Now(), userID,
+	)
+	return err
+}
+
+// DeleteTOTP 關閉兩步驗證並刪除恢復碼
+func DeleteTOTP(tx *sql.Tx, userID int) error {
+	if _, err := tx.Exec("DELETE FROM user_recovery_codes WHERE user_id = ?", userID); err != nil {
+		return err
+	}
+	_, err := tx.Exec("DELETE FROM user_totp WHERE user_id = ?", userID)
+	return err
+}
+
+// ReplaceRecoveryCodes 以新的一組恢復碼雜湊替換舊的
+func ReplaceRecoveryCodes(tx *sql.Tx, userID int, codeHashes []string) error {
+	if _, err := tx.Exec("DELETE FROM user_recovery_codes WHERE user_id = ?", userID); err != nil {
+		return err
+	}
+	for _, hash := range codeHashes {
+		if _, err := tx.Exec(
+			"INSERT INTO user_recovery_codes (user_id, code_hash) VALUES (?, ?)",
+			userID, hash,
+		); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// UseRecoveryCode 消耗一個恢復碼，不存在或已使用時返回 false
+func UseRecoveryCode(tx *sql.Tx, userID int, codeHash string) (bool, error) {
+	result, err := tx.Exec(
+		"UPDATE user_recovery_codes SET used_at = ? WHERE user_id = ? AND code_hash = ? AND used_at IS NULL",
+		time.Now(), userID, codeHash,
+	)
+	if err != nil {
+		return false, err
+	}
+	affected, err := result.RowsAffected()
+	return affected == 1, err
+}
@@ -0,0 +1,320 @@
+package db
+
+import (
+	"baccarat/pkg/tracing"
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"strings"
+	"sync"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// maxStatementLength span 中記錄的 SQL 的最大長度
+const maxStatementLength = 2000
+
+// TransactionContext 與 Transaction 相同，事務及其中的語句記錄為 ctx 中 span 的子 span，
+// ctx 中沒有 span 時（後台任務）不記錄；請求取消不會中斷已開始的事務，與 Transaction 一致
+func TransactionContext(ctx context.Context, fn func(*sql.Tx) error) (err error) {
+	if trace.SpanContextFromContext(ctx).IsValid() {
+		var span trace.Span
+		ctx, span = tracing.Tracer().Start(ctx, "db.transaction", trace.WithAttributes(attribute.String("db.system", Driver())))
+		defer func() {
+			if err != nil {
+				span.RecordError(err)
+				span.SetStatus(codes.Error, "rolled back")
+			}
+			span.End()
+		}()
+	}
+
+	tx, err := DB.BeginTx(context.WithoutCancel(ctx), nil)
+	if err != nil {
+		return err
+	}
+
+	defer func() {
+		if p := recover(); p != nil {
+			tx.Rollback()
+			runAfterCommit(tx, false)
+			panic(p)
+		}
+	}()
+
+	if err := fn(tx); err != nil {
+		tx.Rollback()
+		runAfterCommit(tx, false)
+		return err
+	}
+
+	err = tx.Commit()
+	runAfterCommit(tx, err == nil)
+	return err
+}
+
+var (
+	afterCommitMu  sync.Mutex
+	afterCommitFns = make(map[*sql.Tx][]func())
+)
+
+// afterCommit 登記事務提交成功後執行的 fn，回滾時丟棄；只對 TransactionContext 開始的事務有效
+func afterCommit(tx *sql.Tx, fn func()) {
+	afterCommitMu.Lock()
+	afterCommitFns[tx] = append(afterCommitFns[tx], fn)
+	afterCommitMu.Unlock()
+}
+
+// runAfterCommit 事務結束時取出登記的回調，提交成功才執行
+func runAfterCommit(tx *sql.Tx, committed bool) {
+	afterCommitMu.Lock()
+	fns := afterCommitFns[tx]
+	delete(afterCommitFns, tx)
+	afterCommitMu.Unlock()
+	if committed {
+		for _, fn := range fns {
+			fn()
+		}
+	}
+}
+
+// openTraced 打開為每條語句記錄 span 的連接
+// 現有查詢大多不帶 context，事務內的語句以事務開始時的 context 作為父 span；
+// 沒有父 span 的語句（後台任務、事務外的查詢）不記錄，避免產生孤立的追蹤
+// 使用以 driverName 註冊的驅動實例，SQLite 的自定義函數註冊在該實例上
+func openTraced(driverName, dsn string) (*sql.DB, error) {
+	registered, err := sql.Open(driverName, "")
+	if err != nil {
+		return nil, err
+	}
+	d := registered.Driver()
+	registered.Close()
+
+	var connector driver.Connector = dsnConnector{driver: d, dsn: dsn}
+	if dc, ok := d.(driver.DriverContext); ok {
+		c, err := dc.OpenConnector(dsn)
+		if err != nil {
+			return nil, err
+		}
+		connector = c
+	}
+	return sql.OpenDB(tracedConnector{base: connector}), nil
+}
+
+// dsnConnector 為不支持 DriverContext 的驅動實現 driver.Connector
+type dsnConnector struct {
+	driver driver.Driver
+	dsn    string
+}
+
+func (c dsnConnector) Connect(context.Context) (driver.Conn, error) { return c.driver.Open(c.dsn) }
+func (c dsnConnector) Driver() driver.Driver                        { return c.driver }
+
+type tracedConnector struct {
+	base driver.Connector
+}
+
+func (c tracedConnector) Connect(ctx context.Context) (driver.Conn, error) {
+	conn, err := c.base.Connect(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return &tracedConn{Conn: conn}, nil
+}
+
+func (c tracedConnector) Driver() driver.Driver { return c.base.Driver() }
+
+// tracedConn 包裝驅動連接，database/sql 保證同一連接不會被並發使用
+type tracedConn struct {
+	driver.Conn
+	txCtx context.Context // 進行中的事務開始時的 context
+}
+
+// parent 返回語句的父 context：語句自帶 span 時用語句的，否則用所在事務的
+func (c *tracedConn) parent(ctx context.Context) context.Context {
+	if !trace.SpanContextFromContext(ctx).IsValid() && c.txCtx != nil {
+		return c.txCtx
+	}
+	return ctx
+}
+
+func (c *tracedConn) BeginTx(ctx context.Context, opts driver.TxOptions) (driver.Tx, error) {
+	var tx driver.Tx
+	var err error
+	if b, ok := c.Conn.(driver.ConnBeginTx); ok {
+		tx, err = b.BeginTx(ctx, opts)
+	} else {
+		tx, err = c.Conn.Begin()
+	}
+	if err != nil {
+		return nil, err
+	}
+	c.txCtx = ctx
+	return &tracedTx{Tx: tx, conn: c}, nil
+}
+
+func (c *tracedConn) PrepareContext(ctx context.Context, query string) (driver.Stmt, error) {
+	var stmt driver.Stmt
+	var err error
+	if p, ok := c.Conn.(driver.ConnPrepareContext); ok {
+		stmt, err = p.PrepareContext(ctx, query)
+	} else {
+		stmt, err = c.Conn.Prepare(query)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &tracedStmt{Stmt: stmt, conn: c, query: query}, nil
+}
+
+// ExecContext 驅動不能直接執行時返回 driver.ErrSkip，database/sql 改用 PrepareContext，由 tracedStmt 記錄
+func (c *tracedConn) ExecContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Result, error) {
+	e, ok := c.Conn.(driver.ExecerContext)
+	if !ok {
+		return nil, driver.ErrSkip
+	}
+	start := time.Now()
+	result, err := e.ExecContext(ctx, query, args)
+	if err != driver.ErrSkip {
+		recordStatement(c.parent(ctx), query, start, err)
+	}
+	return result, err
+}
+
+func (c *tracedConn) QueryContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Rows, error) {
+	q, ok := c.Conn.(driver.QueryerContext)
+	if !ok {
+		return nil, driver.ErrSkip
+	}
+	start := time.Now()
+	rows, err := q.QueryContext(ctx, query, args)
+	if err != driver.ErrSkip {
+		recordStatement(c.parent(ctx), query, start, err)
+	}
+	return rows, err
+}
+
+func (c *tracedConn) Ping(ctx context.Context) error {
+	if p, ok := c.Conn.(driver.Pinger); ok {
+		return p.Ping(ctx)
+	}
+	return nil
+}
+
+func (c *tracedConn) ResetSession(ctx context.Context) error {
+	if r, ok := c.Conn.(driver.SessionResetter); ok {
+		return r.ResetSession(ctx)
+	}
+	return nil
+}
+
+func (c *tracedConn) IsValid() bool {
+	if v, ok := c.Conn.(driver.Validator); ok {
+		return v.IsValid()
+	}
+	return true
+}
+
+func (c *tracedConn) CheckNamedValue(nv *driver.NamedValue) error {
+	if n, ok := c.Conn.(driver.NamedValueChecker); ok {
+		return n.CheckNamedValue(nv)
+	}
+	return driver.ErrSkip
+}
+
+type tracedTx struct {
+	driver.Tx
+	conn *tracedConn
+}
+
+func (t *tracedTx) Commit() error {
+	t.conn.txCtx = nil
+	return t.Tx.Commit()
+}
+
+func (t *tracedTx) Rollback() error {
+	t.conn.txCtx = nil
+	return t.Tx.Rollback()
+}
+
+type tracedStmt struct {
+	driver.Stmt
+	conn  *tracedConn
+	query string
+}
+
+func (s *tracedStmt) ExecContext(ctx context.Context, args []driver.NamedValue) (driver.Result, error) {
+	start := time.Now()
+	var result driver.Result
+	var err error
+	if e, ok := s.Stmt.(driver.StmtExecContext); ok {
+		result, err = e.ExecContext(ctx, args)
+	} else {
+		result, err = s.Stmt.Exec(namedValues(args))
+	}
+	recordStatement(s.conn.parent(ctx), s.query, start, err)
+	return result, err
+}
+
+func (s *tracedStmt) QueryContext(ctx context.Context, args []driver.NamedValue) (driver.Rows, error) {
+	start := time.Now()
+	var rows driver.Rows
+	var err error
+	if q, ok := s.Stmt.(driver.StmtQueryContext); ok {
+		rows, err = q.QueryContext(ctx, args)
+	} else {
+		rows, err = s.Stmt.Query(namedValues(args))
+	}
+	recordStatement(s.conn.parent(ctx), s.query, start, err)
+	return rows, err
+}
+
+func (s *tracedStmt) CheckNamedValue(nv *driver.NamedValue) error {
+	if n, ok := s.Stmt.(driver.NamedValueChecker); ok {
+		return n.CheckNamedValue(nv)
+	}
+	return s.conn.CheckNamedValue(nv)
+}
+
+// namedValues 轉換為不支持 context 的舊接口的參數
+func namedValues(args []driver.NamedValue) []driver.Value {
+	values := make([]driver.Value, len(args))
+	for i, arg := range args {
+		values[i] = arg.Value
+	}
+	return values
+}
+
+// recordStatement 為已執行完的語句補記 span，沒有父 span 時不記錄
+func recordStatement(ctx context.Context, query string, start time.Time, err error) {
+	if !trace.SpanContextFromContext(ctx).IsValid() {
+		return
+	}
+	statement := strings.Join(strings.Fields(query), " ")
+	if len(statement) > maxStatementLength {
+		statement = statement[:maxStatementLength]
+	}
+	operation := statement
+	if i := strings.IndexByte(statement, ' '); i > 0 {
+		operation = statement[:i]
+	}
+	operation = strings.ToUpper(operation)
+
+	_, span := tracing.Tracer().Start(ctx, operation,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithTimestamp(start),
+		trace.WithAttributes(
+			attribute.String("db.system", Driver()),
+			attribute.String("db.operation", operation),
+			attribute.String("db.statement", statement),
+		),
+	)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
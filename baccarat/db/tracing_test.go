@@ -0,0 +1,82 @@
+package db
+
+import (
+	"baccarat/internal/clock"
+	"baccarat/internal/replica"
+	"database/sql"
+	"errors"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestAfterCommit(t *testing.T) {
+	conn, err := OpenSQLite(filepath.Join(t.TempDir(), "test.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	previous := DB
+	DB = conn
+	defer func() { DB = previous }()
+
+	ran := 0
+	failed := errors.New("rollback")
+	err = Transaction(func(tx *sql.Tx) error {
+		afterCommit(tx, func() { ran++ })
+		return failed
+	})
+	if err != failed || ran != 0 {
+		t.Errorf("rolled back: err = %v, ran = %d", err, ran)
+	}
+
+	err = Transaction(func(tx *sql.Tx) error {
+		afterCommit(tx, func() { ran++ })
+		afterCommit(tx, func() { ran++ })
+		if ran != 0 {
+			t.Error("callback ran before commit")
+		}
+		return nil
+	})
+	if err != nil || ran != 2 {
+		t.Errorf("committed: err = %v, ran = %d", err, ran)
+	}
+	if len(afterCommitFns) != 0 {
+		t.Errorf("callbacks left registered: %d", len(afterCommitFns))
+	}
+}
+
+func TestMarkWriteAfterCommit(t *testing.T) {
+	conn, err := OpenSQLite(filepath.Join(t.TempDir(), "test.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	previous, previousReplicas := DB, replicas
+	DB = conn
+	replicas = replica.NewRouter(1, time.Second, time.Minute, clock.NewFake(time.Now()))
+	defer func() { DB, replicas = previous, previousReplicas }()
+	replicas.SetLag(0, 0, true)
+
+	// 回滾的寫入不影響路由；提交前仍讀副本，提交後該用戶的查詢走主庫
+	Transaction(func(tx *sql.Tx) error {
+		markWrite(tx, 1)
+		return errors.New("rollback")
+	})
+	if i := replicas.Pick(1); i != 0 {
+		t.Errorf("after rollback Pick = %d, want replica 0", i)
+	}
+	err = Transaction(func(tx *sql.Tx) error {
+		markWrite(tx, 1)
+		if i := replicas.Pick(1); i != 0 {
+			t.Errorf("before commit Pick = %d, want replica 0", i)
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if i := replicas.Pick(1); i != replica.Primary {
+		t.Errorf("after commit Pick = %d, want primary", i)
+	}
+}
@@ -0,0 +1,76 @@
+package game
+
+// Odds 從完整牌靴開一局時各結果的概率
+type Odds struct {
+	Player         float64
+	Banker         float64 // 莊家勝出，不含幸運6
+	Tie            float64
+	LuckySix2Cards float64 // 莊家以兩張牌 6 點勝出
+	LuckySix3Cards float64 // 莊家以三張牌 6 點勝出
+}
+
+// ComputeOdds 按補牌規則窮舉 decks 副牌的牌靴開局時所有可能的牌序，計算各結果的精確概率
+// 只區分點數，10、J、Q、K 合併為 0 點；補牌規則直接使用 DealFrom，與實際開牌一致
+func ComputeOdds(decks int) Odds {
+	var counts [10]int
+	total := 0
+	for value := range counts {
+		counts[value] = 4 * decks
+		if value == 0 {
+			counts[value] = 16 * decks
+		}
+		total += counts[value]
+	}
+
+	var odds Odds
+	cards := make([]Card, 6)
+	var walk func(depth int, p float64)
+	walk = func(depth int, p float64) {
+		// 已發的牌足以完成一局時，後面的牌不影響結果
+		if depth >= 4 {
+			g, drawn, err := DealFrom(cards, 0)
+			if err == nil && len(drawn) <= depth {
+				odds.add(g, p)
+				return
+			}
+		}
+		for value := range counts {
+			if counts[value] == 0 {
+				continue
+			}
+			cards[depth] = cardWithValue(value)
+			q := p * float64(counts[value]) / float64(total)
+			counts[value]--
+			total--
+			walk(depth+1, q)
+			counts[value]++
+			total++
+		}
+	}
+	walk(0, 1)
+	return odds
+}
+
+// add 計入一局結果的概率
+func (o *Odds) add(g *Game, p float64) {
+	switch {
+	case g.Winner == "Player":
+		o.Player += p
+	case g.Winner == "Tie":
+		o.Tie += p
+	case g.IsLuckySix && g.LuckySixType == "2cards":
+		o.LuckySix2Cards += p
+	case g.IsLuckySix:
+		o.LuckySix3Cards += p
+	default:
+		o.Banker += p
+	}
+}
+
+// cardWithValue 點數為 value 的任意一張牌
+func cardWithValue(value int) Card {
+	if value == 0 {
+		return Card{Suit: Spades, Value: 10}
+	}
+	return Card{Suit: Spades, Value: value}
+}
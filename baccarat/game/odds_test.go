@@ -0,0 +1,29 @@
+package game
+
+import (
+	"math"
+	"testing"
+)
+
+func TestComputeOdds(t *testing.T) {
+	odds := ComputeOdds(ShoeDecks)
+
+	// 8 副牌的公認概率：閒 0.446247、莊 0.458597、和 0.095156
+	banker := odds.Banker + odds.LuckySix2Cards + odds.LuckySix3Cards
+	for _, c := range []struct {
+		name      string
+		got, want float64
+	}{
+		{"player", odds.Player, 0.446247},
+		{"banker", banker, 0.458597},
+		{"tie", odds.Tie, 0.095156},
+		{"total", odds.Player + banker + odds.Tie, 1},
+	} {
+		if math.Abs(c.got-c.want) > 1e-6 {
+			t.Errorf("%s = %.6f, want %.6f", c.name, c.got, c.want)
+		}
+	}
+	if odds.LuckySix2Cards <= 0 || odds.LuckySix3Cards <= 0 || odds.LuckySix2Cards+odds.LuckySix3Cards > 0.06 {
+		t.Errorf("lucky six = %.6f / %.6f", odds.LuckySix2Cards, odds.LuckySix3Cards)
+	}
+}
@@ -0,0 +1,164 @@
+package game
+
+import (
+	"errors"
+	"strings"
+)
+
+var (
+	ErrInvalidCard   = errors.New("invalid card")
+	ErrInvalidHands  = errors.New("hands do not follow drawing rules")
+	ErrDeckExhausted = errors.New("fixed deck exhausted")
+)
+
+var (
+	cardSuits  = map[byte]Suit{'S': Spades, 'H': Hearts, 'D': Diamonds, 'C': Clubs}
+	cardValues = map[string]int{
+		"A": 1, "2": 2, "3": 3, "4": 4, "5": 5, "6": 6, "7": 7,
+		"8": 8, "9": 9, "10": 10, "J": 11, "Q": 12, "K": 13,
+	}
+)
+
+// ParseCard 解析 "S7"、"H10"、"CK" 格式的牌
+func ParseCard(s string) (Card, error) {
+	s = strings.TrimSpace(s)
+	if len(s) < 2 {
+		return Card{}, ErrInvalidCard
+	}
+	suit, ok := cardSuits[s[0]]
+	if !ok {
+		return Card{}, ErrInvalidCard
+	}
+	value, ok := cardValues[s[1:]]
+	if !ok {
+		return Card{}, ErrInvalidCard
+	}
+	return Card{Suit: suit, Value: value}, nil
+}
+
+// ParseCards 解析逗號分隔的多張牌
+func ParseCards(s string) ([]Card, error) {
+	var cards []Card
+	for _, part := range strings.Split(s, ",") {
+		card, err := ParseCard(part)
+		if err != nil {
+			return nil, err
+		}
+		cards = append(cards, card)
+	}
+	return cards, nil
+}
+
+// String 返回 "S7"、"H10"、"CK" 格式
+func (c Card) String() string {
+	suits := []string{"S", "H", "D", "C"} // Spades, Hearts, Diamonds, Clubs
+	values := []string{"A", "2", "3", "4", "5", "6", "7", "8", "9", "10", "J", "Q", "K"}
+	return suits[c.Suit] + values[c.Value-1]
+}
+
+// FormatCards 以逗號連接多張牌，與 ParseCards 互逆
+func FormatCards(cards []Card) string {
+	parts := make([]string, len(cards))
+	for i, card := range cards {
+		parts[i] = card.String()
+	}
+	return strings.Join(parts, ",")
+}
+
+// FixedDeck 按給定順序發牌的牌組，用於重放和修正牌局，不洗牌
+type FixedDeck struct {
+	cards []Card
+	next  int
+}
+
+// NewFixedDeck 創建按順序發牌的牌組
+func NewFixedDeck(cards []Card) *FixedDeck {
+	return &FixedDeck{cards: cards}
+}
+
+// DrawCard 按順序抽牌，牌用完時 panic（與 Deck 一致）
+func (d *FixedDeck) DrawCard() Card {
+	if d.next >= len(d.cards) {
+		panic(ErrDeckExhausted)
+	}
+	card := d.cards[d.next]
+	d.next++
+	return card
+}
+
+// Shuffle 固定順序牌組不洗牌
+func (d *FixedDeck) Shuffle() {}
+
+// GetCards 返回剩餘未發的牌
+func (d *FixedDeck) GetCards() []Card {
+	return d.cards[d.next:]
+}
+
+// Replay 以給定的閒、莊手牌按補牌規則重新進行一局，
+// 手牌張數與補牌規則不符時返回 ErrInvalidHands
+func Replay(player, banker []Card) (*Game, error) {
+	if len(player) < 2 || len(player) > 3 || len(banker) < 2 || len(banker) > 3 {
+		return nil, ErrInvalidHands
+	}
+
+	// 發牌順序：閒、閒、莊、莊，然後閒家補牌、莊家補牌
+	order := []Card{player[0], player[1], banker[0], banker[1]}
+	if len(player) == 3 {
+		order = append(order, player[2])
+	}
+	if len(banker) == 3 {
+		order = append(order, banker[2])
+	}
+
+	deck := NewFixedDeck(order)
+	g := &Game{
+		Deck:             deck,
+		PlayerThirdValue: -1,
+		BankerThirdValue: -1,
+		Payouts:          make(map[string]float64),
+	}
+
+	var err error
+	func() {
+		// 規則要求補牌但沒有提供第三張牌
+		defer func() {
+			if recover() != nil {
+				err = ErrInvalidHands
+			}
+		}()
+		g.Play()
+	}()
+	if err != nil {
+		return nil, err
+	}
+	// 提供了規則不允許的第三張牌
+	if len(deck.GetCards()) > 0 || len(g.PlayerHand.Cards) != len(player) || len(g.BankerHand.Cards) != len(banker) {
+		return nil, ErrInvalidHands
+	}
+	return g, nil
+}
+
+// BetReturn 單筆下注返還給玩家的金額（含本金），betType 為 player、banker、tie、luckySix
+func (g *Game) BetReturn(betType string, amount float64) float64 {
+	var bets struct {
+		Player    float64 `json:"player"`
+		Banker    float64 `json:"banker"`
+		Tie       float64 `json:"tie"`
+		LuckySix  float64 `json:"luckySix"`
+		RUN_TIMES string  `json:"RUN_TIMES"`
+	}
+	switch betType {
+	case "player":
+		bets.Player = amount
+	case "banker":
+		bets.Banker = amount
+	case "tie":
+		bets.Tie = amount
+	case "luckySix":
+		bets.LuckySix = amount
+	default:
+		return 0
+	}
+	payouts := g.GetPayouts(bets)
+	return payouts[betType] + payouts[betType+"_principal"]
+}
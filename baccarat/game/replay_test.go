@@ -0,0 +1,105 @@
+package game
+
+import (
+	"baccarat/config"
+	"testing"
+)
+
+func mustCards(t *testing.T, s string) []Card {
+	t.Helper()
+	cards, err := ParseCards(s)
+	if err != nil {
+		t.Fatalf("ParseCards(%q): %v", s, err)
+	}
+	return cards
+}
+
+func TestParseCard(t *testing.T) {
+	tests := []struct {
+		in   string
+		want Card
+	}{
+		{"S7", Card{Suit: Spades, Value: 7}},
+		{"H10", Card{Suit: Hearts, Value: 10}},
+		{"CK", Card{Suit: Clubs, Value: 13}},
+		{" DA ", Card{Suit: Diamonds, Value: 1}},
+	}
+	for _, tt := range tests {
+		got, err := ParseCard(tt.in)
+		if err != nil || got != tt.want {
+			t.Errorf("ParseCard(%q) = %v, %v, want %v", tt.in, got, err, tt.want)
+		}
+	}
+	for _, in := range []string{"", "S", "X7", "S1", "S11", "s7"} {
+		if _, err := ParseCard(in); err != ErrInvalidCard {
+			t.Errorf("ParseCard(%q) error = %v, want ErrInvalidCard", in, err)
+		}
+	}
+}
+
+func TestReplay(t *testing.T) {
+	// 閒家 8 點天牌，雙方都不補牌
+	g, err := Replay(mustCards(t, "S8,HK"), mustCards(t, "D3,C2"))
+	if err != nil {
+		t.Fatalf("Replay natural: %v", err)
+	}
+	if g.GetWinner() != "Player" || g.GetPlayerScore() != 8 || g.GetBankerScore() != 5 {
+		t.Errorf("natural: winner %s, scores %d-%d", g.GetWinner(), g.GetPlayerScore(), g.GetBankerScore())
+	}
+
+	// 閒家 3 點補牌 5，莊家 4 點且閒家第三張為 5 時補牌
+	g, err = Replay(mustCards(t, "S2,HA,D5"), mustCards(t, "C4,SK,H2"))
+	if err != nil {
+		t.Fatalf("Replay with third cards: %v", err)
+	}
+	if g.GetWinner() != "Player" || g.GetPlayerScore() != 8 || g.GetBankerScore() != 6 {
+		t.Errorf("third cards: winner %s, scores %d-%d", g.GetWinner(), g.GetPlayerScore(), g.GetBankerScore())
+	}
+}
+
+func TestReplayInvalidHands(t *testing.T) {
+	tests := []struct {
+		name           string
+		player, banker string
+	}{
+		{"missing player third card", "S2,HA", "C7,SK"},
+		{"third card after natural", "S8,HK,D5", "D3,C2"},
+		{"banker third card not allowed", "S2,HA,D8", "C3,SK,H2"},
+		{"single card", "S2", "C3,SK"},
+	}
+	for _, tt := range tests {
+		if _, err := Replay(mustCards(t, tt.player), mustCards(t, tt.banker)); err != ErrInvalidHands {
+			t.Errorf("%s: error = %v, want ErrInvalidHands", tt.name, err)
+		}
+	}
+}
+
+func TestBetReturn(t *testing.T) {
+	config.AppConfig.PlayerPayout = 1.0
+	config.AppConfig.BankerPayout = 1.0
+	config.AppConfig.TiePayout = 8.0
+
+	// 閒家贏
+	g, err := Replay(mustCards(t, "S8,HK"), mustCards(t, "D3,C2"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := g.BetReturn("player", 100); got != 200 {
+		t.Errorf("player return = %v, want 200", got)
+	}
+	if got := g.BetReturn("banker", 100); got != 0 {
+		t.Errorf("banker return = %v, want 0", got)
+	}
+
+	// 和局退回閒、莊本金
+	g, err = Replay(mustCards(t, "S8,HK"), mustCards(t, "D4,C4"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := g.BetReturn("banker", 50); got != 50 {
+		t.Errorf("banker return on tie = %v, want 50", got)
+	}
+	if got := g.BetReturn("tie", 10); got != 80 {
+		t.Errorf("tie return = %v, want 80", got)
+	}
+}
@@ -0,0 +1,152 @@
+package game
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	mrand "math/rand/v2"
+)
+
+const (
+	// ShoeDecks 每個牌靴的副數
+	ShoeDecks = 8
+	// CutCardReserve 切牌位置：牌靴剩餘少於此張數時不再開新局，換新牌靴
+	CutCardReserve = 16
+	// SeedSize 洗牌種子的字節數
+	SeedSize = 32
+)
+
+var ErrInvalidSeed = errors.New("invalid shoe seed")
+
+// Shoe 一個洗好的牌靴
+// 同一種子總是洗出相同牌序，牌序和種子一起保存後任何一局都可以重新發牌核對
+type Shoe struct {
+	Seed   []byte
+	Cards  []Card
+	Burned int // 開靴時燒掉的張數（含翻開的第一張），第一局從此位置開始發牌
+}
+
+// NewShoe 以種子洗 decks 副牌並燒牌
+// 洗牌使用 math/rand/v2 的 ChaCha8，輸出序列固定，更換算法會使舊牌靴無法由種子重現
+func NewShoe(seed []byte, decks int) (*Shoe, error) {
+	if len(seed) != SeedSize {
+		return nil, ErrInvalidSeed
+	}
+	var key [SeedSize]byte
+	copy(key[:], seed)
+
+	cards := make([]Card, 0, decks*52)
+	for i := 0; i < decks; i++ {
+		cards = append(cards, NewDeck().Cards...)
+	}
+	r := mrand.New(mrand.NewChaCha8(key))
+	r.Shuffle(len(cards), func(i, j int) {
+		cards[i], cards[j] = cards[j], cards[i]
+	})
+
+	// 翻開第一張，按其點數燒牌（10、J、Q、K 燒 10 張）
+	burn := cards[0].Value
+	if burn > 10 {
+		burn = 10
+	}
+	return &Shoe{Seed: key[:], Cards: cards, Burned: 1 + burn}, nil
+}
+
+// NewRandomShoe 以隨機種子創建牌靴
+func NewRandomShoe(decks int) (*Shoe, error) {
+	seed := make([]byte, SeedSize)
+	if _, err := rand.Read(seed); err != nil {
+		return nil, err
+	}
+	return NewShoe(seed, decks)
+}
+
+// HashCards 牌序的 SHA-256（十六進制），按 FormatCards 的文本計算
+func HashCards(cards []Card) string {
+	sum := sha256.Sum256([]byte(FormatCards(cards)))
+	return hex.EncodeToString(sum[:])
+}
+
+// DealFrom 從牌序的 position 處按補牌規則進行一局，返回遊戲和按發牌順序抽出的牌
+// 剩餘的牌不足以完成一局時返回 ErrDeckExhausted
+func DealFrom(cards []Card, position int) (*Game, []Card, error) {
+	if position < 0 || position > len(cards) {
+		return nil, nil, ErrDeckExhausted
+	}
+	deck := NewFixedDeck(cards[position:])
+	g := &Game{
+		Deck:             deck,
+		PlayerThirdValue: -1,
+		BankerThirdValue: -1,
+		Payouts:          make(map[string]float64),
+	}
+
+	var err error
+	func() {
+		defer func() {
+			if recover() != nil {
+				err = ErrDeckExhausted
+			}
+		}()
+		g.Play()
+	}()
+	if err != nil {
+		return nil, nil, err
+	}
+	drawn := len(cards) - position - len(deck.GetCards())
+	return g, cards[position : position+drawn], nil
+}
+
+// RoundAudit 核對一局所需的記錄：牌靴的種子、牌序和雜湊，以及該局的位置、發出的牌和結果
+type RoundAudit struct {
+	Seed        []byte
+	ShoeCards   []Card
+	ShoeHash    string
+	Position    int
+	Drawn       []Card
+	PlayerCards []Card
+	BankerCards []Card
+	Winner      string
+}
+
+// Verification 核對結果
+type Verification struct {
+	HashMatches bool `json:"hashMatches"`         // 牌序與保存的雜湊一致
+	SeedMatches bool `json:"seedMatches"`         // 種子重新洗出相同牌序
+	DrawMatches bool `json:"drawMatches"`         // 從該位置重新發出的牌與記錄的發牌順序一致
+	HandMatches bool `json:"handMatches"`         // 重新發牌得到的手牌和勝方與牌局記錄一致
+	DealtOnly   bool `json:"dealtOnly,omitempty"` // 牌靴仍在使用中，只核對已發出的牌，不核對種子
+}
+
+// OK 全部核對通過
+func (v Verification) OK() bool {
+	return v.HashMatches && (v.SeedMatches || v.DealtOnly) && v.DrawMatches && v.HandMatches
+}
+
+// Verify 由種子重新洗牌並從記錄的位置重新發牌，逐張核對
+func (a RoundAudit) Verify() Verification {
+	v := a.VerifyDealt()
+	v.DealtOnly = false
+	if shoe, err := NewShoe(a.Seed, len(a.ShoeCards)/52); err == nil {
+		v.SeedMatches = len(a.ShoeCards)%52 == 0 && FormatCards(shoe.Cards) == FormatCards(a.ShoeCards)
+	}
+	return v
+}
+
+// VerifyDealt 不使用種子，只核對牌序雜湊和該局從記錄的位置發出的牌；
+// 用於仍在發牌的牌靴，此時種子和完整牌序不能公開
+func (a RoundAudit) VerifyDealt() Verification {
+	v := Verification{DealtOnly: true}
+	v.HashMatches = HashCards(a.ShoeCards) == a.ShoeHash
+
+	g, drawn, err := DealFrom(a.ShoeCards, a.Position)
+	if err != nil {
+		return v
+	}
+	v.DrawMatches = FormatCards(drawn) == FormatCards(a.Drawn)
+	v.HandMatches = FormatCards(g.GetPlayerHand()) == FormatCards(a.PlayerCards) &&
+		FormatCards(g.GetBankerHand()) == FormatCards(a.BankerCards) &&
+		g.GetWinner() == a.Winner
+	return v
+}
@@ -0,0 +1,164 @@
+package game
+
+import (
+	"bytes"
+	"testing"
+)
+
+func testSeed(b byte) []byte {
+	return bytes.Repeat([]byte{b}, SeedSize)
+}
+
+func TestNewShoe(t *testing.T) {
+	shoe, err := NewShoe(testSeed(1), ShoeDecks)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(shoe.Cards) != ShoeDecks*52 {
+		t.Fatalf("shoe has %d cards", len(shoe.Cards))
+	}
+
+	// 每張牌恰好出現 ShoeDecks 次
+	counts := map[Card]int{}
+	for _, card := range shoe.Cards {
+		counts[card]++
+	}
+	if len(counts) != 52 {
+		t.Errorf("shoe has %d distinct cards, want 52", len(counts))
+	}
+	for card, n := range counts {
+		if n != ShoeDecks {
+			t.Errorf("%v appears %d times", card, n)
+		}
+	}
+
+	// 燒牌張數由第一張的點數決定
+	burn := shoe.Cards[0].Value
+	if burn > 10 {
+		burn = 10
+	}
+	if shoe.Burned != 1+burn {
+		t.Errorf("Burned = %d, first card %v", shoe.Burned, shoe.Cards[0])
+	}
+
+	// 相同種子洗出相同牌序，不同種子不同
+	again, _ := NewShoe(testSeed(1), ShoeDecks)
+	if FormatCards(again.Cards) != FormatCards(shoe.Cards) {
+		t.Error("same seed produced a different order")
+	}
+	other, _ := NewShoe(testSeed(2), ShoeDecks)
+	if FormatCards(other.Cards) == FormatCards(shoe.Cards) {
+		t.Error("different seeds produced the same order")
+	}
+
+	if _, err := NewShoe([]byte("short"), ShoeDecks); err != ErrInvalidSeed {
+		t.Errorf("short seed error = %v, want ErrInvalidSeed", err)
+	}
+}
+
+func TestFormatCards(t *testing.T) {
+	in := "S7,H10,CK,DA"
+	cards := mustCards(t, in)
+	if got := FormatCards(cards); got != in {
+		t.Errorf("FormatCards = %q, want %q", got, in)
+	}
+	if len(HashCards(cards)) != 64 || HashCards(cards) == HashCards(cards[1:]) {
+		t.Errorf("HashCards = %q", HashCards(cards))
+	}
+}
+
+func TestDealFrom(t *testing.T) {
+	// 前兩張屬於上一局；閒家 3 點補牌 5，莊家 4 點補牌，剩餘的牌留給下一局
+	order := mustCards(t, "SK,SQ,S2,HA,C4,SK,D5,H2,C9,C8")
+	g, drawn, err := DealFrom(order, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := FormatCards(drawn); got != "S2,HA,C4,SK,D5,H2" {
+		t.Errorf("drawn = %s", got)
+	}
+	if FormatCards(g.GetPlayerHand()) != "S2,HA,D5" || FormatCards(g.GetBankerHand()) != "C4,SK,H2" || g.GetWinner() != "Player" {
+		t.Errorf("player %v, banker %v, winner %s", g.GetPlayerHand(), g.GetBankerHand(), g.GetWinner())
+	}
+
+	// 雙方 7 點都不補牌，只發四張
+	_, drawn, err = DealFrom(order, 6)
+	if err != nil || FormatCards(drawn) != "D5,H2,C9,C8" {
+		t.Errorf("standing drawn = %v, %v", drawn, err)
+	}
+
+	// 剩餘的牌不足以完成一局
+	if _, _, err := DealFrom(order, 7); err != ErrDeckExhausted {
+		t.Errorf("short shoe error = %v, want ErrDeckExhausted", err)
+	}
+}
+
+func TestRoundAuditVerify(t *testing.T) {
+	shoe, err := NewShoe(testSeed(7), ShoeDecks)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// 按牌靴依次發兩局，核對第二局
+	_, first, err := DealFrom(shoe.Cards, shoe.Burned)
+	if err != nil {
+		t.Fatal(err)
+	}
+	position := shoe.Burned + len(first)
+	g, drawn, err := DealFrom(shoe.Cards, position)
+	if err != nil {
+		t.Fatal(err)
+	}
+	audit := RoundAudit{
+		Seed:        shoe.Seed,
+		ShoeCards:   shoe.Cards,
+		ShoeHash:    HashCards(shoe.Cards),
+		Position:    position,
+		Drawn:       drawn,
+		PlayerCards: g.GetPlayerHand(),
+		BankerCards: g.GetBankerHand(),
+		Winner:      g.GetWinner(),
+	}
+	if v := audit.Verify(); !v.OK() || v.DealtOnly {
+		t.Fatalf("Verify = %+v", v)
+	}
+
+	// 不使用種子也能核對已發出的牌
+	dealt := audit
+	dealt.Seed = nil
+	if v := dealt.VerifyDealt(); !v.OK() || v.SeedMatches || !v.DealtOnly {
+		t.Errorf("VerifyDealt = %+v", v)
+	}
+	dealt.Position = position + 1
+	if v := dealt.VerifyDealt(); v.OK() {
+		t.Errorf("VerifyDealt at wrong position = %+v", v)
+	}
+
+	tampered := audit
+	tampered.ShoeHash = HashCards(shoe.Cards[1:])
+	if v := tampered.Verify(); v.HashMatches || !v.SeedMatches {
+		t.Errorf("wrong hash: %+v", v)
+	}
+
+	// 交換牌序中的兩張：雜湊和種子都對不上
+	tampered = audit
+	tampered.ShoeCards = append([]Card(nil), shoe.Cards...)
+	tampered.ShoeCards[0], tampered.ShoeCards[1] = tampered.ShoeCards[1], tampered.ShoeCards[0]
+	if v := tampered.Verify(); v.HashMatches || v.SeedMatches {
+		t.Errorf("swapped cards: %+v", v)
+	}
+
+	tampered = audit
+	tampered.Position = position + 1
+	if v := tampered.Verify(); v.DrawMatches {
+		t.Errorf("wrong position: %+v", v)
+	}
+
+	tampered = audit
+	tampered.Winner = "Tie"
+	if g.GetWinner() != "Tie" {
+		if v := tampered.Verify(); v.HandMatches || !v.DrawMatches {
+			t.Errorf("wrong winner: %+v", v)
+		}
+	}
+}
@@ -0,0 +1,99 @@
+// Package adjustment 人工調整餘額的原因代碼和雙人審批規則
+package adjustment
+
+import (
+	"errors"
+	"math"
+	"time"
+)
+
+// MaxAmount 單筆調整金額上限
+const MaxAmount = 1000000
+
+var (
+	ErrInvalidReason = errors.New("invalid reason code")
+	ErrInvalidAmount = errors.New("invalid adjustment amount")
+	ErrNotPending    = errors.New("adjustment is not pending")
+	ErrSelfApproval  = errors.New("adjustment must be approved by another admin")
+)
+
+// Reason 調整原因代碼
+type Reason string
+
+const (
+	ReasonGoodwill   Reason = "goodwill"   // 客訴補償
+	ReasonCorrection Reason = "correction" // 修正錯誤入帳
+	ReasonChargeback Reason = "chargeback" // 支付退單
+	ReasonPromotion  Reason = "promotion"  // 活動獎勵
+	ReasonOther      Reason = "other"      // 其他，需在備註中說明
+)
+
+var reasons = map[Reason]bool{
+	ReasonGoodwill:   true,
+	ReasonCorrection: true,
+	ReasonChargeback: true,
+	ReasonPromotion:  true,
+	ReasonOther:      true,
+}
+
+// ParseReason 解析原因代碼
+func ParseReason(s string) (Reason, error) {
+	reason := Reason(s)
+	if !reasons[reason] {
+		return "", ErrInvalidReason
+	}
+	return reason, nil
+}
+
+// Status 調整狀態
+type Status string
+
+const (
+	StatusPending  Status = "pending"
+	StatusApplied  Status = "applied"
+	StatusRejected Status = "rejected"
+)
+
+// Adjustment 一筆人工調整
+type Adjustment struct {
+	ID           int64      `json:"id"`
+	UserID       int        `json:"userId"`
+	Username     string     `json:"username,omitempty"`
+	Amount       float64    `json:"amount"`
+	Reason       Reason     `json:"reasonCode"`
+	Note         string     `json:"note"`
+	Status       Status     `json:"status"`
+	RequestedBy  int        `json:"requestedBy"`
+	DecidedBy    *int       `json:"decidedBy,omitempty"`
+	DecisionNote string     `json:"decisionNote,omitempty"`
+	CreatedAt    time.Time  `json:"createdAt"`
+	DecidedAt    *time.Time `json:"decidedAt,omitempty"`
+}
+
+// ValidateAmount 金額不能為零、最多兩位小數且不超過上限
+func ValidateAmount(amount float64) error {
+	if amount == 0 || math.IsNaN(amount) || math.Abs(amount) > MaxAmount {
+		return ErrInvalidAmount
+	}
+	if math.Abs(amount*100-math.Round(amount*100)) > 1e-6 {
+		return ErrInvalidAmount
+	}
+	return nil
+}
+
+// RequiresApproval 金額絕對值加上窗口內已自動入帳的累計金額 applied 超過門檻時需要第二名管理員審批，
+// 防止把大額調整拆成多筆小額繞過審批
+func RequiresApproval(amount, applied, threshold float64) bool {
+	return math.Abs(amount)+applied > threshold
+}
+
+// CanDecide 檢查管理員能否審批該調整：必須待審批，且不能由發起人自己審批
+func (a *Adjustment) CanDecide(adminID int) error {
+	if a.Status != StatusPending {
+		return ErrNotPending
+	}
+	if a.RequestedBy == adminID {
+		return ErrSelfApproval
+	}
+	return nil
+}
@@ -0,0 +1,69 @@
+package adjustment
+
+import "testing"
+
+func TestParseReason(t *testing.T) {
+	if r, err := ParseReason("goodwill"); err != nil || r != ReasonGoodwill {
+		t.Errorf("ParseReason(goodwill) = %v, %v", r, err)
+	}
+	for _, s := range []string{"", "Goodwill", "refund"} {
+		if _, err := ParseReason(s); err != ErrInvalidReason {
+			t.Errorf("ParseReason(%q) error = %v, want ErrInvalidReason", s, err)
+		}
+	}
+}
+
+func TestValidateAmount(t *testing.T) {
+	tests := []struct {
+		amount float64
+		valid  bool
+	}{
+		{100, true},
+		{-50.25, true},
+		{0.01, true},
+		{0, false},
+		{10.005, false},
+		{MaxAmount, true},
+		{-MaxAmount - 1, false},
+	}
+	for _, tt := range tests {
+		err := ValidateAmount(tt.amount)
+		if (err == nil) != tt.valid {
+			t.Errorf("ValidateAmount(%v) error = %v, want valid %v", tt.amount, err, tt.valid)
+		}
+	}
+}
+
+func TestRequiresApproval(t *testing.T) {
+	if RequiresApproval(1000, 0, 1000) {
+		t.Error("amount equal to threshold should not require approval")
+	}
+	if !RequiresApproval(1000.01, 0, 1000) {
+		t.Error("amount above threshold should require approval")
+	}
+	if !RequiresApproval(-5000, 0, 1000) {
+		t.Error("large debit should require approval")
+	}
+	// 拆成多筆小額時按累計金額判斷
+	if RequiresApproval(400, 600, 1000) {
+		t.Error("cumulative amount equal to threshold should not require approval")
+	}
+	if !RequiresApproval(-400, 700, 1000) {
+		t.Error("cumulative amount above threshold should require approval")
+	}
+}
+
+func TestCanDecide(t *testing.T) {
+	a := &Adjustment{Status: StatusPending, RequestedBy: 1}
+	if err := a.CanDecide(1); err != ErrSelfApproval {
+		t.Errorf("CanDecide(requester) error = %v, want ErrSelfApproval", err)
+	}
+	if err := a.CanDecide(2); err != nil {
+		t.Errorf("CanDecide(other) error = %v", err)
+	}
+
+	a.Status = StatusApplied
+	if err := a.CanDecide(2); err != ErrNotPending {
+		t.Errorf("CanDecide(applied) error = %v, want ErrNotPending", err)
+	}
+}
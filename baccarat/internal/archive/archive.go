@@ -0,0 +1,87 @@
+// Package archive 把超過保留期的牌局和下注移到歸檔表
+//
+// 歸檔後的數據仍可查詢：歷史記錄、對帳單、錢包流水、牌局詳情和報表重建經 db 包的 unionArchived
+// 分別查詢在線表和歸檔表後合併，不經過視圖；game_records_all、bets_all 視圖只供驗證工具使用。
+// 已歸檔的牌局不能再作廢或重新結算
+package archive
+
+import (
+	"baccarat/db"
+	"baccarat/internal/clock"
+	"baccarat/pkg/logger"
+	"context"
+	"time"
+)
+
+// batchSize 每個事務最多歸檔的局數
+const batchSize = 500
+
+// MinRetention 最短保留期，限額按自然月統計下注時只查詢在線表
+const MinRetention = 35 * 24 * time.Hour
+
+// Store 移動牌局和下注
+type Store interface {
+	// ArchiveBefore 歸檔 cutoff 之前最早的至多 limit 局，返回歸檔的局數
+	ArchiveBefore(cutoff time.Time, limit int) (int, error)
+}
+
+// Job 定期歸檔超過保留期的牌局
+type Job struct {
+	Interval  time.Duration
+	Retention time.Duration
+	store     Store
+	clock     clock.Clock
+}
+
+// NewJob 創建歸檔任務，保留期短於 MinRetention 時按 MinRetention 計算
+func NewJob(interval, retention time.Duration, store Store, clock clock.Clock) *Job {
+	if retention < MinRetention {
+		retention = MinRetention
+	}
+	return &Job{
+		Interval:  interval,
+		Retention: retention,
+		store:     store,
+		clock:     clock,
+	}
+}
+
+// RunOnce 分批歸檔直到沒有超過保留期的牌局，返回歸檔的局數
+func (j *Job) RunOnce() (int, error) {
+	cutoff := j.clock.Now().Add(-j.Retention)
+	total := 0
+	for {
+		n, err := j.store.ArchiveBefore(cutoff, batchSize)
+		total += n
+		if err != nil || n < batchSize {
+			return total, err
+		}
+	}
+}
+
+// Run 按 Interval 循環執行，直到 ctx 取消
+func (j *Job) Run(ctx context.Context) {
+	ticker := time.NewTicker(j.Interval)
+	defer ticker.Stop()
+
+	for {
+		if n, err := j.RunOnce(); err != nil {
+			logger.Error("Archiving rounds failed after", n, "rounds:", err)
+		} else if n > 0 {
+			logger.Info("Archived", n, "rounds")
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// DBStore 以資料庫的歸檔表保存
+type DBStore struct{}
+
+// ArchiveBefore 把牌局及其下注移到歸檔表
+func (DBStore) ArchiveBefore(cutoff time.Time, limit int) (int, error) {
+	return db.ArchiveRounds(cutoff, limit)
+}
@@ -0,0 +1,71 @@
+package archive
+
+import (
+	"baccarat/internal/clock"
+	"errors"
+	"testing"
+	"time"
+)
+
+// fakeStore 保存待歸檔的局數，每次最多移走 limit 局
+type fakeStore struct {
+	pending int
+	cutoffs []time.Time
+	failAt  int // 第幾次調用時返回錯誤，0 表示不失敗
+}
+
+func (s *fakeStore) ArchiveBefore(cutoff time.Time, limit int) (int, error) {
+	s.cutoffs = append(s.cutoffs, cutoff)
+	if len(s.cutoffs) == s.failAt {
+		return 0, errors.New("archive failed")
+	}
+	n := limit
+	if s.pending < n {
+		n = s.pending
+	}
+	s.pending -= n
+	return n, nil
+}
+
+func TestRunOnceInBatches(t *testing.T) {
+	now := time.Date(2025, 6, 1, 12, 0, 0, 0, time.UTC)
+	store := &fakeStore{pending: 2*batchSize + 7}
+	job := NewJob(time.Hour, 90*24*time.Hour, store, clock.NewFake(now))
+
+	n, err := job.RunOnce()
+	if err != nil || n != 2*batchSize+7 {
+		t.Fatalf("RunOnce = %d, %v", n, err)
+	}
+	if len(store.cutoffs) != 3 {
+		t.Fatalf("expected 3 batches, got %d", len(store.cutoffs))
+	}
+	want := now.Add(-90 * 24 * time.Hour)
+	for _, cutoff := range store.cutoffs {
+		if !cutoff.Equal(want) {
+			t.Errorf("cutoff %v, want %v", cutoff, want)
+		}
+	}
+
+	// 沒有待歸檔的牌局時只查詢一次
+	store.cutoffs = nil
+	if n, err := job.RunOnce(); err != nil || n != 0 || len(store.cutoffs) != 1 {
+		t.Errorf("RunOnce with nothing pending = %d, %v, %d calls", n, err, len(store.cutoffs))
+	}
+}
+
+func TestRunOnceStopsOnError(t *testing.T) {
+	store := &fakeStore{pending: 3 * batchSize, failAt: 2}
+	job := NewJob(time.Hour, 90*24*time.Hour, store, clock.NewFake(time.Now()))
+
+	n, err := job.RunOnce()
+	if err == nil || n != batchSize || len(store.cutoffs) != 2 {
+		t.Errorf("RunOnce = %d, %v after %d calls", n, err, len(store.cutoffs))
+	}
+}
+
+func TestMinRetention(t *testing.T) {
+	job := NewJob(time.Hour, 24*time.Hour, &fakeStore{}, clock.NewFake(time.Time{}))
+	if job.Retention != MinRetention {
+		t.Errorf("Retention = %v, want %v", job.Retention, MinRetention)
+	}
+}
@@ -0,0 +1,126 @@
+package auth
+
+import (
+	"baccarat/internal/clock"
+	"errors"
+	"strings"
+	"sync"
+	"time"
+)
+
+var (
+	ErrInvalidAPIKey = errors.New("invalid api key")
+	ErrInvalidScope  = errors.New("invalid api key scope")
+)
+
+// APIKeyPrefix API 金鑰的固定前綴，便於在日誌和代碼倉庫中識別洩露的金鑰
+const APIKeyPrefix = "bk_"
+
+// API 金鑰可授予的範圍，每個範圍對應一個路由權限
+var apiKeyScopes = map[string]Permission{
+	"play":         PermGamePlay,
+	"read-history": PermHistoryRead,
+	"wallet":       PermWallet,
+}
+
+// GenerateAPIKey 生成新的 API 金鑰，返回明文、雜湊和用於展示的前綴
+func GenerateAPIKey() (string, string, string, error) {
+	token, _, err := GenerateOpaqueToken()
+	if err != nil {
+		return "", "", "", err
+	}
+	key := APIKeyPrefix + token
+	return key, HashToken(key), key[:len(APIKeyPrefix)+6], nil
+}
+
+// ParseAPIKeyScopes 驗證範圍列表並去重
+func ParseAPIKeyScopes(scopes []string) ([]string, error) {
+	if len(scopes) == 0 {
+		return nil, ErrInvalidScope
+	}
+	seen := make(map[string]bool)
+	var result []string
+	for _, scope := range scopes {
+		scope = strings.TrimSpace(scope)
+		if _, ok := apiKeyScopes[scope]; !ok {
+			return nil, ErrInvalidScope
+		}
+		if !seen[scope] {
+			seen[scope] = true
+			result = append(result, scope)
+		}
+	}
+	return result, nil
+}
+
+// ScopePermissions 將範圍轉換為權限，忽略未知範圍
+func ScopePermissions(scopes []string) []Permission {
+	perms := make([]Permission, 0, len(scopes))
+	for _, scope := range scopes {
+		if perm, ok := apiKeyScopes[scope]; ok {
+			perms = append(perms, perm)
+		}
+	}
+	return perms
+}
+
+// RoleAllowsScopes 檢查角色本身是否擁有這些範圍對應的權限（金鑰不能超出持有人的權限）
+func RoleAllowsScopes(role Role, scopes []string) bool {
+	for _, perm := range ScopePermissions(scopes) {
+		if !role.Can(perm) {
+			return false
+		}
+	}
+	return true
+}
+
+// RateLimiter 以令牌桶對每個 API 金鑰限流（單實例內存計數）
+type RateLimiter struct {
+	mu      sync.Mutex
+	clock   clock.Clock
+	buckets map[int64]*tokenBucket
+}
+
+type tokenBucket struct {
+	tokens   float64
+	lastFill time.Time
+}
+
+// NewRateLimiter 創建限流器
+func NewRateLimiter(clock clock.Clock) *RateLimiter {
+	return &RateLimiter{
+		clock:   clock,
+		buckets: make(map[int64]*tokenBucket),
+	}
+}
+
+// Allow 判斷金鑰本次請求是否允許，perMinute 為每分鐘請求上限（同時作為突發容量）
+func (l *RateLimiter) Allow(keyID int64, perMinute int) bool {
+	if perMinute <= 0 {
+		return false
+	}
+	now := l.clock.Now()
+	capacity := float64(perMinute)
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	b, ok := l.buckets[keyID]
+	if !ok {
+		b = &tokenBucket{tokens: capacity, lastFill: now}
+		l.buckets[keyID] = b
+	}
+
+	elapsed := now.Sub(b.lastFill).Minutes()
+	b.tokens += elapsed * capacity
+	if b.tokens > capacity {
+		b.tokens = capacity
+	}
+	b.lastFill = now
+
+	if b.tokens < 1 {
+		return false
+	}
+	b.tokens--
+	return true
+}
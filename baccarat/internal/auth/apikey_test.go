@@ -0,0 +1,68 @@
+package auth
+
+import (
+	"baccarat/internal/clock"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestGenerateAPIKey(t *testing.T) {
+	key, hash, prefix, err := GenerateAPIKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(key, APIKeyPrefix) || !strings.HasPrefix(key, prefix) {
+		t.Errorf("unexpected key %q with prefix %q", key, prefix)
+	}
+	if HashToken(key) != hash {
+		t.Error("hash does not match key")
+	}
+}
+
+func TestParseAPIKeyScopes(t *testing.T) {
+	scopes, err := ParseAPIKeyScopes([]string{"play", "wallet", "play"})
+	if err != nil || len(scopes) != 2 {
+		t.Errorf("ParseAPIKeyScopes() = %v, %v, want deduplicated scopes", scopes, err)
+	}
+	if _, err := ParseAPIKeyScopes([]string{"admin"}); err != ErrInvalidScope {
+		t.Errorf("unknown scope error = %v, want ErrInvalidScope", err)
+	}
+	if _, err := ParseAPIKeyScopes(nil); err != ErrInvalidScope {
+		t.Errorf("empty scopes error = %v, want ErrInvalidScope", err)
+	}
+
+	if !RoleAllowsScopes(RolePlayer, []string{"play", "read-history", "wallet"}) {
+		t.Error("player should be allowed all scopes")
+	}
+	if RoleAllowsScopes(RoleSupport, []string{"play"}) {
+		t.Error("support should not be allowed play scope")
+	}
+}
+
+func TestRateLimiter(t *testing.T) {
+	clock := clock.NewFake(time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC))
+	limiter := NewRateLimiter(clock)
+
+	for i := 0; i < 3; i++ {
+		if !limiter.Allow(1, 3) {
+			t.Fatalf("request %d should be allowed", i+1)
+		}
+	}
+	if limiter.Allow(1, 3) {
+		t.Fatal("request over limit should be rejected")
+	}
+	// 其他金鑰有獨立的額度
+	if !limiter.Allow(2, 3) {
+		t.Fatal("other key should be allowed")
+	}
+
+	// 20秒補充一個令牌
+	clock.Advance(20 * time.Second)
+	if !limiter.Allow(1, 3) {
+		t.Fatal("request after refill should be allowed")
+	}
+	if limiter.Allow(1, 3) {
+		t.Fatal("only one token should have been refilled")
+	}
+}
@@ -16,31 +16,74 @@ var (
 	ErrExpiredToken = errors.New("token has expired")
 )
 
+// Claims 訪問令牌的聲明，SessionID 對應可撤銷的登入會話
+type Claims struct {
+	SessionID string `json:"sid"`
+	Role      Role   `json:"role"`
+	jwt.RegisteredClaims
+}
+
+// UserID 從 Subject 解析用戶ID
+func (c *Claims) UserID() (int, error) {
+	return strconv.Atoi(c.Subject)
+}
+
 type JWTService struct {
-	secretKey []byte
-	expiry    time.Duration
+	keys   *KeySet
+	parser *jwt.Parser
+	expiry time.Duration
 }
 
 // NewJWTService 創建新的JWT服務
-func NewJWTService() *JWTService {
+func NewJWTService(keys *KeySet) *JWTService {
 	return &JWTService{
-		secretKey: []byte(config.AppConfig.JWTSecret),
-		expiry:    time.Duration(config.AppConfig.JWTExpiry) * time.Hour,
+		keys:   keys,
+		parser: jwt.NewParser(jwt.WithValidMethods(keys.ValidMethods())),
+		expiry: time.Duration(config.AppConfig.AccessTokenExpiry) * time.Minute,
 	}
 }
 
-// GenerateToken 生成JWT令牌
-func (s *JWTService) GenerateToken(userID int) (string, error) {
+// LoadConfiguredKeySet 按配置載入簽名密鑰，未配置私鑰時退回 HS256 共享密鑰
+func LoadConfiguredKeySet() (*KeySet, error) {
+	if config.AppConfig.JWTSigningKeyFile == "" {
+		if config.AppConfig.JWTSecret == "" {
+			return nil, errors.New("either JWT_SIGNING_KEY_FILE or JWT_SECRET must be set")
+		}
+		logger.Warn("JWT_SIGNING_KEY_FILE not set, signing tokens with HS256 shared secret")
+		return NewHMACKeySet([]byte(config.AppConfig.JWTSecret)), nil
+	}
+	return LoadKeySet(
+		config.AppConfig.JWTSigningKeyID,
+		config.AppConfig.JWTSigningKeyFile,
+		config.AppConfig.JWTVerificationKeys,
+	)
+}
+
+// Keys 簽名和驗證密鑰集
+func (s *JWTService) Keys() *KeySet {
+	return s.keys
+}
+
+// Expiry 訪問令牌有效期
+func (s *JWTService) Expiry() time.Duration {
+	return s.expiry
+}
+
+// GenerateToken 為指定會話生成短效的JWT訪問令牌
+func (s *JWTService) GenerateToken(userID int, sessionID string, role Role) (string, error) {
 	logger.Debug("Generating JWT token for user:", userID)
 	
-	claims := jwt.RegisteredClaims{
-		Subject:   strconv.Itoa(userID),
-		ExpiresAt: jwt.NewNumericDate(time.Now().Add(s.expiry)),
-		IssuedAt:  jwt.NewNumericDate(time.Now()),
+	claims := Claims{
+		SessionID: sessionID,
+		Role:      role,
+		RegisteredClaims: jwt.RegisteredClaims{
+			Subject:   strconv.Itoa(userID),
+			ExpiresAt: jwt.NewNumericDate(time.Now().Add(s.expiry)),
+			IssuedAt:  jwt.NewNumericDate(time.Now()),
+		},
 	}
 
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-	tokenString, err := token.SignedString(s.secretKey)
+	tokenString, err := s.keys.Sign(claims)
 	if err != nil {
 		logger.Error("Error signing JWT token:", err)
 		return "", fmt.Errorf("error signing token: %v", err)
@@ -51,41 +94,102 @@ func (s *JWTService) GenerateToken(userID int) (string, error) {
 }
 
 // ValidateToken 驗證並解析JWT令牌
-func (s *JWTService) ValidateToken(tokenString string) (int, error) {
-	token, err := jwt.ParseWithClaims(tokenString, &jwt.RegisteredClaims{}, func(token *jwt.Token) (interface{}, error) {
-		return s.secretKey, nil
-	})
+func (s *JWTService) ValidateToken(tokenString string) (*Claims, error) {
+	token, err := s.parser.ParseWithClaims(tokenString, &Claims{}, s.keys.Keyfunc)
 
 	if err != nil {
 		logger.Warn("Error parsing JWT token:", err)
-		return 0, ErrInvalidToken
+		return nil, ErrInvalidToken
 	}
 
 	if !token.Valid {
 		logger.Warn("Invalid JWT token")
-		return 0, ErrInvalidToken
+		return nil, ErrInvalidToken
 	}
 
-	claims, ok := token.Claims.(*jwt.RegisteredClaims)
+	claims, ok := token.Claims.(*Claims)
 	if !ok {
 		logger.Warn("Invalid JWT token claims")
-		return 0, ErrInvalidToken
+		return nil, ErrInvalidToken
 	}
 
 	// 檢查是否過期
-	if claims.ExpiresAt.Time.Before(time.Now()) {
+	if claims.ExpiresAt == nil || claims.ExpiresAt.Time.Before(time.Now()) {
 		logger.Warn("JWT token has expired")
-		return 0, ErrExpiredToken
+		return nil, ErrExpiredToken
+	}
+
+	// 沒有會話ID的令牌無法被撤銷，一律拒絕
+	if claims.SessionID == "" {
+		logger.Warn("JWT token without session ID")
+		return nil, ErrInvalidToken
+	}
+
+	if _, err := ParseRole(string(claims.Role)); err != nil {
+		logger.Warn("JWT token with invalid role:", claims.Role)
+		return nil, ErrInvalidToken
 	}
 
 	// 解析用戶ID
-	userID, err := strconv.Atoi(claims.Subject)
+	userID, err := claims.UserID()
 	if err != nil {
 		logger.Error("Error converting user_id to int:", err)
-		return 0, ErrInvalidToken
+		return nil, ErrInvalidToken
 	}
 
 	logger.Debug("JWT token validated successfully for user:", userID)
+	return claims, nil
+}
+
+// preAuthPurpose 密碼驗證通過、等待第二步驗證的令牌用途
+const preAuthPurpose = "2fa"
+
+// PreAuthClaims 預認證令牌的聲明，只能用於完成兩步驗證，不能訪問其他接口
+type PreAuthClaims struct {
+	Purpose string `json:"purpose"`
+	jwt.RegisteredClaims
+}
+
+// PreAuthExpiry 預認證令牌有效期
+const PreAuthExpiry = 5 * time.Minute
+
+// GeneratePreAuthToken 生成短效的預認證令牌
+func (s *JWTService) GeneratePreAuthToken(userID int) (string, error) {
+	claims := PreAuthClaims{
+		Purpose: preAuthPurpose,
+		RegisteredClaims: jwt.RegisteredClaims{
+			Subject:   strconv.Itoa(userID),
+			ExpiresAt: jwt.NewNumericDate(time.Now().Add(PreAuthExpiry)),
+			IssuedAt:  jwt.NewNumericDate(time.Now()),
+		},
+	}
+
+	tokenString, err := s.keys.Sign(claims)
+	if err != nil {
+		logger.Error("Error signing pre-auth token:", err)
+		return "", fmt.Errorf("error signing token: %v", err)
+	}
+	return tokenString, nil
+}
+
+// ValidatePreAuthToken 驗證預認證令牌，返回用戶ID
+func (s *JWTService) ValidatePreAuthToken(tokenString string) (int, error) {
+	token, err := s.parser.ParseWithClaims(tokenString, &PreAuthClaims{}, s.keys.Keyfunc)
+	if err != nil || !token.Valid {
+		logger.Warn("Invalid pre-auth token:", err)
+		return 0, ErrInvalidToken
+	}
+
+	claims, ok := token.Claims.(*PreAuthClaims)
+	if !ok || claims.Purpose != preAuthPurpose {
+		logger.Warn("Token is not a pre-auth token")
+		return 0, ErrInvalidToken
+	}
+
+	userID, err := strconv.Atoi(claims.Subject)
+	if err != nil {
+		return 0, ErrInvalidToken
+	}
 	return userID, nil
 }
 
@@ -0,0 +1,161 @@
+package auth
+
+import (
+	"baccarat/internal/testdb"
+	"baccarat/pkg/logger"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v4"
+)
+
+// DBAttemptStore 等資料庫實現的測試使用臨時 SQLite 數據庫
+func TestMain(m *testing.M) {
+	logger.InitLogger()
+	cleanup, err := testdb.Open()
+	if err != nil {
+		panic(err)
+	}
+	code := m.Run()
+	cleanup()
+	os.Exit(code)
+}
+
+var testSecret = []byte("test-secret")
+
+func newTestJWTService() *JWTService {
+	return newTestJWTServiceWithKeys(NewHMACKeySet(testSecret))
+}
+
+func newTestJWTServiceWithKeys(keys *KeySet) *JWTService {
+	return &JWTService{
+		keys:   keys,
+		parser: jwt.NewParser(jwt.WithValidMethods(keys.ValidMethods())),
+		expiry: 15 * time.Minute,
+	}
+}
+
+func TestGenerateAndValidateToken(t *testing.T) {
+	s := newTestJWTService()
+
+	token, err := s.GenerateToken(42, "session-1", RoleAdmin)
+	if err != nil {
+		t.Fatalf("GenerateToken() error = %v", err)
+	}
+
+	claims, err := s.ValidateToken(token)
+	if err != nil {
+		t.Fatalf("ValidateToken() error = %v", err)
+	}
+	userID, err := claims.UserID()
+	if err != nil || userID != 42 {
+		t.Errorf("UserID() = %d, %v, want 42", userID, err)
+	}
+	if claims.SessionID != "session-1" {
+		t.Errorf("SessionID = %q, want session-1", claims.SessionID)
+	}
+	if claims.Role != RoleAdmin {
+		t.Errorf("Role = %q, want admin", claims.Role)
+	}
+}
+
+func TestValidateTokenRejects(t *testing.T) {
+	s := newTestJWTService()
+
+	sign := func(claims jwt.Claims, key []byte) string {
+		token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
+		token.Header["kid"] = legacyKeyID
+		signed, err := token.SignedString(key)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return signed
+	}
+	valid := jwt.RegisteredClaims{
+		Subject:   "42",
+		ExpiresAt: jwt.NewNumericDate(time.Now().Add(time.Minute)),
+	}
+
+	tests := []struct {
+		name    string
+		token   string
+		wantErr error
+	}{
+		{"Wrong secret", sign(Claims{SessionID: "s", Role: RolePlayer, RegisteredClaims: valid}, []byte("other")), ErrInvalidToken},
+		{"Unknown role", sign(Claims{SessionID: "s", Role: "root", RegisteredClaims: valid}, testSecret), ErrInvalidToken},
+		{"Missing session", sign(valid, testSecret), ErrInvalidToken},
+		{"Expired", sign(Claims{SessionID: "s", Role: RolePlayer, RegisteredClaims: jwt.RegisteredClaims{
+			Subject:   "42",
+			ExpiresAt: jwt.NewNumericDate(time.Now().Add(-time.Minute)),
+		}}, testSecret), ErrInvalidToken},
+		{"Garbage", "not-a-token", ErrInvalidToken},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := s.ValidateToken(tt.token); err != tt.wantErr {
+				t.Errorf("ValidateToken() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestPreAuthToken(t *testing.T) {
+	s := newTestJWTService()
+
+	preAuth, err := s.GeneratePreAuthToken(7)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if userID, err := s.ValidatePreAuthToken(preAuth); err != nil || userID != 7 {
+		t.Errorf("ValidatePreAuthToken() = %d, %v, want 7", userID, err)
+	}
+	// 預認證令牌不能當作訪問令牌使用，反之亦然
+	if _, err := s.ValidateToken(preAuth); err != ErrInvalidToken {
+		t.Errorf("ValidateToken(preAuth) error = %v, want ErrInvalidToken", err)
+	}
+	access, _ := s.GenerateToken(7, "session-1", RolePlayer)
+	if _, err := s.ValidatePreAuthToken(access); err != ErrInvalidToken {
+		t.Errorf("ValidatePreAuthToken(access) error = %v, want ErrInvalidToken", err)
+	}
+}
+
+func TestRolePermissions(t *testing.T) {
+	tests := []struct {
+		role     Role
+		perm     Permission
+		expected bool
+	}{
+		{RolePlayer, PermGamePlay, true},
+		{RolePlayer, PermGameDetailsAll, false},
+		{RoleSupport, PermGamePlay, false},
+		{RoleSupport, PermUsersRead, true},
+		{RoleFinance, PermReportsRead, true},
+		{RoleFinance, PermBalanceAdjust, true},
+		{RoleSupport, PermBalanceAdjust, false},
+		{RoleAdmin, PermGamesSettle, true},
+		{RoleFinance, PermGamesSettle, false},
+		{RoleAdmin, PermGameDetailsAll, true},
+		{Role("unknown"), PermHistoryRead, false},
+	}
+
+	for _, tt := range tests {
+		if got := tt.role.Can(tt.perm); got != tt.expected {
+			t.Errorf("%s.Can(%s) = %v, want %v", tt.role, tt.perm, got, tt.expected)
+		}
+	}
+}
+
+func TestHashToken(t *testing.T) {
+	token, hash, err := GenerateOpaqueToken()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if HashToken(token) != hash {
+		t.Error("HashToken() does not match hash from GenerateOpaqueToken()")
+	}
+	if token == hash || len(hash) != 64 {
+		t.Errorf("unexpected hash %q", hash)
+	}
+}
@@ -0,0 +1,237 @@
+package auth
+
+import (
+	"crypto/ed25519"
+	"crypto/rsa"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"math/big"
+	"os"
+	"strings"
+
+	"github.com/golang-jwt/jwt/v4"
+)
+
+var (
+	ErrUnknownKeyID       = errors.New("unknown key id")
+	ErrUnsupportedKeyType = errors.New("unsupported key type")
+)
+
+// legacyKeyID 未配置非對稱密鑰時 HS256 共享密鑰使用的 kid
+const legacyKeyID = "hs256"
+
+// SigningKey 簽名密鑰
+type SigningKey struct {
+	ID         string
+	Method     jwt.SigningMethod
+	PrivateKey interface{}
+}
+
+// VerificationKey 驗證密鑰，Method 限定該 kid 只接受一種算法
+type VerificationKey struct {
+	ID        string
+	Method    jwt.SigningMethod
+	PublicKey interface{}
+}
+
+// KeySet 當前簽名密鑰和所有有效的驗證密鑰（輪換期間舊密鑰仍可驗證已簽發的令牌）
+type KeySet struct {
+	signing *SigningKey
+	verify  map[string]*VerificationKey
+	order   []string
+}
+
+// NewKeySet 創建密鑰集，簽名密鑰的公鑰自動加入驗證密鑰
+func NewKeySet(signing *SigningKey, verification ...*VerificationKey) (*KeySet, error) {
+	if signing == nil || signing.ID == "" {
+		return nil, errors.New("signing key id is required")
+	}
+	ks := &KeySet{signing: signing, verify: make(map[string]*VerificationKey)}
+
+	public, err := publicKeyOf(signing.PrivateKey)
+	if err != nil {
+		return nil, err
+	}
+	if err := ks.add(&VerificationKey{ID: signing.ID, Method: signing.Method, PublicKey: public}); err != nil {
+		return nil, err
+	}
+	for _, key := range verification {
+		if err := ks.add(key); err != nil {
+			return nil, err
+		}
+	}
+	return ks, nil
+}
+
+// NewHMACKeySet 使用 HS256 共享密鑰的密鑰集，只用於未配置非對稱密鑰的開發環境
+func NewHMACKeySet(secret []byte) *KeySet {
+	return &KeySet{
+		signing: &SigningKey{ID: legacyKeyID, Method: jwt.SigningMethodHS256, PrivateKey: secret},
+		verify: map[string]*VerificationKey{
+			legacyKeyID: {ID: legacyKeyID, Method: jwt.SigningMethodHS256, PublicKey: secret},
+		},
+		order: []string{legacyKeyID},
+	}
+}
+
+func (ks *KeySet) add(key *VerificationKey) error {
+	if key.ID == "" {
+		return errors.New("verification key id is required")
+	}
+	if _, exists := ks.verify[key.ID]; exists {
+		return fmt.Errorf("duplicate key id %q", key.ID)
+	}
+	ks.verify[key.ID] = key
+	ks.order = append(ks.order, key.ID)
+	return nil
+}
+
+// Sign 使用當前簽名密鑰簽名並寫入 kid
+func (ks *KeySet) Sign(claims jwt.Claims) (string, error) {
+	token := jwt.NewWithClaims(ks.signing.Method, claims)
+	token.Header["kid"] = ks.signing.ID
+	return token.SignedString(ks.signing.PrivateKey)
+}
+
+// Keyfunc 按 kid 選擇驗證密鑰，並拒絕與該密鑰算法不一致的令牌（防止 alg 混淆攻擊）
+func (ks *KeySet) Keyfunc(token *jwt.Token) (interface{}, error) {
+	kid, _ := token.Header["kid"].(string)
+	key, ok := ks.verify[kid]
+	if !ok {
+		return nil, ErrUnknownKeyID
+	}
+	if token.Method.Alg() != key.Method.Alg() {
+		return nil, fmt.Errorf("unexpected signing method %q for key %q", token.Method.Alg(), kid)
+	}
+	return key.PublicKey, nil
+}
+
+// ValidMethods 密鑰集接受的所有算法
+func (ks *KeySet) ValidMethods() []string {
+	seen := make(map[string]bool)
+	var methods []string
+	for _, kid := range ks.order {
+		alg := ks.verify[kid].Method.Alg()
+		if !seen[alg] {
+			seen[alg] = true
+			methods = append(methods, alg)
+		}
+	}
+	return methods
+}
+
+// JWK JSON Web Key（RFC 7517），只包含公鑰參數
+type JWK struct {
+	KeyType   string `json:"kty"`
+	KeyID     string `json:"kid"`
+	Use       string `json:"use"`
+	Algorithm string `json:"alg"`
+	N         string `json:"n,omitempty"`
+	E         string `json:"e,omitempty"`
+	Curve     string `json:"crv,omitempty"`
+	X         string `json:"x,omitempty"`
+}
+
+// JWKS 導出所有非對稱驗證密鑰，共享密鑰永遠不會公開
+func (ks *KeySet) JWKS() []JWK {
+	keys := []JWK{}
+	for _, kid := range ks.order {
+		key := ks.verify[kid]
+		switch pub := key.PublicKey.(type) {
+		case *rsa.PublicKey:
+			keys = append(keys, JWK{
+				KeyType:   "RSA",
+				KeyID:     kid,
+				Use:       "sig",
+				Algorithm: key.Method.Alg(),
+				N:         base64.RawURLEncoding.EncodeToString(pub.N.Bytes()),
+				E:         base64.RawURLEncoding.EncodeToString(big.NewInt(int64(pub.E)).Bytes()),
+			})
+		case ed25519.PublicKey:
+			keys = append(keys, JWK{
+				KeyType:   "OKP",
+				KeyID:     kid,
+				Use:       "sig",
+				Algorithm: key.Method.Alg(),
+				Curve:     "Ed25519",
+				X:         base64.RawURLEncoding.EncodeToString(pub),
+			})
+		}
+	}
+	return keys
+}
+
+// ParseSigningKeyPEM 解析 PEM 格式的 RSA 或 Ed25519 私鑰，RSA 使用 RS256，Ed25519 使用 EdDSA
+func ParseSigningKeyPEM(kid string, data []byte) (*SigningKey, error) {
+	if key, err := jwt.ParseRSAPrivateKeyFromPEM(data); err == nil {
+		if key.N.BitLen() < 2048 {
+			return nil, errors.New("rsa signing key must be at least 2048 bits")
+		}
+		return &SigningKey{ID: kid, Method: jwt.SigningMethodRS256, PrivateKey: key}, nil
+	}
+	if key, err := jwt.ParseEdPrivateKeyFromPEM(data); err == nil {
+		if edKey, ok := key.(ed25519.PrivateKey); ok {
+			return &SigningKey{ID: kid, Method: jwt.SigningMethodEdDSA, PrivateKey: edKey}, nil
+		}
+	}
+	return nil, ErrUnsupportedKeyType
+}
+
+// ParseVerificationKeyPEM 解析 PEM 格式的 RSA 或 Ed25519 公鑰
+func ParseVerificationKeyPEM(kid string, data []byte) (*VerificationKey, error) {
+	if key, err := jwt.ParseRSAPublicKeyFromPEM(data); err == nil {
+		return &VerificationKey{ID: kid, Method: jwt.SigningMethodRS256, PublicKey: key}, nil
+	}
+	if key, err := jwt.ParseEdPublicKeyFromPEM(data); err == nil {
+		if edKey, ok := key.(ed25519.PublicKey); ok {
+			return &VerificationKey{ID: kid, Method: jwt.SigningMethodEdDSA, PublicKey: edKey}, nil
+		}
+	}
+	return nil, ErrUnsupportedKeyType
+}
+
+// LoadKeySet 從文件載入密鑰集
+// verificationKeys 格式為 "kid1=/path/a.pem,kid2=/path/b.pem"，用於輪換後仍需驗證的舊公鑰
+func LoadKeySet(signingKID, signingKeyFile, verificationKeys string) (*KeySet, error) {
+	data, err := os.ReadFile(signingKeyFile)
+	if err != nil {
+		return nil, fmt.Errorf("reading signing key: %w", err)
+	}
+	signing, err := ParseSigningKeyPEM(signingKID, data)
+	if err != nil {
+		return nil, fmt.Errorf("parsing signing key: %w", err)
+	}
+
+	var verification []*VerificationKey
+	for _, entry := range strings.Split(verificationKeys, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		kid, path, ok := strings.Cut(entry, "=")
+		if !ok {
+			return nil, fmt.Errorf("invalid verification key entry %q", entry)
+		}
+		data, err := os.ReadFile(strings.TrimSpace(path))
+		if err != nil {
+			return nil, fmt.Errorf("reading verification key %q: %w", kid, err)
+		}
+		key, err := ParseVerificationKeyPEM(strings.TrimSpace(kid), data)
+		if err != nil {
+			return nil, fmt.Errorf("parsing verification key %q: %w", kid, err)
+		}
+		verification = append(verification, key)
+	}
+	return NewKeySet(signing, verification...)
+}
+
+func publicKeyOf(private interface{}) (interface{}, error) {
+	switch key := private.(type) {
+	case *rsa.PrivateKey:
+		return &key.PublicKey, nil
+	case ed25519.PrivateKey:
+		return key.Public(), nil
+	}
+	return nil, ErrUnsupportedKeyType
+}
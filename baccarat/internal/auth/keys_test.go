@@ -0,0 +1,179 @@
+package auth
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v4"
+)
+
+func newRSASigningKey(t *testing.T, kid string) *SigningKey {
+	t.Helper()
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &SigningKey{ID: kid, Method: jwt.SigningMethodRS256, PrivateKey: key}
+}
+
+func newEdSigningKey(t *testing.T, kid string) *SigningKey {
+	t.Helper()
+	_, key, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &SigningKey{ID: kid, Method: jwt.SigningMethodEdDSA, PrivateKey: key}
+}
+
+func TestAsymmetricSigning(t *testing.T) {
+	for _, signing := range []*SigningKey{newRSASigningKey(t, "rsa-1"), newEdSigningKey(t, "ed-1")} {
+		t.Run(signing.Method.Alg(), func(t *testing.T) {
+			keys, err := NewKeySet(signing)
+			if err != nil {
+				t.Fatal(err)
+			}
+			s := newTestJWTServiceWithKeys(keys)
+
+			token, err := s.GenerateToken(42, "session-1", RolePlayer)
+			if err != nil {
+				t.Fatal(err)
+			}
+			parsed, _, err := jwt.NewParser().ParseUnverified(token, &Claims{})
+			if err != nil || parsed.Header["kid"] != signing.ID {
+				t.Errorf("kid header = %v, want %s", parsed.Header["kid"], signing.ID)
+			}
+			if _, err := s.ValidateToken(token); err != nil {
+				t.Errorf("ValidateToken() error = %v", err)
+			}
+		})
+	}
+}
+
+func TestKeyRotation(t *testing.T) {
+	oldKey := newRSASigningKey(t, "2024-01")
+	oldKeys, err := NewKeySet(oldKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	oldToken, err := newTestJWTServiceWithKeys(oldKeys).GenerateToken(1, "s", RolePlayer)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// 新密鑰簽名，舊公鑰繼續驗證輪換前簽發的令牌
+	oldPublic := &VerificationKey{ID: oldKey.ID, Method: oldKey.Method, PublicKey: &oldKey.PrivateKey.(*rsa.PrivateKey).PublicKey}
+	newKeys, err := NewKeySet(newEdSigningKey(t, "2024-02"), oldPublic)
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := newTestJWTServiceWithKeys(newKeys)
+	if _, err := s.ValidateToken(oldToken); err != nil {
+		t.Errorf("token signed with previous key rejected: %v", err)
+	}
+
+	// 舊公鑰移除後不再接受
+	s = newTestJWTServiceWithKeys(mustKeySet(t, newEdSigningKey(t, "2024-03")))
+	if _, err := s.ValidateToken(oldToken); err != ErrInvalidToken {
+		t.Errorf("ValidateToken() error = %v, want ErrInvalidToken", err)
+	}
+
+	if len(newKeys.JWKS()) != 2 {
+		t.Errorf("JWKS() returned %d keys, want 2", len(newKeys.JWKS()))
+	}
+}
+
+func TestKeyfuncRejectsAlgorithmConfusion(t *testing.T) {
+	signing := newRSASigningKey(t, "rsa-1")
+	s := newTestJWTServiceWithKeys(mustKeySet(t, signing))
+
+	claims := Claims{SessionID: "s", Role: RolePlayer, RegisteredClaims: jwt.RegisteredClaims{
+		Subject:   "42",
+		ExpiresAt: jwt.NewNumericDate(time.Now().Add(time.Minute)),
+	}}
+
+	// 用公開的 RSA 公鑰作為 HMAC 密鑰偽造令牌
+	publicDER, err := x509.MarshalPKIXPublicKey(&signing.PrivateKey.(*rsa.PrivateKey).PublicKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	publicPEM := pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: publicDER})
+	forged := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
+	forged.Header["kid"] = signing.ID
+	forgedToken, err := forged.SignedString(publicPEM)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.ValidateToken(forgedToken); err != ErrInvalidToken {
+		t.Errorf("HS256 token with RSA kid: error = %v, want ErrInvalidToken", err)
+	}
+
+	// 未簽名令牌
+	none := jwt.NewWithClaims(jwt.SigningMethodNone, claims)
+	none.Header["kid"] = signing.ID
+	noneToken, err := none.SignedString(jwt.UnsafeAllowNoneSignatureType)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.ValidateToken(noneToken); err != ErrInvalidToken {
+		t.Errorf("alg none token: error = %v, want ErrInvalidToken", err)
+	}
+
+	// 未知 kid
+	unknown := jwt.NewWithClaims(jwt.SigningMethodRS256, claims)
+	unknown.Header["kid"] = "other"
+	unknownToken, err := unknown.SignedString(signing.PrivateKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.ValidateToken(unknownToken); err != ErrInvalidToken {
+		t.Errorf("unknown kid: error = %v, want ErrInvalidToken", err)
+	}
+}
+
+func TestParseKeyPEM(t *testing.T) {
+	_, private, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	der, err := x509.MarshalPKCS8PrivateKey(private)
+	if err != nil {
+		t.Fatal(err)
+	}
+	signing, err := ParseSigningKeyPEM("ed", pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der}))
+	if err != nil || signing.Method != jwt.SigningMethodEdDSA {
+		t.Fatalf("ParseSigningKeyPEM() = %v, %v", signing, err)
+	}
+
+	publicDER, err := x509.MarshalPKIXPublicKey(private.Public())
+	if err != nil {
+		t.Fatal(err)
+	}
+	verification, err := ParseVerificationKeyPEM("ed", pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: publicDER}))
+	if err != nil || verification.Method != jwt.SigningMethodEdDSA {
+		t.Fatalf("ParseVerificationKeyPEM() = %v, %v", verification, err)
+	}
+
+	if _, err := ParseSigningKeyPEM("bad", []byte("not a key")); err != ErrUnsupportedKeyType {
+		t.Errorf("ParseSigningKeyPEM(garbage) error = %v, want ErrUnsupportedKeyType", err)
+	}
+}
+
+func TestJWKSOmitsSharedSecret(t *testing.T) {
+	if keys := NewHMACKeySet(testSecret).JWKS(); len(keys) != 0 {
+		t.Errorf("JWKS() exposed %d HMAC keys", len(keys))
+	}
+}
+
+func mustKeySet(t *testing.T, signing *SigningKey, verification ...*VerificationKey) *KeySet {
+	t.Helper()
+	keys, err := NewKeySet(signing, verification...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return keys
+}
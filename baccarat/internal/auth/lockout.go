@@ -0,0 +1,208 @@
+package auth
+
+import (
+	"baccarat/config"
+	"baccarat/db"
+	"baccarat/internal/clock"
+	"database/sql"
+	"errors"
+	"time"
+)
+
+var (
+	ErrLoginThrottled = errors.New("too many login attempts, retry later")
+	ErrAccountLocked  = errors.New("account temporarily locked")
+)
+
+// 失敗計數的維度
+const (
+	ScopeUsername = "username"
+	ScopeIP       = "ip"
+)
+
+// FailureRecord 某個維度（用戶名或IP）的登入失敗記錄
+type FailureRecord struct {
+	Failures    int
+	LastFailure time.Time
+	LockedUntil time.Time
+}
+
+// AttemptStore 保存登入失敗記錄
+type AttemptStore interface {
+	Get(scope, key string) (FailureRecord, error)
+	// Update 原子地讀取並以 fn 修改記錄（不存在時傳入空記錄），並發的 Update 依次執行
+	Update(scope, key string, fn func(FailureRecord) FailureRecord) error
+	Delete(scope, key string) error
+}
+
+// LockoutPolicy 單個維度的節流與鎖定策略
+type LockoutPolicy struct {
+	MaxFailures     int           // 窗口內失敗多少次後鎖定
+	Window          time.Duration // 超過此時間沒有失敗則重新計數
+	LockoutDuration time.Duration // 鎖定時長
+	BaseDelay       time.Duration // 第一次失敗後的等待時間，之後每次加倍
+	MaxDelay        time.Duration // 等待時間上限
+}
+
+// delayAfter 第 n 次失敗後需要等待的時間
+func (p LockoutPolicy) delayAfter(failures int) time.Duration {
+	if failures <= 0 || p.BaseDelay <= 0 {
+		return 0
+	}
+	delay := p.BaseDelay
+	for i := 1; i < failures; i++ {
+		delay *= 2
+		if delay >= p.MaxDelay {
+			return p.MaxDelay
+		}
+	}
+	return delay
+}
+
+// LoginGuard 依用戶名和IP追蹤登入失敗，實施遞增延遲和臨時鎖定
+type LoginGuard struct {
+	userPolicy LockoutPolicy
+	ipPolicy   LockoutPolicy
+	store      AttemptStore
+	clock      clock.Clock
+}
+
+// NewLoginGuard 創建登入防護
+func NewLoginGuard(userPolicy, ipPolicy LockoutPolicy, store AttemptStore, clock clock.Clock) *LoginGuard {
+	return &LoginGuard{
+		userPolicy: userPolicy,
+		ipPolicy:   ipPolicy,
+		store:      store,
+		clock:      clock,
+	}
+}
+
+// NewDefaultLoginGuard 使用配置和資料庫存儲創建登入防護
+func NewDefaultLoginGuard() *LoginGuard {
+	userPolicy := LockoutPolicy{
+		MaxFailures:     config.AppConfig.LoginMaxFailures,
+		Window:          time.Duration(config.AppConfig.LoginFailureWindow) * time.Minute,
+		LockoutDuration: time.Duration(config.AppConfig.LoginLockoutDuration) * time.Minute,
+		BaseDelay:       time.Second,
+		MaxDelay:        30 * time.Second,
+	}
+	// 同一IP可能有多個用戶（NAT），門檻放寬
+	ipPolicy := userPolicy
+	ipPolicy.MaxFailures = config.AppConfig.LoginIPMaxFailures
+	ipPolicy.BaseDelay = 0
+
+	return NewLoginGuard(userPolicy, ipPolicy, DBAttemptStore{}, clock.System{})
+}
+
+// Check 檢查是否允許本次登入嘗試，不允許時返回需要等待的時間
+func (g *LoginGuard) Check(username, ip string) (time.Duration, error) {
+	now := g.clock.Now()
+
+	for _, target := range g.targets(username, ip) {
+		record, err := g.store.Get(target.scope, target.key)
+		if err != nil {
+			return 0, err
+		}
+		if record.LockedUntil.After(now) {
+			return record.LockedUntil.Sub(now), ErrAccountLocked
+		}
+		if now.Sub(record.LastFailure) >= target.policy.Window {
+			continue
+		}
+		if ready := record.LastFailure.Add(target.policy.delayAfter(record.Failures)); ready.After(now) {
+			return ready.Sub(now), ErrLoginThrottled
+		}
+	}
+	return 0, nil
+}
+
+// RecordFailure 記錄一次失敗，返回是否因此觸發了鎖定
+func (g *LoginGuard) RecordFailure(username, ip string) (bool, error) {
+	now := g.clock.Now()
+	locked := false
+
+	for _, target := range g.targets(username, ip) {
+		policy := target.policy
+		err := g.store.Update(target.scope, target.key, func(record FailureRecord) FailureRecord {
+			// 窗口過期或上一次鎖定已結束，重新計數
+			if now.Sub(record.LastFailure) >= policy.Window ||
+				(!record.LockedUntil.IsZero() && !record.LockedUntil.After(now)) {
+				record = FailureRecord{}
+			}
+
+			record.Failures++
+			record.LastFailure = now
+			if policy.MaxFailures > 0 && record.Failures >= policy.MaxFailures {
+				record.LockedUntil = now.Add(policy.LockoutDuration)
+				locked = true
+			}
+			return record
+		})
+		if err != nil {
+			return false, err
+		}
+	}
+	return locked, nil
+}
+
+// RecordSuccess 登入成功後清除用戶名的失敗記錄（IP記錄保留，自然過期）
+func (g *LoginGuard) RecordSuccess(username string) error {
+	return g.store.Delete(ScopeUsername, username)
+}
+
+// Unlock 管理員解除用戶名的鎖定
+func (g *LoginGuard) Unlock(username string) error {
+	return g.store.Delete(ScopeUsername, username)
+}
+
+type guardTarget struct {
+	scope  string
+	key    string
+	policy LockoutPolicy
+}
+
+func (g *LoginGuard) targets(username, ip string) []guardTarget {
+	targets := []guardTarget{{ScopeUsername, username, g.userPolicy}}
+	if ip != "" {
+		targets = append(targets, guardTarget{ScopeIP, ip, g.ipPolicy})
+	}
+	return targets
+}
+
+// DBAttemptStore 以資料庫保存失敗記錄，多個實例共享
+type DBAttemptStore struct{}
+
+// Get 查詢失敗記錄，不存在時返回空記錄
+func (DBAttemptStore) Get(scope, key string) (FailureRecord, error) {
+	failures, lastFailure, lockedUntil, err := db.GetLoginFailure(scope, key)
+	if err == sql.ErrNoRows {
+		return FailureRecord{}, nil
+	}
+	if err != nil {
+		return FailureRecord{}, err
+	}
+	record := FailureRecord{Failures: failures, LastFailure: lastFailure}
+	if lockedUntil.Valid {
+		record.LockedUntil = lockedUntil.Time
+	}
+	return record, nil
+}
+
+// Update 在資料庫事務內鎖定並修改失敗記錄
+func (DBAttemptStore) Update(scope, key string, fn func(FailureRecord) FailureRecord) error {
+	return db.UpdateLoginFailure(scope, key, func(f *db.LoginFailure) {
+		record := FailureRecord{Failures: f.Failures, LastFailure: f.LastFailure}
+		if f.LockedUntil.Valid {
+			record.LockedUntil = f.LockedUntil.Time
+		}
+		record = fn(record)
+		f.Failures = record.Failures
+		f.LastFailure = record.LastFailure
+		f.LockedUntil = sql.NullTime{Time: record.LockedUntil, Valid: !record.LockedUntil.IsZero()}
+	})
+}
+
+// Delete 刪除失敗記錄
+func (DBAttemptStore) Delete(scope, key string) error {
+	return db.DeleteLoginFailure(scope, key)
+}
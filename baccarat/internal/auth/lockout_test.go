@@ -0,0 +1,183 @@
+package auth
+
+import (
+	"baccarat/internal/clock"
+	"sync"
+	"testing"
+	"time"
+)
+
+type memoryAttemptStore struct {
+	records map[string]FailureRecord
+}
+
+func newMemoryAttemptStore() *memoryAttemptStore {
+	return &memoryAttemptStore{records: make(map[string]FailureRecord)}
+}
+
+func (s *memoryAttemptStore) Get(scope, key string) (FailureRecord, error) {
+	return s.records[scope+":"+key], nil
+}
+
+func (s *memoryAttemptStore) Update(scope, key string, fn func(FailureRecord) FailureRecord) error {
+	s.records[scope+":"+key] = fn(s.records[scope+":"+key])
+	return nil
+}
+
+func (s *memoryAttemptStore) Delete(scope, key string) error {
+	delete(s.records, scope+":"+key)
+	return nil
+}
+
+func newTestGuard() (*LoginGuard, *clock.Fake) {
+	clock := clock.NewFake(time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC))
+	userPolicy := LockoutPolicy{
+		MaxFailures:     3,
+		Window:          15 * time.Minute,
+		LockoutDuration: 10 * time.Minute,
+		BaseDelay:       time.Second,
+		MaxDelay:        4 * time.Second,
+	}
+	ipPolicy := userPolicy
+	ipPolicy.MaxFailures = 5
+	ipPolicy.BaseDelay = 0
+	return NewLoginGuard(userPolicy, ipPolicy, newMemoryAttemptStore(), clock), clock
+}
+
+func TestLoginGuardProgressiveDelay(t *testing.T) {
+	guard, clock := newTestGuard()
+
+	if _, err := guard.Check("alice", "10.0.0.1"); err != nil {
+		t.Fatalf("first attempt should be allowed, got %v", err)
+	}
+
+	// 第一次失敗後需等待1秒
+	guard.RecordFailure("alice", "10.0.0.1")
+	wait, err := guard.Check("alice", "10.0.0.1")
+	if err != ErrLoginThrottled || wait != time.Second {
+		t.Fatalf("after 1 failure: wait=%v err=%v, want 1s throttled", wait, err)
+	}
+	clock.Advance(time.Second)
+	if _, err := guard.Check("alice", "10.0.0.1"); err != nil {
+		t.Fatalf("after delay: err=%v, want allowed", err)
+	}
+
+	// 第二次失敗後等待加倍
+	guard.RecordFailure("alice", "10.0.0.1")
+	wait, err = guard.Check("alice", "10.0.0.1")
+	if err != ErrLoginThrottled || wait != 2*time.Second {
+		t.Fatalf("after 2 failures: wait=%v err=%v, want 2s throttled", wait, err)
+	}
+
+	// 其他用戶不受影響（IP策略沒有延遲）
+	if _, err := guard.Check("bob", "10.0.0.1"); err != nil {
+		t.Fatalf("other user should be allowed, got %v", err)
+	}
+}
+
+func TestLoginGuardLockout(t *testing.T) {
+	guard, clock := newTestGuard()
+
+	for i := 1; i <= 3; i++ {
+		locked, err := guard.RecordFailure("alice", "10.0.0.1")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if want := i == 3; locked != want {
+			t.Fatalf("failure %d: locked=%v, want %v", i, locked, want)
+		}
+		clock.Advance(5 * time.Second)
+	}
+
+	wait, err := guard.Check("alice", "10.0.0.2")
+	if err != ErrAccountLocked {
+		t.Fatalf("err=%v, want ErrAccountLocked", err)
+	}
+	if wait != 10*time.Minute-5*time.Second {
+		t.Errorf("wait=%v, want remaining lockout", wait)
+	}
+
+	// 鎖定結束後重新計數
+	clock.Advance(10 * time.Minute)
+	if _, err := guard.Check("alice", "10.0.0.2"); err != nil {
+		t.Fatalf("after lockout: err=%v, want allowed", err)
+	}
+	if locked, _ := guard.RecordFailure("alice", "10.0.0.2"); locked {
+		t.Fatal("counter should restart after lockout expires")
+	}
+}
+
+func TestLoginGuardIPLockout(t *testing.T) {
+	guard, clock := newTestGuard()
+
+	// 同一IP嘗試多個用戶名
+	users := []string{"u1", "u2", "u3", "u4", "u5"}
+	for _, u := range users {
+		guard.RecordFailure(u, "10.0.0.9")
+		clock.Advance(time.Second)
+	}
+
+	if _, err := guard.Check("u6", "10.0.0.9"); err != ErrAccountLocked {
+		t.Fatalf("err=%v, want IP locked", err)
+	}
+	if _, err := guard.Check("u6", "10.0.0.10"); err != nil {
+		t.Fatalf("other IP should be allowed, got %v", err)
+	}
+}
+
+func TestLoginGuardWindowAndUnlock(t *testing.T) {
+	guard, clock := newTestGuard()
+
+	guard.RecordFailure("alice", "10.0.0.1")
+	guard.RecordFailure("alice", "10.0.0.1")
+
+	// 窗口過期後舊的失敗不再計入
+	clock.Advance(16 * time.Minute)
+	if locked, _ := guard.RecordFailure("alice", "10.0.0.1"); locked {
+		t.Fatal("failures outside window should not count toward lockout")
+	}
+
+	guard.RecordFailure("alice", "10.0.0.1")
+	guard.RecordFailure("alice", "10.0.0.1")
+	if _, err := guard.Check("alice", "10.0.0.1"); err != ErrAccountLocked {
+		t.Fatalf("err=%v, want ErrAccountLocked", err)
+	}
+
+	if err := guard.Unlock("alice"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := guard.Check("alice", ""); err != nil {
+		t.Fatalf("after unlock: err=%v, want allowed", err)
+	}
+}
+
+func TestDBAttemptStoreConcurrentFailures(t *testing.T) {
+	userPolicy := LockoutPolicy{MaxFailures: 100, Window: 15 * time.Minute, LockoutDuration: 10 * time.Minute}
+	guard := NewLoginGuard(userPolicy, userPolicy, DBAttemptStore{}, clock.System{})
+
+	// 並發的失敗各自計數，不會讀到同一個舊值後互相覆蓋
+	const attempts = 20
+	var wg sync.WaitGroup
+	errs := make(chan error, attempts)
+	for i := 0; i < attempts; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, err := guard.RecordFailure("burst", "10.9.9.9"); err != nil {
+				errs <- err
+			}
+		}()
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		t.Fatal(err)
+	}
+
+	for _, scope := range []struct{ scope, key string }{{ScopeUsername, "burst"}, {ScopeIP, "10.9.9.9"}} {
+		record, err := DBAttemptStore{}.Get(scope.scope, scope.key)
+		if err != nil || record.Failures != attempts {
+			t.Errorf("%s failures = %d, %v, want %d", scope.scope, record.Failures, err, attempts)
+		}
+	}
+}
@@ -0,0 +1,86 @@
+package auth
+
+import (
+	"errors"
+)
+
+var ErrInvalidRole = errors.New("invalid role")
+
+// Role 用戶角色
+type Role string
+
+const (
+	RolePlayer  Role = "player"
+	RoleSupport Role = "support"
+	RoleFinance Role = "finance"
+	RoleAdmin   Role = "admin"
+)
+
+// Permission 路由所需的權限
+type Permission string
+
+const (
+	PermGamePlay       Permission = "game:play"        // 進行遊戲
+	PermWallet         Permission = "wallet:use"       // 查詢餘額、存款、查看自己的交易
+	PermHistoryRead    Permission = "history:read"     // 查看自己的投注記錄和遊戲詳情
+	PermGameDetailsAll Permission = "game:details:all" // 查看任意遊戲的全部下注
+	PermUsersRead      Permission = "users:read"       // 查詢用戶資料
+	PermReportsRead    Permission = "reports:read"     // 查看營運報表
+	PermAccountsManage Permission = "accounts:manage"  // 解鎖、凍結等帳戶管理操作，查看管理審計日誌
+	PermBalanceAdjust  Permission = "balance:adjust"   // 發起和審批人工調整餘額
+	PermGamesSettle    Permission = "games:settle"     // 作廢和重新結算牌局
+)
+
+// rolePermissions 各角色擁有的權限
+var rolePermissions = map[Role][]Permission{
+	RolePlayer: {
+		PermGamePlay,
+		PermWallet,
+		PermHistoryRead,
+	},
+	RoleSupport: {
+		PermHistoryRead,
+		PermUsersRead,
+	},
+	RoleFinance: {
+		PermHistoryRead,
+		PermUsersRead,
+		PermReportsRead,
+		PermBalanceAdjust,
+	},
+	RoleAdmin: {
+		PermGamePlay,
+		PermWallet,
+		PermHistoryRead,
+		PermGameDetailsAll,
+		PermUsersRead,
+		PermReportsRead,
+		PermAccountsManage,
+		PermBalanceAdjust,
+		PermGamesSettle,
+	},
+}
+
+// ParseRole 解析角色字符串
+func ParseRole(s string) (Role, error) {
+	role := Role(s)
+	if _, ok := rolePermissions[role]; !ok {
+		return "", ErrInvalidRole
+	}
+	return role, nil
+}
+
+// Can 判斷角色是否擁有指定權限
+func (r Role) Can(perm Permission) bool {
+	for _, p := range rolePermissions[r] {
+		if p == perm {
+			return true
+		}
+	}
+	return false
+}
+
+// RequiresTwoFactor 員工角色必須啟用兩步驗證，玩家可自行選擇
+func (r Role) RequiresTwoFactor() bool {
+	return r != RolePlayer
+}
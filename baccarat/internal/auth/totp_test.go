@@ -0,0 +1,81 @@
+package auth
+
+import (
+	"encoding/base32"
+	"testing"
+	"time"
+)
+
+// RFC 6238 附錄 B 的 SHA1 測試向量（取後6位）
+func TestGenerateTOTPCode(t *testing.T) {
+	secret := base32.StdEncoding.EncodeToString([]byte("12345678901234567890"))
+
+	tests := []struct {
+		unix     int64
+		expected string
+	}{
+		{59, "287082"},
+		{1111111109, "081804"},
+		{1111111111, "050471"},
+		{1234567890, "005924"},
+		{2000000000, "279037"},
+	}
+
+	for _, tt := range tests {
+		got, err := GenerateTOTPCode(secret, time.Unix(tt.unix, 0))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got != tt.expected {
+			t.Errorf("GenerateTOTPCode(T=%d) = %s, want %s", tt.unix, got, tt.expected)
+		}
+	}
+}
+
+func TestValidateTOTPCode(t *testing.T) {
+	secret, err := GenerateTOTPSecret()
+	if err != nil {
+		t.Fatal(err)
+	}
+	now := time.Unix(1700000000, 0)
+	code, _ := GenerateTOTPCode(secret, now)
+	previous, _ := GenerateTOTPCode(secret, now.Add(-30*time.Second))
+	stale, _ := GenerateTOTPCode(secret, now.Add(-90*time.Second))
+
+	step, ok := ValidateTOTPCode(secret, code, now, 0)
+	if !ok || step != totpStep(now) {
+		t.Fatalf("current code rejected: step=%d ok=%v", step, ok)
+	}
+	if _, ok := ValidateTOTPCode(secret, previous, now, 0); !ok {
+		t.Error("code from previous step should be accepted within skew")
+	}
+	if _, ok := ValidateTOTPCode(secret, stale, now, 0); ok {
+		t.Error("code outside skew should be rejected")
+	}
+	if _, ok := ValidateTOTPCode(secret, code, now, step); ok {
+		t.Error("reused code should be rejected")
+	}
+	if _, ok := ValidateTOTPCode(secret, "12345", now, 0); ok {
+		t.Error("short code should be rejected")
+	}
+}
+
+func TestRecoveryCodes(t *testing.T) {
+	codes, err := GenerateRecoveryCodes(10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	seen := make(map[string]bool)
+	for _, code := range codes {
+		if len(code) != 9 || code[4] != '-' {
+			t.Errorf("unexpected recovery code format %q", code)
+		}
+		if seen[code] {
+			t.Errorf("duplicate recovery code %q", code)
+		}
+		seen[code] = true
+	}
+	if got := NormalizeRecoveryCode(" " + codes[0][:4] + codes[0][5:] + " "); got != codes[0] {
+		t.Errorf("NormalizeRecoveryCode() = %q, want %q", got, codes[0])
+	}
+}
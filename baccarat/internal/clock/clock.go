@@ -0,0 +1,45 @@
+// Package clock 時間來源，後台任務、登入防護和限流通過 Clock 取當前時間，測試時替換為 Fake
+package clock
+
+import (
+	"sync"
+	"time"
+)
+
+// Clock 時間來源
+type Clock interface {
+	Now() time.Time
+}
+
+// System 使用系統時間
+type System struct{}
+
+// Now 返回當前時間
+func (System) Now() time.Time {
+	return time.Now()
+}
+
+// Fake 測試用的假時鐘，只在 Advance 時前進
+type Fake struct {
+	mu  sync.Mutex
+	now time.Time
+}
+
+// NewFake 創建停在 now 的假時鐘
+func NewFake(now time.Time) *Fake {
+	return &Fake{now: now}
+}
+
+// Now 返回假時鐘的當前時間
+func (c *Fake) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+// Advance 將假時鐘向前撥 d
+func (c *Fake) Advance(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.now = c.now.Add(d)
+}
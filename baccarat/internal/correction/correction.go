@@ -0,0 +1,190 @@
+// Package correction 牌局作廢與重新結算的計算規則
+//
+// 修正不改動原始的牌局和下注記錄，只記下每筆下注新的返還金額，
+// 與原返還的差額按用戶記入錢包交易
+package correction
+
+import (
+	"errors"
+	"math"
+	"sort"
+)
+
+var (
+	ErrAlreadyVoided    = errors.New("game is already voided")
+	ErrUnknownBetType   = errors.New("unknown bet type")
+	ErrAmbiguousBetType = errors.New("game has several bets of this type")
+	ErrInvalidReturn    = errors.New("invalid return amount")
+)
+
+// Action 修正類型
+type Action string
+
+const (
+	ActionVoid     Action = "void"     // 作廢：退回全部投注本金
+	ActionResettle Action = "resettle" // 按更正後的牌或返還金額重新結算
+)
+
+// Status 牌局結算狀態
+type Status string
+
+const (
+	StatusSettled   Status = "settled"
+	StatusVoided    Status = "voided"
+	StatusResettled Status = "resettled"
+)
+
+// TransactionType 修正差額記入錢包交易時使用的類型
+func (a Action) TransactionType() string {
+	if a == ActionVoid {
+		return "game_void"
+	}
+	return "game_resettle"
+}
+
+// Bet 一筆下注及其返還金額（含本金）
+type Bet struct {
+	ID      int64   `json:"id"`
+	UserID  int     `json:"userId"`
+	BetType string  `json:"betType"`
+	Amount  float64 `json:"amount"`
+	Return  float64 `json:"return"`
+}
+
+// Snapshot 某一時刻的牌局結果和各筆下注的返還，作為修正記錄保存
+type Snapshot struct {
+	Status      Status   `json:"status"`
+	Winner      string   `json:"winner,omitempty"`
+	PlayerCards []string `json:"playerCards,omitempty"`
+	BankerCards []string `json:"bankerCards,omitempty"`
+	Bets        []Bet    `json:"bets"`
+}
+
+// TotalBets 投注總額
+func (s Snapshot) TotalBets() float64 {
+	total := 0.0
+	for _, b := range s.Bets {
+		total += b.Amount
+	}
+	return roundCents(total)
+}
+
+// TotalReturns 返還總額
+func (s Snapshot) TotalReturns() float64 {
+	total := 0.0
+	for _, b := range s.Bets {
+		total += b.Return
+	}
+	return roundCents(total)
+}
+
+// CanCorrect 作廢是終態，之後不能再作廢或重新結算
+func CanCorrect(status Status) error {
+	if status == StatusVoided {
+		return ErrAlreadyVoided
+	}
+	return nil
+}
+
+// Void 作廢牌局，每筆下注返還本金
+func Void(original Snapshot) Snapshot {
+	corrected := Snapshot{Status: StatusVoided, Bets: make([]Bet, len(original.Bets))}
+	for i, b := range original.Bets {
+		b.Return = b.Amount
+		corrected.Bets[i] = b
+	}
+	return corrected
+}
+
+// Resettle 按更正後的牌重新結算，betReturn 計算單筆下注的返還
+func Resettle(original Snapshot, winner string, playerCards, bankerCards []string, betReturn func(betType string, amount float64) float64) Snapshot {
+	corrected := Snapshot{
+		Status:      StatusResettled,
+		Winner:      winner,
+		PlayerCards: playerCards,
+		BankerCards: bankerCards,
+		Bets:        make([]Bet, len(original.Bets)),
+	}
+	for i, b := range original.Bets {
+		b.Return = roundCents(betReturn(b.BetType, b.Amount))
+		corrected.Bets[i] = b
+	}
+	return corrected
+}
+
+// ResettleReturns 按投注類型直接指定返還金額重新結算，未指定的類型保持不變
+// 同一類型有多筆下注時無法區分，返回 ErrAmbiguousBetType
+func ResettleReturns(original Snapshot, returns map[string]float64) (Snapshot, error) {
+	counts := make(map[string]int)
+	for _, b := range original.Bets {
+		counts[b.BetType]++
+	}
+	for betType, amount := range returns {
+		switch {
+		case counts[betType] == 0:
+			return Snapshot{}, ErrUnknownBetType
+		case counts[betType] > 1:
+			return Snapshot{}, ErrAmbiguousBetType
+		case amount < 0 || math.IsNaN(amount) || math.IsInf(amount, 0):
+			return Snapshot{}, ErrInvalidReturn
+		}
+	}
+
+	corrected := Snapshot{
+		Status:      StatusResettled,
+		Winner:      original.Winner,
+		PlayerCards: original.PlayerCards,
+		BankerCards: original.BankerCards,
+		Bets:        make([]Bet, len(original.Bets)),
+	}
+	for i, b := range original.Bets {
+		if amount, ok := returns[b.BetType]; ok {
+			b.Return = roundCents(amount)
+		}
+		corrected.Bets[i] = b
+	}
+	return corrected, nil
+}
+
+// Delta 修正對單個用戶餘額的影響
+type Delta struct {
+	UserID int     `json:"userId"`
+	Amount float64 `json:"amount"`
+}
+
+// Deltas 按用戶匯總修正前後返還的差額，忽略為零的用戶，按用戶ID排序
+// original 和 corrected 的下注必須一一對應
+func Deltas(original, corrected Snapshot) []Delta {
+	byUser := make(map[int]float64)
+	for i, b := range corrected.Bets {
+		byUser[b.UserID] += b.Return - original.Bets[i].Return
+	}
+
+	deltas := []Delta{}
+	for userID, amount := range byUser {
+		if amount = roundCents(amount); amount != 0 {
+			deltas = append(deltas, Delta{UserID: userID, Amount: amount})
+		}
+	}
+	sort.Slice(deltas, func(i, j int) bool { return deltas[i].UserID < deltas[j].UserID })
+	return deltas
+}
+
+// AffectedUsers 牌局中所有下注用戶的ID（不論差額是否為零），按ID排序
+func (s Snapshot) AffectedUsers() []int {
+	seen := make(map[int]bool)
+	users := []int{}
+	for _, b := range s.Bets {
+		if !seen[b.UserID] {
+			seen[b.UserID] = true
+			users = append(users, b.UserID)
+		}
+	}
+	sort.Ints(users)
+	return users
+}
+
+// roundCents 四捨五入到分，與 DECIMAL(10,2) 一致
+func roundCents(amount float64) float64 {
+	return math.Round(amount*100) / 100
+}
@@ -0,0 +1,110 @@
+package correction
+
+import (
+	"reflect"
+	"testing"
+)
+
+// settled 用戶 1 押閒 100 贏得 200，用戶 2 押莊 50 輸，用戶 1 押和 10 輸
+func settled() Snapshot {
+	return Snapshot{
+		Status:      StatusSettled,
+		Winner:      "Player",
+		PlayerCards: []string{"S8", "HK"},
+		BankerCards: []string{"D3", "C2"},
+		Bets: []Bet{
+			{ID: 1, UserID: 1, BetType: "player", Amount: 100, Return: 200},
+			{ID: 2, UserID: 2, BetType: "banker", Amount: 50, Return: 0},
+			{ID: 3, UserID: 1, BetType: "tie", Amount: 10, Return: 0},
+		},
+	}
+}
+
+func TestVoid(t *testing.T) {
+	original := settled()
+	voided := Void(original)
+	if voided.Status != StatusVoided || voided.Winner != "" {
+		t.Errorf("voided snapshot = %+v", voided)
+	}
+	if voided.TotalReturns() != voided.TotalBets() {
+		t.Errorf("voided returns %v, want stakes %v", voided.TotalReturns(), voided.TotalBets())
+	}
+	if original.Bets[0].Return != 200 {
+		t.Error("Void modified the original snapshot")
+	}
+
+	// 用戶 1：110 - 200 = -90；用戶 2：50 - 0 = 50
+	want := []Delta{{UserID: 1, Amount: -90}, {UserID: 2, Amount: 50}}
+	if got := Deltas(original, voided); !reflect.DeepEqual(got, want) {
+		t.Errorf("Deltas = %+v, want %+v", got, want)
+	}
+}
+
+func TestResettle(t *testing.T) {
+	original := settled()
+	// 莊家贏：押莊返還 1.95 倍
+	corrected := Resettle(original, "Banker", []string{"S2", "HK"}, []string{"D3", "C4"}, func(betType string, amount float64) float64 {
+		if betType == "banker" {
+			return amount * 1.95
+		}
+		return 0
+	})
+	if corrected.Status != StatusResettled || corrected.Winner != "Banker" {
+		t.Errorf("corrected snapshot = %+v", corrected)
+	}
+	want := []Delta{{UserID: 1, Amount: -200}, {UserID: 2, Amount: 97.5}}
+	if got := Deltas(original, corrected); !reflect.DeepEqual(got, want) {
+		t.Errorf("Deltas = %+v, want %+v", got, want)
+	}
+}
+
+func TestResettleReturns(t *testing.T) {
+	original := settled()
+	corrected, err := ResettleReturns(original, map[string]float64{"player": 150})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if corrected.Winner != "Player" || corrected.Bets[1].Return != 0 {
+		t.Errorf("unspecified bets should be unchanged: %+v", corrected)
+	}
+	want := []Delta{{UserID: 1, Amount: -50}}
+	if got := Deltas(original, corrected); !reflect.DeepEqual(got, want) {
+		t.Errorf("Deltas = %+v, want %+v", got, want)
+	}
+
+	tests := []struct {
+		returns map[string]float64
+		want    error
+	}{
+		{map[string]float64{"luckySix": 10}, ErrUnknownBetType},
+		{map[string]float64{"player": -1}, ErrInvalidReturn},
+	}
+	for _, tt := range tests {
+		if _, err := ResettleReturns(original, tt.returns); err != tt.want {
+			t.Errorf("ResettleReturns(%v) error = %v, want %v", tt.returns, err, tt.want)
+		}
+	}
+
+	original.Bets = append(original.Bets, Bet{ID: 4, UserID: 3, BetType: "player", Amount: 20, Return: 40})
+	if _, err := ResettleReturns(original, map[string]float64{"player": 0}); err != ErrAmbiguousBetType {
+		t.Errorf("duplicate bet type error = %v, want ErrAmbiguousBetType", err)
+	}
+}
+
+func TestCanCorrect(t *testing.T) {
+	if err := CanCorrect(StatusSettled); err != nil {
+		t.Errorf("settled: %v", err)
+	}
+	if err := CanCorrect(StatusResettled); err != nil {
+		t.Errorf("resettled: %v", err)
+	}
+	if err := CanCorrect(StatusVoided); err != ErrAlreadyVoided {
+		t.Errorf("voided: %v, want ErrAlreadyVoided", err)
+	}
+}
+
+func TestAffectedUsers(t *testing.T) {
+	if got := settled().AffectedUsers(); !reflect.DeepEqual(got, []int{1, 2}) {
+		t.Errorf("AffectedUsers = %v", got)
+	}
+}
@@ -0,0 +1,88 @@
+// Package events 向 webhook 服務發布業務事件
+//
+// 事件先在業務事務內寫入 event_outbox，再由 Relay 異步投遞，
+// 保證事件與狀態變更同時提交；接收方以事件 id 去重，因此允許重複投遞
+package events
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// 事件類型，與 webhook 服務註冊的結構一致（均為 v1）
+const (
+	TypeGameVoided        = "game.voided"
+	TypeGameResettled     = "game.resettled"
+	TypeWalletTransaction = "wallet.transaction"
+)
+
+// 簽名請求頭，格式與 webhook 服務一致
+const (
+	SignatureHeader = "X-Webhook-Signature"
+	TimestampHeader = "X-Webhook-Timestamp"
+	signaturePrefix = "sha256="
+)
+
+// Event 帶版本的事件信封
+type Event struct {
+	ID        string      `json:"id"`
+	Type      string      `json:"type"`
+	Version   int         `json:"version"`
+	CreatedAt time.Time   `json:"created_at"`
+	Data      interface{} `json:"data"`
+}
+
+// New 創建 v1 事件
+func New(eventType string, data interface{}) *Event {
+	return &Event{
+		ID:        "evt_" + uuid.New().String(),
+		Type:      eventType,
+		Version:   1,
+		CreatedAt: time.Now().UTC(),
+		Data:      data,
+	}
+}
+
+// Payload 序列化事件
+func (e *Event) Payload() ([]byte, error) {
+	return json.Marshal(e)
+}
+
+// GameVoided game.voided 事件數據
+type GameVoided struct {
+	GameID        string `json:"game_id"`
+	Reason        string `json:"reason"`
+	AffectedUsers []int  `json:"affected_users"`
+}
+
+// GameResettled game.resettled 事件數據
+type GameResettled struct {
+	GameID        string  `json:"game_id"`
+	Winner        string  `json:"winner"`
+	TotalBets     float64 `json:"total_bets"`
+	TotalPayouts  float64 `json:"total_payouts"`
+	Reason        string  `json:"reason"`
+	AffectedUsers []int   `json:"affected_users"`
+}
+
+// WalletTransaction wallet.transaction 事件數據
+type WalletTransaction struct {
+	UserID          int     `json:"user_id"`
+	Amount          float64 `json:"amount"`
+	TransactionType string  `json:"transaction_type"`
+	Reference       string  `json:"reference,omitempty"`
+}
+
+// Sign 計算 "<timestamp>.<body>" 的 HMAC-SHA256 簽名，返回請求頭的值
+func Sign(secret []byte, timestamp string, body []byte) string {
+	mac := hmac.New(sha256.New, secret)
+	mac.Write([]byte(timestamp))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return signaturePrefix + hex.EncodeToString(mac.Sum(nil))
+}
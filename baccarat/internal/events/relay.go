@@ -0,0 +1,169 @@
+package events
+
+import (
+	"baccarat/db"
+	"baccarat/internal/clock"
+	"baccarat/pkg/logger"
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+const (
+	relayBatchSize = 50
+	retryBaseDelay = 10 * time.Second
+	retryMaxDelay  = time.Hour
+)
+
+// Pending 待投遞的事件
+type Pending struct {
+	ID       int64
+	Payload  []byte
+	Attempts int
+}
+
+// Outbox 事件發件箱
+type Outbox interface {
+	Pending(now time.Time, limit int) ([]Pending, error)
+	MarkSent(id int64) error
+	MarkFailed(id int64, attempts int, nextAttempt time.Time, reason string) error
+}
+
+// Relay 將發件箱中的事件簽名後投遞到 webhook 服務，失敗時按指數退避重試
+type Relay struct {
+	URL      string
+	Secret   []byte
+	Interval time.Duration
+	client   *http.Client
+	outbox   Outbox
+	clock    clock.Clock
+}
+
+// NewRelay 創建事件投遞任務
+func NewRelay(url string, secret []byte, interval time.Duration, outbox Outbox, clock clock.Clock) *Relay {
+	return &Relay{
+		URL:      url,
+		Secret:   secret,
+		Interval: interval,
+		client:   &http.Client{Timeout: 10 * time.Second},
+		outbox:   outbox,
+		clock:    clock,
+	}
+}
+
+// retryDelay 第 n 次失敗後的等待時間
+func retryDelay(attempts int) time.Duration {
+	delay := retryBaseDelay
+	for i := 1; i < attempts && delay < retryMaxDelay; i++ {
+		delay *= 2
+	}
+	if delay > retryMaxDelay {
+		delay = retryMaxDelay
+	}
+	return delay
+}
+
+// send 投遞單個事件，2xx 視為成功
+func (r *Relay) send(payload []byte) error {
+	timestamp := strconv.FormatInt(r.clock.Now().Unix(), 10)
+	req, err := http.NewRequest(http.MethodPost, r.URL, bytes.NewReader(payload))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(TimestampHeader, timestamp)
+	req.Header.Set(SignatureHeader, Sign(r.Secret, timestamp, payload))
+
+	resp, err := r.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 4096))
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("webhook responded %d", resp.StatusCode)
+	}
+	return nil
+}
+
+// RunOnce 投遞一批到期的事件，返回成功投遞的數量
+func (r *Relay) RunOnce() (int, error) {
+	now := r.clock.Now()
+	pending, err := r.outbox.Pending(now, relayBatchSize)
+	if err != nil {
+		return 0, err
+	}
+
+	sent := 0
+	for _, p := range pending {
+		if err := r.send(p.Payload); err != nil {
+			attempts := p.Attempts + 1
+			logger.Warn("Event delivery failed, outbox id:", p.ID, "attempt:", attempts, "Error:", err)
+			if err := r.outbox.MarkFailed(p.ID, attempts, now.Add(retryDelay(attempts)), err.Error()); err != nil {
+				return sent, err
+			}
+			continue
+		}
+		if err := r.outbox.MarkSent(p.ID); err != nil {
+			return sent, err
+		}
+		sent++
+	}
+	return sent, nil
+}
+
+// Run 按 Interval 循環投遞，直到 ctx 取消
+func (r *Relay) Run(ctx context.Context) {
+	ticker := time.NewTicker(r.Interval)
+	defer ticker.Stop()
+
+	for {
+		if _, err := r.RunOnce(); err != nil {
+			logger.Error("Event relay failed:", err)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// DBOutbox 以 event_outbox 表作為發件箱
+type DBOutbox struct{}
+
+// Pending 查詢到期待投遞的事件
+func (DBOutbox) Pending(now time.Time, limit int) ([]Pending, error) {
+	rows, err := db.ListPendingEvents(now, limit)
+	if err != nil {
+		return nil, err
+	}
+	pending := make([]Pending, len(rows))
+	for i, row := range rows {
+		pending[i] = Pending{ID: row.ID, Payload: row.Payload, Attempts: row.Attempts}
+	}
+	return pending, nil
+}
+
+// MarkSent 標記已投遞
+func (DBOutbox) MarkSent(id int64) error {
+	return db.MarkEventSent(id)
+}
+
+// MarkFailed 記錄失敗並安排下次重試
+func (DBOutbox) MarkFailed(id int64, attempts int, nextAttempt time.Time, reason string) error {
+	return db.MarkEventFailed(id, attempts, nextAttempt, reason)
+}
+
+// Enqueue 在事務內把事件寫入發件箱
+func Enqueue(exec db.Execer, e *Event) error {
+	payload, err := e.Payload()
+	if err != nil {
+		return err
+	}
+	return db.SaveEvent(exec, e.ID, e.Type, payload)
+}
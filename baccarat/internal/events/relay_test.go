@@ -0,0 +1,119 @@
+package events
+
+import (
+	"baccarat/internal/clock"
+	"baccarat/pkg/logger"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestMain(m *testing.M) {
+	logger.InitLogger()
+	os.Exit(m.Run())
+}
+
+type fakeOutbox struct {
+	pending []Pending
+	sent    []int64
+	failed  map[int64]time.Time
+}
+
+func (o *fakeOutbox) Pending(now time.Time, limit int) ([]Pending, error) {
+	return o.pending, nil
+}
+
+func (o *fakeOutbox) MarkSent(id int64) error {
+	o.sent = append(o.sent, id)
+	return nil
+}
+
+func (o *fakeOutbox) MarkFailed(id int64, attempts int, nextAttempt time.Time, reason string) error {
+	o.failed[id] = nextAttempt
+	return nil
+}
+
+func TestSign(t *testing.T) {
+	// echo -n '1700000000.{}' | openssl dgst -sha256 -hmac secret
+	want := "sha256=b8569b78799ff9e3cbff0fc2d63a33a2b57f3282abd07c37ae5e8e7d79a5f163"
+	if got := Sign([]byte("secret"), "1700000000", []byte("{}")); got != want {
+		t.Errorf("Sign = %q, want %q", got, want)
+	}
+}
+
+func TestRetryDelay(t *testing.T) {
+	tests := []struct {
+		attempts int
+		want     time.Duration
+	}{
+		{1, 10 * time.Second},
+		{2, 20 * time.Second},
+		{5, 160 * time.Second},
+		{20, time.Hour},
+	}
+	for _, tt := range tests {
+		if got := retryDelay(tt.attempts); got != tt.want {
+			t.Errorf("retryDelay(%d) = %v, want %v", tt.attempts, got, tt.want)
+		}
+	}
+}
+
+func TestRelayRunOnce(t *testing.T) {
+	secret := []byte("secret")
+	clock := clock.NewFake(time.Unix(1700000000, 0))
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		if r.Header.Get(TimestampHeader) != "1700000000" ||
+			r.Header.Get(SignatureHeader) != Sign(secret, "1700000000", body) {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		if string(body) == `{"fail":true}` {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		w.WriteHeader(http.StatusAccepted)
+	}))
+	defer server.Close()
+
+	outbox := &fakeOutbox{
+		pending: []Pending{
+			{ID: 1, Payload: []byte(`{"id":"evt_1"}`)},
+			{ID: 2, Payload: []byte(`{"fail":true}`), Attempts: 2},
+		},
+		failed: make(map[int64]time.Time),
+	}
+	relay := NewRelay(server.URL, secret, time.Minute, outbox, clock)
+
+	sent, err := relay.RunOnce()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if sent != 1 || len(outbox.sent) != 1 || outbox.sent[0] != 1 {
+		t.Errorf("sent = %d, %v", sent, outbox.sent)
+	}
+	// 第 3 次失敗後等待 40 秒
+	if next, ok := outbox.failed[2]; !ok || !next.Equal(clock.Now().Add(40*time.Second)) {
+		t.Errorf("failed event next attempt = %v, %v", next, ok)
+	}
+}
+
+func TestNewEvent(t *testing.T) {
+	e := New(TypeGameVoided, GameVoided{GameID: "g1", Reason: "dealer error", AffectedUsers: []int{1}})
+	if e.Version != 1 || len(e.ID) != len("evt_")+36 || e.ID[:4] != "evt_" {
+		t.Errorf("event = %+v", e)
+	}
+	payload, err := e.Payload()
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `"data":{"game_id":"g1","reason":"dealer error","affected_users":[1]}`
+	if !strings.Contains(string(payload), want) {
+		t.Errorf("payload = %s", payload)
+	}
+}
@@ -0,0 +1,86 @@
+package limits
+
+import (
+	"errors"
+	"time"
+)
+
+// ExclusionKind 帳戶暫停類型
+type ExclusionKind string
+
+const (
+	// KindCoolingOff 短期冷靜期
+	KindCoolingOff ExclusionKind = "cooling_off"
+	// KindSelfExclusion 長期或永久自我排除
+	KindSelfExclusion ExclusionKind = "self_exclusion"
+)
+
+var ErrInvalidExclusion = errors.New("invalid exclusion type or duration")
+
+// 每種類型可選的時長，空值代表永久
+var exclusionDurations = map[ExclusionKind]map[string]func(time.Time) *time.Time{
+	KindCoolingOff: {
+		"24h": addDate(0, 0, 1),
+		"7d":  addDate(0, 0, 7),
+		"30d": addDate(0, 0, 30),
+		"42d": addDate(0, 0, 42),
+	},
+	KindSelfExclusion: {
+		"6m":        addDate(0, 6, 0),
+		"1y":        addDate(1, 0, 0),
+		"5y":        addDate(5, 0, 0),
+		"permanent": func(time.Time) *time.Time { return nil },
+	},
+}
+
+func addDate(years, months, days int) func(time.Time) *time.Time {
+	return func(start time.Time) *time.Time {
+		end := start.AddDate(years, months, days)
+		return &end
+	}
+}
+
+// Exclusion 一次冷靜期或自我排除，EndsAt 為 nil 代表永久
+type Exclusion struct {
+	ID       int64         `json:"id"`
+	Kind     ExclusionKind `json:"type"`
+	StartsAt time.Time     `json:"startsAt"`
+	EndsAt   *time.Time    `json:"endsAt"`
+	LiftedAt *time.Time    `json:"liftedAt,omitempty"`
+	LiftedBy *int          `json:"liftedBy,omitempty"`
+	Reason   string        `json:"reason,omitempty"`
+}
+
+// NewExclusion 按類型和時長（如 "7d"、"1y"、"permanent"）創建從 now 開始的排除
+func NewExclusion(kind ExclusionKind, duration string, now time.Time) (*Exclusion, error) {
+	durations, ok := exclusionDurations[kind]
+	if !ok {
+		return nil, ErrInvalidExclusion
+	}
+	end, ok := durations[duration]
+	if !ok {
+		return nil, ErrInvalidExclusion
+	}
+	return &Exclusion{Kind: kind, StartsAt: now, EndsAt: end(now)}, nil
+}
+
+// Permanent 是否為永久排除
+func (e *Exclusion) Permanent() bool {
+	return e.EndsAt == nil
+}
+
+// Active 排除在 now 時是否仍然有效
+func (e *Exclusion) Active(now time.Time) bool {
+	if e.LiftedAt != nil {
+		return false
+	}
+	return e.EndsAt == nil || now.Before(*e.EndsAt)
+}
+
+// Extends 新的排除是否比當前的持續更久（生效中的排除只能延長，不能縮短）
+func (e *Exclusion) Extends(current *Exclusion) bool {
+	if current == nil || current.EndsAt == nil {
+		return current == nil
+	}
+	return e.EndsAt == nil || e.EndsAt.After(*current.EndsAt)
+}
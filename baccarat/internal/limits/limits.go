@@ -0,0 +1,162 @@
+// Package limits 實現玩家自設的負責任博彩限額（存款、虧損、投注）
+package limits
+
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+// Type 限額類型
+type Type string
+
+const (
+	TypeDeposit Type = "deposit"
+	TypeLoss    Type = "loss"
+	TypeWager   Type = "wager"
+)
+
+// Period 限額週期，按自然日、自然週（週一開始）和自然月計算
+type Period string
+
+const (
+	PeriodDaily   Period = "daily"
+	PeriodWeekly  Period = "weekly"
+	PeriodMonthly Period = "monthly"
+)
+
+var (
+	ErrInvalidType   = errors.New("invalid limit type")
+	ErrInvalidPeriod = errors.New("invalid limit period")
+	ErrInvalidAmount = errors.New("invalid limit amount")
+)
+
+// Types 所有限額類型
+var Types = []Type{TypeDeposit, TypeLoss, TypeWager}
+
+// Periods 所有限額週期
+var Periods = []Period{PeriodDaily, PeriodWeekly, PeriodMonthly}
+
+// ParseType 解析限額類型
+func ParseType(s string) (Type, error) {
+	for _, t := range Types {
+		if string(t) == s {
+			return t, nil
+		}
+	}
+	return "", ErrInvalidType
+}
+
+// ParsePeriod 解析限額週期
+func ParsePeriod(s string) (Period, error) {
+	for _, p := range Periods {
+		if string(p) == s {
+			return p, nil
+		}
+	}
+	return "", ErrInvalidPeriod
+}
+
+// Start 返回 now 所在週期的開始時間
+func (p Period) Start(now time.Time) time.Time {
+	day := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+	switch p {
+	case PeriodWeekly:
+		offset := (int(day.Weekday()) + 6) % 7 // 週一為 0
+		return day.AddDate(0, 0, -offset)
+	case PeriodMonthly:
+		return time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, now.Location())
+	}
+	return day
+}
+
+// End 返回 now 所在週期的結束時間（即下一週期開始）
+func (p Period) End(now time.Time) time.Time {
+	start := p.Start(now)
+	switch p {
+	case PeriodWeekly:
+		return start.AddDate(0, 0, 7)
+	case PeriodMonthly:
+		return start.AddDate(0, 1, 0)
+	}
+	return start.AddDate(0, 0, 1)
+}
+
+// Limit 一個用戶在某類型和週期上的限額
+// Amount 為 0 代表沒有限額；Pending 為等待冷靜期結束後生效的新值（0 代表取消限額）
+type Limit struct {
+	Type               Type
+	Period             Period
+	Amount             float64
+	PendingAmount      *float64
+	PendingEffectiveAt *time.Time
+}
+
+// Effective 返回 now 時生效的限額，ok 為 false 代表沒有限額
+func (l Limit) Effective(now time.Time) (float64, bool) {
+	amount := l.Amount
+	if l.PendingAmount != nil && l.PendingEffectiveAt != nil && !now.Before(*l.PendingEffectiveAt) {
+		amount = *l.PendingAmount
+	}
+	return amount, amount > 0
+}
+
+// Change 計算設置新限額後的結果：收緊立即生效，放寬或取消需等待冷靜期
+func (l Limit) Change(amount float64, now time.Time, coolingOff time.Duration) (Limit, error) {
+	if amount < 0 {
+		return l, ErrInvalidAmount
+	}
+	current, limited := l.Effective(now)
+	next := Limit{Type: l.Type, Period: l.Period, Amount: current}
+	if !limited {
+		next.Amount = 0
+	}
+
+	tighter := amount > 0 && (!limited || amount <= current)
+	if tighter {
+		next.Amount = amount
+		return next, nil
+	}
+	if !limited {
+		// 本來就沒有限額，取消操作無需處理
+		return next, nil
+	}
+
+	effectiveAt := now.Add(coolingOff)
+	next.PendingAmount = &amount
+	next.PendingEffectiveAt = &effectiveAt
+	return next, nil
+}
+
+// ExceededError 本次操作將超出限額
+type ExceededError struct {
+	Type      Type
+	Period    Period
+	Limit     float64
+	Remaining float64
+}
+
+func (e *ExceededError) Error() string {
+	return fmt.Sprintf("%s %s limit exceeded: remaining %.2f of %.2f", e.Period, e.Type, e.Remaining, e.Limit)
+}
+
+// Remaining 返回剩餘額度（不小於 0）
+func Remaining(limit, used float64) float64 {
+	if used >= limit {
+		return 0
+	}
+	return limit - used
+}
+
+// Check 檢查在已用額度 used 上再增加 amount 是否超出生效中的限額
+func (l Limit) Check(now time.Time, used, amount float64) error {
+	limit, ok := l.Effective(now)
+	if !ok {
+		return nil
+	}
+	// 金額以分為單位存儲，容忍浮點誤差
+	if used+amount > limit+0.005 {
+		return &ExceededError{Type: l.Type, Period: l.Period, Limit: limit, Remaining: Remaining(limit, used)}
+	}
+	return nil
+}
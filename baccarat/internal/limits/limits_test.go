@@ -0,0 +1,156 @@
+package limits
+
+import (
+	"testing"
+	"time"
+)
+
+func TestPeriodStart(t *testing.T) {
+	loc := time.FixedZone("UTC+8", 8*3600)
+	// 2025-01-15 是週三
+	now := time.Date(2025, 1, 15, 13, 30, 0, 0, loc)
+
+	tests := []struct {
+		period    Period
+		wantStart time.Time
+		wantEnd   time.Time
+	}{
+		{PeriodDaily, time.Date(2025, 1, 15, 0, 0, 0, 0, loc), time.Date(2025, 1, 16, 0, 0, 0, 0, loc)},
+		{PeriodWeekly, time.Date(2025, 1, 13, 0, 0, 0, 0, loc), time.Date(2025, 1, 20, 0, 0, 0, 0, loc)},
+		{PeriodMonthly, time.Date(2025, 1, 1, 0, 0, 0, 0, loc), time.Date(2025, 2, 1, 0, 0, 0, 0, loc)},
+	}
+	for _, tt := range tests {
+		if got := tt.period.Start(now); !got.Equal(tt.wantStart) {
+			t.Errorf("%s.Start() = %v, want %v", tt.period, got, tt.wantStart)
+		}
+		if got := tt.period.End(now); !got.Equal(tt.wantEnd) {
+			t.Errorf("%s.End() = %v, want %v", tt.period, got, tt.wantEnd)
+		}
+	}
+
+	// 週日屬於前一個週一開始的週
+	sunday := time.Date(2025, 1, 19, 23, 0, 0, 0, loc)
+	if got := PeriodWeekly.Start(sunday); !got.Equal(time.Date(2025, 1, 13, 0, 0, 0, 0, loc)) {
+		t.Errorf("PeriodWeekly.Start(sunday) = %v", got)
+	}
+}
+
+func TestChange(t *testing.T) {
+	now := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+	coolingOff := 24 * time.Hour
+	base := Limit{Type: TypeDeposit, Period: PeriodDaily, Amount: 100}
+
+	// 收緊立即生效
+	tighter, err := base.Change(50, now, coolingOff)
+	if err != nil || tighter.Amount != 50 || tighter.PendingAmount != nil {
+		t.Errorf("decrease = %+v, %v, want immediate 50", tighter, err)
+	}
+
+	// 放寬需等待冷靜期
+	looser, err := base.Change(200, now, coolingOff)
+	if err != nil || looser.Amount != 100 || looser.PendingAmount == nil || *looser.PendingAmount != 200 {
+		t.Fatalf("increase = %+v, %v, want pending 200", looser, err)
+	}
+	if amount, _ := looser.Effective(now.Add(23 * time.Hour)); amount != 100 {
+		t.Errorf("Effective() before cooling-off = %v, want 100", amount)
+	}
+	if amount, _ := looser.Effective(now.Add(24 * time.Hour)); amount != 200 {
+		t.Errorf("Effective() after cooling-off = %v, want 200", amount)
+	}
+
+	// 取消限額同樣需要冷靜期
+	removed, _ := base.Change(0, now, coolingOff)
+	if _, ok := removed.Effective(now); !ok {
+		t.Error("removal should not take effect immediately")
+	}
+	if _, ok := removed.Effective(now.Add(coolingOff)); ok {
+		t.Error("removal should take effect after cooling-off")
+	}
+
+	// 沒有限額時設置新限額立即生效
+	none := Limit{Type: TypeLoss, Period: PeriodWeekly}
+	set, _ := none.Change(300, now, coolingOff)
+	if amount, ok := set.Effective(now); !ok || amount != 300 {
+		t.Errorf("new limit = %v, %v, want 300", amount, ok)
+	}
+
+	// 等待中的放寬可被收緊覆蓋
+	reset, _ := looser.Change(80, now.Add(time.Hour), coolingOff)
+	if reset.Amount != 80 || reset.PendingAmount != nil {
+		t.Errorf("decrease with pending increase = %+v, want immediate 80", reset)
+	}
+
+	if _, err := base.Change(-1, now, coolingOff); err != ErrInvalidAmount {
+		t.Errorf("negative amount error = %v, want ErrInvalidAmount", err)
+	}
+}
+
+func TestCheck(t *testing.T) {
+	now := time.Now()
+	limit := Limit{Type: TypeWager, Period: PeriodDaily, Amount: 100}
+
+	if err := limit.Check(now, 60, 40); err != nil {
+		t.Errorf("Check() at limit error = %v", err)
+	}
+	err := limit.Check(now, 60, 50)
+	exceeded, ok := err.(*ExceededError)
+	if !ok || exceeded.Remaining != 40 {
+		t.Errorf("Check() over limit = %v, want remaining 40", err)
+	}
+	if err := (Limit{Type: TypeWager, Period: PeriodDaily}).Check(now, 1e6, 1e6); err != nil {
+		t.Errorf("Check() without limit error = %v", err)
+	}
+}
+
+func TestExclusion(t *testing.T) {
+	now := time.Date(2025, 1, 31, 10, 0, 0, 0, time.UTC)
+
+	coolingOff, err := NewExclusion(KindCoolingOff, "7d", now)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !coolingOff.Active(now.Add(6*24*time.Hour)) || coolingOff.Active(now.Add(7*24*time.Hour)) {
+		t.Errorf("cooling-off should end after 7 days, ends at %v", coolingOff.EndsAt)
+	}
+
+	halfYear, _ := NewExclusion(KindSelfExclusion, "6m", now)
+	if want := time.Date(2025, 7, 31, 10, 0, 0, 0, time.UTC); !halfYear.EndsAt.Equal(want) {
+		t.Errorf("6m exclusion ends at %v, want %v", halfYear.EndsAt, want)
+	}
+
+	permanent, _ := NewExclusion(KindSelfExclusion, "permanent", now)
+	if !permanent.Permanent() || !permanent.Active(now.AddDate(50, 0, 0)) {
+		t.Error("permanent exclusion should never end")
+	}
+
+	// 只能延長，不能縮短
+	if !halfYear.Extends(coolingOff) || coolingOff.Extends(halfYear) {
+		t.Error("longer exclusion should extend shorter one")
+	}
+	if !permanent.Extends(halfYear) || halfYear.Extends(permanent) || permanent.Extends(permanent) {
+		t.Error("only a fixed-term exclusion can be extended to permanent")
+	}
+	if !coolingOff.Extends(nil) {
+		t.Error("any exclusion should apply when none is active")
+	}
+
+	lifted := *halfYear
+	liftedAt := now.Add(time.Hour)
+	lifted.LiftedAt = &liftedAt
+	if lifted.Active(now.Add(2 * time.Hour)) {
+		t.Error("lifted exclusion should not be active")
+	}
+
+	for _, tt := range []struct {
+		kind     ExclusionKind
+		duration string
+	}{
+		{KindCoolingOff, "permanent"},
+		{KindCoolingOff, "1y"},
+		{"vacation", "7d"},
+	} {
+		if _, err := NewExclusion(tt.kind, tt.duration, now); err != ErrInvalidExclusion {
+			t.Errorf("NewExclusion(%s, %s) error = %v, want ErrInvalidExclusion", tt.kind, tt.duration, err)
+		}
+	}
+}
@@ -0,0 +1,299 @@
+// Package migrate 按版本執行資料庫結構遷移
+//
+// 已執行的版本記錄在 schema_migrations 表中。MySQL 的 DDL 不能回滾，
+// 執行中的遷移先標記為 dirty，完成後才清除；中途失敗時需人工修復後用 force 指定版本
+package migrate
+
+import (
+	"baccarat/db"
+	"baccarat/db/migrations"
+	"errors"
+	"fmt"
+	"io/fs"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+var (
+	ErrDirty          = errors.New("schema is dirty: a migration failed part way, fix it manually and run migrate force")
+	ErrUnknownVersion = errors.New("unknown migration version")
+	ErrNewerSchema    = errors.New("schema is newer than this binary")
+)
+
+// Migration 一個版本的升級和回退腳本
+type Migration struct {
+	Version int
+	Name    string
+	Up      string
+	Down    string
+}
+
+// State 資料庫當前的結構版本，沒有執行過遷移時 Version 為 0
+type State struct {
+	Version int
+	Dirty   bool
+}
+
+// Store 遷移記錄和語句執行
+type Store interface {
+	State() (State, error)
+	Exec(statement string) error
+	// SetVersion 記錄版本及其是否 dirty，並刪除更高版本的記錄
+	SetVersion(version int, name string, dirty bool) error
+}
+
+// VersionError 資料庫結構版本低於程序要求
+type VersionError struct {
+	Current  int
+	Required int
+}
+
+func (e *VersionError) Error() string {
+	return fmt.Sprintf("schema version %d is older than required %d, run: baccarat migrate up", e.Current, e.Required)
+}
+
+// Load 從目錄讀取遷移腳本，每個版本必須同時有 up 和 down
+func Load(fsys fs.FS) ([]Migration, error) {
+	files, err := fs.Glob(fsys, "*.sql")
+	if err != nil {
+		return nil, err
+	}
+
+	byVersion := make(map[int]*Migration)
+	for _, file := range files {
+		base := path.Base(file)
+		var direction string
+		switch {
+		case strings.HasSuffix(base, ".up.sql"):
+			direction = "up"
+		case strings.HasSuffix(base, ".down.sql"):
+			direction = "down"
+		default:
+			return nil, fmt.Errorf("migration %s: name must end in .up.sql or .down.sql", base)
+		}
+		stem := strings.TrimSuffix(base, "."+direction+".sql")
+		parts := strings.SplitN(stem, "_", 2)
+		version, err := strconv.Atoi(parts[0])
+		if len(parts) != 2 || err != nil || version <= 0 {
+			return nil, fmt.Errorf("migration %s: name must look like 0001_name.%s.sql", base, direction)
+		}
+
+		content, err := fs.ReadFile(fsys, file)
+		if err != nil {
+			return nil, err
+		}
+		m, ok := byVersion[version]
+		if !ok {
+			m = &Migration{Version: version, Name: parts[1]}
+			byVersion[version] = m
+		} else if m.Name != parts[1] {
+			return nil, fmt.Errorf("migration %d: conflicting names %s and %s", version, m.Name, parts[1])
+		}
+		if direction == "up" {
+			m.Up = string(content)
+		} else {
+			m.Down = string(content)
+		}
+	}
+
+	migrations := make([]Migration, 0, len(byVersion))
+	for _, m := range byVersion {
+		if m.Up == "" || m.Down == "" {
+			return nil, fmt.Errorf("migration %04d_%s: both up and down scripts are required", m.Version, m.Name)
+		}
+		migrations = append(migrations, *m)
+	}
+	sort.Slice(migrations, func(i, j int) bool { return migrations[i].Version < migrations[j].Version })
+	return migrations, nil
+}
+
+// LoadEmbedded 載入當前數據庫類型（MySQL 或 SQLite）對應的內嵌遷移腳本
+func LoadEmbedded() ([]Migration, error) {
+	if db.Driver() == db.DriverSQLite {
+		return Load(migrations.SQLiteFS)
+	}
+	return Load(migrations.FS)
+}
+
+// SplitStatements 按行末的分號拆分語句（行末的 -- 註釋不計），忽略只有註釋的片段
+// 字符串中不能包含 --
+func SplitStatements(script string) []string {
+	var statements []string
+	var current []string
+	hasSQL := false
+	for _, line := range strings.Split(script, "\n") {
+		current = append(current, line)
+		// 去掉行末註釋後再判斷是否以分號結束
+		code := strings.TrimSpace(line)
+		if i := strings.Index(code, "--"); i >= 0 {
+			code = strings.TrimSpace(code[:i])
+		}
+		if code == "" {
+			continue
+		}
+		hasSQL = true
+		if strings.HasSuffix(code, ";") {
+			current[len(current)-1] = strings.TrimSuffix(code, ";")
+			statements = append(statements, strings.TrimSpace(strings.Join(current, "\n")))
+			current, hasSQL = nil, false
+		}
+	}
+	if hasSQL {
+		statements = append(statements, strings.TrimSpace(strings.Join(current, "\n")))
+	}
+	return statements
+}
+
+// Migrator 執行遷移
+type Migrator struct {
+	migrations []Migration
+	store      Store
+}
+
+// New 創建遷移器，migrations 需按版本排序
+func New(migrations []Migration, store Store) *Migrator {
+	return &Migrator{migrations: migrations, store: store}
+}
+
+// Latest 程序內最新的版本
+func (m *Migrator) Latest() int {
+	if len(m.migrations) == 0 {
+		return 0
+	}
+	return m.migrations[len(m.migrations)-1].Version
+}
+
+// State 資料庫當前的版本
+func (m *Migrator) State() (State, error) {
+	return m.store.State()
+}
+
+// Check 啟動時檢查結構版本：dirty 或低於程序要求時返回錯誤
+// 資料庫版本高於程序時（新版本已遷移後回滾程序）視為兼容，遷移應保持向後兼容
+func (m *Migrator) Check() (State, error) {
+	state, err := m.store.State()
+	if err != nil {
+		return state, err
+	}
+	if state.Dirty {
+		return state, ErrDirty
+	}
+	if state.Version < m.Latest() {
+		return state, &VersionError{Current: state.Version, Required: m.Latest()}
+	}
+	return state, nil
+}
+
+// Up 執行最多 n 個未執行的遷移，n <= 0 時執行全部，返回已執行的遷移
+func (m *Migrator) Up(n int) ([]Migration, error) {
+	state, err := m.store.State()
+	if err != nil {
+		return nil, err
+	}
+	if state.Dirty {
+		return nil, ErrDirty
+	}
+
+	var applied []Migration
+	for _, migration := range m.migrations {
+		if migration.Version <= state.Version {
+			continue
+		}
+		if n > 0 && len(applied) == n {
+			break
+		}
+		if err := m.up(migration); err != nil {
+			return applied, err
+		}
+		applied = append(applied, migration)
+	}
+	return applied, nil
+}
+
+// Down 回退最近的 n 個遷移，返回已回退的遷移
+func (m *Migrator) Down(n int) ([]Migration, error) {
+	state, err := m.store.State()
+	if err != nil {
+		return nil, err
+	}
+	if state.Dirty {
+		return nil, ErrDirty
+	}
+	if state.Version > m.Latest() {
+		return nil, ErrNewerSchema
+	}
+
+	var reverted []Migration
+	for i := len(m.migrations) - 1; i >= 0 && len(reverted) < n; i-- {
+		migration := m.migrations[i]
+		if migration.Version > state.Version {
+			continue
+		}
+		// 回退後的版本為前一個遷移，沒有時為 0
+		previous, previousName := 0, ""
+		if i > 0 {
+			previous, previousName = m.migrations[i-1].Version, m.migrations[i-1].Name
+		}
+		if err := m.store.SetVersion(migration.Version, migration.Name, true); err != nil {
+			return reverted, err
+		}
+		for _, statement := range SplitStatements(migration.Down) {
+			if err := m.store.Exec(statement); err != nil {
+				return reverted, fmt.Errorf("migration %04d_%s down: %w", migration.Version, migration.Name, err)
+			}
+		}
+		if err := m.store.SetVersion(previous, previousName, false); err != nil {
+			return reverted, err
+		}
+		reverted = append(reverted, migration)
+	}
+	return reverted, nil
+}
+
+// Force 不執行腳本，直接把版本設為 version 並清除 dirty，用於修復失敗的遷移或接管已有的資料庫
+func (m *Migrator) Force(version int) error {
+	if version == 0 {
+		return m.store.SetVersion(0, "", false)
+	}
+	for _, migration := range m.migrations {
+		if migration.Version == version {
+			return m.store.SetVersion(version, migration.Name, false)
+		}
+	}
+	return ErrUnknownVersion
+}
+
+// up 標記 dirty 後逐條執行升級腳本，全部成功後清除 dirty
+func (m *Migrator) up(migration Migration) error {
+	if err := m.store.SetVersion(migration.Version, migration.Name, true); err != nil {
+		return err
+	}
+	for _, statement := range SplitStatements(migration.Up) {
+		if err := m.store.Exec(statement); err != nil {
+			return fmt.Errorf("migration %04d_%s up: %w", migration.Version, migration.Name, err)
+		}
+	}
+	return m.store.SetVersion(migration.Version, migration.Name, false)
+}
+
+// DBStore 以 schema_migrations 表記錄版本
+type DBStore struct{}
+
+// State 查詢當前版本，表不存在時先創建
+func (DBStore) State() (State, error) {
+	version, dirty, err := db.GetSchemaVersion()
+	return State{Version: version, Dirty: dirty}, err
+}
+
+// Exec 執行一條語句
+func (DBStore) Exec(statement string) error {
+	_, err := db.DB.Exec(statement)
+	return err
+}
+
+// SetVersion 記錄版本
+func (DBStore) SetVersion(version int, name string, dirty bool) error {
+	return db.SetSchemaVersion(version, name, dirty)
+}
@@ -0,0 +1,199 @@
+package migrate
+
+import (
+	"baccarat/db/migrations"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+	"testing/fstest"
+)
+
+// fakeStore 以內存記錄版本，Exec 的語句依次記下
+type fakeStore struct {
+	state    State
+	executed []string
+	failOn   string
+}
+
+func (s *fakeStore) State() (State, error) {
+	return s.state, nil
+}
+
+func (s *fakeStore) Exec(statement string) error {
+	if s.failOn != "" && strings.Contains(statement, s.failOn) {
+		return errors.New("syntax error")
+	}
+	s.executed = append(s.executed, statement)
+	return nil
+}
+
+func (s *fakeStore) SetVersion(version int, name string, dirty bool) error {
+	s.state = State{Version: version, Dirty: dirty}
+	return nil
+}
+
+func testMigrations() []Migration {
+	return []Migration{
+		{Version: 1, Name: "users", Up: "CREATE TABLE users (id INT);", Down: "DROP TABLE users;"},
+		{Version: 2, Name: "bets", Up: "CREATE TABLE bets (id INT);", Down: "DROP TABLE bets;"},
+		{Version: 3, Name: "index", Up: "CREATE INDEX idx ON bets(id);", Down: "DROP INDEX idx ON bets;"},
+	}
+}
+
+func TestLoad(t *testing.T) {
+	fsys := fstest.MapFS{
+		"0002_bets.up.sql":    {Data: []byte("CREATE TABLE bets (id INT);")},
+		"0002_bets.down.sql":  {Data: []byte("DROP TABLE bets;")},
+		"0001_users.up.sql":   {Data: []byte("CREATE TABLE users (id INT);")},
+		"0001_users.down.sql": {Data: []byte("DROP TABLE users;")},
+	}
+	list, err := Load(fsys)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(list) != 2 || list[0].Version != 1 || list[0].Name != "users" || list[1].Down != "DROP TABLE bets;" {
+		t.Errorf("Load = %+v", list)
+	}
+
+	invalid := []fstest.MapFS{
+		{"0001_users.up.sql": {Data: []byte("x;")}},
+		{"users.up.sql": {Data: []byte("x;")}, "users.down.sql": {Data: []byte("x;")}},
+		{"0001_users.sql": {Data: []byte("x;")}},
+		{"0001_a.up.sql": {Data: []byte("x;")}, "0001_b.down.sql": {Data: []byte("x;")}},
+	}
+	for _, fsys := range invalid {
+		if _, err := Load(fsys); err == nil {
+			t.Errorf("Load(%v) succeeded, want error", fsys)
+		}
+	}
+}
+
+// 內嵌的遷移腳本必須能正確載入，且版本連續；SQLite 版本與 MySQL 版本一一對應
+func TestEmbeddedMigrations(t *testing.T) {
+	list, err := Load(migrations.FS)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sqliteList, err := Load(migrations.SQLiteFS)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(sqliteList) != len(list) {
+		t.Fatalf("%d SQLite migrations, want %d", len(sqliteList), len(list))
+	}
+	for i, m := range list {
+		if m.Version != i+1 {
+			t.Errorf("migration %d_%s: expected version %d", m.Version, m.Name, i+1)
+		}
+		if sqliteList[i].Version != m.Version || sqliteList[i].Name != m.Name {
+			t.Errorf("SQLite migration %d_%s does not match %d_%s", sqliteList[i].Version, sqliteList[i].Name, m.Version, m.Name)
+		}
+		for _, script := range []string{m.Up, m.Down, sqliteList[i].Up, sqliteList[i].Down} {
+			if len(SplitStatements(script)) == 0 {
+				t.Errorf("migration %d_%s has an empty script", m.Version, m.Name)
+			}
+		}
+	}
+}
+
+func TestSplitStatements(t *testing.T) {
+	script := `-- 用戶表
+CREATE TABLE users (
+    id INT, -- 主鍵;
+    name VARCHAR(10)
+);
+
+-- 只追加
+DROP TRIGGER IF EXISTS t; -- 可重複執行
+CREATE TRIGGER t BEFORE UPDATE ON users
+FOR EACH ROW SIGNAL SQLSTATE '45000' SET MESSAGE_TEXT = 'no';
+-- 結尾註釋
+`
+	got := SplitStatements(script)
+	want := []string{
+		"-- 用戶表\nCREATE TABLE users (\n    id INT, -- 主鍵;\n    name VARCHAR(10)\n)",
+		"-- 只追加\nDROP TRIGGER IF EXISTS t",
+		"CREATE TRIGGER t BEFORE UPDATE ON users\nFOR EACH ROW SIGNAL SQLSTATE '45000' SET MESSAGE_TEXT = 'no'",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("SplitStatements = %q, want %q", got, want)
+	}
+}
+
+func TestUpDown(t *testing.T) {
+	store := &fakeStore{}
+	m := New(testMigrations(), store)
+
+	applied, err := m.Up(2)
+	if err != nil || len(applied) != 2 || store.state != (State{Version: 2}) {
+		t.Fatalf("Up(2) = %v, %v, state %+v", applied, err, store.state)
+	}
+	applied, err = m.Up(0)
+	if err != nil || len(applied) != 1 || store.state.Version != 3 {
+		t.Fatalf("Up(0) = %v, %v, state %+v", applied, err, store.state)
+	}
+	if applied, _ := m.Up(0); len(applied) != 0 {
+		t.Errorf("Up on latest applied %v", applied)
+	}
+
+	reverted, err := m.Down(2)
+	if err != nil || len(reverted) != 2 || reverted[0].Version != 3 || store.state != (State{Version: 1}) {
+		t.Fatalf("Down(2) = %v, %v, state %+v", reverted, err, store.state)
+	}
+	if got := store.executed[len(store.executed)-1]; got != "DROP TABLE bets" {
+		t.Errorf("last statement = %q", got)
+	}
+}
+
+func TestFailedMigrationIsDirty(t *testing.T) {
+	store := &fakeStore{failOn: "bets"}
+	m := New(testMigrations(), store)
+
+	applied, err := m.Up(0)
+	if err == nil || len(applied) != 1 {
+		t.Fatalf("Up = %v, %v, want failure after first migration", applied, err)
+	}
+	if store.state != (State{Version: 2, Dirty: true}) {
+		t.Errorf("state = %+v, want version 2 dirty", store.state)
+	}
+	if _, err := m.Up(0); err != ErrDirty {
+		t.Errorf("Up on dirty schema error = %v, want ErrDirty", err)
+	}
+	if _, err := m.Check(); err != ErrDirty {
+		t.Errorf("Check on dirty schema error = %v, want ErrDirty", err)
+	}
+
+	// 人工修復後 force 到上一個版本再重試
+	if err := m.Force(1); err != nil || store.state != (State{Version: 1}) {
+		t.Errorf("Force(1) = %v, state %+v", err, store.state)
+	}
+	if err := m.Force(9); err != ErrUnknownVersion {
+		t.Errorf("Force(9) error = %v, want ErrUnknownVersion", err)
+	}
+}
+
+func TestCheck(t *testing.T) {
+	store := &fakeStore{state: State{Version: 2}}
+	m := New(testMigrations(), store)
+
+	_, err := m.Check()
+	var versionErr *VersionError
+	if !errors.As(err, &versionErr) || versionErr.Current != 2 || versionErr.Required != 3 {
+		t.Errorf("Check behind = %v", err)
+	}
+
+	store.state.Version = 3
+	if _, err := m.Check(); err != nil {
+		t.Errorf("Check latest = %v", err)
+	}
+
+	// 新版本程序已遷移過，回滾後的程序仍可啟動，但不能回退
+	store.state.Version = 4
+	if _, err := m.Check(); err != nil {
+		t.Errorf("Check ahead = %v", err)
+	}
+	if _, err := m.Down(1); err != ErrNewerSchema {
+		t.Errorf("Down on newer schema error = %v, want ErrNewerSchema", err)
+	}
+}
@@ -0,0 +1,119 @@
+package notify
+
+import (
+	"baccarat/internal/events"
+	"baccarat/pkg/logger"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// AccountNotifier 向用戶發送帳戶相關通知（郵件、短信等由具體實作決定）
+type AccountNotifier interface {
+	SendPasswordReset(userID int, username, token string, expiresAt time.Time) error
+}
+
+// LogNotifier 只把通知寫入日誌，用於開發環境或尚未接入通知渠道時
+type LogNotifier struct{}
+
+// NewLogNotifier 創建日誌通知器
+func NewLogNotifier() *LogNotifier {
+	return &LogNotifier{}
+}
+
+// SendPasswordReset 記錄重設密碼令牌
+func (n *LogNotifier) SendPasswordReset(userID int, username, token string, expiresAt time.Time) error {
+	logger.Info("[notify] Password reset for user", username, "UserID:", userID,
+		"Token:", token, "ExpiresAt:", expiresAt.Format(time.RFC3339))
+	return nil
+}
+
+// Alert 運營告警
+type Alert struct {
+	Name    string             `json:"name"`    // 告警類型，如 rtp_drift
+	Summary string             `json:"summary"` // 一句話說明
+	Labels  map[string]string  `json:"labels"`  // 告警對象，如牌桌和投注類型
+	Values  map[string]float64 `json:"values"`  // 觀測值、期望值等
+	FiredAt time.Time          `json:"fired_at"`
+}
+
+// AlertNotifier 發送運營告警
+type AlertNotifier interface {
+	SendAlert(alert Alert) error
+}
+
+// SendAlert 以 WARN 級別記錄告警
+func (n *LogNotifier) SendAlert(alert Alert) error {
+	fields := logger.Fields{"alert": alert.Name}
+	for k, v := range alert.Labels {
+		fields[k] = v
+	}
+	for k, v := range alert.Values {
+		fields[k] = v
+	}
+	logger.Log.WithFields(fields).Warn("[notify] " + alert.Summary)
+	return nil
+}
+
+// AlertNotifiers 依次發送給每個通知器，返回第一個錯誤
+type AlertNotifiers []AlertNotifier
+
+// SendAlert 發送給所有通知器，某個失敗不影響其餘的發送
+func (ns AlertNotifiers) SendAlert(alert Alert) error {
+	var first error
+	for _, n := range ns {
+		if err := n.SendAlert(alert); err != nil && first == nil {
+			first = err
+		}
+	}
+	return first
+}
+
+// WebhookNotifier 以 JSON POST 發送告警；配置了 Secret 時按事件投遞的格式簽名
+type WebhookNotifier struct {
+	URL    string
+	Secret []byte
+	client *http.Client
+}
+
+// NewWebhookNotifier 創建 webhook 告警通知器
+func NewWebhookNotifier(url string, secret []byte) *WebhookNotifier {
+	return &WebhookNotifier{
+		URL:    url,
+		Secret: secret,
+		client: &http.Client{Timeout: 10 * time.Second},
+	}
+}
+
+// SendAlert 發送告警，2xx 視為成功
+func (n *WebhookNotifier) SendAlert(alert Alert) error {
+	payload, err := json.Marshal(alert)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequest(http.MethodPost, n.URL, bytes.NewReader(payload))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if len(n.Secret) > 0 {
+		timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+		req.Header.Set(events.TimestampHeader, timestamp)
+		req.Header.Set(events.SignatureHeader, events.Sign(n.Secret, timestamp, payload))
+	}
+
+	resp, err := n.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 4096))
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("alert webhook responded %d", resp.StatusCode)
+	}
+	return nil
+}
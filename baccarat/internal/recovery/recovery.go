@@ -0,0 +1,198 @@
+// Package recovery 處理因實例重啟或崩潰而中斷的自動賭局
+//
+// 賭局在 betting、closed、drawing 階段中斷時，玩家的下注已扣款但仍為 pending。
+// 牌靴只記錄真人牌桌的牌局，自動賭局沒有保存牌序和發牌位置，無法確定性地完成開牌，因此一律取消並退回下注。
+// 多個實例同時運行時，其他實例的賭局可能仍在進行：只處理開始超過 Timeout 仍未完成的賭局，
+// 並以資料庫鎖保證同一時間只有一個實例在處理
+package recovery
+
+import (
+	"baccarat/db"
+	"baccarat/internal/clock"
+	"baccarat/internal/events"
+	"baccarat/pkg/logger"
+	"context"
+	"database/sql"
+	"time"
+)
+
+// ActionCancelled 取消賭局並退款
+const ActionCancelled = "cancelled"
+
+// lockName 恢復任務使用的資料庫鎖
+const lockName = "baccarat.auto_game_recovery"
+
+// Round 未完成的賭局
+type Round struct {
+	GameID string
+	Status string // betting, closed, drawing
+}
+
+// Refund 退回給單個用戶的下注
+type Refund struct {
+	UserID int
+	Bets   int
+	Amount float64
+}
+
+// Result 對一局的處理結果
+type Result struct {
+	GameID         string
+	PreviousStatus string
+	Action         string
+	Refunds        []Refund
+}
+
+// Store 賭局數據
+type Store interface {
+	// Lock 不等待地取得恢復任務的鎖，其他實例正在處理時 ok 為 false
+	Lock() (unlock func(), ok bool, err error)
+	// Unfinished 查詢 before 之前開始且仍未完成的賭局
+	Unfinished(before time.Time) ([]Round, error)
+	// Cancel 在同一事務內取消賭局、退回下注並記錄恢復操作
+	Cancel(round Round) ([]Refund, error)
+}
+
+// Recover 取得鎖後處理 before 之前開始且仍未完成的賭局；其他實例持有鎖時不處理。
+// 單局失敗不影響其他賭局，返回第一個錯誤
+func Recover(store Store, before time.Time) ([]Result, error) {
+	unlock, ok, err := store.Lock()
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		logger.Info("Auto game recovery is running on another instance, skipped")
+		return nil, nil
+	}
+	defer unlock()
+
+	rounds, err := store.Unfinished(before)
+	if err != nil {
+		return nil, err
+	}
+
+	var results []Result
+	var firstErr error
+	for _, round := range rounds {
+		refunds, err := store.Cancel(round)
+		if err == db.ErrAutoGameChanged {
+			logger.Warn("Auto game", round.GameID, "changed during recovery, skipped")
+			continue
+		}
+		if err != nil {
+			logger.Error("Error recovering auto game", round.GameID, "Error:", err)
+			if firstErr == nil {
+				firstErr = err
+			}
+			continue
+		}
+
+		result := Result{GameID: round.GameID, PreviousStatus: round.Status, Action: ActionCancelled, Refunds: refunds}
+		results = append(results, result)
+		logger.Info("Recovered auto game", round.GameID, "from", round.Status, "action:", result.Action,
+			"refunded bets:", result.RefundedBets(), "amount:", result.RefundedAmount())
+	}
+	return results, firstErr
+}
+
+// Job 定期處理超時未完成的賭局，崩潰實例留下的賭局在超時後由任一存活的實例取消
+type Job struct {
+	Interval time.Duration
+	Timeout  time.Duration
+	store    Store
+	clock    clock.Clock
+}
+
+// NewJob 創建恢復任務
+func NewJob(interval, timeout time.Duration, store Store, clock clock.Clock) *Job {
+	return &Job{Interval: interval, Timeout: timeout, store: store, clock: clock}
+}
+
+// RunOnce 處理開始超過 Timeout 仍未完成的賭局
+func (j *Job) RunOnce() ([]Result, error) {
+	return Recover(j.store, j.clock.Now().Add(-j.Timeout))
+}
+
+// Run 按 Interval 循環執行，直到 ctx 取消
+func (j *Job) Run(ctx context.Context) {
+	ticker := time.NewTicker(j.Interval)
+	defer ticker.Stop()
+
+	for {
+		if _, err := j.RunOnce(); err != nil {
+			logger.Error("Auto game recovery failed:", err)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// RefundedBets 退回的下注筆數
+func (r Result) RefundedBets() int {
+	total := 0
+	for _, refund := range r.Refunds {
+		total += refund.Bets
+	}
+	return total
+}
+
+// RefundedAmount 退回的總金額
+func (r Result) RefundedAmount() float64 {
+	total := 0.0
+	for _, refund := range r.Refunds {
+		total += refund.Amount
+	}
+	return total
+}
+
+// DBStore 以資料庫處理賭局
+type DBStore struct{}
+
+// Lock 取得資料庫鎖
+func (DBStore) Lock() (func(), bool, error) {
+	return db.TryLock(lockName)
+}
+
+// Unfinished 查詢 before 之前開始且仍未完成的賭局
+func (DBStore) Unfinished(before time.Time) ([]Round, error) {
+	games, err := db.ListUnfinishedAutoGames(before)
+	if err != nil {
+		return nil, err
+	}
+	rounds := make([]Round, len(games))
+	for i, g := range games {
+		rounds[i] = Round{GameID: g.GameID, Status: g.Status}
+	}
+	return rounds, nil
+}
+
+// Cancel 取消賭局並退款，恢復記錄和錢包交易事件在同一事務內寫入
+func (DBStore) Cancel(round Round) ([]Refund, error) {
+	var refunds []Refund
+	err := db.Transaction(func(tx *sql.Tx) error {
+		cancelled, err := db.CancelAutoGame(tx, round.GameID, round.Status)
+		if err != nil {
+			return err
+		}
+
+		result := Result{GameID: round.GameID}
+		for _, c := range cancelled {
+			result.Refunds = append(result.Refunds, Refund{UserID: c.UserID, Bets: c.Bets, Amount: c.Amount})
+			err := events.Enqueue(tx, events.New(events.TypeWalletTransaction, events.WalletTransaction{
+				UserID:          c.UserID,
+				Amount:          c.Amount,
+				TransactionType: db.AutoGameRefundType,
+				Reference:       round.GameID,
+			}))
+			if err != nil {
+				return err
+			}
+		}
+		refunds = result.Refunds
+		return db.SaveAutoGameRecovery(tx, round.GameID, round.Status, ActionCancelled, result.RefundedBets(), result.RefundedAmount())
+	})
+	return refunds, err
+}
@@ -0,0 +1,112 @@
+package recovery
+
+import (
+	"baccarat/db"
+	"baccarat/internal/clock"
+	"baccarat/pkg/logger"
+	"errors"
+	"os"
+	"testing"
+	"time"
+)
+
+func TestMain(m *testing.M) {
+	logger.InitLogger()
+	os.Exit(m.Run())
+}
+
+type fakeStore struct {
+	rounds     []Round
+	refunds    map[string][]Refund
+	errs       map[string]error
+	cancelled  []string
+	heldByPeer bool      // 鎖被其他實例持有
+	locked     bool      // 鎖當前由本實例持有
+	before     time.Time // 最近一次查詢的截止時間
+}
+
+func (s *fakeStore) Lock() (func(), bool, error) {
+	if s.heldByPeer {
+		return nil, false, nil
+	}
+	s.locked = true
+	return func() { s.locked = false }, true, nil
+}
+
+func (s *fakeStore) Unfinished(before time.Time) ([]Round, error) {
+	s.before = before
+	return s.rounds, nil
+}
+
+func (s *fakeStore) Cancel(round Round) ([]Refund, error) {
+	if !s.locked {
+		return nil, errors.New("cancel without lock")
+	}
+	s.cancelled = append(s.cancelled, round.GameID)
+	if err := s.errs[round.GameID]; err != nil {
+		return nil, err
+	}
+	return s.refunds[round.GameID], nil
+}
+
+func TestRecover(t *testing.T) {
+	failure := errors.New("deadlock")
+	store := &fakeStore{
+		rounds: []Round{
+			{GameID: "g1", Status: "betting"},
+			{GameID: "g2", Status: "drawing"},
+			{GameID: "g3", Status: "closed"},
+			{GameID: "g4", Status: "betting"},
+		},
+		refunds: map[string][]Refund{
+			"g1": {{UserID: 1, Bets: 2, Amount: 150}, {UserID: 2, Bets: 1, Amount: 20.5}},
+		},
+		errs: map[string]error{
+			"g2": failure,
+			"g3": db.ErrAutoGameChanged,
+		},
+	}
+
+	results, err := Recover(store, time.Now())
+	if err != failure {
+		t.Errorf("error = %v, want %v", err, failure)
+	}
+	if len(store.cancelled) != 4 {
+		t.Errorf("cancelled %v, want all rounds attempted", store.cancelled)
+	}
+	if len(results) != 2 || results[0].GameID != "g1" || results[1].GameID != "g4" {
+		t.Fatalf("results = %+v", results)
+	}
+	r := results[0]
+	if r.PreviousStatus != "betting" || r.Action != ActionCancelled || r.RefundedBets() != 3 || r.RefundedAmount() != 170.5 {
+		t.Errorf("g1 result = %+v", r)
+	}
+	if results[1].RefundedBets() != 0 {
+		t.Errorf("round without bets refunded %d", results[1].RefundedBets())
+	}
+}
+
+func TestRecoverSkipsWhileAnotherInstanceHoldsTheLock(t *testing.T) {
+	store := &fakeStore{rounds: []Round{{GameID: "g1", Status: "betting"}}, heldByPeer: true}
+	results, err := Recover(store, time.Now())
+	if err != nil || len(results) != 0 || len(store.cancelled) != 0 {
+		t.Errorf("results = %+v, %v, cancelled %v", results, err, store.cancelled)
+	}
+}
+
+func TestJobRecoversOnlyTimedOutRounds(t *testing.T) {
+	now := time.Date(2025, 6, 1, 12, 0, 0, 0, time.UTC)
+	store := &fakeStore{rounds: []Round{{GameID: "g1", Status: "drawing"}}}
+	job := NewJob(time.Minute, 10*time.Minute, store, clock.NewFake(now))
+
+	results, err := job.RunOnce()
+	if err != nil || len(results) != 1 {
+		t.Fatalf("RunOnce = %+v, %v", results, err)
+	}
+	if want := now.Add(-10 * time.Minute); !store.before.Equal(want) {
+		t.Errorf("cutoff = %v, want %v", store.before, want)
+	}
+	if store.locked {
+		t.Error("lock not released")
+	}
+}
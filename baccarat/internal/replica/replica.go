@@ -0,0 +1,101 @@
+// Package replica 為只讀查詢選擇主庫或只讀副本
+//
+// 副本的複製延遲由定期檢測更新，超過 MaxLag 或檢測失敗的副本不參與分配。
+// 用戶寫入後的 StickyFor 內，該用戶的查詢仍走主庫，保證讀到自己剛寫入的數據。
+// 寫入時間只記錄在本進程內，這一保證只在同一實例內成立；多實例部署時同一用戶的請求需固定轉發到同一實例
+package replica
+
+import (
+	"baccarat/internal/clock"
+	"sync"
+	"time"
+)
+
+// Primary Pick 返回此值表示使用主庫
+const Primary = -1
+
+// Router 記錄各副本的延遲和用戶最近的寫入時間
+type Router struct {
+	MaxLag    time.Duration
+	StickyFor time.Duration
+	clock     clock.Clock
+
+	mu      sync.Mutex
+	healthy []bool
+	lags    []time.Duration
+	next    int
+	writes  map[int]time.Time
+}
+
+// NewRouter 創建 n 個副本的路由，副本在首次 SetLag 之前不參與分配
+func NewRouter(n int, maxLag, stickyFor time.Duration, clock clock.Clock) *Router {
+	return &Router{
+		MaxLag:    maxLag,
+		StickyFor: stickyFor,
+		clock:     clock,
+		healthy:   make([]bool, n),
+		lags:      make([]time.Duration, n),
+		writes:    map[int]time.Time{},
+	}
+}
+
+// SetLag 更新第 i 個副本的檢測結果，ok 為 false 表示檢測失敗或複製已停止
+func (r *Router) SetLag(i int, lag time.Duration, ok bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.healthy[i] = ok && lag <= r.MaxLag
+	r.lags[i] = lag
+}
+
+// Lag 返回第 i 個副本最近一次的延遲及是否可用
+func (r *Router) Lag(i int) (time.Duration, bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.lags[i], r.healthy[i]
+}
+
+// MarkWrite 記錄用戶剛寫入，userID 為 0 時忽略
+func (r *Router) MarkWrite(userID int) {
+	if userID == 0 {
+		return
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.writes[userID] = r.clock.Now()
+}
+
+// Pick 返回查詢應使用的副本下標，用戶最近寫入過或沒有可用副本時返回 Primary
+// userID 為 0 表示查詢不屬於某個用戶的會話（如報表）
+func (r *Router) Pick(userID int) int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if wrote, ok := r.writes[userID]; ok {
+		if r.clock.Now().Sub(wrote) < r.StickyFor {
+			return Primary
+		}
+		delete(r.writes, userID)
+	}
+
+	// 從上次分配的下一個副本開始輪詢
+	for k := 0; k < len(r.healthy); k++ {
+		i := (r.next + k) % len(r.healthy)
+		if r.healthy[i] {
+			r.next = i + 1
+			return i
+		}
+	}
+	return Primary
+}
+
+// Prune 清除已過 StickyFor 的寫入記錄
+func (r *Router) Prune() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	now := r.clock.Now()
+	for userID, wrote := range r.writes {
+		if now.Sub(wrote) >= r.StickyFor {
+			delete(r.writes, userID)
+		}
+	}
+}
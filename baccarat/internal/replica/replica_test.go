@@ -0,0 +1,94 @@
+package replica
+
+import (
+	"baccarat/internal/clock"
+	"testing"
+	"time"
+)
+
+func newTestRouter(n int) (*Router, *clock.Fake) {
+	clock := clock.NewFake(time.Date(2025, 6, 1, 12, 0, 0, 0, time.UTC))
+	return NewRouter(n, 5*time.Second, 10*time.Second, clock), clock
+}
+
+func TestPickRoundRobin(t *testing.T) {
+	r, _ := newTestRouter(3)
+
+	// 尚未檢測延遲的副本不參與分配
+	if got := r.Pick(0); got != Primary {
+		t.Fatalf("Pick before any check = %d, want Primary", got)
+	}
+
+	r.SetLag(0, 0, true)
+	r.SetLag(1, time.Second, true)
+	r.SetLag(2, 2*time.Second, true)
+	var got []int
+	for i := 0; i < 4; i++ {
+		got = append(got, r.Pick(0))
+	}
+	if got[0] != 0 || got[1] != 1 || got[2] != 2 || got[3] != 0 {
+		t.Errorf("Pick sequence = %v, want [0 1 2 0]", got)
+	}
+}
+
+func TestPickSkipsLaggingReplicas(t *testing.T) {
+	r, _ := newTestRouter(2)
+	r.SetLag(0, 6*time.Second, true)
+	r.SetLag(1, 0, false)
+	if got := r.Pick(0); got != Primary {
+		t.Errorf("Pick with no usable replica = %d, want Primary", got)
+	}
+
+	// 延遲恰好等於 MaxLag 仍可用
+	r.SetLag(0, 5*time.Second, true)
+	for i := 0; i < 3; i++ {
+		if got := r.Pick(0); got != 0 {
+			t.Errorf("Pick = %d, want 0", got)
+		}
+	}
+	if lag, ok := r.Lag(1); ok || lag != 0 {
+		t.Errorf("Lag(1) = %v, %v", lag, ok)
+	}
+}
+
+func TestReadYourWrites(t *testing.T) {
+	r, clock := newTestRouter(1)
+	r.SetLag(0, 0, true)
+
+	r.MarkWrite(7)
+	if got := r.Pick(7); got != Primary {
+		t.Errorf("Pick right after write = %d, want Primary", got)
+	}
+	// 其他用戶和報表照常使用副本
+	if got := r.Pick(8); got != 0 {
+		t.Errorf("Pick for another user = %d, want 0", got)
+	}
+	if got := r.Pick(0); got != 0 {
+		t.Errorf("Pick without a user = %d, want 0", got)
+	}
+
+	clock.Advance(9 * time.Second)
+	if got := r.Pick(7); got != Primary {
+		t.Errorf("Pick within StickyFor = %d, want Primary", got)
+	}
+	clock.Advance(time.Second)
+	if got := r.Pick(7); got != 0 {
+		t.Errorf("Pick after StickyFor = %d, want 0", got)
+	}
+}
+
+func TestPrune(t *testing.T) {
+	r, clock := newTestRouter(1)
+	r.MarkWrite(1)
+	clock.Advance(6 * time.Second)
+	r.MarkWrite(2)
+	clock.Advance(5 * time.Second)
+
+	r.Prune()
+	if _, ok := r.writes[1]; ok {
+		t.Error("expired write for user 1 kept")
+	}
+	if _, ok := r.writes[2]; !ok {
+		t.Error("recent write for user 2 pruned")
+	}
+}
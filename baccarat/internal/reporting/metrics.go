@@ -0,0 +1,46 @@
+package reporting
+
+import (
+	"baccarat/db"
+	"math"
+)
+
+// Metrics 一個分組的營運指標
+type Metrics struct {
+	Period        string  `json:"period,omitempty"`
+	TableID       string  `json:"table,omitempty"`
+	BetType       string  `json:"betType,omitempty"`
+	Bets          int     `json:"bets"`
+	ActivePlayers int     `json:"activePlayers"`
+	Wagered       float64 `json:"wagered"`
+	Paid          float64 `json:"paid"`     // 返還給玩家的金額（含本金）
+	GGR           float64 `json:"ggr"`      // 投注額 - 返還額
+	RTP           float64 `json:"rtp"`      // 返還額 / 投注額，沒有投注時為 0
+	AvgStake      float64 `json:"avgStake"` // 平均每注金額
+}
+
+// round2 保留兩位小數，避免浮點累加誤差出現在報表中
+func round2(v float64) float64 {
+	return math.Round(v*100) / 100
+}
+
+// NewMetrics 從匯總行計算指標
+func NewMetrics(row db.ReportRow) Metrics {
+	m := Metrics{
+		Period:        row.Period,
+		TableID:       row.TableID,
+		BetType:       row.BetType,
+		Bets:          row.Bets,
+		ActivePlayers: row.Players,
+		Wagered:       round2(row.Wagered),
+		Paid:          round2(row.Returned),
+		GGR:           round2(row.Wagered - row.Returned),
+	}
+	if row.Wagered > 0 {
+		m.RTP = math.Round(row.Returned/row.Wagered*10000) / 10000
+	}
+	if row.Bets > 0 {
+		m.AvgStake = round2(row.Wagered / float64(row.Bets))
+	}
+	return m
+}
@@ -0,0 +1,119 @@
+// Package reporting 維護營運報表的小時匯總並計算 GGR、RTP 等指標
+package reporting
+
+import (
+	"baccarat/db"
+	"baccarat/internal/clock"
+	"baccarat/pkg/logger"
+	"context"
+	"time"
+)
+
+// rebuildChunk 每個事務最多重建的時長，首次回填大量歷史數據時分段提交
+const rebuildChunk = 24 * time.Hour
+
+// Store 讀寫匯總表
+type Store interface {
+	Watermark() (time.Time, bool, error)
+	EarliestBet() (time.Time, bool, error)
+	Rebuild(from, to, watermark time.Time) error
+}
+
+// RollupJob 定期重建小時匯總：從上次進度往前 Lookback 開始，到當前小時（含未結束的小時）為止
+// 當前小時每次都會重算，Lookback 用於吸收在整點前開始、整點後才提交的下注
+type RollupJob struct {
+	Interval time.Duration
+	Lookback time.Duration
+	store    Store
+	clock    clock.Clock
+	location *time.Location
+}
+
+// NewRollupJob 創建匯總任務，小時按 location 劃分
+func NewRollupJob(interval, lookback time.Duration, store Store, clock clock.Clock, location *time.Location) *RollupJob {
+	return &RollupJob{
+		Interval: interval,
+		Lookback: lookback,
+		store:    store,
+		clock:    clock,
+		location: location,
+	}
+}
+
+// hourStart 返回 t 所在小時的起點（按時區的牆上時間，兼容非整點時差）
+func hourStart(t time.Time, loc *time.Location) time.Time {
+	t = t.In(loc)
+	return time.Date(t.Year(), t.Month(), t.Day(), t.Hour(), 0, 0, 0, loc)
+}
+
+// RunOnce 執行一次增量匯總
+func (j *RollupJob) RunOnce() error {
+	now := hourStart(j.clock.Now(), j.location)
+	end := now.Add(time.Hour)
+
+	start, ok, err := j.store.Watermark()
+	if err != nil {
+		return err
+	}
+	if ok {
+		start = start.Add(-j.Lookback)
+	} else {
+		start, ok, err = j.store.EarliestBet()
+		if err != nil || !ok {
+			return err
+		}
+	}
+	start = hourStart(start, j.location)
+
+	for from := start; from.Before(end); {
+		to := from.Add(rebuildChunk)
+		if to.After(end) {
+			to = end
+		}
+		// 進度只推進到已結束的小時
+		watermark := to
+		if watermark.After(now) {
+			watermark = now
+		}
+		if err := j.store.Rebuild(from, to, watermark); err != nil {
+			return err
+		}
+		from = to
+	}
+	return nil
+}
+
+// Run 按 Interval 循環執行，直到 ctx 取消
+func (j *RollupJob) Run(ctx context.Context) {
+	ticker := time.NewTicker(j.Interval)
+	defer ticker.Stop()
+
+	for {
+		if err := j.RunOnce(); err != nil {
+			logger.Error("Report rollup failed:", err)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// DBStore 以資料庫保存匯總
+type DBStore struct{}
+
+// Watermark 返回已匯總到的時間
+func (DBStore) Watermark() (time.Time, bool, error) {
+	return db.GetReportRollupWatermark()
+}
+
+// EarliestBet 返回最早一筆下注的時間
+func (DBStore) EarliestBet() (time.Time, bool, error) {
+	return db.GetEarliestBetTime()
+}
+
+// Rebuild 重建 [from, to) 的匯總
+func (DBStore) Rebuild(from, to, watermark time.Time) error {
+	return db.RebuildReportRollups(from, to, watermark)
+}
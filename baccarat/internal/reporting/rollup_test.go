@@ -0,0 +1,123 @@
+package reporting
+
+import (
+	"baccarat/db"
+	"baccarat/internal/clock"
+	"testing"
+	"time"
+)
+
+type rebuildCall struct {
+	from, to, watermark time.Time
+}
+
+type fakeStore struct {
+	watermark   time.Time
+	hasMark     bool
+	earliest    time.Time
+	hasEarliest bool
+	calls       []rebuildCall
+}
+
+func (s *fakeStore) Watermark() (time.Time, bool, error) {
+	return s.watermark, s.hasMark, nil
+}
+
+func (s *fakeStore) EarliestBet() (time.Time, bool, error) {
+	return s.earliest, s.hasEarliest, nil
+}
+
+func (s *fakeStore) Rebuild(from, to, watermark time.Time) error {
+	s.calls = append(s.calls, rebuildCall{from, to, watermark})
+	s.watermark, s.hasMark = watermark, true
+	return nil
+}
+
+func TestRollupJobNoBets(t *testing.T) {
+	store := &fakeStore{}
+	clock := clock.NewFake(time.Date(2024, 5, 1, 10, 30, 0, 0, time.UTC))
+	job := NewRollupJob(time.Minute, time.Hour, store, clock, time.UTC)
+
+	if err := job.RunOnce(); err != nil {
+		t.Fatalf("RunOnce: %v", err)
+	}
+	if len(store.calls) != 0 {
+		t.Fatalf("expected no rebuild without bets, got %v", store.calls)
+	}
+}
+
+func TestRollupJobBackfillInChunks(t *testing.T) {
+	store := &fakeStore{
+		earliest:    time.Date(2024, 4, 29, 8, 45, 0, 0, time.UTC),
+		hasEarliest: true,
+	}
+	clock := clock.NewFake(time.Date(2024, 5, 1, 10, 30, 0, 0, time.UTC))
+	job := NewRollupJob(time.Minute, time.Hour, store, clock, time.UTC)
+
+	if err := job.RunOnce(); err != nil {
+		t.Fatalf("RunOnce: %v", err)
+	}
+
+	want := []rebuildCall{
+		{time.Date(2024, 4, 29, 8, 0, 0, 0, time.UTC), time.Date(2024, 4, 30, 8, 0, 0, 0, time.UTC), time.Date(2024, 4, 30, 8, 0, 0, 0, time.UTC)},
+		{time.Date(2024, 4, 30, 8, 0, 0, 0, time.UTC), time.Date(2024, 5, 1, 8, 0, 0, 0, time.UTC), time.Date(2024, 5, 1, 8, 0, 0, 0, time.UTC)},
+		// 最後一段包含未結束的小時，進度只推進到當前小時起點
+		{time.Date(2024, 5, 1, 8, 0, 0, 0, time.UTC), time.Date(2024, 5, 1, 11, 0, 0, 0, time.UTC), time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC)},
+	}
+	if len(store.calls) != len(want) {
+		t.Fatalf("got %d rebuilds, want %d: %v", len(store.calls), len(want), store.calls)
+	}
+	for i := range want {
+		c := store.calls[i]
+		if !c.from.Equal(want[i].from) || !c.to.Equal(want[i].to) || !c.watermark.Equal(want[i].watermark) {
+			t.Errorf("rebuild %d = %v, want %v", i, c, want[i])
+		}
+	}
+}
+
+func TestRollupJobIncrementalWithLookback(t *testing.T) {
+	store := &fakeStore{
+		watermark: time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC),
+		hasMark:   true,
+	}
+	clock := clock.NewFake(time.Date(2024, 5, 1, 10, 59, 0, 0, time.UTC))
+	job := NewRollupJob(time.Minute, time.Hour, store, clock, time.UTC)
+
+	if err := job.RunOnce(); err != nil {
+		t.Fatalf("RunOnce: %v", err)
+	}
+	if len(store.calls) != 1 {
+		t.Fatalf("got %d rebuilds, want 1", len(store.calls))
+	}
+	c := store.calls[0]
+	if !c.from.Equal(time.Date(2024, 5, 1, 9, 0, 0, 0, time.UTC)) || !c.to.Equal(time.Date(2024, 5, 1, 11, 0, 0, 0, time.UTC)) {
+		t.Errorf("rebuild window = %v - %v", c.from, c.to)
+	}
+}
+
+func TestHourStartHalfHourOffset(t *testing.T) {
+	loc := time.FixedZone("IST", 5*3600+1800)
+	got := hourStart(time.Date(2024, 5, 1, 4, 50, 0, 0, time.UTC), loc)
+	want := time.Date(2024, 5, 1, 10, 0, 0, 0, loc)
+	if !got.Equal(want) {
+		t.Errorf("hourStart = %v, want %v", got, want)
+	}
+}
+
+func TestNewMetrics(t *testing.T) {
+	m := NewMetrics(db.ReportRow{Period: "2024-05-01", Bets: 4, Players: 2, Wagered: 400, Returned: 390.5})
+	if m.GGR != 9.5 {
+		t.Errorf("GGR = %v, want 9.5", m.GGR)
+	}
+	if m.RTP != 0.9763 {
+		t.Errorf("RTP = %v, want 0.9763", m.RTP)
+	}
+	if m.AvgStake != 100 {
+		t.Errorf("AvgStake = %v, want 100", m.AvgStake)
+	}
+
+	empty := NewMetrics(db.ReportRow{})
+	if empty.RTP != 0 || empty.AvgStake != 0 {
+		t.Errorf("empty metrics = %+v", empty)
+	}
+}
@@ -0,0 +1,301 @@
+// Package rtp 監控滾動窗口內的實際返還率和開局結果是否偏離理論值
+//
+// 每種投注的返還率、勝方分佈和幸運6出現頻率按牌桌計算 z 值（偏離期望的標準差倍數），
+// 超過閾值時通過 notify.AlertNotifier 告警，用於及早發現發牌隨機性或結算的錯誤
+package rtp
+
+import (
+	"baccarat/db"
+	"baccarat/internal/clock"
+	"baccarat/internal/notify"
+	"baccarat/pkg/logger"
+	"context"
+	"fmt"
+	"math"
+	"sort"
+	"time"
+)
+
+// allTables 多張牌桌時合計所有牌桌的檢測對象
+const allTables = "all"
+
+// 檢測類型，也用作告警名
+const (
+	KindRTP      = "rtp_drift"
+	KindWinRate  = "win_rate_skew"
+	KindLuckySix = "lucky_six_frequency"
+)
+
+// Store 窗口內的下注和開局統計
+type Store interface {
+	BetStats(since time.Time) ([]db.RTPBetStat, error)
+	OutcomeStats(since time.Time) ([]db.RTPOutcomeStat, error)
+}
+
+// Check 一項檢測的結果
+type Check struct {
+	Kind     string
+	TableID  string
+	Subject  string  // 投注類型、勝方或幸運6類型
+	Rounds   int     // 樣本局數
+	Observed float64 // 實際返還率或出現頻率
+	Expected float64 // 理論值
+	ZScore   float64
+}
+
+// key 告警去重的鍵
+func (c Check) key() string {
+	return c.Kind + "|" + c.TableID + "|" + c.Subject
+}
+
+// Monitor 定期檢測偏離，同一對象持續偏離時只在開始時告警一次
+type Monitor struct {
+	Interval  time.Duration
+	Window    time.Duration
+	Sigma     float64 // z 值的絕對值超過此值時告警
+	MinRounds int     // 樣本少於此局數時不檢測
+	theory    Theory
+	store     Store
+	notifier  notify.AlertNotifier
+	clock     clock.Clock
+	firing    map[string]bool
+}
+
+// NewMonitor 創建偏離監控任務
+func NewMonitor(interval, window time.Duration, sigma float64, minRounds int, theory Theory, store Store, notifier notify.AlertNotifier, clock clock.Clock) *Monitor {
+	return &Monitor{
+		Interval:  interval,
+		Window:    window,
+		Sigma:     sigma,
+		MinRounds: minRounds,
+		theory:    theory,
+		store:     store,
+		notifier:  notifier,
+		clock:     clock,
+		firing:    make(map[string]bool),
+	}
+}
+
+// RunOnce 檢測窗口內的數據，返回超過閾值的檢測；新出現的偏離發送告警，恢復正常的記錄日誌
+func (m *Monitor) RunOnce() ([]Check, error) {
+	now := m.clock.Now()
+	since := now.Add(-m.Window)
+	bets, err := m.store.BetStats(since)
+	if err != nil {
+		return nil, err
+	}
+	outcomes, err := m.store.OutcomeStats(since)
+	if err != nil {
+		return nil, err
+	}
+
+	checks := append(m.checkRTP(withTotalBets(bets)), m.checkOutcomes(withTotalOutcomes(outcomes))...)
+	var breached []Check
+	seen := make(map[string]bool)
+	for _, c := range checks {
+		key := c.key()
+		if math.Abs(c.ZScore) <= m.Sigma {
+			if m.firing[key] {
+				logger.Log.WithFields(logger.Fields{
+					"alert":   c.Kind,
+					"table":   c.TableID,
+					"subject": c.Subject,
+					"z_score": round(c.ZScore, 2),
+				}).Info("RTP monitor back within range")
+				delete(m.firing, key)
+			}
+			continue
+		}
+		breached = append(breached, c)
+		seen[key] = true
+		if m.firing[key] {
+			continue
+		}
+		// 發送失敗時不標記，下次檢測重試
+		if err := m.notifier.SendAlert(m.alert(c, now)); err != nil {
+			logger.Error("Sending RTP alert failed:", err)
+			continue
+		}
+		m.firing[key] = true
+	}
+	// 樣本已不足以檢測的對象視為恢復
+	for key := range m.firing {
+		if !seen[key] {
+			delete(m.firing, key)
+		}
+	}
+	return breached, nil
+}
+
+// checkRTP 每局的投注額不同，返還之和的標準差按每局投注額的平方和計算
+func (m *Monitor) checkRTP(stats []db.RTPBetStat) []Check {
+	var checks []Check
+	for _, s := range stats {
+		expected, ok := m.theory.Bets[s.BetType]
+		if !ok || s.Rounds < m.MinRounds || s.Wagered <= 0 || s.Squares <= 0 || expected.StdDev == 0 {
+			continue
+		}
+		checks = append(checks, Check{
+			Kind:     KindRTP,
+			TableID:  s.TableID,
+			Subject:  s.BetType,
+			Rounds:   s.Rounds,
+			Observed: s.Returned / s.Wagered,
+			Expected: expected.Mean,
+			ZScore:   (s.Returned - expected.Mean*s.Wagered) / (expected.StdDev * math.Sqrt(s.Squares)),
+		})
+	}
+	return checks
+}
+
+// checkOutcomes 勝方和幸運6的出現次數按二項分佈檢測
+func (m *Monitor) checkOutcomes(stats []db.RTPOutcomeStat) []Check {
+	rounds := make(map[string]int)
+	winners := make(map[string]map[string]int)
+	luckySix := make(map[string]map[string]int)
+	for _, s := range stats {
+		rounds[s.TableID] += s.Rounds
+		if winners[s.TableID] == nil {
+			winners[s.TableID] = make(map[string]int)
+			luckySix[s.TableID] = make(map[string]int)
+		}
+		winners[s.TableID][s.Winner] += s.Rounds
+		if s.LuckySixType != "" {
+			luckySix[s.TableID][s.LuckySixType] += s.Rounds
+		}
+	}
+
+	var checks []Check
+	for _, table := range sortedKeys(rounds) {
+		n := rounds[table]
+		if n < m.MinRounds {
+			continue
+		}
+		for _, winner := range []string{"Player", "Banker", "Tie"} {
+			checks = append(checks, binomialCheck(KindWinRate, table, winner, winners[table][winner], n, m.theory.winRate(winner)))
+		}
+		for _, luckySixType := range []string{"2cards", "3cards"} {
+			checks = append(checks, binomialCheck(KindLuckySix, table, luckySixType, luckySix[table][luckySixType], n, m.theory.luckySixRate(luckySixType)))
+		}
+	}
+	return checks
+}
+
+// binomialCheck n 局中出現 k 次、理論概率為 p 的檢測
+func binomialCheck(kind, table, subject string, k, n int, p float64) Check {
+	c := Check{
+		Kind:     kind,
+		TableID:  table,
+		Subject:  subject,
+		Rounds:   n,
+		Observed: float64(k) / float64(n),
+		Expected: p,
+	}
+	if sd := math.Sqrt(float64(n) * p * (1 - p)); sd > 0 {
+		c.ZScore = (float64(k) - float64(n)*p) / sd
+	}
+	return c
+}
+
+// alert 把檢測結果轉為告警
+func (m *Monitor) alert(c Check, now time.Time) notify.Alert {
+	subject := map[string]string{KindRTP: "bet_type", KindWinRate: "winner", KindLuckySix: "lucky_six_type"}[c.Kind]
+	return notify.Alert{
+		Name: c.Kind,
+		Summary: fmt.Sprintf("%s %s on table %s is %.4f, expected %.4f (%+.1f sigma over %d rounds in %s)",
+			subject, c.Subject, c.TableID, c.Observed, c.Expected, c.ZScore, c.Rounds, m.Window),
+		Labels: map[string]string{"table": c.TableID, subject: c.Subject},
+		Values: map[string]float64{
+			"observed": round(c.Observed, 6),
+			"expected": round(c.Expected, 6),
+			"z_score":  round(c.ZScore, 2),
+			"rounds":   float64(c.Rounds),
+			"sigma":    m.Sigma,
+		},
+		FiredAt: now,
+	}
+}
+
+// Run 按 Interval 循環檢測，直到 ctx 取消
+func (m *Monitor) Run(ctx context.Context) {
+	ticker := time.NewTicker(m.Interval)
+	defer ticker.Stop()
+
+	for {
+		if _, err := m.RunOnce(); err != nil {
+			logger.Error("RTP monitor failed:", err)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// withTotalBets 多張牌桌時追加所有牌桌按投注類型的合計
+func withTotalBets(stats []db.RTPBetStat) []db.RTPBetStat {
+	tables := make(map[string]bool)
+	totals := make(map[string]*db.RTPBetStat)
+	for _, s := range stats {
+		tables[s.TableID] = true
+		t := totals[s.BetType]
+		if t == nil {
+			t = &db.RTPBetStat{TableID: allTables, BetType: s.BetType}
+			totals[s.BetType] = t
+		}
+		t.Rounds += s.Rounds
+		t.Wagered += s.Wagered
+		t.Squares += s.Squares
+		t.Returned += s.Returned
+	}
+	if len(tables) < 2 {
+		return stats
+	}
+	for _, betType := range sortedKeys(totals) {
+		stats = append(stats, *totals[betType])
+	}
+	return stats
+}
+
+// withTotalOutcomes 多張牌桌時追加所有牌桌的合計
+func withTotalOutcomes(stats []db.RTPOutcomeStat) []db.RTPOutcomeStat {
+	tables := make(map[string]bool)
+	var totals []db.RTPOutcomeStat
+	for _, s := range stats {
+		tables[s.TableID] = true
+		s.TableID = allTables
+		totals = append(totals, s)
+	}
+	if len(tables) < 2 {
+		return stats
+	}
+	return append(stats, totals...)
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func round(v float64, places int) float64 {
+	scale := math.Pow(10, float64(places))
+	return math.Round(v*scale) / scale
+}
+
+// DBStore 從資料庫統計，查詢只讀副本
+type DBStore struct{}
+
+// BetStats 按牌桌和投注類型匯總下注和返還
+func (DBStore) BetStats(since time.Time) ([]db.RTPBetStat, error) {
+	return db.ListRTPBetStats(since)
+}
+
+// OutcomeStats 按牌桌統計勝方和幸運6
+func (DBStore) OutcomeStats(since time.Time) ([]db.RTPOutcomeStat, error) {
+	return db.ListRTPOutcomeStats(since)
+}
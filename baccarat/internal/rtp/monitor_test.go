@@ -0,0 +1,245 @@
+package rtp
+
+import (
+	"baccarat/db"
+	"baccarat/game"
+	"baccarat/internal/clock"
+	"baccarat/internal/notify"
+	"baccarat/pkg/logger"
+	"errors"
+	"math"
+	"os"
+	"testing"
+	"time"
+)
+
+func TestMain(m *testing.M) {
+	logger.InitLogger()
+	os.Exit(m.Run())
+}
+
+type fakeStore struct {
+	bets     []db.RTPBetStat
+	outcomes []db.RTPOutcomeStat
+	since    time.Time
+}
+
+func (s *fakeStore) BetStats(since time.Time) ([]db.RTPBetStat, error) {
+	s.since = since
+	return s.bets, nil
+}
+
+func (s *fakeStore) OutcomeStats(since time.Time) ([]db.RTPOutcomeStat, error) {
+	return s.outcomes, nil
+}
+
+type fakeNotifier struct {
+	alerts []notify.Alert
+	err    error
+}
+
+func (n *fakeNotifier) SendAlert(alert notify.Alert) error {
+	if n.err != nil {
+		return n.err
+	}
+	n.alerts = append(n.alerts, alert)
+	return nil
+}
+
+// testOdds 8 副牌的開局概率（幸運6按莊家勝出的一部分拆分）
+var testOdds = game.Odds{
+	Player:         0.446247,
+	Banker:         0.404597,
+	Tie:            0.095156,
+	LuckySix2Cards: 0.024,
+	LuckySix3Cards: 0.030,
+}
+
+var testPayouts = Payouts{
+	Player:               1,
+	Banker:               1,
+	BankerLuckySix2Cards: 0.5,
+	BankerLuckySix3Cards: 0.95,
+	Tie:                  8,
+	LuckySix2Cards:       12,
+	LuckySix3Cards:       20,
+}
+
+// fairOutcomes n 局按理論概率分佈的結果
+func fairOutcomes(table string, n int) []db.RTPOutcomeStat {
+	count := func(p float64) int { return int(math.Round(p * float64(n))) }
+	return []db.RTPOutcomeStat{
+		{TableID: table, Winner: "Player", Rounds: count(testOdds.Player)},
+		{TableID: table, Winner: "Banker", Rounds: count(testOdds.Banker)},
+		{TableID: table, Winner: "Banker", LuckySixType: "2cards", Rounds: count(testOdds.LuckySix2Cards)},
+		{TableID: table, Winner: "Banker", LuckySixType: "3cards", Rounds: count(testOdds.LuckySix3Cards)},
+		{TableID: table, Winner: "Tie", Rounds: count(testOdds.Tie)},
+	}
+}
+
+// betStat n 局每局下注 stake、返還率為 rtp 的匯總
+func betStat(table, betType string, n int, stake, rtp float64) db.RTPBetStat {
+	return db.RTPBetStat{
+		TableID:  table,
+		BetType:  betType,
+		Rounds:   n,
+		Wagered:  float64(n) * stake,
+		Squares:  float64(n) * stake * stake,
+		Returned: float64(n) * stake * rtp,
+	}
+}
+
+func newTestMonitor(store Store, notifier notify.AlertNotifier, now time.Time) *Monitor {
+	return NewMonitor(time.Minute, 24*time.Hour, 4, 1000, NewTheory(testOdds, testPayouts), store, notifier, clock.NewFake(now))
+}
+
+func TestTheory(t *testing.T) {
+	theory := NewTheory(testOdds, testPayouts)
+
+	// 閒：贏返還 2 倍，和局退回本金
+	if got, want := theory.Bets["player"].Mean, 2*testOdds.Player+testOdds.Tie; math.Abs(got-want) > 1e-9 {
+		t.Errorf("player mean = %v, want %v", got, want)
+	}
+	if got, want := theory.Bets["tie"].Mean, 8*testOdds.Tie; math.Abs(got-want) > 1e-9 {
+		t.Errorf("tie mean = %v, want %v", got, want)
+	}
+	for betType, e := range theory.Bets {
+		if e.Mean <= 0 || e.Mean >= 1.2 || e.StdDev <= 0 {
+			t.Errorf("%s = %+v", betType, e)
+		}
+	}
+}
+
+func TestFairResultsDoNotAlert(t *testing.T) {
+	now := time.Date(2025, 6, 1, 12, 0, 0, 0, time.UTC)
+	theory := NewTheory(testOdds, testPayouts)
+	store := &fakeStore{
+		bets: []db.RTPBetStat{
+			betStat("main", "banker", 5000, 10, theory.Bets["banker"].Mean),
+			betStat("main", "tie", 5000, 10, theory.Bets["tie"].Mean+0.05),
+		},
+		outcomes: fairOutcomes("main", 5000),
+	}
+	notifier := &fakeNotifier{}
+	monitor := newTestMonitor(store, notifier, now)
+
+	breached, err := monitor.RunOnce()
+	if err != nil || len(breached) != 0 || len(notifier.alerts) != 0 {
+		t.Fatalf("RunOnce = %+v, %v; alerts %+v", breached, err, notifier.alerts)
+	}
+	if !store.since.Equal(now.Add(-24 * time.Hour)) {
+		t.Errorf("since = %v", store.since)
+	}
+}
+
+func TestRTPDriftAlertsOnce(t *testing.T) {
+	now := time.Date(2025, 6, 1, 12, 0, 0, 0, time.UTC)
+	theory := NewTheory(testOdds, testPayouts)
+	store := &fakeStore{bets: []db.RTPBetStat{betStat("main", "banker", 5000, 10, theory.Bets["banker"].Mean+0.1)}}
+	notifier := &fakeNotifier{}
+	monitor := newTestMonitor(store, notifier, now)
+
+	breached, err := monitor.RunOnce()
+	if err != nil || len(breached) != 1 || breached[0].Kind != KindRTP || breached[0].Subject != "banker" || breached[0].ZScore < 4 {
+		t.Fatalf("RunOnce = %+v, %v", breached, err)
+	}
+	if len(notifier.alerts) != 1 {
+		t.Fatalf("alerts = %+v", notifier.alerts)
+	}
+	alert := notifier.alerts[0]
+	if alert.Name != KindRTP || alert.Labels["table"] != "main" || alert.Labels["bet_type"] != "banker" || !alert.FiredAt.Equal(now) {
+		t.Errorf("alert = %+v", alert)
+	}
+
+	// 持續偏離時不重複告警
+	if _, err := monitor.RunOnce(); err != nil || len(notifier.alerts) != 1 {
+		t.Fatalf("second run: %v, %d alerts", err, len(notifier.alerts))
+	}
+
+	// 恢復後再次偏離時重新告警
+	store.bets = []db.RTPBetStat{betStat("main", "banker", 5000, 10, theory.Bets["banker"].Mean)}
+	if breached, _ := monitor.RunOnce(); len(breached) != 0 {
+		t.Fatalf("recovered run breached %+v", breached)
+	}
+	store.bets = []db.RTPBetStat{betStat("main", "banker", 5000, 10, theory.Bets["banker"].Mean-0.1)}
+	monitor.RunOnce()
+	if len(notifier.alerts) != 2 || notifier.alerts[1].Values["z_score"] > -4 {
+		t.Errorf("alerts after second drift = %+v", notifier.alerts)
+	}
+}
+
+func TestTooFewRoundsSkipped(t *testing.T) {
+	theory := NewTheory(testOdds, testPayouts)
+	store := &fakeStore{
+		bets:     []db.RTPBetStat{betStat("main", "tie", 999, 10, theory.Bets["tie"].Mean*2)},
+		outcomes: []db.RTPOutcomeStat{{TableID: "main", Winner: "Player", Rounds: 999}},
+	}
+	notifier := &fakeNotifier{}
+	monitor := newTestMonitor(store, notifier, time.Now())
+
+	if breached, err := monitor.RunOnce(); err != nil || len(breached) != 0 || len(notifier.alerts) != 0 {
+		t.Errorf("RunOnce = %+v, %v", breached, err)
+	}
+}
+
+func TestOutcomeSkew(t *testing.T) {
+	outcomes := fairOutcomes("main", 10000)
+	// 莊家勝出多 400 局，幸運6（兩張牌）從未出現
+	outcomes[1].Rounds += 400 + outcomes[2].Rounds
+	outcomes[0].Rounds -= 400
+	outcomes[2].Rounds = 0
+	store := &fakeStore{outcomes: outcomes}
+	notifier := &fakeNotifier{}
+	monitor := newTestMonitor(store, notifier, time.Now())
+
+	breached, err := monitor.RunOnce()
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := make(map[string]bool)
+	for _, c := range breached {
+		got[c.Kind+" "+c.Subject] = true
+	}
+	for _, want := range []string{"win_rate_skew Player", "win_rate_skew Banker", "lucky_six_frequency 2cards"} {
+		if !got[want] {
+			t.Errorf("missing %s in %+v", want, breached)
+		}
+	}
+	if got["win_rate_skew Tie"] || got["lucky_six_frequency 3cards"] {
+		t.Errorf("unexpected breach in %+v", breached)
+	}
+	if len(notifier.alerts) != len(breached) {
+		t.Errorf("%d alerts for %d breaches", len(notifier.alerts), len(breached))
+	}
+}
+
+func TestFailedAlertRetried(t *testing.T) {
+	theory := NewTheory(testOdds, testPayouts)
+	store := &fakeStore{bets: []db.RTPBetStat{betStat("main", "player", 5000, 10, theory.Bets["player"].Mean+0.1)}}
+	notifier := &fakeNotifier{err: errors.New("webhook down")}
+	monitor := newTestMonitor(store, notifier, time.Now())
+
+	monitor.RunOnce()
+	notifier.err = nil
+	monitor.RunOnce()
+	if len(notifier.alerts) != 1 {
+		t.Errorf("alerts after retry = %+v", notifier.alerts)
+	}
+}
+
+func TestAllTablesTotal(t *testing.T) {
+	theory := NewTheory(testOdds, testPayouts)
+	// 每張牌桌單獨看都在範圍內，合計後偏離
+	mean := theory.Bets["banker"].Mean
+	store := &fakeStore{bets: []db.RTPBetStat{
+		betStat("main", "banker", 4000, 10, mean+0.045),
+		betStat("vip", "banker", 4000, 10, mean+0.045),
+	}}
+	notifier := &fakeNotifier{}
+	monitor := newTestMonitor(store, notifier, time.Now())
+
+	breached, err := monitor.RunOnce()
+	if err != nil || len(breached) != 1 || breached[0].TableID != allTables || breached[0].Rounds != 8000 {
+		t.Errorf("RunOnce = %+v, %v", breached, err)
+	}
+}
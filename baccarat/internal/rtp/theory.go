@@ -0,0 +1,109 @@
+package rtp
+
+import (
+	"baccarat/config"
+	"baccarat/game"
+	"math"
+)
+
+// Payouts 賠率配置，含義與 config 中的同名配置一致
+// 理論值按配置獨立計算，不經過 game.GetPayouts，結算的錯誤因此也能被發現
+type Payouts struct {
+	Player               float64 // 閒贏，不含本金
+	Banker               float64 // 莊贏（非幸運6），不含本金
+	BankerLuckySix2Cards float64 // 莊家以兩張牌 6 點勝出時莊注的賠率，不含本金
+	BankerLuckySix3Cards float64 // 莊家以三張牌 6 點勝出時莊注的賠率，不含本金
+	Tie                  float64 // 和局，已含本金
+	LuckySix2Cards       float64 // 幸運6注，已含本金
+	LuckySix3Cards       float64 // 幸運6注，已含本金
+}
+
+// ConfiguredPayouts 當前配置的賠率
+func ConfiguredPayouts() Payouts {
+	return Payouts{
+		Player:               config.AppConfig.PlayerPayout,
+		Banker:               config.AppConfig.BankerPayout,
+		BankerLuckySix2Cards: config.AppConfig.BankerLucky6_2Cards,
+		BankerLuckySix3Cards: config.AppConfig.BankerLucky6_3Cards,
+		Tie:                  config.AppConfig.TiePayout,
+		LuckySix2Cards:       config.AppConfig.Lucky6_2CardsPayout,
+		LuckySix3Cards:       config.AppConfig.Lucky6_3CardsPayout,
+	}
+}
+
+// Expectation 每單位投注返還（含本金）的期望和標準差
+type Expectation struct {
+	Mean   float64
+	StdDev float64
+}
+
+// Theory 各結果的概率和各投注類型的理論返還
+type Theory struct {
+	Odds game.Odds
+	Bets map[string]Expectation // 以投注類型為鍵
+}
+
+// outcome 一種結果的概率和該結果下每單位投注的返還（含本金）
+type outcome struct {
+	p        float64
+	returned float64
+}
+
+// NewTheory 由開局概率和賠率計算理論返還；和局時閒、莊、幸運6注退回本金
+func NewTheory(odds game.Odds, p Payouts) Theory {
+	bets := map[string][]outcome{
+		"player": {
+			{odds.Player, 1 + p.Player},
+			{odds.Tie, 1},
+		},
+		"banker": {
+			{odds.Banker, 1 + p.Banker},
+			{odds.LuckySix2Cards, 1 + p.BankerLuckySix2Cards},
+			{odds.LuckySix3Cards, 1 + p.BankerLuckySix3Cards},
+			{odds.Tie, 1},
+		},
+		"tie": {
+			{odds.Tie, p.Tie},
+		},
+		"luckySix": {
+			{odds.LuckySix2Cards, p.LuckySix2Cards},
+			{odds.LuckySix3Cards, p.LuckySix3Cards},
+			{odds.Tie, 1},
+		},
+	}
+
+	theory := Theory{Odds: odds, Bets: make(map[string]Expectation, len(bets))}
+	for betType, outcomes := range bets {
+		var mean, square float64
+		for _, o := range outcomes {
+			mean += o.p * o.returned
+			square += o.p * o.returned * o.returned
+		}
+		theory.Bets[betType] = Expectation{Mean: mean, StdDev: math.Sqrt(square - mean*mean)}
+	}
+	return theory
+}
+
+// winRate 勝方的理論概率，莊家勝出包含幸運6
+func (t Theory) winRate(winner string) float64 {
+	switch winner {
+	case "Player":
+		return t.Odds.Player
+	case "Banker":
+		return t.Odds.Banker + t.Odds.LuckySix2Cards + t.Odds.LuckySix3Cards
+	case "Tie":
+		return t.Odds.Tie
+	}
+	return 0
+}
+
+// luckySixRate 幸運6類型的理論概率
+func (t Theory) luckySixRate(luckySixType string) float64 {
+	switch luckySixType {
+	case "2cards":
+		return t.Odds.LuckySix2Cards
+	case "3cards":
+		return t.Odds.LuckySix3Cards
+	}
+	return 0
+}
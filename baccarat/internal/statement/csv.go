@@ -0,0 +1,77 @@
+package statement
+
+import (
+	"encoding/csv"
+	"io"
+	"strconv"
+)
+
+type csvWriter struct {
+	w *csv.Writer
+}
+
+// NewCSVWriter 創建 CSV 格式的對帳單，每行寫出後立即刷新
+func NewCSVWriter(w io.Writer) Writer {
+	return &csvWriter{w: csv.NewWriter(w)}
+}
+
+func formatAmount(v float64) string {
+	return strconv.FormatFloat(v, 'f', 2, 64)
+}
+
+func (c *csvWriter) write(record ...string) error {
+	if err := c.w.Write(record); err != nil {
+		return err
+	}
+	c.w.Flush()
+	return c.w.Error()
+}
+
+func (c *csvWriter) Begin(meta Meta) error {
+	if err := c.write("username", meta.Username); err != nil {
+		return err
+	}
+	if err := c.write("from", meta.From.Format("2006-01-02")); err != nil {
+		return err
+	}
+	if err := c.write("to", meta.To.AddDate(0, 0, -1).Format("2006-01-02")); err != nil {
+		return err
+	}
+	if err := c.write("opening_balance", formatAmount(meta.OpeningBalance)); err != nil {
+		return err
+	}
+	if err := c.write(); err != nil {
+		return err
+	}
+	return c.write("date", "deposits", "other", "wagered", "won", "net", "balance")
+}
+
+func (c *csvWriter) WriteDay(day Day, balance float64) error {
+	return c.write(
+		day.Date.Format("2006-01-02"),
+		formatAmount(day.Deposits),
+		formatAmount(day.Other),
+		formatAmount(day.Wagered),
+		formatAmount(day.Won),
+		formatAmount(day.Change()),
+		formatAmount(balance),
+	)
+}
+
+func (c *csvWriter) End(totals Totals) error {
+	if err := c.write(
+		"total",
+		formatAmount(totals.Deposits),
+		formatAmount(totals.Other),
+		formatAmount(totals.Wagered),
+		formatAmount(totals.Won),
+		formatAmount(totals.ClosingBalance-totals.OpeningBalance),
+		formatAmount(totals.ClosingBalance),
+	); err != nil {
+		return err
+	}
+	if err := c.write(); err != nil {
+		return err
+	}
+	return c.write("closing_balance", formatAmount(totals.ClosingBalance))
+}
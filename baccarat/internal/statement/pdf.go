@@ -0,0 +1,271 @@
+package statement
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// A4 版面，單位為 point
+const (
+	pdfPageWidth   = 595
+	pdfPageHeight  = 842
+	pdfMargin      = 50
+	pdfLineHeight  = 14
+	pdfFontSize    = 9
+	pdfTitleSize   = 16
+	pdfFooterSpace = 40
+)
+
+// 固定對象編號，頁面樹在最後寫出，其餘對象按順序追加
+const (
+	pdfCatalogObj = 1
+	pdfPagesObj   = 2
+	pdfFontObj    = 3
+	pdfBoldObj    = 4
+	pdfFirstFree  = 5
+)
+
+// pdfColumns 表格各列右對齊的 x 坐標（日期列左對齊）
+var pdfColumns = []struct {
+	title string
+	x     float64
+}{
+	{"Date", pdfMargin},
+	{"Deposits", 165},
+	{"Other", 235},
+	{"Wagered", 310},
+	{"Won", 385},
+	{"Net", 460},
+	{"Balance", pdfPageWidth - pdfMargin},
+}
+
+// countingWriter 記錄已寫出的字節數，用於交叉引用表的偏移
+type countingWriter struct {
+	w   io.Writer
+	n   int64
+	err error
+}
+
+func (c *countingWriter) printf(format string, args ...interface{}) {
+	if c.err != nil {
+		return
+	}
+	n, err := fmt.Fprintf(c.w, format, args...)
+	c.n += int64(n)
+	c.err = err
+}
+
+func (c *countingWriter) write(p []byte) {
+	if c.err != nil {
+		return
+	}
+	n, err := c.w.Write(p)
+	c.n += int64(n)
+	c.err = err
+}
+
+// pdfWriter 以純 Go 生成 PDF，每頁寫滿後立即輸出，內存中只保留當前頁
+// 只使用 PDF 內置的 Helvetica 字體，非 ASCII 字符以 ? 代替
+type pdfWriter struct {
+	out     *countingWriter
+	offsets []int64 // 下標為對象編號
+	kids    []int
+	page    bytes.Buffer
+	y       float64
+	meta    Meta
+}
+
+// NewPDFWriter 創建 PDF 格式的對帳單
+func NewPDFWriter(w io.Writer) Writer {
+	return &pdfWriter{
+		out:     &countingWriter{w: w},
+		offsets: make([]int64, pdfFirstFree),
+	}
+}
+
+// beginObject 記錄對象偏移並寫出對象頭
+func (p *pdfWriter) beginObject(num int) {
+	for len(p.offsets) <= num {
+		p.offsets = append(p.offsets, 0)
+	}
+	p.offsets[num] = p.out.n
+	p.out.printf("%d 0 obj\n", num)
+}
+
+// newObject 分配下一個對象編號
+func (p *pdfWriter) newObject() int {
+	p.offsets = append(p.offsets, 0)
+	return len(p.offsets) - 1
+}
+
+func (p *pdfWriter) Begin(meta Meta) error {
+	p.meta = meta
+	p.out.printf("%%PDF-1.4\n%%\xe2\xe3\xcf\xd3\n")
+
+	p.beginObject(pdfCatalogObj)
+	p.out.printf("<< /Type /Catalog /Pages %d 0 R >>\nendobj\n", pdfPagesObj)
+	p.beginObject(pdfFontObj)
+	p.out.printf("<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica /Encoding /WinAnsiEncoding >>\nendobj\n")
+	p.beginObject(pdfBoldObj)
+	p.out.printf("<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica-Bold /Encoding /WinAnsiEncoding >>\nendobj\n")
+
+	p.startPage()
+	p.text("F2", pdfTitleSize, pdfMargin, p.y, "Account Statement")
+	p.y -= pdfLineHeight * 2
+	p.text("F1", pdfFontSize, pdfMargin, p.y, "Username: "+meta.Username)
+	p.y -= pdfLineHeight
+	p.text("F1", pdfFontSize, pdfMargin, p.y, "Period: "+meta.From.Format("2006-01-02")+" to "+meta.To.AddDate(0, 0, -1).Format("2006-01-02"))
+	p.y -= pdfLineHeight
+	if !meta.GeneratedAt.IsZero() {
+		p.text("F1", pdfFontSize, pdfMargin, p.y, "Generated: "+meta.GeneratedAt.Format("2006-01-02 15:04:05 MST"))
+		p.y -= pdfLineHeight
+	}
+	p.text("F1", pdfFontSize, pdfMargin, p.y, "Opening balance: "+formatAmount(meta.OpeningBalance))
+	p.y -= pdfLineHeight * 2
+	p.tableHeader()
+	return p.out.err
+}
+
+func (p *pdfWriter) WriteDay(day Day, balance float64) error {
+	p.ensureSpace(1)
+	p.row("F1", day.Date.Format("2006-01-02"),
+		day.Deposits, day.Other, day.Wagered, day.Won, day.Change(), balance)
+	return p.out.err
+}
+
+func (p *pdfWriter) End(totals Totals) error {
+	p.ensureSpace(4)
+	p.rule()
+	p.row("F2", "Total",
+		totals.Deposits, totals.Other, totals.Wagered, totals.Won,
+		totals.ClosingBalance-totals.OpeningBalance, totals.ClosingBalance)
+	p.y -= pdfLineHeight
+	p.text("F2", pdfFontSize, pdfMargin, p.y, "Closing balance: "+formatAmount(totals.ClosingBalance))
+	p.flushPage()
+
+	// 頁面樹
+	p.beginObject(pdfPagesObj)
+	kids := make([]string, len(p.kids))
+	for i, kid := range p.kids {
+		kids[i] = strconv.Itoa(kid) + " 0 R"
+	}
+	p.out.printf("<< /Type /Pages /Kids [%s] /Count %d >>\nendobj\n", strings.Join(kids, " "), len(p.kids))
+
+	// 交叉引用表
+	xref := p.out.n
+	p.out.printf("xref\n0 %d\n0000000000 65535 f \n", len(p.offsets))
+	for _, offset := range p.offsets[1:] {
+		p.out.printf("%010d 00000 n \n", offset)
+	}
+	p.out.printf("trailer\n<< /Size %d /Root %d 0 R >>\nstartxref\n%d\n%%%%EOF\n", len(p.offsets), pdfCatalogObj, xref)
+	return p.out.err
+}
+
+// startPage 開始新的一頁
+func (p *pdfWriter) startPage() {
+	p.page.Reset()
+	p.y = pdfPageHeight - pdfMargin
+}
+
+// ensureSpace 剩餘空間不足 lines 行時換頁並重新輸出表頭
+func (p *pdfWriter) ensureSpace(lines int) {
+	if p.y-float64(lines*pdfLineHeight) >= pdfFooterSpace {
+		return
+	}
+	p.flushPage()
+	p.startPage()
+	p.tableHeader()
+}
+
+// flushPage 寫出當前頁的內容流和頁面對象
+func (p *pdfWriter) flushPage() {
+	pageNum := len(p.kids) + 1
+	footer := "Page " + strconv.Itoa(pageNum)
+	p.text("F1", pdfFontSize, pdfPageWidth-pdfMargin-textWidth(footer, pdfFontSize), pdfFooterSpace/2, footer)
+
+	content := p.newObject()
+	p.beginObject(content)
+	p.out.printf("<< /Length %d >>\nstream\n", p.page.Len())
+	p.out.write(p.page.Bytes())
+	p.out.printf("\nendstream\nendobj\n")
+
+	page := p.newObject()
+	p.beginObject(page)
+	p.out.printf("<< /Type /Page /Parent %d 0 R /MediaBox [0 0 %d %d] "+
+		"/Resources << /Font << /F1 %d 0 R /F2 %d 0 R >> >> /Contents %d 0 R >>\nendobj\n",
+		pdfPagesObj, pdfPageWidth, pdfPageHeight, pdfFontObj, pdfBoldObj, content)
+	p.kids = append(p.kids, page)
+}
+
+// tableHeader 輸出表格列名
+func (p *pdfWriter) tableHeader() {
+	for i, col := range pdfColumns {
+		x := col.x
+		if i > 0 {
+			x -= textWidth(col.title, pdfFontSize)
+		}
+		p.text("F2", pdfFontSize, x, p.y, col.title)
+	}
+	p.y -= 4
+	p.rule()
+}
+
+// row 輸出一行表格，金額右對齊
+func (p *pdfWriter) row(font, label string, amounts ...float64) {
+	p.text(font, pdfFontSize, pdfColumns[0].x, p.y, label)
+	for i, amount := range amounts {
+		s := formatAmount(amount)
+		p.text(font, pdfFontSize, pdfColumns[i+1].x-textWidth(s, pdfFontSize), p.y, s)
+	}
+	p.y -= pdfLineHeight
+}
+
+// rule 輸出一條橫線
+func (p *pdfWriter) rule() {
+	fmt.Fprintf(&p.page, "0.5 w %d %.2f m %d %.2f l S\n", pdfMargin, p.y, pdfPageWidth-pdfMargin, p.y)
+	p.y -= pdfLineHeight - 4
+}
+
+func (p *pdfWriter) text(font string, size, x, y float64, s string) {
+	fmt.Fprintf(&p.page, "BT /%s %g Tf %.2f %.2f Td (%s) Tj ET\n", font, size, x, y, escapePDFText(s))
+}
+
+// escapePDFText 轉義 PDF 字符串中的特殊字符，非 ASCII 字符替換為 ?
+func escapePDFText(s string) string {
+	var b strings.Builder
+	for _, r := range s {
+		switch {
+		case r == '(' || r == ')' || r == '\\':
+			b.WriteByte('\\')
+			b.WriteRune(r)
+		case r < 0x20 || r > 0x7e:
+			b.WriteByte('?')
+		default:
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
+
+// helveticaWidths Helvetica 常用字符寬度（千分之一 em），其餘按 556 估算
+var helveticaWidths = map[rune]int{
+	' ': 278, '.': 278, ',': 278, '-': 333, ':': 278,
+	'f': 278, 'i': 222, 'j': 222, 'l': 222, 'r': 333, 's': 500, 't': 278,
+	'B': 667, 'D': 722, 'N': 722, 'O': 778, 'P': 667, 'T': 611, 'W': 944,
+}
+
+// textWidth 估算文字寬度，用於右對齊
+func textWidth(s string, size float64) float64 {
+	width := 0
+	for _, r := range s {
+		if w, ok := helveticaWidths[r]; ok {
+			width += w
+		} else {
+			width += 556
+		}
+	}
+	return float64(width) * size / 1000
+}
@@ -0,0 +1,69 @@
+// Package statement 生成帳戶對帳單，按天逐行寫出以支持大範圍的流式輸出
+package statement
+
+import "time"
+
+// Meta 對帳單抬頭
+type Meta struct {
+	Username       string
+	From           time.Time // 包含
+	To             time.Time // 不包含
+	OpeningBalance float64
+	GeneratedAt    time.Time
+}
+
+// Day 一天的帳戶變動
+type Day struct {
+	Date     time.Time
+	Deposits float64 // 存款
+	Other    float64 // 其他交易（調整、沖正等），可為負數
+	Wagered  float64 // 投注
+	Won      float64 // 派彩（含退回本金）
+}
+
+// Change 當天餘額變動
+func (d Day) Change() float64 {
+	return d.Deposits + d.Other - d.Wagered + d.Won
+}
+
+// Totals 整個期間的匯總
+type Totals struct {
+	Deposits       float64
+	Other          float64
+	Wagered        float64
+	Won            float64
+	OpeningBalance float64
+	ClosingBalance float64
+}
+
+// Writer 對帳單輸出格式
+type Writer interface {
+	Begin(meta Meta) error
+	WriteDay(day Day, balance float64) error
+	End(totals Totals) error
+}
+
+// Generate 逐天讀取數據並寫出對帳單，balance 為每天結束時的餘額
+// days 依次回調每一天（按日期升序），回調返回錯誤時停止
+func Generate(w Writer, meta Meta, days func(func(Day) error) error) error {
+	if err := w.Begin(meta); err != nil {
+		return err
+	}
+
+	totals := Totals{OpeningBalance: meta.OpeningBalance}
+	balance := meta.OpeningBalance
+	err := days(func(day Day) error {
+		balance += day.Change()
+		totals.Deposits += day.Deposits
+		totals.Other += day.Other
+		totals.Wagered += day.Wagered
+		totals.Won += day.Won
+		return w.WriteDay(day, balance)
+	})
+	if err != nil {
+		return err
+	}
+
+	totals.ClosingBalance = balance
+	return w.End(totals)
+}
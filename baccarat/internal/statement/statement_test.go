@@ -0,0 +1,125 @@
+package statement
+
+import (
+	"bytes"
+	"encoding/csv"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+func testDays(n int) func(func(Day) error) error {
+	return func(emit func(Day) error) error {
+		start := time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)
+		for i := 0; i < n; i++ {
+			day := Day{Date: start.AddDate(0, 0, i), Deposits: 100, Wagered: 50, Won: 20}
+			if err := emit(day); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+}
+
+func testMeta() Meta {
+	return Meta{
+		Username:       "alice",
+		From:           time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC),
+		To:             time.Date(2024, 4, 1, 0, 0, 0, 0, time.UTC),
+		OpeningBalance: 1000,
+	}
+}
+
+func TestGenerateCSV(t *testing.T) {
+	var buf bytes.Buffer
+	if err := Generate(NewCSVWriter(&buf), testMeta(), testDays(3)); err != nil {
+		t.Fatalf("Generate: %v", err)
+	}
+
+	r := csv.NewReader(strings.NewReader(buf.String()))
+	r.FieldsPerRecord = -1
+	records, err := r.ReadAll()
+	if err != nil {
+		t.Fatalf("ReadAll: %v", err)
+	}
+
+	want := [][]string{
+		{"username", "alice"},
+		{"from", "2024-03-01"},
+		{"to", "2024-03-31"},
+		{"opening_balance", "1000.00"},
+		{"date", "deposits", "other", "wagered", "won", "net", "balance"},
+		{"2024-03-01", "100.00", "0.00", "50.00", "20.00", "70.00", "1070.00"},
+		{"2024-03-02", "100.00", "0.00", "50.00", "20.00", "70.00", "1140.00"},
+		{"2024-03-03", "100.00", "0.00", "50.00", "20.00", "70.00", "1210.00"},
+		{"total", "300.00", "0.00", "150.00", "60.00", "210.00", "1210.00"},
+		{"closing_balance", "1210.00"},
+	}
+	if len(records) != len(want) {
+		t.Fatalf("got %d records, want %d:\n%s", len(records), len(want), buf.String())
+	}
+	for i := range want {
+		if strings.Join(records[i], ",") != strings.Join(want[i], ",") {
+			t.Errorf("record %d = %v, want %v", i, records[i], want[i])
+		}
+	}
+}
+
+func TestGeneratePDF(t *testing.T) {
+	var buf bytes.Buffer
+	// 足夠多的天數以產生多頁
+	if err := Generate(NewPDFWriter(&buf), testMeta(), testDays(120)); err != nil {
+		t.Fatalf("Generate: %v", err)
+	}
+	out := buf.Bytes()
+
+	if !bytes.HasPrefix(out, []byte("%PDF-1.4\n")) {
+		t.Fatal("missing PDF header")
+	}
+	if !bytes.HasSuffix(out, []byte("%%EOF\n")) {
+		t.Fatal("missing EOF marker")
+	}
+
+	m := regexp.MustCompile(`startxref\n(\d+)\n`).FindSubmatch(out)
+	if m == nil {
+		t.Fatal("missing startxref")
+	}
+	xref, _ := strconv.Atoi(string(m[1]))
+	if !bytes.HasPrefix(out[xref:], []byte("xref\n")) {
+		t.Fatalf("startxref %d does not point at xref table", xref)
+	}
+
+	// 交叉引用表中的每個偏移都必須指向對應的對象
+	lines := strings.Split(string(out[xref:]), "\n")
+	var count int
+	if _, err := fmt.Sscanf(lines[1], "0 %d", &count); err != nil {
+		t.Fatalf("bad xref subsection %q", lines[1])
+	}
+	for num := 1; num < count; num++ {
+		offset, err := strconv.Atoi(lines[2+num][:10])
+		if err != nil {
+			t.Fatalf("bad xref entry %q", lines[2+num])
+		}
+		prefix := strconv.Itoa(num) + " 0 obj\n"
+		if !bytes.HasPrefix(out[offset:], []byte(prefix)) {
+			t.Errorf("object %d offset %d points at %q", num, offset, out[offset:offset+10])
+		}
+	}
+
+	pages := regexp.MustCompile(`/Count (\d+)`).FindSubmatch(out)
+	if pages == nil || string(pages[1]) == "1" {
+		t.Errorf("expected multiple pages, got %s", pages)
+	}
+	if !bytes.Contains(out, []byte("(Closing balance: 9400.00) Tj")) {
+		t.Error("missing closing balance")
+	}
+}
+
+func TestEscapePDFText(t *testing.T) {
+	if got := escapePDFText(`a(b)c\d 中`); got != `a\(b\)c\\d ?` {
+		t.Errorf("escapePDFText = %q", got)
+	}
+}
@@ -0,0 +1,218 @@
+// Package store 用戶、錢包、牌局、下注和牌靴的存取接口
+//
+// 處理器經這些接口存取上述數據；會話、兩步驗證、限額、排除、管理後台和報表等功能仍直接調用 db 包。
+// DBStore 是目前唯一的實現，以 db 包實現；db 包按 DB_DRIVER 連接 MySQL 或本地的 SQLite 文件，
+// 兩者共用同一套查詢。需要與限額、排除等檢查放在同一事務的操作接收 *sql.Tx
+package store
+
+import (
+	"baccarat/db"
+	"context"
+	"database/sql"
+)
+
+// Users 用戶帳號
+type Users interface {
+	Create(username string, passwordHash []byte) error
+	Exists(username string) (bool, error)
+	// FindByUsername 返回用戶ID和密碼雜湊，用戶不存在時返回 sql.ErrNoRows
+	FindByUsername(username string) (int, string, error)
+	Username(userID int) (string, error)
+	Role(userID int) (string, error)
+	PasswordHash(userID int) (string, error)
+	SetPasswordHash(tx *sql.Tx, userID int, passwordHash []byte) error
+	// Frozen 帳戶是否被管理員凍結
+	Frozen(tx *sql.Tx, userID int) (bool, error)
+}
+
+// Wallet 餘額和交易記錄
+type Wallet interface {
+	Balance(userID int) (float64, error)
+	// Lock 鎖定用戶直到事務結束，使同一用戶的餘額變動串行執行，返回鎖定後的餘額
+	Lock(tx *sql.Tx, userID int) (float64, error)
+	// AddBalance 變動餘額，amount 為負數時扣款
+	AddBalance(tx *sql.Tx, userID int, amount float64) error
+	// Record 記錄一筆交易，不變動餘額
+	Record(tx *sql.Tx, userID int, amount float64, transactionType string) error
+	Transactions(userID, limit, offset int) ([]db.WalletTransaction, error)
+}
+
+// Games 牌局記錄
+type Games interface {
+	Save(tx *sql.Tx, record *db.GameRecord) error
+	Details(gameID string) (*db.GameResult, error)
+}
+
+// Bets 下注記錄
+type Bets interface {
+	Save(tx *sql.Tx, userID int, gameID string, amount float64, betType string) error
+	ListByUser(userID, limit, offset int) ([]db.UserBet, error)
+	// History 按篩選條件以遊標分頁查詢，cursor 為 nil 時從最新記錄開始
+	History(filter db.BetHistoryFilter, cursor *db.BetHistoryCursor, limit int) ([]db.BetHistoryItem, error)
+	HistorySummary(filter db.BetHistoryFilter) (*db.BetHistorySummary, error)
+}
+
+// Shoes 牌靴
+type Shoes interface {
+	// Current 鎖定牌桌當前的牌靴直到事務結束，使同一牌桌的牌局按牌序依次發牌；
+	// 牌桌還沒有牌靴時返回 sql.ErrNoRows
+	Current(tx *sql.Tx, tableID string) (*db.Shoe, error)
+	Create(tx *sql.Tx, shoe *db.Shoe) error
+	// Advance 更新牌靴下一局的起始位置
+	Advance(tx *sql.Tx, shoeID string, nextPosition int) error
+	// Round 查詢牌局及其牌靴，用於重新發牌核對
+	Round(gameID string) (*db.ShoeRound, error)
+}
+
+// Store 處理器使用的全部存取接口
+type Store struct {
+	Users  Users
+	Wallet Wallet
+	Games  Games
+	Bets   Bets
+	Shoes  Shoes
+	// Transaction 在一個事務內執行 fn，fn 返回錯誤時回滾；事務的 span 記錄在 ctx 的 span 之下
+	Transaction func(ctx context.Context, fn func(tx *sql.Tx) error) error
+}
+
+// NewDBStore 以 db 包實現的存取接口，使用 db.InitDB 打開的連接
+func NewDBStore() Store {
+	return Store{
+		Users:       DBUsers{},
+		Wallet:      DBWallet{},
+		Games:       DBGames{},
+		Bets:        DBBets{},
+		Shoes:       DBShoes{},
+		Transaction: db.TransactionContext,
+	}
+}
+
+// DBUsers 以 users 表實現 Users
+type DBUsers struct{}
+
+// Create 創建新用戶
+func (DBUsers) Create(username string, passwordHash []byte) error {
+	return db.CreateUser(username, passwordHash)
+}
+
+// Exists 用戶名是否已被使用
+func (DBUsers) Exists(username string) (bool, error) {
+	return db.UsernameExists(username)
+}
+
+// FindByUsername 通過用戶名查找用戶
+func (DBUsers) FindByUsername(username string) (int, string, error) {
+	return db.GetUserByUsername(username)
+}
+
+// Username 通過用戶ID獲取用戶名
+func (DBUsers) Username(userID int) (string, error) {
+	return db.GetUsernameByID(userID)
+}
+
+// Role 獲取用戶角色
+func (DBUsers) Role(userID int) (string, error) {
+	return db.GetUserRole(userID)
+}
+
+// PasswordHash 獲取用戶的密碼雜湊
+func (DBUsers) PasswordHash(userID int) (string, error) {
+	return db.GetPasswordHash(userID)
+}
+
+// SetPasswordHash 更新密碼雜湊
+func (DBUsers) SetPasswordHash(tx *sql.Tx, userID int, passwordHash []byte) error {
+	return db.UpdatePasswordHash(tx, userID, passwordHash)
+}
+
+// Frozen 帳戶是否被凍結
+func (DBUsers) Frozen(tx *sql.Tx, userID int) (bool, error) {
+	return db.IsUserFrozen(tx, userID)
+}
+
+// DBWallet 以 users.balance 和 transactions 表實現 Wallet
+type DBWallet struct{}
+
+// Balance 獲取用戶餘額
+func (DBWallet) Balance(userID int) (float64, error) {
+	return db.GetUserBalance(userID)
+}
+
+// Lock 鎖定用戶行
+func (DBWallet) Lock(tx *sql.Tx, userID int) (float64, error) {
+	return db.LockUserBalance(tx, userID)
+}
+
+// AddBalance 變動餘額
+func (DBWallet) AddBalance(tx *sql.Tx, userID int, amount float64) error {
+	return db.UpdateUserBalance(tx, userID, amount)
+}
+
+// Record 記錄交易
+func (DBWallet) Record(tx *sql.Tx, userID int, amount float64, transactionType string) error {
+	return db.SaveTransaction(tx, userID, amount, transactionType)
+}
+
+// Transactions 分頁查詢交易記錄
+func (DBWallet) Transactions(userID, limit, offset int) ([]db.WalletTransaction, error) {
+	return db.ListTransactions(userID, limit, offset)
+}
+
+// DBGames 以 game_records 表實現 Games
+type DBGames struct{}
+
+// Save 保存牌局記錄
+func (DBGames) Save(tx *sql.Tx, record *db.GameRecord) error {
+	return db.SaveGameRecord(tx, record)
+}
+
+// Details 查詢牌局詳情及全部下注
+func (DBGames) Details(gameID string) (*db.GameResult, error) {
+	return db.GetGameDetails(gameID)
+}
+
+// DBBets 以 bets 表實現 Bets
+type DBBets struct{}
+
+// Save 保存投注記錄
+func (DBBets) Save(tx *sql.Tx, userID int, gameID string, amount float64, betType string) error {
+	return db.SaveBet(tx, userID, gameID, amount, betType)
+}
+
+// ListByUser 分頁查詢用戶的投注
+func (DBBets) ListByUser(userID, limit, offset int) ([]db.UserBet, error) {
+	return db.ListUserBets(userID, limit, offset)
+}
+
+// History 查詢下注歷史
+func (DBBets) History(filter db.BetHistoryFilter, cursor *db.BetHistoryCursor, limit int) ([]db.BetHistoryItem, error) {
+	return db.GetBetHistory(filter, cursor, limit)
+}
+
+// HistorySummary 統計篩選範圍內的下注
+func (DBBets) HistorySummary(filter db.BetHistoryFilter) (*db.BetHistorySummary, error) {
+	return db.GetBetHistorySummary(filter)
+}
+
+// DBShoes 以 shoes 表實現 Shoes
+type DBShoes struct{}
+
+// Current 鎖定牌桌當前的牌靴
+func (DBShoes) Current(tx *sql.Tx, tableID string) (*db.Shoe, error) {
+	return db.LockCurrentShoe(tx, tableID)
+}
+
+// Create 保存新牌靴
+func (DBShoes) Create(tx *sql.Tx, shoe *db.Shoe) error {
+	return db.CreateShoe(tx, shoe)
+}
+
+// Advance 更新牌靴位置
+func (DBShoes) Advance(tx *sql.Tx, shoeID string, nextPosition int) error {
+	return db.AdvanceShoe(tx, shoeID, nextPosition)
+}
+
+// Round 查詢牌局及其牌靴
+func (DBShoes) Round(gameID string) (*db.ShoeRound, error) {
+	return db.GetShoeRound(gameID)
+}
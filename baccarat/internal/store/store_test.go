@@ -0,0 +1,352 @@
+package store
+
+import (
+	"baccarat/db"
+	"baccarat/internal/limits"
+	"baccarat/internal/statement"
+	"baccarat/internal/testdb"
+	"context"
+	"database/sql"
+	"fmt"
+	"os"
+	"strings"
+	"testing"
+	"time"
+)
+
+// 測試使用臨時目錄中的 SQLite 數據庫，執行全部遷移後共用
+func TestMain(m *testing.M) {
+	cleanup, err := testdb.Open()
+	if err != nil {
+		panic(err)
+	}
+	code := m.Run()
+	cleanup()
+	os.Exit(code)
+}
+
+// createUser 創建用戶並存入 amount
+func createUser(t *testing.T, s Store, username string, amount float64) int {
+	t.Helper()
+	if err := s.Users.Create(username, []byte("hash")); err != nil {
+		t.Fatal(err)
+	}
+	userID, _, err := s.Users.FindByUsername(username)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = s.Transaction(context.Background(), func(tx *sql.Tx) error {
+		if _, err := s.Wallet.Lock(tx, userID); err != nil {
+			return err
+		}
+		if err := s.Wallet.AddBalance(tx, userID, amount); err != nil {
+			return err
+		}
+		return s.Wallet.Record(tx, userID, amount, "deposit")
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return userID
+}
+
+// playerWin 保存一局閒家贏的牌局和 userID 的閒家下注
+func playerWin(t *testing.T, s Store, gameID string, userID int, bet float64) {
+	t.Helper()
+	record := &db.GameRecord{
+		GameID:             gameID,
+		PlayerInitialCards: "S7,H2",
+		BankerInitialCards: "D5,CK",
+		PlayerInitialScore: 9,
+		BankerInitialScore: 5,
+		PlayerFinalScore:   9,
+		BankerFinalScore:   5,
+		Winner:             "Player",
+		Payouts:            map[string]float64{"player": bet * 2, "player_bet": bet},
+	}
+	err := s.Transaction(context.Background(), func(tx *sql.Tx) error {
+		if err := s.Wallet.AddBalance(tx, userID, -bet); err != nil {
+			return err
+		}
+		if err := s.Wallet.AddBalance(tx, userID, bet*2); err != nil {
+			return err
+		}
+		if err := s.Games.Save(tx, record); err != nil {
+			return err
+		}
+		return s.Bets.Save(tx, userID, gameID, bet, "player")
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestUsers(t *testing.T) {
+	s := NewDBStore()
+	userID := createUser(t, s, "alice", 0)
+
+	if exists, err := s.Users.Exists("alice"); err != nil || !exists {
+		t.Errorf("Exists(alice) = %v, %v", exists, err)
+	}
+	if exists, err := s.Users.Exists("nobody"); err != nil || exists {
+		t.Errorf("Exists(nobody) = %v, %v", exists, err)
+	}
+	if _, _, err := s.Users.FindByUsername("nobody"); err != sql.ErrNoRows {
+		t.Errorf("FindByUsername(nobody) error = %v, want sql.ErrNoRows", err)
+	}
+	if err := s.Users.Create("alice", []byte("hash")); err == nil {
+		t.Error("duplicate username accepted")
+	}
+	if name, err := s.Users.Username(userID); err != nil || name != "alice" {
+		t.Errorf("Username = %q, %v", name, err)
+	}
+	if role, err := s.Users.Role(userID); err != nil || role != "player" {
+		t.Errorf("Role = %q, %v", role, err)
+	}
+}
+
+func TestWalletAndGames(t *testing.T) {
+	s := NewDBStore()
+	userID := createUser(t, s, "bob", 500)
+	playerWin(t, s, "game-bob-1", userID, 100)
+
+	if balance, err := s.Wallet.Balance(userID); err != nil || balance != 600 {
+		t.Errorf("Balance = %v, %v, want 600", balance, err)
+	}
+	// 鎖定時讀到的餘額用於事務內的再次檢查
+	err := s.Transaction(context.Background(), func(tx *sql.Tx) error {
+		balance, err := s.Wallet.Lock(tx, userID)
+		if err == nil && balance != 600 {
+			t.Errorf("Lock balance = %v, want 600", balance)
+		}
+		return err
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	transactions, err := s.Wallet.Transactions(userID, 20, 0)
+	if err != nil || len(transactions) != 1 || transactions[0].Amount != 500 || transactions[0].TransactionType != "deposit" {
+		t.Fatalf("Transactions = %+v, %v", transactions, err)
+	}
+	if time.Since(transactions[0].CreatedAt) > time.Minute || time.Since(transactions[0].CreatedAt) < -time.Minute {
+		t.Errorf("transaction created at %v, want about now", transactions[0].CreatedAt)
+	}
+
+	bets, err := s.Bets.ListByUser(userID, 20, 0)
+	if err != nil || len(bets) != 1 || bets[0].GameID != "game-bob-1" || bets[0].Winner != "Player" {
+		t.Fatalf("ListByUser = %+v, %v", bets, err)
+	}
+
+	details, err := s.Games.Details("game-bob-1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if details.Status != "settled" || len(details.Bets) != 1 || details.Bets[0].Username != "bob" || details.TotalPayouts != 200 {
+		t.Errorf("Details = %+v", details)
+	}
+
+	// 投注引用不存在的牌局時外鍵約束生效
+	err = s.Transaction(context.Background(), func(tx *sql.Tx) error {
+		return s.Bets.Save(tx, userID, "missing-game", 10, "tie")
+	})
+	if err == nil {
+		t.Error("bet on missing game accepted")
+	}
+}
+
+// 限額、對帳單和報表的查詢用到 upsert、DATE、DATE_FORMAT 等方言相關的語法
+func TestSharedQueries(t *testing.T) {
+	s := NewDBStore()
+	userID := createUser(t, s, "carol", 300)
+	playerWin(t, s, "game-carol-1", userID, 50)
+
+	wagered, returned, err := db.GetWagerTotals(db.DB, userID, time.Now().Add(-time.Hour))
+	if err != nil || wagered != 50 || returned != 100 {
+		t.Errorf("GetWagerTotals = %v, %v, %v", wagered, returned, err)
+	}
+
+	// 同一限額保存兩次，第二次更新原記錄
+	err = s.Transaction(context.Background(), func(tx *sql.Tx) error {
+		for _, amount := range []float64{100, 200} {
+			l := limits.Limit{Type: limits.TypeDeposit, Period: limits.PeriodDaily, Amount: amount}
+			if err := db.SaveUserLimit(tx, userID, l); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if saved, err := db.GetUserLimits(db.DB, userID); err != nil || len(saved) != 1 || saved[0].Amount != 200 {
+		t.Errorf("GetUserLimits = %+v, %v", saved, err)
+	}
+
+	now := time.Now()
+	from := now.Add(-24 * time.Hour)
+	var days []statement.Day
+	var opening float64
+	err = db.StreamStatement(context.Background(), userID, from, now.Add(time.Hour),
+		func(o float64, each func(func(statement.Day) error) error) error {
+			opening = o
+			return each(func(d statement.Day) error {
+				days = append(days, d)
+				return nil
+			})
+		})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if opening != 0 || len(days) == 0 || days[len(days)-1].Date.IsZero() {
+		t.Errorf("statement opening %v, days %+v", opening, days)
+	}
+
+	hour := now.Truncate(time.Hour)
+	if err := db.RebuildReportRollups(hour, hour.Add(time.Hour), hour.Add(time.Hour)); err != nil {
+		t.Fatal(err)
+	}
+	if err := db.RebuildReportRollups(hour, hour.Add(time.Hour), hour.Add(time.Hour)); err != nil {
+		t.Fatalf("rebuilding again: %v", err)
+	}
+	report, err := db.GetReport(db.ReportQuery{From: hour, To: hour.Add(time.Hour), Granularity: "hour"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(report) != 1 || report[0].Period != now.Format("2006-01-02 15:00") || report[0].Wagered == 0 {
+		t.Errorf("GetReport = %+v", report)
+	}
+	if watermark, ok, err := db.GetReportRollupWatermark(); err != nil || !ok || !watermark.Equal(hour.Add(time.Hour)) {
+		t.Errorf("watermark = %v, %v, %v", watermark, ok, err)
+	}
+}
+
+func TestShoes(t *testing.T) {
+	s := NewDBStore()
+	userID := createUser(t, s, "dave", 100)
+
+	var current *db.Shoe
+	err := s.Transaction(context.Background(), func(tx *sql.Tx) error {
+		if _, err := s.Shoes.Current(tx, "shoe-table"); err != sql.ErrNoRows {
+			t.Errorf("Current on empty table error = %v, want sql.ErrNoRows", err)
+		}
+		for number := 1; number <= 2; number++ {
+			shoe := &db.Shoe{
+				ShoeID:        fmt.Sprintf("shoe-%d", number),
+				TableID:       "shoe-table",
+				ShoeNumber:    number,
+				Decks:         8,
+				Seed:          "00",
+				CardOrder:     "S7,H2,D5,CK",
+				CardOrderHash: "hash",
+				BurnCards:     2,
+				NextPosition:  2,
+			}
+			if err := s.Shoes.Create(tx, shoe); err != nil {
+				return err
+			}
+		}
+		var err error
+		if current, err = s.Shoes.Current(tx, "shoe-table"); err != nil {
+			return err
+		}
+		return s.Shoes.Advance(tx, current.ShoeID, 6)
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if current.ShoeID != "shoe-2" || current.NextPosition != 2 {
+		t.Errorf("Current = %+v, want the latest shoe", current)
+	}
+
+	// 牌局引用牌靴和位置
+	err = s.Transaction(context.Background(), func(tx *sql.Tx) error {
+		record := &db.GameRecord{
+			GameID:             "game-dave-1",
+			PlayerInitialCards: "S7,H2",
+			BankerInitialCards: "D5,CK",
+			PlayerInitialScore: 9,
+			BankerInitialScore: 5,
+			PlayerFinalScore:   9,
+			BankerFinalScore:   5,
+			Winner:             "Player",
+			Payouts:            map[string]float64{},
+			ShoeID:             sql.NullString{String: "shoe-2", Valid: true},
+			ShoePosition:       sql.NullInt64{Int64: 0, Valid: true},
+			DrawnCards:         sql.NullString{String: "S7,H2,D5,CK", Valid: true},
+		}
+		return s.Games.Save(tx, record)
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	round, err := s.Shoes.Round("game-dave-1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if round.ShoeID != "shoe-2" || round.NextPosition != 6 || round.Position != 0 || round.DrawnCards != "S7,H2,D5,CK" ||
+		strings.Join(round.PlayerCards, ",") != "S7,H2" || round.Winner != "Player" {
+		t.Errorf("Round = %+v", round)
+	}
+
+	// 沒有牌靴記錄的牌局
+	playerWin(t, s, "game-dave-2", userID, 10)
+	if _, err := s.Shoes.Round("game-dave-2"); err != db.ErrNoShoeRecord {
+		t.Errorf("Round without shoe error = %v, want db.ErrNoShoeRecord", err)
+	}
+	if _, err := s.Shoes.Round("missing-game"); err != db.ErrGameNotFound {
+		t.Errorf("Round of missing game error = %v, want db.ErrGameNotFound", err)
+	}
+}
+
+// 歸檔後歷史記錄、錢包流水、牌局詳情和牌靴核對照常讀取，但不能再修正
+func TestArchive(t *testing.T) {
+	s := NewDBStore()
+	userID := createUser(t, s, "erin", 200)
+	playerWin(t, s, "game-erin-1", userID, 20)
+
+	moved, err := db.ArchiveRounds(time.Now().Add(time.Hour), 1000)
+	if err != nil || moved == 0 {
+		t.Fatalf("ArchiveRounds = %d, %v", moved, err)
+	}
+	var live int
+	if err := db.DB.QueryRow("SELECT COUNT(*) FROM game_records").Scan(&live); err != nil || live != 0 {
+		t.Errorf("%d live rounds left, %v", live, err)
+	}
+	if again, err := db.ArchiveRounds(time.Now().Add(time.Hour), 1000); err != nil || again != 0 {
+		t.Errorf("second ArchiveRounds = %d, %v", again, err)
+	}
+
+	history, err := db.GetBetHistory(db.BetHistoryFilter{UserID: userID}, nil, 10)
+	if err != nil || len(history) != 1 || history[0].GameID != "game-erin-1" || history[0].Payout != 40 {
+		t.Errorf("GetBetHistory = %+v, %v", history, err)
+	}
+	if bets, err := s.Bets.ListByUser(userID, 10, 0); err != nil || len(bets) != 1 || bets[0].Winner != "Player" {
+		t.Errorf("ListByUser = %+v, %v", bets, err)
+	}
+	if details, err := s.Games.Details("game-erin-1"); err != nil || len(details.Bets) != 1 {
+		t.Errorf("Details = %+v, %v", details, err)
+	}
+	ledger, err := db.GetLedger(userID, 10, 0)
+	if err != nil || len(ledger) != 3 {
+		t.Fatalf("GetLedger = %+v, %v", ledger, err)
+	}
+	kinds := map[string]string{}
+	for _, entry := range ledger {
+		kinds[entry.Kind] = entry.GameID
+	}
+	if kinds["wager"] != "game-erin-1" || kinds["payout"] != "game-erin-1" {
+		t.Errorf("GetLedger = %+v", ledger)
+	}
+	if round, err := s.Shoes.Round("game-dave-1"); err != nil || round.ShoeID != "shoe-2" {
+		t.Errorf("Round of archived game = %+v, %v", round, err)
+	}
+
+	err = s.Transaction(context.Background(), func(tx *sql.Tx) error {
+		_, err := db.LockGameForCorrection(tx, "game-erin-1")
+		return err
+	})
+	if err != db.ErrGameArchived {
+		t.Errorf("LockGameForCorrection error = %v, want db.ErrGameArchived", err)
+	}
+}
@@ -0,0 +1,37 @@
+// Package testdb 測試用的臨時 SQLite 數據庫
+package testdb
+
+import (
+	"baccarat/db"
+	"baccarat/internal/migrate"
+	"os"
+	"path/filepath"
+)
+
+// Open 在臨時目錄創建 SQLite 數據庫，執行全部遷移後設為 db.DB，返回關閉並刪除數據庫的函數
+func Open() (func(), error) {
+	dir, err := os.MkdirTemp("", "baccarat-test")
+	if err != nil {
+		return nil, err
+	}
+	conn, err := db.OpenSQLite(filepath.Join(dir, "test.db"))
+	if err != nil {
+		os.RemoveAll(dir)
+		return nil, err
+	}
+	cleanup := func() {
+		conn.Close()
+		os.RemoveAll(dir)
+	}
+	db.DB = conn
+
+	list, err := migrate.LoadEmbedded()
+	if err == nil {
+		_, err = migrate.New(list, migrate.DBStore{}).Up(0)
+	}
+	if err != nil {
+		cleanup()
+		return nil, err
+	}
+	return cleanup, nil
+}
@@ -4,9 +4,24 @@ import (
 	"baccarat/api"
 	"baccarat/config"
 	"baccarat/db"
+	"baccarat/game"
+	"baccarat/internal/archive"
+	"baccarat/internal/auth"
+	"baccarat/internal/clock"
+	"baccarat/internal/events"
+	"baccarat/internal/notify"
+	"baccarat/internal/recovery"
+	"baccarat/internal/reporting"
+	"baccarat/internal/rtp"
+	"baccarat/internal/store"
 	"baccarat/pkg/logger"
+	"baccarat/pkg/metrics"
+	"baccarat/pkg/tracing"
+	"context"
 	"log"
 	"net/http"
+	"os"
+	"time"
 )
 
 func main() {
@@ -18,17 +33,106 @@ func main() {
 	// 初始化日志
 	logger.InitLogger()
 
+	// 初始化追踪
+	shutdownTracing, err := tracing.Init(context.Background())
+	if err != nil {
+		logger.Fatal("追踪初始化失败: ", err)
+	}
+	defer shutdownTracing(context.Background())
+
 	// 连接数据库
-	err := db.InitDB()
+	err = db.InitDB()
 	if err != nil {
 		logger.Fatal("数据库连接失败: ", err)
 	}
 	defer db.DB.Close()
 
+	// 数据库迁移子命令
+	if len(os.Args) > 1 && os.Args[1] == "migrate" {
+		code := runMigrate(os.Args[2:])
+		db.DB.Close()
+		os.Exit(code)
+	}
+
+	// 检查数据库结构版本
+	if err := checkSchema(); err != nil {
+		logger.Fatal("数据库结构版本不兼容: ", err)
+	}
+
 	logger.Info("服务器启动成功")
 
+	// 载入JWT签名密钥
+	keys, err := auth.LoadConfiguredKeySet()
+	if err != nil {
+		logger.Fatal("JWT密钥载入失败: ", err)
+	}
+
+	// 启动中断赌局的恢复任务
+	recoveryJob := recovery.NewJob(
+		time.Duration(config.AppConfig.AutoGameRecoveryInterval)*time.Minute,
+		time.Duration(config.AppConfig.AutoGameRecoveryTimeout)*time.Minute,
+		recovery.DBStore{},
+		clock.System{},
+	)
+	go recoveryJob.Run(context.Background())
+
+	// 启动报表汇总任务
+	rollup := reporting.NewRollupJob(
+		time.Duration(config.AppConfig.ReportRollupInterval)*time.Minute,
+		time.Hour,
+		reporting.DBStore{},
+		clock.System{},
+		config.Location(),
+	)
+	go rollup.Run(context.Background())
+
+	// 启动事件投递任务
+	if config.AppConfig.WebhookURL != "" {
+		relay := events.NewRelay(
+			config.AppConfig.WebhookURL,
+			[]byte(config.AppConfig.WebhookSecret),
+			time.Duration(config.AppConfig.EventRelayInterval)*time.Second,
+			events.DBOutbox{},
+			clock.System{},
+		)
+		go relay.Run(context.Background())
+	}
+
+	// 启动牌局归档任务
+	if config.AppConfig.ArchiveAfterDays > 0 {
+		archiver := archive.NewJob(
+			time.Duration(config.AppConfig.ArchiveInterval)*time.Minute,
+			time.Duration(config.AppConfig.ArchiveAfterDays)*24*time.Hour,
+			archive.DBStore{},
+			clock.System{},
+		)
+		go archiver.Run(context.Background())
+	}
+
+	// 启动返还率偏离监控
+	if config.AppConfig.RTPMonitorInterval > 0 {
+		notifiers := notify.AlertNotifiers{notify.NewLogNotifier()}
+		if config.AppConfig.RTPAlertWebhookURL != "" {
+			notifiers = append(notifiers, notify.NewWebhookNotifier(config.AppConfig.RTPAlertWebhookURL, []byte(config.AppConfig.RTPAlertWebhookSecret)))
+		}
+		monitor := rtp.NewMonitor(
+			time.Duration(config.AppConfig.RTPMonitorInterval)*time.Minute,
+			time.Duration(config.AppConfig.RTPMonitorWindow)*time.Hour,
+			config.AppConfig.RTPAlertSigma,
+			config.AppConfig.RTPMinRounds,
+			rtp.NewTheory(game.ComputeOdds(game.ShoeDecks), rtp.ConfiguredPayouts()),
+			rtp.DBStore{},
+			notifiers,
+			clock.System{},
+		)
+		go monitor.Run(context.Background())
+	}
+
+	// 导出进行中的自动赌局数
+	metrics.RegisterActiveRounds(db.CountActiveAutoGames)
+
 	// 设置路由
-	router := api.NewRouter(db.DB)
+	router := api.NewRouter(store.NewDBStore(), keys)
 
 	// 启动服务器
 	logger.Info("Server starting on :8080...")
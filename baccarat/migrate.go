@@ -0,0 +1,140 @@
+package main
+
+import (
+	"baccarat/db"
+	"baccarat/internal/migrate"
+	"baccarat/pkg/logger"
+	"fmt"
+	"os"
+	"strconv"
+)
+
+const migrateUsage = `usage: baccarat migrate <command>
+
+commands:
+  up [N]       執行全部（或最多 N 個）未執行的遷移
+  down [N]     回退最近的 N 個遷移（默認 1）
+  status       顯示當前版本和最新版本
+  force V      不執行腳本，直接把版本設為 V 並清除 dirty（修復失敗的遷移，或接管已有的資料庫）
+
+接管按舊 sql 腳本建立的資料庫：核對現有表與 0001_core 一致後執行 force 1，再執行 up
+（已包含後續遷移的改動時 force 到最後一個已包含的版本）`
+
+// newMigrator 載入當前數據庫類型的內嵌遷移腳本
+func newMigrator() (*migrate.Migrator, error) {
+	list, err := migrate.LoadEmbedded()
+	if err != nil {
+		return nil, err
+	}
+	return migrate.New(list, migrate.DBStore{}), nil
+}
+
+// runMigrate 執行 migrate 子命令，返回進程退出碼
+func runMigrate(args []string) int {
+	if len(args) == 0 {
+		fmt.Fprintln(os.Stderr, migrateUsage)
+		return 2
+	}
+
+	// 可選的數量或版本參數
+	number := func(defaultVal int) (int, bool) {
+		if len(args) < 2 {
+			return defaultVal, true
+		}
+		n, err := strconv.Atoi(args[1])
+		if err != nil || n < 0 {
+			fmt.Fprintln(os.Stderr, "invalid number:", args[1])
+			return 0, false
+		}
+		return n, true
+	}
+
+	m, err := newMigrator()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "load migrations:", err)
+		return 1
+	}
+
+	switch args[0] {
+	case "up":
+		n, ok := number(0)
+		if !ok {
+			return 2
+		}
+		applied, err := m.Up(n)
+		for _, migration := range applied {
+			fmt.Printf("applied %04d_%s\n", migration.Version, migration.Name)
+		}
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return 1
+		}
+		if len(applied) == 0 {
+			fmt.Println("no pending migrations")
+		}
+	case "down":
+		n, ok := number(1)
+		if !ok {
+			return 2
+		}
+		reverted, err := m.Down(n)
+		for _, migration := range reverted {
+			fmt.Printf("reverted %04d_%s\n", migration.Version, migration.Name)
+		}
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return 1
+		}
+	case "status":
+		state, err := m.State()
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return 1
+		}
+		fmt.Printf("current: %d\nlatest:  %d\ndirty:   %t\n", state.Version, m.Latest(), state.Dirty)
+	case "force":
+		if len(args) < 2 {
+			fmt.Fprintln(os.Stderr, migrateUsage)
+			return 2
+		}
+		version, ok := number(0)
+		if !ok {
+			return 2
+		}
+		if err := m.Force(version); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return 1
+		}
+		fmt.Printf("version set to %d\n", version)
+	default:
+		fmt.Fprintln(os.Stderr, migrateUsage)
+		return 2
+	}
+	return 0
+}
+
+// checkSchema 啟動時確認資料庫結構版本與程序兼容
+// 本地的 SQLite 數據庫先自動執行未執行的遷移
+func checkSchema() error {
+	m, err := newMigrator()
+	if err != nil {
+		return err
+	}
+	if db.Driver() == db.DriverSQLite {
+		applied, err := m.Up(0)
+		for _, migration := range applied {
+			logger.Info(fmt.Sprintf("Applied migration %04d_%s", migration.Version, migration.Name))
+		}
+		if err != nil {
+			return err
+		}
+	}
+	state, err := m.Check()
+	if err != nil {
+		return err
+	}
+	if state.Version > m.Latest() {
+		logger.Warn("Schema version", state.Version, "is newer than this binary", m.Latest())
+	}
+	return nil
+}
@@ -0,0 +1,64 @@
+package logger
+
+import (
+	"context"
+	"sync"
+
+	"github.com/sirupsen/logrus"
+	"go.opentelemetry.io/otel/trace"
+)
+
+type contextKey struct{}
+
+// contextFields 隨 context 傳遞的日誌字段，AddFields 可在下層補充，上層記錄時也能看到
+type contextFields struct {
+	mu     sync.Mutex
+	fields Fields
+}
+
+// WithFields 返回帶有日誌字段的 context，繼承上層 context 的字段
+func WithFields(ctx context.Context, fields Fields) context.Context {
+	merged := Fields{}
+	if parent, ok := ctx.Value(contextKey{}).(*contextFields); ok {
+		parent.mu.Lock()
+		for k, v := range parent.fields {
+			merged[k] = v
+		}
+		parent.mu.Unlock()
+	}
+	for k, v := range fields {
+		merged[k] = v
+	}
+	return context.WithValue(ctx, contextKey{}, &contextFields{fields: merged})
+}
+
+// AddFields 在 context 已有的字段上補充，用於認證後加上 user_id 等只有下層才知道的字段
+// context 沒有經過 WithFields 時不做任何事
+func AddFields(ctx context.Context, fields Fields) {
+	holder, ok := ctx.Value(contextKey{}).(*contextFields)
+	if !ok {
+		return
+	}
+	holder.mu.Lock()
+	defer holder.mu.Unlock()
+	for k, v := range fields {
+		holder.fields[k] = v
+	}
+}
+
+// FromContext 返回帶有 context 字段的記錄器，context 中有 OpenTelemetry span 時自動加上 trace_id 和 span_id
+func FromContext(ctx context.Context) *logrus.Entry {
+	entry := logrus.NewEntry(Log).WithContext(ctx)
+	if holder, ok := ctx.Value(contextKey{}).(*contextFields); ok {
+		holder.mu.Lock()
+		entry = entry.WithFields(holder.fields)
+		holder.mu.Unlock()
+	}
+	if span := trace.SpanContextFromContext(ctx); span.IsValid() {
+		entry = entry.WithFields(Fields{
+			"trace_id": span.TraceID().String(),
+			"span_id":  span.SpanID().String(),
+		})
+	}
+	return entry
+}
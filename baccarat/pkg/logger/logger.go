@@ -4,7 +4,9 @@ import (
 	"fmt"
 	"os"
 	"runtime"
+	"sort"
 	"strings"
+	"time"
 
 	"baccarat/config"
 
@@ -15,15 +17,21 @@ var (
 	Log *logrus.Logger
 )
 
+// Fields 結構化日誌的字段
+type Fields = logrus.Fields
+
+// loggerPackage 本包的導入路徑，查找調用位置時跳過本包的封裝函數
+const loggerPackage = "baccarat/pkg/logger"
+
 type CustomFormatter struct{}
 
 func (f *CustomFormatter) Format(entry *logrus.Entry) ([]byte, error) {
 	// 获取调用者信息
 	var caller string
-	if entry.HasCaller() {
-		fileName := entry.Caller.File
-		fileNameParts := strings.Split(fileName, "/")
-		caller = fmt.Sprintf("%s:%d", fileNameParts[len(fileNameParts)-1], entry.Caller.Line)
+	frame := callerFrame(entry)
+	if frame != nil {
+		fileNameParts := strings.Split(frame.File, "/")
+		caller = fmt.Sprintf("%s:%d", fileNameParts[len(fileNameParts)-1], frame.Line)
 	}
 
 	// 格式化时间
@@ -34,17 +42,82 @@ func (f *CustomFormatter) Format(entry *logrus.Entry) ([]byte, error) {
 	alignedLevel := fmt.Sprintf("%-5s", level)
 
 	// 构建日志消息
-	logMessage := fmt.Sprintf("[%s] %s %s %s() %s\n", 
-		timestamp, 
-		alignedLevel, 
-		caller, 
-		getCallerFuncName(entry.Caller),
+	logMessage := fmt.Sprintf("[%s] %s %s %s() %s%s\n",
+		timestamp,
+		alignedLevel,
+		caller,
+		getCallerFuncName(frame),
 		entry.Message,
+		formatFields(entry.Data),
 	)
 
 	return []byte(logMessage), nil
 }
 
+// formatFields 按鍵名排序，以 " key=value" 附加在消息後
+func formatFields(data logrus.Fields) string {
+	if len(data) == 0 {
+		return ""
+	}
+	keys := make([]string, 0, len(data))
+	for k := range data {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	var b strings.Builder
+	for _, k := range keys {
+		fmt.Fprintf(&b, " %s=%v", k, data[k])
+	}
+	return b.String()
+}
+
+// JSONFormatter 每條日誌輸出一行 JSON，字段與消息、級別、時間和調用位置並列
+type JSONFormatter struct {
+	json logrus.JSONFormatter
+}
+
+// NewJSONFormatter 創建 JSON 格式
+func NewJSONFormatter() *JSONFormatter {
+	return &JSONFormatter{json: logrus.JSONFormatter{
+		TimestampFormat: time.RFC3339Nano,
+		FieldMap:        logrus.FieldMap{logrus.FieldKeyFile: "caller"},
+		CallerPrettyfier: func(frame *runtime.Frame) (string, string) {
+			return getCallerFuncName(frame), fmt.Sprintf("%s:%d", frame.File[strings.LastIndex(frame.File, "/")+1:], frame.Line)
+		},
+	}}
+}
+
+// Format 實現 logrus.Formatter
+func (f *JSONFormatter) Format(entry *logrus.Entry) ([]byte, error) {
+	entry.Caller = callerFrame(entry)
+	return f.json.Format(entry)
+}
+
+// callerFrame 返回記錄日誌的位置：通過 Info 等封裝函數記錄時 logrus 報告的是本包，
+// 此時沿調用棧跳過 logrus 和本包找到真正的調用者
+func callerFrame(entry *logrus.Entry) *runtime.Frame {
+	if entry.Caller == nil || !inLogger(entry.Caller) {
+		return entry.Caller
+	}
+	pcs := make([]uintptr, 32)
+	frames := runtime.CallersFrames(pcs[:runtime.Callers(2, pcs)])
+	for {
+		frame, more := frames.Next()
+		if !inLogger(&frame) && !strings.HasPrefix(frame.Function, "github.com/sirupsen/logrus") {
+			return &frame
+		}
+		if !more {
+			return entry.Caller
+		}
+	}
+}
+
+// inLogger 調用位置是否在本包的封裝函數內（本包的測試除外）
+func inLogger(frame *runtime.Frame) bool {
+	return strings.HasPrefix(frame.Function, loggerPackage+".") && !strings.HasSuffix(frame.File, "_test.go")
+}
+
 // 获取函数名
 func getCallerFuncName(caller *runtime.Frame) string {
 	if caller == nil {
@@ -57,10 +130,16 @@ func getCallerFuncName(caller *runtime.Frame) string {
 
 func InitLogger() {
 	Log = logrus.New()
-	Log.SetFormatter(&CustomFormatter{})
 	Log.SetOutput(os.Stdout)
 	Log.SetReportCaller(true)
 
+	// LOG_FORMAT=json 時輸出 JSON，供日誌收集系統按字段檢索
+	if strings.EqualFold(config.AppConfig.LogFormat, "json") {
+		Log.SetFormatter(NewJSONFormatter())
+	} else {
+		Log.SetFormatter(&CustomFormatter{})
+	}
+
 	// 根据配置设置日志级别
 	switch strings.ToUpper(config.AppConfig.LogLevel) {
 	case "DEBUG":
@@ -0,0 +1,105 @@
+package logger
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/sirupsen/logrus"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// captureJSON 以 JSON 格式記錄到緩衝區，返回解析每行日誌的函數
+func captureJSON(t *testing.T) func() []map[string]interface{} {
+	t.Helper()
+	var buf bytes.Buffer
+	Log = logrus.New()
+	Log.SetOutput(&buf)
+	Log.SetReportCaller(true)
+	Log.SetFormatter(NewJSONFormatter())
+	return func() []map[string]interface{} {
+		var lines []map[string]interface{}
+		for _, line := range strings.Split(strings.TrimSpace(buf.String()), "\n") {
+			var m map[string]interface{}
+			if err := json.Unmarshal([]byte(line), &m); err != nil {
+				t.Fatalf("invalid JSON line %q: %v", line, err)
+			}
+			lines = append(lines, m)
+		}
+		buf.Reset()
+		return lines
+	}
+}
+
+func TestContextFields(t *testing.T) {
+	read := captureJSON(t)
+
+	ctx := WithFields(context.Background(), Fields{"request_id": "req-1", "route": "/api/game/play"})
+	inner := context.WithValue(ctx, struct{}{}, "other")
+	// 下層補充的字段，上層的 context 也能看到
+	AddFields(inner, Fields{"user_id": 7})
+	child := WithFields(inner, Fields{"game_id": "g-1"})
+
+	FromContext(ctx).Info("outer")
+	FromContext(child).WithField("round", 2).Info("inner")
+	lines := read()
+
+	if lines[0]["msg"] != "outer" || lines[0]["request_id"] != "req-1" || lines[0]["user_id"] != float64(7) {
+		t.Errorf("outer line = %v", lines[0])
+	}
+	if _, ok := lines[0]["game_id"]; ok {
+		t.Errorf("child field leaked to parent: %v", lines[0])
+	}
+	if lines[1]["route"] != "/api/game/play" || lines[1]["game_id"] != "g-1" || lines[1]["round"] != float64(2) {
+		t.Errorf("inner line = %v", lines[1])
+	}
+
+	// 沒有經過 WithFields 的 context 不受 AddFields 影響
+	AddFields(context.Background(), Fields{"user_id": 1})
+	FromContext(context.Background()).Info("plain")
+	if line := read()[0]; line["user_id"] != nil {
+		t.Errorf("plain line = %v", line)
+	}
+}
+
+func TestTraceIDs(t *testing.T) {
+	read := captureJSON(t)
+
+	span := trace.NewSpanContext(trace.SpanContextConfig{
+		TraceID:    trace.TraceID{1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12, 13, 14, 15, 16},
+		SpanID:     trace.SpanID{1, 2, 3, 4, 5, 6, 7, 8},
+		TraceFlags: trace.FlagsSampled,
+	})
+	FromContext(trace.ContextWithSpanContext(context.Background(), span)).Info("traced")
+	line := read()[0]
+	if line["trace_id"] != "0102030405060708090a0b0c0d0e0f10" || line["span_id"] != "0102030405060708" {
+		t.Errorf("traced line = %v", line)
+	}
+}
+
+func TestCallerSkipsWrappers(t *testing.T) {
+	read := captureJSON(t)
+
+	Info("via wrapper")
+	line := read()[0]
+	if caller, _ := line["caller"].(string); !strings.HasPrefix(caller, "logger_test.go:") {
+		t.Errorf("caller = %v", line["caller"])
+	}
+	if fn, _ := line["func"].(string); !strings.HasSuffix(fn, "TestCallerSkipsWrappers") {
+		t.Errorf("func = %v", line["func"])
+	}
+}
+
+func TestTextFields(t *testing.T) {
+	entry := logrus.NewEntry(logrus.New()).WithFields(Fields{"user_id": 7, "game_id": "g-1"})
+	entry.Message = "Game processed"
+	out, err := (&CustomFormatter{}).Format(entry)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasSuffix(string(out), "Game processed game_id=g-1 user_id=7\n") {
+		t.Errorf("text line = %q", out)
+	}
+}
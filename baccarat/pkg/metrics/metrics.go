@@ -0,0 +1,147 @@
+// Package metrics 以 Prometheus 格式導出的服務指標
+//
+// 請求延遲、牌局結果、投注和賠付金額、錢包交易在發生時累計；
+// 連接池狀態和進行中的自動賭局在每次抓取時讀取。指標註冊在本包的 Registry 上，由 /metrics 導出
+package metrics
+
+import (
+	"database/sql"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+// namespace 指標名的前綴
+const namespace = "baccarat"
+
+// BetTypes 投注類型，與下注請求的字段名一致
+var BetTypes = []string{"player", "banker", "tie", "luckySix"}
+
+// Registry 本服務的指標，包括 Go 運行時和進程指標
+var Registry = prometheus.NewRegistry()
+
+var (
+	requestDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "http_request_duration_seconds",
+		Help:      "HTTP request latency by route, method and status code.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"route", "method", "status"})
+
+	roundsPlayed = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "rounds_played_total",
+		Help:      "Rounds dealt and settled, by table.",
+	}, []string{"table"})
+
+	roundOutcomes = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "round_outcomes_total",
+		Help:      "Settled rounds by winner and Lucky 6 type (none, 2cards, 3cards).",
+	}, []string{"table", "winner", "lucky_six"})
+
+	wagered = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "wagered_amount_total",
+		Help:      "Amount wagered by bet type.",
+	}, []string{"table", "bet_type"})
+
+	paid = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "paid_amount_total",
+		Help:      "Amount paid back to players, principal included, by bet type.",
+	}, []string{"table", "bet_type"})
+
+	walletTransactions = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "wallet_transactions_total",
+		Help:      "Wallet transactions committed, by type.",
+	}, []string{"type"})
+)
+
+func init() {
+	Registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		requestDuration,
+		roundsPlayed,
+		roundOutcomes,
+		wagered,
+		paid,
+		walletTransactions,
+	)
+}
+
+// Handler 以 Prometheus 文本格式導出 Registry 中的指標
+func Handler() http.Handler {
+	return promhttp.HandlerFor(Registry, promhttp.HandlerOpts{Registry: Registry})
+}
+
+// ObserveRequest 記錄一個請求的耗時
+func ObserveRequest(route, method string, status int, elapsed time.Duration) {
+	requestDuration.WithLabelValues(route, method, strconv.Itoa(status)).Observe(elapsed.Seconds())
+}
+
+// ObserveRound 記錄一局已結算的牌局；bets 和 payouts 以投注類型為鍵，payouts 含本金
+func ObserveRound(table, winner, luckySixType string, bets, payouts map[string]float64) {
+	if luckySixType == "" {
+		luckySixType = "none"
+	}
+	roundsPlayed.WithLabelValues(table).Inc()
+	roundOutcomes.WithLabelValues(table, winner, luckySixType).Inc()
+	for _, betType := range BetTypes {
+		if amount := bets[betType]; amount > 0 {
+			wagered.WithLabelValues(table, betType).Add(amount)
+		}
+		if amount := payouts[betType]; amount > 0 {
+			paid.WithLabelValues(table, betType).Add(amount)
+		}
+	}
+}
+
+// ObserveWalletTransaction 記錄提交的一筆錢包交易
+func ObserveWalletTransaction(transactionType string) {
+	walletTransactions.WithLabelValues(transactionType).Inc()
+}
+
+// RegisterDB 導出連接的連接池狀態（go_sql_* 指標），name 區分主庫和各副本
+func RegisterDB(name string, conn *sql.DB) {
+	Registry.MustRegister(collectors.NewDBStatsCollector(conn, name))
+}
+
+var activeRoundsDesc = prometheus.NewDesc(
+	prometheus.BuildFQName(namespace, "", "live_rounds_active"),
+	"Live-table rounds in progress, by stage (betting, closed, drawing).",
+	[]string{"status"}, nil,
+)
+
+// activeRounds 進行中的自動賭局數，抓取時調用 count 查詢
+type activeRounds struct {
+	desc  *prometheus.Desc
+	count func() (map[string]int, error)
+}
+
+// RegisterActiveRounds 導出進行中的自動賭局數，count 返回每個階段的局數；
+// 查詢失敗時本次抓取報告錯誤，不導出過期的數值
+func RegisterActiveRounds(count func() (map[string]int, error)) {
+	Registry.MustRegister(&activeRounds{desc: activeRoundsDesc, count: count})
+}
+
+func (c *activeRounds) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.desc
+}
+
+func (c *activeRounds) Collect(ch chan<- prometheus.Metric) {
+	counts, err := c.count()
+	if err != nil {
+		ch <- prometheus.NewInvalidMetric(c.desc, err)
+		return
+	}
+	for status, n := range counts {
+		ch <- prometheus.MustNewConstMetric(c.desc, prometheus.GaugeValue, float64(n), status)
+	}
+}
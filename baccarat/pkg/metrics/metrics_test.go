@@ -0,0 +1,62 @@
+package metrics
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+func TestObserveRound(t *testing.T) {
+	ObserveRound("test", "Banker", "", map[string]float64{"banker": 100, "luckySix": 10}, map[string]float64{"banker": 195})
+	ObserveRound("test", "Banker", "2cards", map[string]float64{"banker": 100, "luckySix": 10}, map[string]float64{"banker": 160, "luckySix": 130})
+
+	if n := testutil.ToFloat64(roundsPlayed.WithLabelValues("test")); n != 2 {
+		t.Errorf("rounds = %v", n)
+	}
+	if n := testutil.ToFloat64(roundOutcomes.WithLabelValues("test", "Banker", "none")); n != 1 {
+		t.Errorf("outcomes without lucky six = %v", n)
+	}
+	if n := testutil.ToFloat64(wagered.WithLabelValues("test", "luckySix")); n != 20 {
+		t.Errorf("lucky six wagered = %v", n)
+	}
+	if n := testutil.ToFloat64(paid.WithLabelValues("test", "banker")); n != 355 {
+		t.Errorf("banker paid = %v", n)
+	}
+	// 沒有投注的類型不產生序列
+	if n := testutil.CollectAndCount(wagered); n != 2 {
+		t.Errorf("wagered series = %d", n)
+	}
+}
+
+func TestActiveRounds(t *testing.T) {
+	counts := map[string]int{"betting": 1, "closed": 0, "drawing": 2}
+	var err error
+	c := &activeRounds{
+		desc: activeRoundsDesc,
+		count: func() (map[string]int, error) {
+			return counts, err
+		},
+	}
+
+	expected := `
+# HELP baccarat_live_rounds_active Live-table rounds in progress, by stage (betting, closed, drawing).
+# TYPE baccarat_live_rounds_active gauge
+baccarat_live_rounds_active{status="betting"} 1
+baccarat_live_rounds_active{status="closed"} 0
+baccarat_live_rounds_active{status="drawing"} 2
+`
+	if err := testutil.CollectAndCompare(c, strings.NewReader(expected)); err != nil {
+		t.Error(err)
+	}
+
+	// 查詢失敗時抓取報錯，而不是導出過期或為 0 的數值
+	err = errors.New("database is locked")
+	registry := prometheus.NewRegistry()
+	registry.MustRegister(c)
+	if _, gatherErr := registry.Gather(); gatherErr == nil {
+		t.Error("Gather succeeded with a failing count")
+	}
+}
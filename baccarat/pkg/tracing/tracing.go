@@ -0,0 +1,90 @@
+// Package tracing 配置 OpenTelemetry 追蹤
+//
+// 無論是否導出 span，都以 W3C traceparent 傳播上游（網關）的追蹤，日誌因此能帶上網關的 trace_id。
+// TRACING_EXPORTER 為 otlp 時以 OTLP/HTTP 導出到收集器，為 stdout 時輸出到標準輸出供本地調試
+package tracing
+
+import (
+	"baccarat/config"
+	"context"
+	"fmt"
+	"os"
+	"strings"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/exporters/stdout/stdouttrace"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// ServiceName 導出的 span 所屬的服務名，與網關、webhook 的 serviceName 對應
+const ServiceName = "baccarat"
+
+// 支持的導出器
+const (
+	ExporterNone   = "none"
+	ExporterOTLP   = "otlp"
+	ExporterStdout = "stdout"
+)
+
+// Tracer 返回本服務的 tracer，未啟用導出時創建的 span 不會被記錄
+func Tracer() trace.Tracer {
+	return otel.Tracer(ServiceName)
+}
+
+// Init 設置 traceparent 傳播，並按配置創建導出器和全局 TracerProvider
+// 返回的函數在退出前調用，導出尚未發送的 span
+func Init(ctx context.Context) (func(context.Context) error, error) {
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(
+		propagation.TraceContext{},
+		propagation.Baggage{},
+	))
+
+	var exporter sdktrace.SpanExporter
+	var err error
+	switch strings.ToLower(config.AppConfig.TracingExporter) {
+	case "", ExporterNone:
+		return func(context.Context) error { return nil }, nil
+	case ExporterOTLP:
+		var opts []otlptracehttp.Option
+		// 未配置時使用 OTEL_EXPORTER_OTLP_ENDPOINT，默認 localhost:4318
+		if endpoint := config.AppConfig.TracingEndpoint; endpoint != "" {
+			opts = append(opts, otlptracehttp.WithEndpointURL(endpoint))
+		}
+		exporter, err = otlptracehttp.New(ctx, opts...)
+	case ExporterStdout:
+		exporter, err = stdouttrace.New(stdouttrace.WithWriter(os.Stdout))
+	default:
+		return nil, fmt.Errorf("unknown TRACING_EXPORTER %q", config.AppConfig.TracingExporter)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error creating %s exporter: %v", config.AppConfig.TracingExporter, err)
+	}
+
+	hostname, _ := os.Hostname()
+	provider := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithResource(resource.NewSchemaless(
+			attribute.String("service.name", ServiceName),
+			attribute.String("host.name", hostname),
+		)),
+		// 上游已決定是否採樣時沿用，否則按比例採樣
+		sdktrace.WithSampler(sdktrace.ParentBased(sdktrace.TraceIDRatioBased(config.AppConfig.TracingSampleRatio))),
+	)
+	otel.SetTracerProvider(provider)
+	return provider.Shutdown, nil
+}
+
+// End 結束 span，err 不為空時記錄錯誤並把 span 標記為失敗
+func End(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
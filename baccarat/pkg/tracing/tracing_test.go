@@ -0,0 +1,36 @@
+package tracing
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+func TestEnd(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)))
+
+	ctx, round := Tracer().Start(context.Background(), "game.round")
+	_, settle := Tracer().Start(ctx, "game.settle")
+	End(settle, errors.New("insufficient balance"))
+	End(round, nil)
+
+	spans := recorder.Ended()
+	if len(spans) != 2 {
+		t.Fatalf("ended %d spans, want 2", len(spans))
+	}
+	if spans[0].Status().Code != codes.Error || len(spans[0].Events()) != 1 {
+		t.Errorf("failed span status = %v, events = %v", spans[0].Status(), spans[0].Events())
+	}
+	if spans[1].Status().Code != codes.Unset {
+		t.Errorf("ok span status = %v", spans[1].Status())
+	}
+	if spans[0].Parent().SpanID() != spans[1].SpanContext().SpanID() {
+		t.Errorf("settle span is not a child of round span")
+	}
+}
@@ -50,3 +50,8 @@ func ServerError(w http.ResponseWriter, err string) {
 func UnauthorizedError(w http.ResponseWriter) {
 	ErrorResponse(w, http.StatusUnauthorized, "Unauthorized")
 }
+
+// ForbiddenError 發送權限不足錯誤響應
+func ForbiddenError(w http.ResponseWriter) {
+	ErrorResponse(w, http.StatusForbidden, "Forbidden")
+}
@@ -0,0 +1,173 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+)
+
+var (
+	ErrUnknownSchema = errors.New("unknown event schema")
+	ErrInvalidEvent  = errors.New("invalid event")
+)
+
+// Event 代表一個 webhook 事件的信封
+type Event struct {
+	ID        string          `json:"id"`
+	Type      string          `json:"type"`
+	Version   int             `json:"version"`
+	CreatedAt time.Time       `json:"created_at"`
+	Data      json.RawMessage `json:"data"`
+}
+
+// fieldKind 代表 JSON 欄位的類型
+type fieldKind string
+
+const (
+	kindString fieldKind = "string"
+	kindNumber fieldKind = "number"
+	kindBool   fieldKind = "bool"
+	kindObject fieldKind = "object"
+	kindArray  fieldKind = "array"
+)
+
+// eventSchema 描述某個事件類型某個版本的 data 結構
+type eventSchema struct {
+	Required map[string]fieldKind
+	Optional map[string]fieldKind
+}
+
+type schemaKey struct {
+	Type    string
+	Version int
+}
+
+// 已註冊的事件結構，新版本以新的 Version 加入，舊版本保留以兼容舊的發送方
+var eventSchemas = map[schemaKey]eventSchema{
+	{"game.settled", 1}: {
+		Required: map[string]fieldKind{
+			"game_id":       kindString,
+			"winner":        kindString,
+			"total_bets":    kindNumber,
+			"total_payouts": kindNumber,
+		},
+		Optional: map[string]fieldKind{
+			"is_lucky_six":   kindBool,
+			"lucky_six_type": kindString,
+			"settled_at":     kindString,
+		},
+	},
+	{"game.voided", 1}: {
+		Required: map[string]fieldKind{
+			"game_id": kindString,
+			"reason":  kindString,
+		},
+		Optional: map[string]fieldKind{
+			"affected_users": kindArray,
+		},
+	},
+	{"game.resettled", 1}: {
+		Required: map[string]fieldKind{
+			"game_id":       kindString,
+			"winner":        kindString,
+			"total_bets":    kindNumber,
+			"total_payouts": kindNumber,
+			"reason":        kindString,
+		},
+		Optional: map[string]fieldKind{
+			"affected_users": kindArray,
+		},
+	},
+	{"wallet.transaction", 1}: {
+		Required: map[string]fieldKind{
+			"user_id":          kindNumber,
+			"amount":           kindNumber,
+			"transaction_type": kindString,
+		},
+		Optional: map[string]fieldKind{
+			"reference": kindString,
+		},
+	},
+}
+
+// parseEvent 解析並驗證事件信封及其 data
+func parseEvent(body []byte) (*Event, error) {
+	var event Event
+	if err := json.Unmarshal(body, &event); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidEvent, err)
+	}
+	if event.ID == "" {
+		return nil, fmt.Errorf("%w: missing id", ErrInvalidEvent)
+	}
+	if event.Type == "" {
+		return nil, fmt.Errorf("%w: missing type", ErrInvalidEvent)
+	}
+	if event.Version <= 0 {
+		return nil, fmt.Errorf("%w: missing version", ErrInvalidEvent)
+	}
+	// 鏡像按 created_at 判斷事件先後，缺少時無法排序
+	if event.CreatedAt.IsZero() {
+		return nil, fmt.Errorf("%w: missing created_at", ErrInvalidEvent)
+	}
+
+	schema, ok := eventSchemas[schemaKey{event.Type, event.Version}]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s v%d", ErrUnknownSchema, event.Type, event.Version)
+	}
+	if err := schema.validate(event.Data); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidEvent, err)
+	}
+
+	return &event, nil
+}
+
+// validate 檢查必填欄位存在且類型正確，並拒絕未定義的欄位
+func (s eventSchema) validate(raw json.RawMessage) error {
+	if len(raw) == 0 {
+		return errors.New("missing data")
+	}
+	var data map[string]interface{}
+	if err := json.Unmarshal(raw, &data); err != nil {
+		return fmt.Errorf("data must be an object: %v", err)
+	}
+
+	for name, kind := range s.Required {
+		value, ok := data[name]
+		if !ok {
+			return fmt.Errorf("missing field %q", name)
+		}
+		if !kind.matches(value) {
+			return fmt.Errorf("field %q must be %s", name, kind)
+		}
+	}
+	for name, value := range data {
+		if _, ok := s.Required[name]; ok {
+			continue
+		}
+		kind, ok := s.Optional[name]
+		if !ok {
+			return fmt.Errorf("unexpected field %q", name)
+		}
+		if value != nil && !kind.matches(value) {
+			return fmt.Errorf("field %q must be %s", name, kind)
+		}
+	}
+	return nil
+}
+
+func (k fieldKind) matches(value interface{}) bool {
+	switch value.(type) {
+	case string:
+		return k == kindString
+	case float64:
+		return k == kindNumber
+	case bool:
+		return k == kindBool
+	case map[string]interface{}:
+		return k == kindObject
+	case []interface{}:
+		return k == kindArray
+	}
+	return false
+}
@@ -0,0 +1,188 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// EventHandler 處理某類已驗證的事件，實作需保證冪等（發送方可能重試）
+type EventHandler interface {
+	Name() string
+	Handle(ctx context.Context, event *Event) error
+}
+
+// Dispatcher 依事件類型將事件路由到已註冊的處理器
+type Dispatcher struct {
+	handlers map[string][]EventHandler
+}
+
+// NewDispatcher 創建事件路由器
+func NewDispatcher() *Dispatcher {
+	return &Dispatcher{handlers: make(map[string][]EventHandler)}
+}
+
+// Register 為一個或多個事件類型註冊處理器
+func (d *Dispatcher) Register(handler EventHandler, eventTypes ...string) {
+	for _, eventType := range eventTypes {
+		d.handlers[eventType] = append(d.handlers[eventType], handler)
+	}
+}
+
+// Dispatch 依序調用處理器，任一失敗即返回錯誤
+func (d *Dispatcher) Dispatch(ctx context.Context, event *Event) error {
+	handlers := d.handlers[event.Type]
+	if len(handlers) == 0 {
+		log.Printf("No handler registered for event type %s, event %s stored only", event.Type, event.ID)
+		return nil
+	}
+	for _, handler := range handlers {
+		ctx, span := tracer.Start(ctx, "webhook.handler."+handler.Name())
+		err := handler.Handle(ctx, event)
+		if err != nil {
+			span.RecordError(err)
+		}
+		span.End()
+		if err != nil {
+			return fmt.Errorf("handler %s failed: %w", handler.Name(), err)
+		}
+	}
+	return nil
+}
+
+// Settlement 代表本地鏡像中的一局結算
+type Settlement struct {
+	GameID       string    `json:"game_id"`
+	Winner       string    `json:"winner"`
+	TotalBets    float64   `json:"total_bets"`
+	TotalPayouts float64   `json:"total_payouts"`
+	Status       string    `json:"status"` // settled, voided, resettled
+	LastEventID  string    `json:"last_event_id"`
+	UpdatedAt    time.Time `json:"updated_at"`
+}
+
+// SettlementMirror 維護遊戲結算的本地鏡像，並以 JSON 快照持久化
+type SettlementMirror struct {
+	mu          sync.RWMutex
+	path        string
+	settlements map[string]*Settlement
+}
+
+// NewSettlementMirror 從快照文件載入結算鏡像
+func NewSettlementMirror(path string) (*SettlementMirror, error) {
+	m := &SettlementMirror{
+		path:        path,
+		settlements: make(map[string]*Settlement),
+	}
+
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return m, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read settlement mirror: %v", err)
+	}
+	if err := json.Unmarshal(data, &m.settlements); err != nil {
+		return nil, fmt.Errorf("failed to parse settlement mirror: %v", err)
+	}
+	return m, nil
+}
+
+// Name 處理器名稱
+func (m *SettlementMirror) Name() string {
+	return "settlement_mirror"
+}
+
+// Handle 依事件更新鏡像中的結算狀態
+func (m *SettlementMirror) Handle(ctx context.Context, event *Event) error {
+	var data struct {
+		GameID       string  `json:"game_id"`
+		Winner       string  `json:"winner"`
+		TotalBets    float64 `json:"total_bets"`
+		TotalPayouts float64 `json:"total_payouts"`
+	}
+	if err := json.Unmarshal(event.Data, &data); err != nil {
+		return err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	current, exists := m.settlements[data.GameID]
+	// 亂序到達的舊事件不覆蓋較新的狀態
+	if exists && current.UpdatedAt.After(event.CreatedAt) {
+		log.Printf("Ignoring out-of-order event %s for game %s", event.ID, data.GameID)
+		return nil
+	}
+
+	next := &Settlement{
+		GameID:      data.GameID,
+		LastEventID: event.ID,
+		UpdatedAt:   event.CreatedAt,
+	}
+	switch event.Type {
+	case "game.settled":
+		next.Status = "settled"
+		next.Winner = data.Winner
+		next.TotalBets = data.TotalBets
+		next.TotalPayouts = data.TotalPayouts
+	case "game.resettled":
+		next.Status = "resettled"
+		next.Winner = data.Winner
+		next.TotalBets = data.TotalBets
+		next.TotalPayouts = data.TotalPayouts
+	case "game.voided":
+		next.Status = "voided"
+		if exists {
+			next.Winner = current.Winner
+			next.TotalBets = current.TotalBets
+		}
+	default:
+		return fmt.Errorf("unsupported event type %s", event.Type)
+	}
+
+	previous := current
+	m.settlements[data.GameID] = next
+	if err := m.persist(); err != nil {
+		// 回滾記憶體狀態，讓發送方重試
+		if previous != nil {
+			m.settlements[data.GameID] = previous
+		} else {
+			delete(m.settlements, data.GameID)
+		}
+		return err
+	}
+	return nil
+}
+
+// Get 查詢某局的鏡像結算
+func (m *SettlementMirror) Get(gameID string) (Settlement, bool) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	s, ok := m.settlements[gameID]
+	if !ok {
+		return Settlement{}, false
+	}
+	return *s, true
+}
+
+// persist 先寫臨時文件再改名，避免寫到一半的快照
+func (m *SettlementMirror) persist() error {
+	data, err := json.MarshalIndent(m.settlements, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(m.path), 0o755); err != nil {
+		return err
+	}
+	tmp := m.path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, m.path)
+}
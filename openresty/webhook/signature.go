@@ -0,0 +1,60 @@
+package main
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	signatureHeader = "X-Webhook-Signature"
+	timestampHeader = "X-Webhook-Timestamp"
+	signaturePrefix = "sha256="
+)
+
+var (
+	ErrMissingSignature = errors.New("missing signature")
+	ErrInvalidSignature = errors.New("invalid signature")
+	ErrInvalidTimestamp = errors.New("invalid timestamp")
+	ErrStaleTimestamp   = errors.New("timestamp outside tolerance")
+)
+
+// signPayload 計算 "<timestamp>.<body>" 的 HMAC-SHA256 簽名
+func signPayload(secret []byte, timestamp string, body []byte) string {
+	mac := hmac.New(sha256.New, secret)
+	mac.Write([]byte(timestamp))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// verifySignature 驗證簽名與時間戳，時間戳超出容忍範圍視為重放
+func verifySignature(secret []byte, timestamp, signature string, body []byte, now time.Time, tolerance time.Duration) error {
+	if timestamp == "" || signature == "" {
+		return ErrMissingSignature
+	}
+
+	unix, err := strconv.ParseInt(timestamp, 10, 64)
+	if err != nil {
+		return ErrInvalidTimestamp
+	}
+	sentAt := time.Unix(unix, 0)
+	if sentAt.Before(now.Add(-tolerance)) || sentAt.After(now.Add(tolerance)) {
+		return fmt.Errorf("%w: sent at %s", ErrStaleTimestamp, sentAt.UTC().Format(time.RFC3339))
+	}
+
+	// 允許多個簽名（密鑰輪換期間），以逗號分隔
+	expected := signPayload(secret, timestamp, body)
+	for _, candidate := range strings.Split(signature, ",") {
+		candidate = strings.TrimPrefix(strings.TrimSpace(candidate), signaturePrefix)
+		if hmac.Equal([]byte(candidate), []byte(expected)) {
+			return nil
+		}
+	}
+	return ErrInvalidSignature
+}
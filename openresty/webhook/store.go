@@ -0,0 +1,105 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// EventStore 持久化已接受的事件並提供去重查詢
+type EventStore interface {
+	Seen(eventID string) bool
+	Save(event *Event) error
+}
+
+// storedEvent 代表寫入磁碟的事件記錄
+type storedEvent struct {
+	*Event
+	ReceivedAt time.Time `json:"received_at"`
+}
+
+// fileEventStore 以 JSONL 追加寫入事件，啟動時重建去重索引
+type fileEventStore struct {
+	mu   sync.Mutex
+	file *os.File
+	seen map[string]struct{}
+}
+
+// newFileEventStore 打開（或創建）事件日誌並載入已處理的事件ID
+func newFileEventStore(path string) (*fileEventStore, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return nil, fmt.Errorf("failed to create event store dir: %v", err)
+	}
+
+	store := &fileEventStore{seen: make(map[string]struct{})}
+
+	if existing, err := os.Open(path); err == nil {
+		scanner := bufio.NewScanner(existing)
+		scanner.Buffer(make([]byte, 64*1024), maxBodyBytes*2)
+		for scanner.Scan() {
+			var record struct {
+				ID string `json:"id"`
+			}
+			if err := json.Unmarshal(scanner.Bytes(), &record); err != nil {
+				existing.Close()
+				return nil, fmt.Errorf("corrupt event store line: %v", err)
+			}
+			store.seen[record.ID] = struct{}{}
+		}
+		err := scanner.Err()
+		existing.Close()
+		if err != nil {
+			return nil, fmt.Errorf("failed to read event store: %v", err)
+		}
+	} else if !os.IsNotExist(err) {
+		return nil, fmt.Errorf("failed to open event store: %v", err)
+	}
+
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o644)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open event store: %v", err)
+	}
+	store.file = file
+
+	return store, nil
+}
+
+// Seen 檢查事件是否已被接受
+func (s *fileEventStore) Seen(eventID string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	_, ok := s.seen[eventID]
+	return ok
+}
+
+// Save 追加事件並同步到磁碟，成功後才記入去重索引
+func (s *fileEventStore) Save(event *Event) error {
+	line, err := json.Marshal(storedEvent{Event: event, ReceivedAt: time.Now().UTC()})
+	if err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.seen[event.ID]; ok {
+		return nil
+	}
+	if _, err := s.file.Write(append(line, '\n')); err != nil {
+		return err
+	}
+	if err := s.file.Sync(); err != nil {
+		return err
+	}
+	s.seen[event.ID] = struct{}{}
+	return nil
+}
+
+// Close 關閉事件日誌
+func (s *fileEventStore) Close() error {
+	return s.file.Close()
+}
@@ -3,12 +3,17 @@ package main
 import (
 	"context"
 	"encoding/json"
+	"io"
 	"log"
 	"net/http"
 	"os"
+	"path/filepath"
+	"sync"
+	"time"
 
 	"go.opentelemetry.io/otel"
 	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
 	"go.opentelemetry.io/otel/exporters/jaeger"
 	"go.opentelemetry.io/otel/propagation"
 	"go.opentelemetry.io/otel/sdk/resource"
@@ -17,7 +22,9 @@ import (
 )
 
 const (
-	serviceName = "webhook"
+	serviceName      = "webhook"
+	maxBodyBytes     = 1 << 20
+	defaultTolerance = 5 * time.Minute
 )
 
 var (
@@ -88,10 +95,28 @@ func healthHandler(w http.ResponseWriter, r *http.Request) {
 	})
 }
 
-func webhookHandler(w http.ResponseWriter, r *http.Request) {
-	// 記錄收到的請求頭
-	log.Printf("Received headers: %v", r.Header)
+// Receiver 接收簽名事件：驗簽、防重放、結構驗證、去重、分派並持久化
+type Receiver struct {
+	secret     []byte
+	tolerance  time.Duration
+	store      EventStore
+	dispatcher *Dispatcher
+	now        func() time.Time
+	mu         sync.Mutex
+}
+
+// NewReceiver 創建事件接收器
+func NewReceiver(secret []byte, tolerance time.Duration, store EventStore, dispatcher *Dispatcher) *Receiver {
+	return &Receiver{
+		secret:     secret,
+		tolerance:  tolerance,
+		store:      store,
+		dispatcher: dispatcher,
+		now:        time.Now,
+	}
+}
 
+func (rc *Receiver) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 	ctx := r.Context()
 	propagator := otel.GetTextMapPropagator()
 	ctx = propagator.Extract(ctx, propagation.HeaderCarrier(r.Header))
@@ -111,21 +136,96 @@ func webhookHandler(w http.ResponseWriter, r *http.Request) {
 		attribute.String("http.host", r.Host),
 	)
 
-	// 處理請求
-	response := map[string]interface{}{
-		"status":   "success",
-		"message":  "Webhook received",
-		"service":  serviceName,
-		"trace_id": spanContext.TraceID().String(),
-		"span_id":  spanContext.SpanID().String(),
+	reply := func(status int, message string, extra map[string]interface{}) {
+		response := map[string]interface{}{
+			"status":   "success",
+			"message":  message,
+			"service":  serviceName,
+			"trace_id": spanContext.TraceID().String(),
+			"span_id":  spanContext.SpanID().String(),
+		}
+		if status >= 400 {
+			response["status"] = "error"
+			span.SetStatus(codes.Error, message)
+		}
+		for k, v := range extra {
+			response[k] = v
+		}
+		span.SetAttributes(attribute.Int("http.status_code", status))
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(status)
+		json.NewEncoder(w).Encode(response)
 	}
 
-	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(response)
+	if r.Method != http.MethodPost {
+		reply(http.StatusMethodNotAllowed, "Method not allowed", nil)
+		return
+	}
+
+	body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxBodyBytes))
+	if err != nil {
+		log.Printf("Failed to read webhook body: %v", err)
+		reply(http.StatusRequestEntityTooLarge, "Request body too large", nil)
+		return
+	}
+
+	// 驗證簽名與時間戳
+	_, verifySpan := tracer.Start(ctx, "webhook.verify_signature")
+	err = verifySignature(rc.secret, r.Header.Get(timestampHeader), r.Header.Get(signatureHeader), body, rc.now(), rc.tolerance)
+	verifySpan.End()
+	if err != nil {
+		log.Printf("Rejected webhook: %v", err)
+		reply(http.StatusUnauthorized, "Invalid signature", nil)
+		return
+	}
+
+	// 驗證事件結構
+	event, err := parseEvent(body)
+	if err != nil {
+		log.Printf("Rejected webhook event: %v", err)
+		reply(http.StatusBadRequest, err.Error(), nil)
+		return
+	}
+	span.SetAttributes(
+		attribute.String("event.id", event.ID),
+		attribute.String("event.type", event.Type),
+		attribute.Int("event.version", event.Version),
+	)
+
+	// 同一時間只處理一個事件，避免重複投遞並發穿透去重
+	rc.mu.Lock()
+	defer rc.mu.Unlock()
+
+	if rc.store.Seen(event.ID) {
+		log.Printf("Duplicate webhook event %s ignored", event.ID)
+		span.SetAttributes(attribute.Bool("event.duplicate", true))
+		reply(http.StatusOK, "Duplicate event ignored", map[string]interface{}{"event_id": event.ID, "duplicate": true})
+		return
+	}
+
+	if err := rc.dispatcher.Dispatch(ctx, event); err != nil {
+		log.Printf("Failed to handle event %s: %v", event.ID, err)
+		span.RecordError(err)
+		reply(http.StatusInternalServerError, "Failed to handle event", nil)
+		return
+	}
+
+	// 處理成功後才持久化，處理失敗時發送方重試可再次處理
+	_, persistSpan := tracer.Start(ctx, "webhook.persist")
+	err = rc.store.Save(event)
+	persistSpan.End()
+	if err != nil {
+		log.Printf("Failed to persist event %s: %v", event.ID, err)
+		span.RecordError(err)
+		reply(http.StatusInternalServerError, "Failed to persist event", nil)
+		return
+	}
 
-	log.Printf("Webhook response sent with trace_id=%s span_id=%s",
+	log.Printf("Webhook event %s (%s v%d) accepted with trace_id=%s span_id=%s",
+		event.ID, event.Type, event.Version,
 		spanContext.TraceID(),
 		spanContext.SpanID())
+	reply(http.StatusOK, "Webhook received", map[string]interface{}{"event_id": event.ID})
 }
 
 func main() {
@@ -141,8 +241,39 @@ func main() {
 
 	tracer = tp.Tracer(serviceName)
 
+	secret := os.Getenv("WEBHOOK_SECRET")
+	if secret == "" {
+		log.Fatal("WEBHOOK_SECRET is required")
+	}
+
+	tolerance := defaultTolerance
+	if value := os.Getenv("WEBHOOK_TOLERANCE"); value != "" {
+		if tolerance, err = time.ParseDuration(value); err != nil {
+			log.Fatalf("Invalid WEBHOOK_TOLERANCE: %v", err)
+		}
+	}
+
+	dataDir := os.Getenv("WEBHOOK_DATA_DIR")
+	if dataDir == "" {
+		dataDir = "data"
+	}
+
+	store, err := newFileEventStore(filepath.Join(dataDir, "events.jsonl"))
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer store.Close()
+
+	mirror, err := NewSettlementMirror(filepath.Join(dataDir, "settlements.json"))
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	dispatcher := NewDispatcher()
+	dispatcher.Register(mirror, "game.settled", "game.voided", "game.resettled")
+
 	http.HandleFunc("/health", healthHandler)
-	http.HandleFunc("/", webhookHandler)
+	http.Handle("/", NewReceiver([]byte(secret), tolerance, store, dispatcher))
 
 	port := os.Getenv("PORT")
 	if port == "" {
@@ -0,0 +1,124 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strconv"
+	"testing"
+	"time"
+)
+
+var testSecret = []byte("test-secret")
+
+func TestVerifySignature(t *testing.T) {
+	now := time.Unix(1700000000, 0)
+	body := []byte(`{"id":"evt_1"}`)
+	ts := strconv.FormatInt(now.Unix(), 10)
+	valid := signaturePrefix + signPayload(testSecret, ts, body)
+
+	tests := []struct {
+		name      string
+		timestamp string
+		signature string
+		body      []byte
+		wantErr   error
+	}{
+		{"Valid signature", ts, valid, body, nil},
+		{"Rotated secrets", ts, "sha256=deadbeef," + valid, body, nil},
+		{"Missing signature", ts, "", body, ErrMissingSignature},
+		{"Bad timestamp", "abc", valid, body, ErrInvalidTimestamp},
+		{"Stale timestamp", strconv.FormatInt(now.Add(-10*time.Minute).Unix(), 10), valid, body, ErrStaleTimestamp},
+		{"Tampered body", ts, valid, []byte(`{"id":"evt_2"}`), ErrInvalidSignature},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := verifySignature(testSecret, tt.timestamp, tt.signature, tt.body, now, 5*time.Minute)
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("verifySignature() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestReceiver(t *testing.T) {
+	dir := t.TempDir()
+	store, err := newFileEventStore(filepath.Join(dir, "events.jsonl"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer store.Close()
+	mirror, err := NewSettlementMirror(filepath.Join(dir, "settlements.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	dispatcher := NewDispatcher()
+	dispatcher.Register(mirror, "game.settled", "game.voided", "game.resettled")
+
+	now := time.Unix(1700000000, 0)
+	receiver := NewReceiver(testSecret, 5*time.Minute, store, dispatcher)
+	receiver.now = func() time.Time { return now }
+
+	send := func(body string) int {
+		ts := strconv.FormatInt(now.Unix(), 10)
+		req := httptest.NewRequest(http.MethodPost, "/", bytes.NewBufferString(body))
+		req.Header.Set(timestampHeader, ts)
+		req.Header.Set(signatureHeader, signaturePrefix+signPayload(testSecret, ts, []byte(body)))
+		rec := httptest.NewRecorder()
+		receiver.ServeHTTP(rec, req)
+		return rec.Code
+	}
+
+	settled := `{"id":"evt_1","type":"game.settled","version":1,"created_at":"2023-11-14T22:13:20Z",
+		"data":{"game_id":"g1","winner":"Banker","total_bets":100,"total_payouts":195}}`
+	if code := send(settled); code != http.StatusOK {
+		t.Fatalf("settled event: got status %d", code)
+	}
+	if s, ok := mirror.Get("g1"); !ok || s.Status != "settled" || s.TotalPayouts != 195 {
+		t.Fatalf("mirror not updated: %+v", s)
+	}
+
+	// 重複投遞應被接受但不再處理
+	if code := send(settled); code != http.StatusOK {
+		t.Fatalf("duplicate event: got status %d", code)
+	}
+
+	voided := `{"id":"evt_2","type":"game.voided","version":1,"created_at":"2023-11-14T22:14:20Z",
+		"data":{"game_id":"g1","reason":"dealer error"}}`
+	if code := send(voided); code != http.StatusOK {
+		t.Fatalf("voided event: got status %d", code)
+	}
+	if s, _ := mirror.Get("g1"); s.Status != "voided" {
+		t.Fatalf("expected voided, got %s", s.Status)
+	}
+
+	badSchema := `{"id":"evt_3","type":"game.settled","version":1,"created_at":"2023-11-14T22:13:20Z","data":{"game_id":"g2"}}`
+	if code := send(badSchema); code != http.StatusBadRequest {
+		t.Fatalf("invalid schema: got status %d", code)
+	}
+	missingCreatedAt := `{"id":"evt_5","type":"game.voided","version":1,"data":{"game_id":"g1","reason":"late"}}`
+	if code := send(missingCreatedAt); code != http.StatusBadRequest {
+		t.Fatalf("missing created_at: got status %d", code)
+	}
+	zeroCreatedAt := `{"id":"evt_6","type":"game.voided","version":1,"created_at":"0001-01-01T00:00:00Z","data":{"game_id":"g1","reason":"late"}}`
+	if code := send(zeroCreatedAt); code != http.StatusBadRequest {
+		t.Fatalf("zero created_at: got status %d", code)
+	}
+	unknownVersion := `{"id":"evt_4","type":"game.settled","version":9,"created_at":"2023-11-14T22:13:20Z","data":{}}`
+	if code := send(unknownVersion); code != http.StatusBadRequest {
+		t.Fatalf("unknown version: got status %d", code)
+	}
+
+	// 重新載入後去重索引仍然有效
+	reloaded, err := newFileEventStore(filepath.Join(dir, "events.jsonl"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer reloaded.Close()
+	if !reloaded.Seen("evt_1") || !reloaded.Seen("evt_2") || reloaded.Seen("evt_3") || reloaded.Seen("evt_5") {
+		t.Fatal("dedupe index not rebuilt from event store")
+	}
+}
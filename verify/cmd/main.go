@@ -42,13 +42,13 @@ func main() {
 	v := validator.NewValidator(cfg, apiClient)
 
 	if cfg.SingleGameMode {
-		validateSingleGame(cfg, v, apiClient)
+		validateSingleGame(cfg, v, apiClient, db)
 	} else {
 		validateMultipleGames(cfg, v, apiClient, db)
 	}
 }
 
-func validateSingleGame(cfg *config.Config, v *validator.Validator, apiClient *api.APIClient) {
+func validateSingleGame(cfg *config.Config, v *validator.Validator, apiClient *api.APIClient, db *db.DB) {
 	fmt.Printf("\n=== Validating Single Game ID: %s ===\n", cfg.SingleGameID)
 	
 	// 從 API 獲取遊戲詳情
@@ -59,6 +59,7 @@ func validateSingleGame(cfg *config.Config, v *validator.Validator, apiClient *a
 
 	// 驗證遊戲
 	result := v.ValidateGame(gameDetails)
+	validateShoe(v, db, cfg.SingleGameID, &result)
 	
 	// 輸出驗證結果
 	if len(result.InvalidGameIDs) == 0 {
@@ -99,6 +100,7 @@ func validateMultipleGames(cfg *config.Config, v *validator.Validator, apiClient
 
 		// 驗證遊戲
 		result := v.ValidateGame(gameDetails)
+		validateShoe(v, db, gameID, &result)
 		
 		// 更新總結果
 		if len(result.InvalidGameIDs) > 0 {
@@ -124,3 +126,18 @@ func validateMultipleGames(cfg *config.Config, v *validator.Validator, apiClient
 	// 輸出總結果
 	fmt.Println(totalResult.String())
 }
+
+// validateShoe 由牌靴重新發牌核對牌局，沒有牌靴記錄的舊牌局跳過
+func validateShoe(v *validator.Validator, db *db.DB, gameID string, result *validator.ValidationResult) {
+	round, err := db.GetShoeRound(gameID)
+	if err != nil {
+		log.Printf("Error fetching shoe for %s: %v\n", gameID, err)
+		return
+	}
+	if round == nil {
+		fmt.Println("No shoe record, redeal skipped")
+		return
+	}
+	fmt.Printf("Shoe %s, position %d, cards %s\n", round.ShoeID, round.Position, round.DrawnCards)
+	result.Merge(v.ValidateShoe(round))
+}
@@ -18,6 +18,9 @@ type Config struct {
 	DBPassword string
 	DBName     string
 	TimeZone   string
+	// 只讀副本：設置後所有查詢改連副本，減輕遊戲主庫的負擔
+	DBReplicaHost string
+	DBReplicaPort int
 	APIURL     string
 	Token      string
 	GameIDLimit int
@@ -36,6 +39,14 @@ type Config struct {
 	BankerLucky6_3CardsPayout float64
 }
 
+// ReadAddr 返回查詢使用的主機和端口，設置了 DB_REPLICA_HOST 時為副本
+func (c *Config) ReadAddr() (string, int) {
+	if c.DBReplicaHost != "" {
+		return c.DBReplicaHost, c.DBReplicaPort
+	}
+	return c.DBHost, c.DBPort
+}
+
 // LoadConfig 從 .env 讀取配置
 func LoadConfig() *Config {
 	// 獲取當前執行檔案的目錄
@@ -115,6 +126,15 @@ func LoadConfig() *Config {
 		log.Fatal("Invalid BANKER_LUCKY6_3CARDS_PAYOUT")
 	}
 
+	// 未設置副本端口時沿用 DB_PORT
+	dbReplicaPort := dbPort
+	if portStr := os.Getenv("DB_REPLICA_PORT"); portStr != "" {
+		dbReplicaPort, err = strconv.Atoi(portStr)
+		if err != nil {
+			log.Fatal("Invalid DB_REPLICA_PORT")
+		}
+	}
+
 	sqlVerifyMode := false
 	if sqlVerifyModeStr := os.Getenv("SQL_VERIFY_MODE"); sqlVerifyModeStr != "" {
 		var err error
@@ -132,6 +152,8 @@ func LoadConfig() *Config {
 		DBPassword: os.Getenv("DB_PASSWORD"),
 		DBName:     os.Getenv("DB_NAME"),
 		TimeZone:   os.Getenv("TIME_ZONE"),
+		DBReplicaHost: os.Getenv("DB_REPLICA_HOST"),
+		DBReplicaPort: dbReplicaPort,
 		APIURL:     os.Getenv("API_URL"),
 		Token:      os.Getenv("API_TOKEN"),
 		GameIDLimit: gameIDLimit,
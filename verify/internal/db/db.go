@@ -19,11 +19,12 @@ type DB struct {
 
 // NewDB 建立新的資料庫連線
 func NewDB(cfg *config.Config) *DB {
+	host, port := cfg.ReadAddr()
 	dsn := fmt.Sprintf("%s:%s@tcp(%s:%d)/%s",
 		cfg.DBUser,
 		cfg.DBPassword,
-		cfg.DBHost,
-		cfg.DBPort,
+		host,
+		port,
 		cfg.DBName,
 	)
 
@@ -71,6 +72,55 @@ func (db *DB) GetLatestGameIDs(limit int) ([]string, error) {
 	return gameIDs, nil
 }
 
+// ShoeRound 一局牌的牌靴記錄：種子、完整牌序及其雜湊，該局在牌序中的位置、發出的牌和結果
+type ShoeRound struct {
+	GameID        string
+	ShoeID        string
+	Seed          string
+	CardOrder     string
+	CardOrderHash string
+	Position      int
+	DrawnCards    string
+	PlayerCards   string // 初始牌和補牌，逗號分隔
+	BankerCards   string
+	Winner        string
+}
+
+// GetShoeRound 從 game_records_all 和 shoes 查詢牌局的牌靴記錄，牌局沒有牌靴記錄時返回 nil
+func (db *DB) GetShoeRound(gameID string) (*ShoeRound, error) {
+	var r ShoeRound
+	var shoeID, seed, cardOrder, cardOrderHash, drawnCards, playerThird, bankerThird sql.NullString
+	var position sql.NullInt64
+	err := db.conn.QueryRow(`
+		SELECT gr.game_id, gr.shoe_id, s.seed, s.card_order, s.card_order_hash, gr.shoe_position, gr.drawn_cards,
+			gr.player_initial_cards, gr.player_third_card, gr.banker_initial_cards, gr.banker_third_card, gr.winner
+		FROM game_records_all gr
+		LEFT JOIN shoes s ON gr.shoe_id = s.shoe_id
+		WHERE gr.game_id = ?`, gameID,
+	).Scan(&r.GameID, &shoeID, &seed, &cardOrder, &cardOrderHash, &position, &drawnCards,
+		&r.PlayerCards, &playerThird, &r.BankerCards, &bankerThird, &r.Winner)
+	if err != nil {
+		return nil, err
+	}
+	if !shoeID.Valid {
+		return nil, nil
+	}
+
+	r.ShoeID = shoeID.String
+	r.Seed = seed.String
+	r.CardOrder = cardOrder.String
+	r.CardOrderHash = cardOrderHash.String
+	r.Position = int(position.Int64)
+	r.DrawnCards = drawnCards.String
+	if playerThird.Valid {
+		r.PlayerCards += "," + playerThird.String
+	}
+	if bankerThird.Valid {
+		r.BankerCards += "," + bankerThird.String
+	}
+	return &r, nil
+}
+
 // Close 關閉資料庫連線
 func (db *DB) Close() error {
 	if db.conn != nil {
@@ -0,0 +1,143 @@
+// shoe.go
+package validator
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	mrand "math/rand/v2"
+	"strings"
+
+	"github.com/letron/verify/internal/db"
+)
+
+var (
+	cardSuits  = []string{"S", "H", "D", "C"}
+	cardValues = []string{"A", "2", "3", "4", "5", "6", "7", "8", "9", "10", "J", "Q", "K"}
+)
+
+// parseCards 解析 "S7,H10,CK" 格式的牌
+func parseCards(s string) ([]Card, error) {
+	var cards []Card
+	for _, part := range strings.Split(s, ",") {
+		part = strings.TrimSpace(part)
+		card, ok := Card{}, false
+		for suit, name := range cardSuits {
+			if strings.HasPrefix(part, name) {
+				for i, value := range cardValues {
+					if part[len(name):] == value {
+						card, ok = Card{Suit: suit, Value: i + 1}, true
+					}
+				}
+			}
+		}
+		if !ok {
+			return nil, fmt.Errorf("invalid card %q", part)
+		}
+		cards = append(cards, card)
+	}
+	return cards, nil
+}
+
+// formatCards 以逗號連接多張牌，與遊戲服務保存的格式相同
+func formatCards(cards []Card) string {
+	parts := make([]string, len(cards))
+	for i, card := range cards {
+		parts[i] = cardSuits[card.Suit] + cardValues[card.Value-1]
+	}
+	return strings.Join(parts, ",")
+}
+
+// shuffleShoe 以種子重新洗牌，與遊戲服務相同：decks 副按花色、點數排列的牌，
+// 以種子作為 ChaCha8 密鑰調用 math/rand/v2 的 Shuffle
+func shuffleShoe(seed []byte, decks int) []Card {
+	var key [32]byte
+	copy(key[:], seed)
+	cards := make([]Card, 0, decks*52)
+	for i := 0; i < decks; i++ {
+		for suit := 0; suit < 4; suit++ {
+			for value := 1; value <= 13; value++ {
+				cards = append(cards, Card{Suit: suit, Value: value})
+			}
+		}
+	}
+	r := mrand.New(mrand.NewChaCha8(key))
+	r.Shuffle(len(cards), func(i, j int) {
+		cards[i], cards[j] = cards[j], cards[i]
+	})
+	return cards
+}
+
+// redeal 從牌序的 position 處按補牌規則重新發一局，返回遊戲和發出的張數
+func redeal(order []Card, position int) (*Game, int, error) {
+	if position < 0 || len(order)-position < 4 {
+		return nil, 0, fmt.Errorf("position %d outside shoe of %d cards", position, len(order))
+	}
+	g := &Game{Deck: order[position:]}
+	remaining := len(g.Deck)
+	g.Deal()
+	if g.PlayerScore < 8 && g.BankerScore < 8 {
+		if len(g.Deck) < 2 {
+			return nil, 0, fmt.Errorf("shoe exhausted at position %d", position)
+		}
+		g.DealThirdCard()
+	}
+	g.DetermineWinner()
+	return g, remaining - len(g.Deck), nil
+}
+
+// ValidateShoe 獨立於遊戲服務重新核對一局：牌序雜湊、由種子重新洗牌、
+// 從記錄的位置重新發牌，並與記錄的發牌順序、手牌和勝方比對
+func (v *Validator) ValidateShoe(round *db.ShoeRound) ValidationResult {
+	result := ValidationResult{
+		TotalGames:     1,
+		ValidGames:     1,
+		InvalidGames:   0,
+		InvalidGameIDs: []string{},
+		ErrorDetails:   []string{},
+	}
+	fail := func(format string, args ...interface{}) {
+		if result.InvalidGames == 0 {
+			result.ValidGames = 0
+			result.InvalidGames = 1
+			result.InvalidGameIDs = append(result.InvalidGameIDs, round.GameID)
+		}
+		result.ErrorDetails = append(result.ErrorDetails, fmt.Sprintf(format, args...))
+	}
+
+	sum := sha256.Sum256([]byte(round.CardOrder))
+	if hex.EncodeToString(sum[:]) != round.CardOrderHash {
+		fail("Shoe %s card order does not match its hash", round.ShoeID)
+	}
+
+	order, err := parseCards(round.CardOrder)
+	if err != nil {
+		fail("Shoe %s card order: %v", round.ShoeID, err)
+		return result
+	}
+	seed, err := hex.DecodeString(round.Seed)
+	if err != nil || len(seed) != 32 || len(order)%52 != 0 {
+		fail("Shoe %s has an invalid seed or card count", round.ShoeID)
+	} else if formatCards(shuffleShoe(seed, len(order)/52)) != round.CardOrder {
+		fail("Shoe %s seed does not reproduce its card order", round.ShoeID)
+	}
+
+	g, drawn, err := redeal(order, round.Position)
+	if err != nil {
+		fail("Redeal: %v", err)
+		return result
+	}
+	if got := formatCards(order[round.Position : round.Position+drawn]); got != round.DrawnCards {
+		fail("Redealt cards %s, recorded %s", got, round.DrawnCards)
+	}
+	if got := formatCards(g.PlayerHand.Cards); got != round.PlayerCards {
+		fail("Redealt player hand %s, recorded %s", got, round.PlayerCards)
+	}
+	if got := formatCards(g.BankerHand.Cards); got != round.BankerCards {
+		fail("Redealt banker hand %s, recorded %s", got, round.BankerCards)
+	}
+	if g.Winner != round.Winner {
+		fail("Redealt winner %s, recorded %s", g.Winner, round.Winner)
+	}
+	return result
+}
@@ -95,6 +95,19 @@ type ValidationResult struct {
 	ErrorDetails  []string `json:"error_details"`     
 }
 
+// Merge 合併同一局的另一項驗證結果
+func (vr *ValidationResult) Merge(other ValidationResult) {
+	if other.InvalidGames == 0 {
+		return
+	}
+	if vr.InvalidGames == 0 {
+		vr.ValidGames = 0
+		vr.InvalidGames = 1
+		vr.InvalidGameIDs = append(vr.InvalidGameIDs, other.InvalidGameIDs...)
+	}
+	vr.ErrorDetails = append(vr.ErrorDetails, other.ErrorDetails...)
+}
+
 // String returns a string representation of the validation result
 func (vr *ValidationResult) String() string {
     var sb strings.Builder
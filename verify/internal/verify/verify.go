@@ -17,11 +17,12 @@ type SQLVerifier struct {
 }
 
 func NewSQLVerifier(cfg *config.Config) (*SQLVerifier, error) {
+	host, port := cfg.ReadAddr()
 	dsn := fmt.Sprintf("%s:%s@tcp(%s:%d)/%s?charset=utf8mb4&parseTime=True&loc=Local",
 		cfg.DBUser,
 		cfg.DBPassword,
-		cfg.DBHost,
-		cfg.DBPort,
+		host,
+		port,
 		cfg.DBName,
 	)
 
@@ -157,7 +158,7 @@ func ValidateGames(db *sql.DB, rules *ValidationRules) (*ValidationResults, erro
 	}
 
 	// 獲取所有遊戲ID
-	rows, err := db.Query("SELECT game_id FROM game_records")
+	rows, err := db.Query("SELECT game_id FROM game_records_all")
 	if err != nil {
 		return nil, fmt.Errorf("獲取所有遊戲記錄失敗: %v", err)
 	}
@@ -197,7 +198,7 @@ func (v *SQLVerifier) VerifyGames() error {
 
 	// 獲取總遊戲數
 	var totalGames int
-	err = v.db.QueryRow("SELECT COUNT(*) FROM game_records").Scan(&totalGames)
+	err = v.db.QueryRow("SELECT COUNT(*) FROM game_records_all").Scan(&totalGames)
 	if err != nil {
 		return fmt.Errorf("獲取總遊戲數失敗: %v", err)
 	}